@@ -0,0 +1,4 @@
+// Package shortenerv1 holds the generated gRPC API of the shortener.
+package shortenerv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative shortener/v1/shortener.proto
@@ -0,0 +1,308 @@
+// Command url-shortener-cli manages links directly in the configured
+// storage, without going through the HTTP API:
+//
+//	url-shortener-cli create --url=https://example.com [--alias=example] [--owner=jane@example.com]
+//	url-shortener-cli get --alias=example
+//	url-shortener-cli delete --alias=example
+//
+// It reads the config of CONFIG_PATH, like the server, and applies the
+// same alias and URL rules. Errors exit with status 1, usage errors with 2.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"url-shortener/internal/cache"
+	"url-shortener/internal/config"
+	"url-shortener/internal/lib/generator"
+	"url-shortener/internal/lib/normalize"
+	"url-shortener/internal/lib/urlnorm"
+	"url-shortener/internal/lib/validate"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/postgres"
+	"url-shortener/internal/storage/sqlite"
+)
+
+const (
+	exitError = 1
+	exitUsage = 2
+)
+
+const usage = `usage: url-shortener-cli <command> [flags]
+
+commands:
+  create --url=URL [--alias=ALIAS] [--owner=EMAIL]  save a link, a random alias is generated when none is given
+  get --alias=ALIAS                                 print the target of an alias
+  delete --alias=ALIAS                              delete the link of an alias
+`
+
+// store is the storage the commands work on.
+type store interface {
+	SaveURLContext(ctx context.Context, urlToSave string, alias string, opts storage.SaveOptions) (int64, error)
+	GetURL(alias string) (string, error)
+	DeleteURL(alias string) error
+}
+
+// urlCache is the cache deleted links are evicted from.
+type urlCache interface {
+	Delete(ctx context.Context, key string) error
+}
+
+type app struct {
+	store store
+	// cache is nil when Redis is unreachable, deleted links then stay
+	// cached until their entry expires.
+	cache  urlCache
+	cfg    *config.Config
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(exitUsage)
+	}
+	switch os.Args[1] {
+	case "create", "get", "delete":
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(os.Stdout, usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(exitUsage)
+	}
+
+	cfg := config.MustLoad()
+
+	s, err := newStorage(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to init storage: %v\n", err)
+		os.Exit(exitError)
+	}
+
+	a := &app{
+		store:  s,
+		cfg:    cfg,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+
+	var c *cache.Cache
+	if os.Args[1] == "delete" {
+		c, err = cache.New(cfg.Redis.Address, cfg.Redis.Password, cfg.Redis.DB)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: redis is unreachable, the link stays cached until it expires: %v\n", err)
+		} else {
+			a.cache = c
+		}
+	}
+
+	code := a.run(os.Args[1:])
+
+	// os.Exit skips deferred calls
+	if c != nil {
+		c.Close()
+	}
+	s.Close()
+
+	os.Exit(code)
+}
+
+// closer is a store that holds connections.
+type closer interface {
+	store
+	Close() error
+}
+
+// newStorage opens the storage backend selected by cfg.Storage.Driver.
+func newStorage(cfg *config.Config) (closer, error) {
+	if cfg.Storage.Driver == "sqlite" {
+		return sqlite.New(cfg.Storage.SQLitePath)
+	}
+
+	psqlInfo := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		cfg.Postgres.Host, cfg.Postgres.Port, cfg.Postgres.User, cfg.Postgres.Password, cfg.Postgres.DBName)
+
+	return postgres.New(psqlInfo, cfg.MigrationsPath, postgres.PoolOptions{})
+}
+
+// run runs the command of args and returns the exit status.
+func (a *app) run(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(a.stderr, usage)
+		return exitUsage
+	}
+
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+
+	var (
+		target = fs.String("url", "", "target URL of the link")
+		alias  = fs.String("alias", "", "alias of the link")
+		owner  = fs.String("owner", "", "email of the owner of the link")
+	)
+	if err := fs.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(a.stderr, "unexpected arguments: %v\n", fs.Args())
+		return exitUsage
+	}
+
+	var err error
+	switch args[0] {
+	case "create":
+		err = a.create(*target, *alias, *owner)
+	case "get":
+		err = a.get(*alias)
+	case "delete":
+		err = a.delete(*alias)
+	default:
+		fmt.Fprintf(a.stderr, "unknown command %q\n\n%s", args[0], usage)
+		return exitUsage
+	}
+
+	var usageErr usageError
+	if errors.As(err, &usageErr) {
+		fmt.Fprintf(a.stderr, "%s\n", usageErr)
+		return exitUsage
+	}
+	if err != nil {
+		fmt.Fprintf(a.stderr, "%s: %v\n", args[0], err)
+		return exitError
+	}
+
+	return 0
+}
+
+// usageError is a missing or malformed flag.
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+// create saves target under alias, or a generated alias when it is empty,
+// and prints the alias and target.
+func (a *app) create(target, alias, owner string) error {
+	if target == "" {
+		return usageError("--url is required")
+	}
+
+	if err := validate.URL(target, a.cfg.URL.AllowedSchemes); err != nil {
+		return err
+	}
+	if err := validate.Owner(owner, a.cfg.URL.RequireOwner); err != nil {
+		return err
+	}
+	if a.cfg.URL.NormalizeURLs {
+		target = urlnorm.Normalize(target)
+	}
+	if a.cfg.URL.CanonicalQuery {
+		target = normalize.Query(target)
+	}
+
+	if alias != "" {
+		alias = a.canonical(alias)
+		if err := validate.Alias(alias, a.cfg.Alias.Reserved); err != nil {
+			return err
+		}
+
+		_, err := a.store.SaveURLContext(context.Background(), target, alias, storage.SaveOptions{Owner: owner})
+		if errors.Is(err, storage.ErrURLExists) {
+			return fmt.Errorf("alias %s already exists", alias)
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(a.stdout, "%s\t%s\n", alias, target)
+
+		return nil
+	}
+
+	gen := generator.Random{Length: a.cfg.Alias.Length, Alphabet: a.cfg.Alias.Chars()}
+	attempts := max(a.cfg.Alias.SaveAttempts, 1)
+	for attempt := 1; ; attempt++ {
+		generated, err := gen.Generate(context.Background())
+		if err != nil {
+			return err
+		}
+		generated = a.canonical(generated)
+
+		_, err = a.store.SaveURLContext(context.Background(), target, generated, storage.SaveOptions{Owner: owner})
+		if errors.Is(err, storage.ErrURLExists) && attempt < attempts {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(a.stdout, "%s\t%s\n", generated, target)
+
+		return nil
+	}
+}
+
+// get prints the target of alias.
+func (a *app) get(alias string) error {
+	if alias == "" {
+		return usageError("--alias is required")
+	}
+
+	alias = a.canonical(alias)
+
+	target, err := a.store.GetURL(alias)
+	if errors.Is(err, storage.ErrURLNotFound) {
+		return fmt.Errorf("alias %s not found", alias)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(a.stdout, target)
+
+	return nil
+}
+
+// delete deletes the link of alias and prints the alias.
+func (a *app) delete(alias string) error {
+	if alias == "" {
+		return usageError("--alias is required")
+	}
+	alias = a.canonical(alias)
+
+	err := a.store.DeleteURL(alias)
+	if errors.Is(err, storage.ErrURLNotFound) {
+		return fmt.Errorf("alias %s not found", alias)
+	}
+	if err != nil {
+		return err
+	}
+
+	if a.cache != nil {
+		// stale redirects must stop right away
+		if err := a.cache.Delete(context.Background(), alias); err != nil {
+			fmt.Fprintf(a.stderr, "warning: failed to evict %s from cache: %v\n", alias, err)
+		}
+	}
+
+	fmt.Fprintln(a.stdout, alias)
+
+	return nil
+}
+
+// canonical returns alias the way it is stored.
+func (a *app) canonical(alias string) string {
+	if a.cfg.Alias.Folded() {
+		return normalize.Alias(alias)
+	}
+
+	return alias
+}
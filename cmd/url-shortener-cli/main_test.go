@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/config"
+	"url-shortener/internal/storage/sqlite"
+)
+
+func newTestApp(t *testing.T, cfg *config.Config) (*app, *bytes.Buffer, *bytes.Buffer) {
+	t.Helper()
+
+	s, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+
+	var stdout, stderr bytes.Buffer
+
+	return &app{store: s, cfg: cfg, stdout: &stdout, stderr: &stderr}, &stdout, &stderr
+}
+
+func TestRun(t *testing.T) {
+	cfg := &config.Config{
+		Alias: config.AliasConfig{Length: 6, SaveAttempts: 3, Reserved: []string{"admin"}},
+	}
+
+	a, stdout, stderr := newTestApp(t, cfg)
+
+	steps := []struct {
+		name   string
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{
+			name:   "Create",
+			args:   []string{"create", "--url=https://google.com", "--alias=google"},
+			stdout: "google\thttps://google.com\n",
+		},
+		{
+			name:   "Create taken",
+			args:   []string{"create", "--url=https://bing.com", "--alias=google"},
+			code:   exitError,
+			stderr: "create: alias google already exists\n",
+		},
+		{
+			name:   "Get",
+			args:   []string{"get", "--alias=google"},
+			stdout: "https://google.com\n",
+		},
+		{
+			name:   "Delete",
+			args:   []string{"delete", "--alias=google"},
+			stdout: "google\n",
+		},
+		{
+			name:   "Get deleted",
+			args:   []string{"get", "--alias=google"},
+			code:   exitError,
+			stderr: "get: alias google not found\n",
+		},
+		{
+			name:   "Delete missing",
+			args:   []string{"delete", "--alias=google"},
+			code:   exitError,
+			stderr: "delete: alias google not found\n",
+		},
+		{
+			name:   "Invalid url",
+			args:   []string{"create", "--url=ftp://google.com", "--alias=google"},
+			code:   exitError,
+			stderr: "create: url scheme is not allowed\n",
+		},
+		{
+			name:   "Reserved alias",
+			args:   []string{"create", "--url=https://google.com", "--alias=admin"},
+			code:   exitError,
+			stderr: "create: alias is reserved\n",
+		},
+		{
+			name:   "Missing url",
+			args:   []string{"create", "--alias=google"},
+			code:   exitUsage,
+			stderr: "--url is required\n",
+		},
+		{
+			name:   "Missing alias",
+			args:   []string{"get"},
+			code:   exitUsage,
+			stderr: "--alias is required\n",
+		},
+		{
+			name:   "Unknown command",
+			args:   []string{"list"},
+			code:   exitUsage,
+			stderr: `unknown command "list"`,
+		},
+	}
+
+	// steps share the storage, so they run in order
+	for _, step := range steps {
+		stdout.Reset()
+		stderr.Reset()
+
+		code := a.run(step.args)
+
+		require.Equal(t, step.code, code, step.name)
+		assert.Equal(t, step.stdout, stdout.String(), step.name)
+		if step.stderr != "" {
+			assert.True(t, strings.HasPrefix(stderr.String(), step.stderr), "%s: %q", step.name, stderr.String())
+		}
+	}
+}
+
+func TestRun_GeneratedAlias(t *testing.T) {
+	a, stdout, _ := newTestApp(t, &config.Config{
+		Alias: config.AliasConfig{Length: 8, SaveAttempts: 3},
+	})
+
+	require.Equal(t, 0, a.run([]string{"create", "--url=https://google.com"}))
+
+	fields := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\t")
+	require.Len(t, fields, 2)
+	assert.Regexp(t, regexp.MustCompile(`^[a-zA-Z0-9]{8}$`), fields[0])
+	assert.Equal(t, "https://google.com", fields[1])
+
+	stdout.Reset()
+	require.Equal(t, 0, a.run([]string{"get", "--alias=" + fields[0]}))
+	assert.Equal(t, "https://google.com\n", stdout.String())
+}
+
+func TestRun_CaseInsensitive(t *testing.T) {
+	a, stdout, _ := newTestApp(t, &config.Config{
+		Alias: config.AliasConfig{CaseInsensitive: true},
+	})
+
+	require.Equal(t, 0, a.run([]string{"create", "--url=https://google.com", "--alias=Foo"}))
+	assert.Equal(t, "foo\thttps://google.com\n", stdout.String())
+
+	stdout.Reset()
+	require.Equal(t, 0, a.run([]string{"get", "--alias=FOO"}))
+	assert.Equal(t, "https://google.com\n", stdout.String())
+}
@@ -4,8 +4,7 @@ What Is Remaining (The Next Steps)
   To build a more robust and scalable production system, consider these improvements:
 
   1. Improve Database Management
-   * Current: The application creates the database schema on startup.
-   * Next Step: Implement a database migration tool like golang-migrate/migrate to version your schema, enabling safer updates and rollbacks.
+   * Done: The schema is versioned in migrations/ and applied on startup with golang-migrate/migrate.
 
   2. Enhance Observability
    * Current: Basic logging, no application metrics.
@@ -33,25 +32,85 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"html/template"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+
+	shortenerv1 "url-shortener/api/shortener/v1"
 
 	"url-shortener/internal/cache"
 	"url-shortener/internal/config"
+	"url-shortener/internal/export"
+	"url-shortener/internal/grpc-server/shortener"
+	"url-shortener/internal/http-server/handlers/backup"
+	"url-shortener/internal/http-server/handlers/cache/invalidate"
+	"url-shortener/internal/http-server/handlers/capabilities"
+	"url-shortener/internal/http-server/handlers/download"
+	folderCreate "url-shortener/internal/http-server/handlers/folder/create"
+	folderDelete "url-shortener/internal/http-server/handlers/folder/delete"
+	folderList "url-shortener/internal/http-server/handlers/folder/list"
+	folderRename "url-shortener/internal/http-server/handlers/folder/rename"
+	"url-shortener/internal/http-server/handlers/health"
+	"url-shortener/internal/http-server/handlers/latency"
+	"url-shortener/internal/http-server/handlers/maintenance"
 	"url-shortener/internal/http-server/handlers/redirect"
+	"url-shortener/internal/http-server/handlers/robots"
+	"url-shortener/internal/http-server/handlers/url/analytics"
+	"url-shortener/internal/http-server/handlers/url/available"
+	"url-shortener/internal/http-server/handlers/url/batch"
+	"url-shortener/internal/http-server/handlers/url/delete"
+	"url-shortener/internal/http-server/handlers/url/duplicates"
+	"url-shortener/internal/http-server/handlers/url/external"
+	"url-shortener/internal/http-server/handlers/url/info"
+	"url-shortener/internal/http-server/handlers/url/list"
+	"url-shortener/internal/http-server/handlers/url/qr"
 	"url-shortener/internal/http-server/handlers/url/save"
+	"url-shortener/internal/http-server/handlers/url/search"
+	"url-shortener/internal/http-server/handlers/url/stats"
+	"url-shortener/internal/http-server/handlers/url/update"
+	"url-shortener/internal/http-server/handlers/url/validate"
+	mwAPIKey "url-shortener/internal/http-server/middleware/apikey"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/http-server/middleware/correlation"
+	mwLatency "url-shortener/internal/http-server/middleware/latency"
 	mwLogger "url-shortener/internal/http-server/middleware/logger"
+	mwMaintenance "url-shortener/internal/http-server/middleware/maintenance"
+	"url-shortener/internal/http-server/middleware/namespace"
+	"url-shortener/internal/http-server/middleware/ratelimit"
+	"url-shortener/internal/http-server/middleware/respcache"
+	mwShed "url-shortener/internal/http-server/middleware/shed"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/apikey"
+	"url-shortener/internal/lib/bloom"
+	"url-shortener/internal/lib/fingerprint"
+	"url-shortener/internal/lib/generator"
+	latencyRecorder "url-shortener/internal/lib/latency"
 	"url-shortener/internal/lib/logger/handlers/slogpretty"
 	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/metrics"
+	"url-shortener/internal/lib/normalize"
+	"url-shortener/internal/lib/retry"
+	"url-shortener/internal/lib/signing"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/fallback"
 	"url-shortener/internal/storage/postgres"
+	"url-shortener/internal/storage/sqlite"
+	"url-shortener/internal/webhook"
 )
 
 const (
@@ -60,7 +119,29 @@ const (
 	envProd  = "prod"
 )
 
+const (
+	driverPostgres = "postgres"
+	driverSQLite   = "sqlite"
+)
+
+// frontendAssets are the files served by the frontend routes.
+var frontendAssets = health.Files{
+	"frontend/index.html",
+	"frontend/style.css",
+	"frontend/script.js",
+}
+
+// version is set at build time with -ldflags "-X main.version=...".
+var version = "dev"
+
 func main() {
+	started := time.Now()
+
+	migrateOnly := flag.Bool("migrate-only", false, "apply database migrations and exit")
+	createAPIKey := flag.Bool("create-api-key", false, "create an API key, print it and exit")
+	checkFolding := flag.Bool("check-alias-folding", false, "report stored aliases that collide when folded and exit")
+	flag.Parse()
+
 	cfg := config.MustLoad()
 
 	log := setupLogger(cfg.Env)
@@ -68,44 +149,474 @@ func main() {
 	log.Info(
 		"starting url-shortener",
 		slog.String("env", cfg.Env),
-		slog.String("version", "123"),
+		slog.String("version", version),
 	)
 	log.Debug("debug messages are enabled")
 
+	for _, v := range config.Defaults(cfg) {
+		log.Debug("config", slog.String("env", v.Name), slog.String("value", v.Value))
+	}
+
 	psqlInfo := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		cfg.Postgres.Host, cfg.Postgres.Port, cfg.Postgres.User, cfg.Postgres.Password, cfg.Postgres.DBName)
 
-	storage, err := postgres.New(psqlInfo)
+	if cfg.Storage.Driver != driverPostgres && cfg.Storage.Driver != driverSQLite {
+		log.Error("unknown storage driver", slog.String("driver", cfg.Storage.Driver))
+		os.Exit(1)
+	}
+
+	if *migrateOnly {
+		migrate := func() (uint, bool, error) { return postgres.Migrate(psqlInfo, cfg.MigrationsPath) }
+		if cfg.Storage.Driver == driverSQLite {
+			migrate = func() (uint, bool, error) { return sqlite.Migrate(cfg.Storage.SQLitePath) }
+		}
+
+		version, dirty, err := migrate()
+		if err != nil {
+			log.Error("failed to apply migrations", sl.Err(err))
+			os.Exit(1)
+		}
+
+		log.Info("migrations applied", slog.Uint64("version", uint64(version)), slog.Bool("dirty", dirty))
+		return
+	}
+
+	if *createAPIKey {
+		if err := createKey(cfg, psqlInfo); err != nil {
+			log.Error("failed to create api key", sl.Err(err))
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *checkFolding {
+		n, err := checkAliasFolding(log, cfg, psqlInfo)
+		if err != nil {
+			log.Error("failed to check alias folding", sl.Err(err))
+			os.Exit(1)
+		}
+		if n > 0 {
+			log.Error("aliases collide when folded, resolve them before enabling alias.fold", slog.Int("collisions", n))
+			os.Exit(1)
+		}
+
+		log.Info("no aliases collide when folded")
+		return
+	}
+
+	// dependencies may still be starting, wait for them instead of crash-looping
+	startupRetry := retry.Options{
+		MaxAttempts: cfg.Startup.MaxAttempts,
+		Timeout:     cfg.Startup.Timeout,
+		Backoff:     cfg.Startup.Backoff,
+		MaxBackoff:  cfg.Startup.MaxBackoff,
+	}
+
+	storage, err := retry.Do(context.Background(), log.With(slog.String("dependency", cfg.Storage.Driver)), startupRetry,
+		func() (storage.Storage, error) {
+			return newStorage(cfg, psqlInfo)
+		},
+	)
 	if err != nil {
 		log.Error("failed to init storage", sl.Err(err))
 		os.Exit(1)
 	}
 
-	cache, err := cache.New(cfg.Redis.Address, cfg.Redis.Password, cfg.Redis.DB)
+	cache, err := newCache(log, cfg, startupRetry)
 	if err != nil {
 		log.Error("failed to init cache", sl.Err(err))
 		os.Exit(1)
 	}
 
+	if cfg.Alias.Folded() && cfg.Alias.FoldCheck {
+		// colliding aliases don't stop the service, they just shadow each other
+		collisions, err := aliasCollisions(storage)
+		if err != nil {
+			log.Error("failed to check alias folding", sl.Err(err))
+		}
+		logAliasCollisions(log, collisions)
+	}
+
+	var signer *signing.Signer
+	if cfg.Signing.Key != "" {
+		signer = signing.New(cfg.Signing.Key, cfg.Signing.Length)
+	}
+
+	var aliasGenerator generator.Generator = generator.Random{
+		Length:   cfg.Alias.Length,
+		Alphabet: cfg.Alias.Chars(),
+	}
+	if cfg.Alias.AutoScale.Enabled {
+		aliasGenerator, err = generator.NewScaling(context.Background(), log, storage, generator.ScalingOptions{
+			MinLength: cfg.Alias.Length,
+			MaxLength: cfg.Alias.AutoScale.MaxLength,
+			Window:    cfg.Alias.AutoScale.Window,
+			Threshold: cfg.Alias.AutoScale.Threshold,
+			Alphabet:  cfg.Alias.Chars(),
+		})
+		if err != nil {
+			log.Error("failed to init alias generator", sl.Err(err))
+			os.Exit(1)
+		}
+	}
+	if cfg.Alias.GeneratorURL != "" {
+		aliasGenerator = generator.NewHTTP(log, cfg.Alias.GeneratorURL,
+			&http.Client{Timeout: cfg.Alias.GeneratorTimeout},
+			aliasGenerator,
+		)
+	}
+
+	var aliases *bloom.Filter
+	if cfg.Alias.Bloom.Enabled {
+		var loaded int
+		aliases, loaded, err = loadAliases(storage, cfg.Alias.Bloom)
+		if err != nil {
+			log.Error("failed to load alias bloom filter", sl.Err(err))
+			os.Exit(1)
+		}
+		log.Info("alias bloom filter loaded", slog.Int("aliases", loaded))
+	}
+
+	var fallbackLinks *fallback.Fallback
+	if cfg.Fallback.Path != "" {
+		fallbackLinks, err = fallback.New(cfg.Fallback.Path)
+		if err != nil {
+			log.Error("failed to load fallback links", sl.Err(err))
+			os.Exit(1)
+		}
+		log.Info("fallback links loaded", slog.Int("links", fallbackLinks.Len()))
+
+		reload := make(chan os.Signal, 1)
+		signal.Notify(reload, syscall.SIGHUP)
+
+		go func() {
+			for range reload {
+				if err := fallbackLinks.Reload(); err != nil {
+					log.Error("failed to reload fallback links", sl.Err(err))
+					continue
+				}
+				log.Info("fallback links reloaded", slog.Int("links", fallbackLinks.Len()))
+			}
+		}()
+	}
+
+	var backups *export.S3
+	if cfg.Backup.Endpoint != "" {
+		backups, err = export.NewS3(export.S3Options{
+			Endpoint:  cfg.Backup.Endpoint,
+			Bucket:    cfg.Backup.Bucket,
+			AccessKey: cfg.Backup.AccessKey,
+			SecretKey: cfg.Backup.SecretKey,
+			UseSSL:    cfg.Backup.UseSSL,
+			Prefix:    cfg.Backup.Prefix,
+		})
+		if err != nil {
+			log.Error("failed to init backup store", sl.Err(err))
+			os.Exit(1)
+		}
+	}
+
+	// a nil *fingerprint.Fetcher must not end up in the interface
+	var fingerprinter save.Fingerprinter
+	if cfg.Fingerprint.Enabled {
+		fingerprinter = fingerprint.New(fingerprint.Options{
+			Timeout:  cfg.Fingerprint.Timeout,
+			MaxBytes: cfg.Fingerprint.MaxBytes,
+		})
+	}
+
+	var auditor *webhook.Sender
+	if cfg.Audit.WebhookURL != "" {
+		auditor = webhook.New(log, cfg.Audit.WebhookURL, &http.Client{Timeout: cfg.Audit.Timeout}, webhook.Options{
+			QueueSize: cfg.Audit.QueueSize,
+			Retry: retry.Options{
+				MaxAttempts: cfg.Audit.MaxAttempts,
+				Backoff:     cfg.Audit.Backoff,
+			},
+		})
+	}
+
+	maintenanceMode := mwMaintenance.NewMode(cfg.Maintenance.Enabled, cfg.Maintenance.RetryAfter)
+
 	router := chi.NewRouter()
 
-	router.Use(middleware.RequestID)
+	if cfg.HTTPServer.CorrelationHeader != "" {
+		router.Use(correlation.New(cfg.HTTPServer.CorrelationHeader))
+	} else {
+		router.Use(middleware.RequestID)
+	}
 	router.Use(middleware.Logger)
 	router.Use(mwLogger.New(log))
 	router.Use(middleware.Recoverer)
+	if len(cfg.Domains) > 0 {
+		router.Use(namespace.New(cfg.Domains))
+	}
+	if cfg.HTTPServer.ProblemDetails {
+		router.Use(resp.PreferProblems)
+	}
+	if cfg.Shedding.Enabled {
+		router.Use(mwShed.NewLimiter(log, cfg.Shedding.MaxInFlight, cfg.Shedding.ReservedForReads, cfg.Shedding.RetryAfter).Limit)
+	}
+
+	var latencies *latencyRecorder.Recorder
+	if cfg.Latency.Enabled {
+		latencies = latencyRecorder.NewRecorder(cfg.Latency.Window)
+		router.Use(mwLatency.New(log, latencies))
+	}
 
 	// Health check endpoint (supports both GET and HEAD)
-	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	})
-	router.Head("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-	})
+	statusOpts := health.StatusOptions{
+		JSON:    cfg.Health.JSON,
+		Version: version,
+		Started: started,
+	}
+	if cfg.Health.Assets {
+		statusOpts.Assets = frontendAssets
+	}
+	router.Get("/health", health.Status(statusOpts))
+	router.Head("/health", health.New(log, cfg.Health.Timeout, healthChecks(cfg, storage, cache)...))
+	// unlike /health, /ready always checks the dependencies
+	router.Get("/ready", health.Ready(log, cfg.Health.Timeout, readyChecks(cfg, storage, cache)...))
+
+	var hostMetrics *metrics.Hosts
+	if cfg.Metrics.Enabled {
+		registry := prometheus.NewRegistry()
+
+		hostMetrics, err = metrics.NewHosts(registry, cfg.Metrics.MaxHosts)
+		if err != nil {
+			log.Error("failed to register metrics", sl.Err(err))
+			os.Exit(1)
+		}
+
+		router.Handle(cfg.Metrics.Path, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	}
+
+	clicks := cfg.Stats.Clicks && cfg.Feature(config.FeatureAnalytics)
+	uniqueVisitors := cfg.Stats.UniqueVisitors && cfg.Feature(config.FeatureAnalytics)
+	clickEvents := cfg.Stats.Events && cfg.Feature(config.FeatureAnalytics)
+
+	var saveLimits []func(http.Handler) http.Handler
+	if rateLimit := cfg.HTTPServer.RateLimit; rateLimit.Enabled {
+		byIP := ratelimit.New(log, rateLimit.Requests, rateLimit.Window)
+
+		if rateLimit.PerKey {
+			saveLimits = append(saveLimits, ratelimit.NewKeyLimiter(log, cache, byIP, ratelimit.KeyOptions{
+				Key:      mwAPIKey.KeyHash,
+				Requests: rateLimit.KeyRequests,
+				Window:   rateLimit.Window,
+				Limits:   rateLimit.KeyLimits,
+			}).Limit)
+		} else {
+			saveLimits = append(saveLimits, byIP.Limit)
+		}
+	}
+
+	// cached responses about a link must go when it changes. They are
+	// keyed by the folded alias, as the handlers evict them, so requests
+	// for aliases folding alike share them.
+	infoKey := func(alias string) string {
+		if cfg.Alias.Folded() {
+			alias = normalize.Alias(alias)
+		}
+		return respcache.Key("/admin/url/" + alias)
+	}
+	var relatedKeys func(alias string) []string
+	if cfg.Responses.Cache {
+		relatedKeys = func(alias string) []string {
+			return []string{infoKey(alias)}
+		}
+	}
 
 	// API routes
 	router.Route("/url", func(r chi.Router) {
-		r.Post("/", save.New(log, storage, cache))
+		r.Use(auth.Admin(cfg.HTTPServer.User, cfg.HTTPServer.Password))
+		if cfg.HTTPServer.RequireAPIKey {
+			r.Use(mwAPIKey.New(log, storage))
+		}
+		r.Use(maintenanceMode.BlockWrites)
+
+		saveOpts := save.Options{
+			FoldAliases:           cfg.Alias.Folded(),
+			Signer:                signer,
+			MinUserAliasLength:    cfg.Alias.MinUserLength,
+			AllowedSchemes:        cfg.URL.AllowedSchemes,
+			Sponsored:             cfg.Ads.Enabled && cfg.Feature(config.FeatureInterstitials),
+			Passwords:             cfg.Feature(config.FeaturePasswords),
+			Generator:             aliasGenerator,
+			RejectURLAliases:      cfg.Alias.RejectURLs,
+			ReservedAliases:       cfg.Alias.Reserved,
+			ReturnIDs:             cfg.URL.NumericIDs,
+			Fingerprinter:         fingerprinter,
+			Audit:                 auditor != nil,
+			Deduplicate:           cfg.URL.DeduplicateSaves,
+			ReferrerAllowlists:    cfg.URL.ReferrerAllowlists,
+			BaseURL:               cfg.HTTPServer.BaseURL,
+			CanonicalQuery:        cfg.URL.CanonicalQuery,
+			CacheTTL:              cfg.Redis.TTL,
+			RecordCreator:         cfg.URL.RecordCreator,
+			SaveAttempts:          cfg.Alias.SaveAttempts,
+			QueryTimeout:          cfg.Postgres.QueryTimeout,
+			ExternalIDs:           cfg.URL.ExternalIDs,
+			ExternalIDsPerCreator: cfg.URL.ExternalIDsPerCreator,
+			SplitTargets:          cfg.URL.SplitTargets,
+			NormalizeURLs:         cfg.URL.NormalizeURLs,
+			RequireOwner:          cfg.URL.RequireOwner,
+		}
+		if aliases != nil {
+			saveOpts.Aliases = aliases
+		}
+		if cfg.URL.ReuseAliases {
+			saveOpts.Existing = storage
+		}
+		if cfg.Folders.Enabled {
+			saveOpts.Folders = storage
+		}
+		r.With(saveLimits...).Post("/", save.New(log, storage, cache, saveOpts))
+		if cfg.URL.ExternalIDs {
+			r.Get("/by-external/{id}", external.New(log, storage, external.Options{
+				PerCreator: cfg.URL.ExternalIDsPerCreator,
+				BaseURL:    cfg.HTTPServer.BaseURL,
+			}))
+		}
+
+		batchOpts := batch.Options{
+			MaxItems:           cfg.URL.BatchLimit,
+			FoldAliases:        cfg.Alias.Folded(),
+			MinUserAliasLength: cfg.Alias.MinUserLength,
+			AllowedSchemes:     cfg.URL.AllowedSchemes,
+			RejectURLAliases:   cfg.Alias.RejectURLs,
+			ReservedAliases:    cfg.Alias.Reserved,
+			CanonicalQuery:     cfg.URL.CanonicalQuery,
+			Signer:             signer,
+			Generator:          aliasGenerator,
+			BaseURL:            cfg.HTTPServer.BaseURL,
+			CacheTTL:           cfg.Redis.TTL,
+			RequireOwner:       cfg.URL.RequireOwner,
+			SaveAttempts:       cfg.Alias.SaveAttempts,
+		}
+		if aliases != nil {
+			batchOpts.Aliases = aliases
+		}
+		r.With(saveLimits...).Post("/batch", batch.New(log, storage, cache, batchOpts))
+		// anyone may save links, only admins may browse, change and delete them
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.BasicAuth("url-shortener", map[string]string{
+				cfg.HTTPServer.User: cfg.HTTPServer.Password,
+			}))
+
+			r.Get("/", list.New(log, storage))
+			r.Put("/{alias}", update.New(log, storage, cache, update.Options{
+				FoldAliases:    cfg.Alias.Folded(),
+				AllowedSchemes: cfg.URL.AllowedSchemes,
+				CanonicalQuery: cfg.URL.CanonicalQuery,
+				NormalizeURLs:  cfg.URL.NormalizeURLs,
+				RelatedKeys:    relatedKeys,
+			}))
+			r.Delete("/{alias}", delete.New(log, storage, cache, delete.Options{
+				FoldAliases: cfg.Alias.Folded(),
+				RelatedKeys: relatedKeys,
+			}))
+		})
+		r.Get("/{alias}/qr", qr.New(log, storage, qr.Options{
+			FoldAliases: cfg.Alias.Folded(),
+			BaseURL:     cfg.HTTPServer.BaseURL,
+		}))
+		r.Get("/{alias}/available", available.New(log, storage, available.Options{
+			FoldAliases:     cfg.Alias.Folded(),
+			ReservedAliases: cfg.Alias.Reserved,
+		}))
+		r.Post("/validate", validate.New(log, validate.Options{
+			AllowedSchemes: cfg.URL.AllowedSchemes,
+		}))
+		if clicks || uniqueVisitors {
+			statsOpts := stats.Options{
+				FoldAliases: cfg.Alias.Folded(),
+			}
+			if uniqueVisitors {
+				statsOpts.Visitors = cache
+			}
+			if clicks && cfg.URL.SplitTargets {
+				statsOpts.Variants = storage
+			}
+			r.Get("/{alias}/stats", stats.New(log, storage, statsOpts))
+		}
+		if clickEvents {
+			r.Get("/{alias}/analytics", analytics.New(log, storage, analytics.Options{
+				FoldAliases: cfg.Alias.Folded(),
+				MaxDays:     cfg.Stats.EventDays,
+			}))
+		}
+	})
+
+	// Admin routes
+	router.Route("/admin", func(r chi.Router) {
+		r.Use(middleware.BasicAuth("url-shortener", map[string]string{
+			cfg.HTTPServer.User: cfg.HTTPServer.Password,
+		}))
+
+		r.Post("/cache/invalidate", invalidate.New(log, cache, invalidate.Options{
+			FoldAliases: cfg.Alias.Folded(),
+			RelatedKeys: relatedKeys,
+		}))
+
+		r.Get("/url/search", search.New(log, storage, search.Options{
+			FoldAliases: cfg.Alias.Folded(),
+			MaxLimit:    cfg.URL.SearchLimit,
+		}))
+
+		if clicks || uniqueVisitors {
+			batchOpts := stats.BatchOptions{
+				FoldAliases: cfg.Alias.Folded(),
+				MaxAliases:  cfg.Stats.BatchLimit,
+			}
+			if uniqueVisitors {
+				batchOpts.Visitors = cache
+			}
+			r.Post("/url/stats", stats.NewBatch(log, storage, batchOpts))
+		}
+
+		if cfg.Folders.Enabled {
+			r.Route("/folders", func(r chi.Router) {
+				r.Get("/", folderList.New(log, storage))
+				r.Post("/", folderCreate.New(log, storage))
+				r.Put("/{id}", folderRename.New(log, storage))
+				r.Delete("/{id}", folderDelete.New(log, storage, cache, folderDelete.Options{
+					Cascade: cfg.Folders.OnDelete == config.FolderDeleteCascade,
+				}))
+			})
+		}
+
+		r.Get("/maintenance", maintenance.Get(maintenanceMode))
+		r.Put("/maintenance", maintenance.Set(log, maintenanceMode))
+
+		r.Group(func(r chi.Router) {
+			if cfg.Responses.Cache {
+				r.Use(respcache.New(log, cache, cfg.Responses.TTL, func(r *http.Request) string {
+					return infoKey(chi.URLParam(r, "alias"))
+				}))
+			}
+
+			r.Get("/url/{alias}", info.New(log, storage, info.Options{
+				FoldAliases:   cfg.Alias.Folded(),
+				ExpiryWarning: cfg.URL.ExpiryWarning,
+			}))
+		})
+
+		if latencies != nil {
+			r.Get("/latency", latency.New(log, latencies))
+		}
+		if cfg.Fingerprint.Enabled {
+			r.Get("/duplicates", duplicates.New(log, storage))
+		}
+		if cfg.Export.Enabled {
+			r.Get("/export", download.New(log, storage, download.Options{
+				Gzip:       cfg.Export.Gzip,
+				FlushEvery: cfg.Export.FlushEvery,
+			}))
+		}
+		if backups != nil {
+			r.Post("/backup", backup.New(log, backups, storage))
+		}
 	})
 
 	// Serve index.html at root
@@ -123,9 +634,132 @@ func main() {
 		http.ServeFile(w, r, "frontend/script.js")
 	})
 
+	// Keep crawlers away from aliases
+	router.Get("/robots.txt", robots.New(cfg.HTTPServer.Robots))
+	if cfg.HTTPServer.Capabilities {
+		capabilitiesOpts := capabilities.Options{
+			Sponsored:          cfg.Ads.Enabled && cfg.Feature(config.FeatureInterstitials),
+			Analytics:          cfg.Feature(config.FeatureAnalytics),
+			Passwords:          cfg.Feature(config.FeaturePasswords),
+			SignedLinks:        signer != nil,
+			SplitTargets:       cfg.URL.SplitTargets,
+			ExternalIDs:        cfg.URL.ExternalIDs,
+			ReferrerAllowlists: cfg.URL.ReferrerAllowlists,
+			NumericIDs:         cfg.URL.NumericIDs,
+			Folders:            cfg.Folders.Enabled,
+			Audit:              auditor != nil,
+			AliasLength:        cfg.Alias.Length,
+			MinUserAliasLength: cfg.Alias.MinUserLength,
+			FoldAliases:        cfg.Alias.Folded(),
+			ReservedAliases:    cfg.Alias.Reserved,
+			AllowedSchemes:     cfg.URL.AllowedSchemes,
+			BatchLimit:         cfg.URL.BatchLimit,
+			RequireOwner:       cfg.URL.RequireOwner,
+			RequireAPIKey:      cfg.HTTPServer.RequireAPIKey,
+		}
+		if rateLimit := cfg.HTTPServer.RateLimit; rateLimit.Enabled {
+			capabilitiesOpts.RateLimitRequests = rateLimit.Requests
+			capabilitiesOpts.RateLimitWindow = rateLimit.Window
+			if rateLimit.PerKey {
+				capabilitiesOpts.RateLimitKeyRequests = rateLimit.KeyRequests
+			}
+		}
+		router.Get("/capabilities", capabilities.New(capabilitiesOpts))
+	}
+
 	// Redirect route (catches all other GET requests as aliases)
 	// This must be last to avoid catching static files
-	router.Get("/{alias}", redirect.New(log, storage, cache))
+	redirectOpts := redirect.Options{
+		FoldAliases:    cfg.Alias.Folded(),
+		Signer:         signer,
+		LinkHeaders:    cfg.Redirect.LinkHeaders,
+		CacheTTL:       cfg.Redis.TTL,
+		QueryTimeout:   cfg.Postgres.QueryTimeout,
+		ExpiryWarning:  cfg.URL.ExpiryWarning,
+		RedirectStatus: cfg.HTTPServer.RedirectStatus,
+	}
+	if auditor != nil {
+		redirectOpts.Auditor = auditor
+	}
+	if uniqueVisitors {
+		redirectOpts.Visitors = cache
+	}
+	if clicks {
+		redirectOpts.Clicks = storage
+	}
+	if clickEvents {
+		redirectOpts.Analytics = storage
+	}
+	if cfg.LastAccess.Enabled {
+		redirectOpts.Toucher = storage
+		redirectOpts.TouchInterval = cfg.LastAccess.Interval
+	}
+	if cfg.Ads.Enabled && cfg.Feature(config.FeatureInterstitials) {
+		redirectOpts.Interstitial = &redirect.Interstitial{
+			SkipAfter: cfg.Ads.SkipAfter,
+			Snippet:   template.HTML(cfg.Ads.Snippet),
+			CSP:       cfg.Redirect.CSP,
+		}
+	}
+
+	if cfg.Redirect.StaleWhileRevalidate {
+		redirectOpts.StaleWhileRevalidate = &redirect.StaleWhileRevalidate{
+			SoftTTL: cfg.Redirect.SoftTTL,
+			HardTTL: cfg.Redirect.HardTTL,
+		}
+	}
+
+	if fallbackLinks != nil {
+		redirectOpts.Fallback = fallbackLinks
+	}
+	if hostMetrics != nil {
+		redirectOpts.Hosts = hostMetrics
+	}
+	if aliases != nil {
+		redirectOpts.Aliases = aliases
+	}
+
+	if cfg.URL.SplitTargets {
+		redirectOpts.Split = &redirect.Split{
+			Sticky:    cfg.Redirect.StickySplit,
+			CookieTTL: cfg.Redirect.SplitCookieTTL,
+		}
+		if clicks {
+			redirectOpts.Split.Clicks = storage
+		}
+	}
+
+	if cfg.Redirect.Splash {
+		redirectOpts.Splash = &redirect.Splash{
+			Threshold: cfg.Redirect.SplashThreshold,
+			Brand:     cfg.Redirect.SplashBrand,
+			CSP:       cfg.Redirect.CSP,
+		}
+	}
+
+	if len(cfg.Redirect.LoopHosts) > 0 {
+		redirectOpts.LoopDetection = &redirect.LoopDetection{
+			Hosts:   cfg.Redirect.LoopHosts,
+			MaxHops: cfg.Redirect.MaxHops,
+		}
+	}
+
+	if len(cfg.Redirect.DelayHosts) > 0 {
+		hosts := make(map[string]time.Duration, len(cfg.Redirect.DelayHosts))
+		for host, d := range cfg.Redirect.DelayHosts {
+			hosts[strings.ToLower(host)] = d
+		}
+
+		redirectOpts.Delay = &redirect.Delay{
+			Hosts: hosts,
+			Max:   cfg.Redirect.MaxDelay,
+		}
+	}
+
+	if cfg.URL.NumericIDs {
+		router.Get("/i/{id}", redirect.NewByID(log, storage, cache, redirectOpts))
+	}
+	router.Get("/{alias}", redirect.New(log, storage, cache, redirectOpts))
 
 	log.Info("starting server", slog.String("address", cfg.Address))
 
@@ -141,18 +775,57 @@ func main() {
 	}
 
 	go func() {
-		if err := srv.ListenAndServe(); err != nil {
-			log.Error("failed to start server")
+		// Shutdown makes ListenAndServe return ErrServerClosed
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("failed to start server", sl.Err(err))
 		}
 	}()
 
 	log.Info("server started")
 
+	var grpcSrv *grpc.Server
+	if cfg.GRPC.Enabled {
+		lis, err := net.Listen("tcp", cfg.GRPC.Address)
+		if err != nil {
+			log.Error("failed to listen for grpc", sl.Err(err))
+			os.Exit(1)
+		}
+
+		shortenerOpts := shortener.Options{
+			FoldAliases:     cfg.Alias.Folded(),
+			Signer:          signer,
+			AllowedSchemes:  cfg.URL.AllowedSchemes,
+			Generator:       aliasGenerator,
+			ReservedAliases: cfg.Alias.Reserved,
+			CanonicalQuery:  cfg.URL.CanonicalQuery,
+			NormalizeURLs:   cfg.URL.NormalizeURLs,
+			CacheTTL:        cfg.Redis.TTL,
+			RequireOwner:    cfg.URL.RequireOwner,
+			Maintenance:     maintenanceMode,
+		}
+		if aliases != nil {
+			shortenerOpts.Aliases = aliases
+		}
+		if cfg.URL.SplitTargets && clicks {
+			shortenerOpts.VariantClicks = storage
+		}
+
+		grpcSrv = grpc.NewServer()
+		shortenerv1.RegisterShortenerServer(grpcSrv, shortener.New(log, storage, cache, shortenerOpts))
+
+		go func() {
+			if err := grpcSrv.Serve(lis); err != nil {
+				log.Error("failed to start grpc server", sl.Err(err))
+			}
+		}()
+
+		log.Info("grpc server started", slog.String("address", cfg.GRPC.Address))
+	}
+
 	<-done
 	log.Info("stopping server")
 
-	// TODO: move timeout to config
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.HTTPServer.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
@@ -160,12 +833,21 @@ func main() {
 		return
 	}
 
+	if grpcSrv != nil {
+		grpcSrv.GracefulStop()
+	}
+
+	// deliver the audit events of the last redirects
+	if auditor != nil {
+		auditor.Close()
+	}
+
 	// Close storage
 	if err := storage.Close(); err != nil {
 		log.Error("failed to close storage", sl.Err(err))
 	}
 
-	// Close cache
+	// Close cache, evicting the keys of redis.evict_on_shutdown
 	if err := cache.Close(); err != nil {
 		log.Error("failed to close cache", sl.Err(err))
 	}
@@ -173,6 +855,205 @@ func main() {
 	log.Info("server stopped")
 }
 
+// newStorage opens the storage backend selected by cfg.Storage.Driver.
+func newStorage(cfg *config.Config, psqlInfo string) (storage.Storage, error) {
+	if cfg.Storage.Driver == driverSQLite {
+		s, err := sqlite.New(cfg.Storage.SQLitePath)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+
+	s, err := postgres.New(psqlInfo, cfg.MigrationsPath, postgres.PoolOptions{
+		MaxOpenConns:    cfg.Postgres.MaxOpenConns,
+		MaxIdleConns:    cfg.Postgres.MaxIdleConns,
+		ConnMaxLifetime: cfg.Postgres.ConnMaxLifetime,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// createKey stores a new API key and prints it. Only its hash is kept,
+// the key can't be shown again.
+func createKey(cfg *config.Config, psqlInfo string) error {
+	s, err := newStorage(cfg, psqlInfo)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	key, err := apikey.Generate()
+	if err != nil {
+		return err
+	}
+
+	if err := s.SaveAPIKey(key); err != nil {
+		return err
+	}
+
+	fmt.Println(key)
+
+	return nil
+}
+
+// checkAliasFolding logs the stored aliases that collide when folded and
+// returns the number of collisions.
+func checkAliasFolding(log *slog.Logger, cfg *config.Config, psqlInfo string) (int, error) {
+	s, err := newStorage(cfg, psqlInfo)
+	if err != nil {
+		return 0, err
+	}
+	defer s.Close()
+
+	collisions, err := aliasCollisions(s)
+	if err != nil {
+		return 0, err
+	}
+	logAliasCollisions(log, collisions)
+
+	return len(collisions), nil
+}
+
+// aliasCollisions returns the stored aliases that fold to the same alias,
+// by folded alias.
+func aliasCollisions(s storage.Storage) (map[string][]string, error) {
+	var aliases []string
+	err := s.ExportURLs(context.Background(), func(u storage.URL) error {
+		aliases = append(aliases, u.Alias)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return normalize.AliasCollisions(aliases), nil
+}
+
+func logAliasCollisions(log *slog.Logger, collisions map[string][]string) {
+	for folded, aliases := range collisions {
+		log.Warn("stored aliases collide when folded",
+			slog.String("folded", folded),
+			slog.Any("aliases", aliases),
+		)
+	}
+}
+
+// loadAliases fills a Bloom filter with every stored alias. It returns
+// the number of aliases added.
+func loadAliases(s storage.Storage, cfg config.BloomConfig) (*bloom.Filter, int, error) {
+	filter := bloom.New(cfg.Capacity, cfg.FalsePositiveRate)
+
+	var n int
+	err := s.ExportURLs(context.Background(), func(u storage.URL) error {
+		filter.Add(u.Alias)
+		n++
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return filter, n, nil
+}
+
+// urlCache is the cache shared by the handlers, Redis or a no-op
+// stand-in when Redis is optional and unreachable.
+type urlCache interface {
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error)
+	Get(ctx context.Context, key string) (string, error)
+	GetEntry(ctx context.Context, key string) (cache.Entry, error)
+	PFAdd(ctx context.Context, key string, els ...interface{}) error
+	PFCount(ctx context.Context, keys ...string) (int64, error)
+	PFCountEach(ctx context.Context, keys []string) ([]int64, error)
+	Delete(ctx context.Context, key string) error
+	DeleteMany(ctx context.Context, keys []string) error
+	IncrWindow(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error)
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// newCache connects to Redis. An optional Redis gets a single attempt,
+// the service runs uncached rather than wait for it.
+func newCache(log *slog.Logger, cfg *config.Config, startupRetry retry.Options) (urlCache, error) {
+	connect := func() (*cache.Cache, error) {
+		return cache.New(cfg.Redis.Address, cfg.Redis.Password, cfg.Redis.DB)
+	}
+
+	var (
+		c   *cache.Cache
+		err error
+	)
+	if cfg.Redis.Required {
+		c, err = retry.Do(context.Background(), log.With(slog.String("dependency", "redis")), startupRetry, connect)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		c, err = connect()
+		if err != nil {
+			log.Warn("redis is unavailable, running without cache", sl.Err(err))
+			return cache.NewNoop(), nil
+		}
+	}
+
+	if cfg.Redis.MaxValueSize > 0 {
+		c.LimitValueSize(log, cfg.Redis.MaxValueSize)
+	}
+	if len(cfg.Redis.EvictOnShutdown) > 0 {
+		c.EvictOnClose(cfg.Redis.EvictOnShutdown)
+	}
+
+	return c, nil
+}
+
+// healthChecks returns the dependencies reported by HEAD /health.
+// Load balancer probes only see them when enabled in config.
+func healthChecks(cfg *config.Config, storage storage.Storage, cache urlCache) []health.Check {
+	var checks []health.Check
+
+	if cfg.Health.Dependencies {
+		storageName := "Postgres"
+		if cfg.Storage.Driver == driverSQLite {
+			storageName = "SQLite"
+		}
+
+		checks = append(checks,
+			health.Check{Name: storageName, Pinger: storage},
+			health.Check{Name: "Redis", Pinger: cache},
+		)
+	}
+
+	// API-only deployments don't ship the frontend, so this is opt-in
+	if cfg.Health.Assets {
+		checks = append(checks, health.Check{Name: "Assets", Pinger: frontendAssets})
+	}
+
+	return checks
+}
+
+// readyChecks returns the dependencies reported by GET /ready. An
+// optional Redis is left out, the service works without it.
+func readyChecks(cfg *config.Config, storage storage.Storage, cache urlCache) []health.Check {
+	storageName := "Postgres"
+	if cfg.Storage.Driver == driverSQLite {
+		storageName = "SQLite"
+	}
+
+	checks := []health.Check{{Name: storageName, Pinger: storage}}
+	if cfg.Redis.Required {
+		checks = append(checks, health.Check{Name: "Redis", Pinger: cache})
+	}
+	if cfg.Health.Assets {
+		checks = append(checks, health.Check{Name: "Assets", Pinger: frontendAssets})
+	}
+
+	return checks
+}
+
 func setupLogger(env string) *slog.Logger {
 	var log *slog.Logger
 
@@ -16,7 +16,7 @@ document.getElementById('shorten-form').addEventListener('submit', async (event)
         const data = await response.json();
 
         if (response.ok) {
-            const shortenedURL = `${window.location.origin}/${data.alias}`;
+            const shortenedURL = data.short_url || `${window.location.origin}/${data.alias}`;
             resultDiv.innerHTML = `
                 <p>Shortened URL:</p>
                 <a href="${shortenedURL}" target="_blank">${shortenedURL}</a>
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// DefaultTTL is how long links are cached unless configured otherwise.
+const DefaultTTL = 5 * time.Minute
+
+// Entry is the cached form of a link, enough to redirect without storage.
+// It is stored as JSON; go-redis encodes it through MarshalBinary.
+type Entry struct {
+	URL string `json:"url"`
+	// Code is the redirect status code, the handler's default when zero.
+	Code      int        `json:"code,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Enabled   bool       `json:"enabled"`
+	// FreshUntil is the soft TTL, after it the entry is still served but
+	// should be revalidated. StaleUntil is the hard TTL, after it the entry
+	// must not be served at all.
+	FreshUntil *time.Time `json:"fresh_until,omitempty"`
+	StaleUntil *time.Time `json:"stale_until,omitempty"`
+}
+
+// Usable reports whether the entry can be served at now. Expired,
+// disabled and entries past their hard TTL must be resolved from storage.
+func (e Entry) Usable(now time.Time) bool {
+	return e.Enabled &&
+		(e.ExpiresAt == nil || now.Before(*e.ExpiresAt)) &&
+		(e.StaleUntil == nil || now.Before(*e.StaleUntil))
+}
+
+// Stale reports whether the entry is past its soft TTL at now.
+func (e Entry) Stale(now time.Time) bool {
+	return e.FreshUntil != nil && !now.Before(*e.FreshUntil)
+}
+
+// CapTTL returns ttl, shortened so the entry doesn't outlive ExpiresAt.
+// ok is false when no time is left, the entry must not be cached then:
+// Redis would keep it forever with a zero TTL.
+func (e Entry) CapTTL(ttl time.Duration, now time.Time) (_ time.Duration, ok bool) {
+	if e.ExpiresAt != nil {
+		ttl = min(ttl, e.ExpiresAt.Sub(now))
+	}
+
+	return ttl, ttl > 0
+}
+
+func (e Entry) MarshalBinary() ([]byte, error) {
+	return json.Marshal(e)
+}
+
+// UnmarshalBinary decodes an entry. Values cached before entries were
+// introduced hold the bare target URL and decode as an enabled link to it.
+func (e *Entry) UnmarshalBinary(data []byte) error {
+	if !bytes.HasPrefix(data, []byte("{")) {
+		*e = Entry{URL: string(data), Enabled: true}
+		return nil
+	}
+
+	return json.Unmarshal(data, e)
+}
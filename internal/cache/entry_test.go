@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntry_RoundTrip(t *testing.T) {
+	expiresAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		entry Entry
+	}{
+		{
+			name:  "url only",
+			entry: Entry{URL: "https://example.com", Enabled: true},
+		},
+		{
+			name: "all fields",
+			entry: Entry{
+				URL:       "https://example.com/path?q=1",
+				Code:      301,
+				ExpiresAt: &expiresAt,
+				Enabled:   true,
+			},
+		},
+		{
+			name:  "disabled",
+			entry: Entry{URL: "https://example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.entry.MarshalBinary()
+			require.NoError(t, err)
+
+			var got Entry
+			require.NoError(t, got.UnmarshalBinary(data))
+			assert.Equal(t, tt.entry, got)
+		})
+	}
+}
+
+func TestEntry_UnmarshalBinary_Legacy(t *testing.T) {
+	var got Entry
+	require.NoError(t, got.UnmarshalBinary([]byte("https://example.com")))
+	assert.Equal(t, Entry{URL: "https://example.com", Enabled: true}, got)
+}
+
+func TestEntry_Usable(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+
+	assert.True(t, Entry{Enabled: true}.Usable(now))
+	assert.True(t, Entry{Enabled: true, ExpiresAt: &future}.Usable(now))
+	assert.False(t, Entry{Enabled: true, ExpiresAt: &past}.Usable(now))
+	assert.False(t, Entry{}.Usable(now))
+	assert.True(t, Entry{Enabled: true, StaleUntil: &future}.Usable(now))
+	assert.False(t, Entry{Enabled: true, StaleUntil: &past}.Usable(now))
+}
+
+func TestEntry_Stale(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+
+	assert.False(t, Entry{Enabled: true}.Stale(now))
+	assert.False(t, Entry{Enabled: true, FreshUntil: &future}.Stale(now))
+	assert.True(t, Entry{Enabled: true, FreshUntil: &past}.Stale(now))
+}
+
+func TestEntry_CapTTL(t *testing.T) {
+	now := time.Now()
+	soon, later := now.Add(time.Minute), now.Add(time.Hour)
+
+	ttl, ok := Entry{}.CapTTL(5*time.Minute, now)
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Minute, ttl)
+
+	ttl, ok = Entry{ExpiresAt: &soon}.CapTTL(5*time.Minute, now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, ttl)
+
+	ttl, ok = Entry{ExpiresAt: &later}.CapTTL(5*time.Minute, now)
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Minute, ttl)
+}
+
+func TestEntry_CapTTLExpired(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+
+	// a zero TTL would cache the entry forever
+	_, ok := Entry{ExpiresAt: &now}.CapTTL(5*time.Minute, now)
+	assert.False(t, ok)
+
+	_, ok = Entry{ExpiresAt: &past}.CapTTL(5*time.Minute, now)
+	assert.False(t, ok)
+}
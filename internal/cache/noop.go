@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrNoop is reported by the health check of a Noop cache.
+var ErrNoop = errors.New("cache is disabled")
+
+// Noop is a cache that holds nothing, for running without Redis. Every
+// read misses and every write succeeds, so links are always resolved
+// from storage.
+type Noop struct{}
+
+func NewNoop() *Noop {
+	return &Noop{}
+}
+
+func (Noop) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	return nil
+}
+
+// SetNX never sets key. Nothing is throttled without Redis, so
+// throttled work such as last access updates is skipped rather than
+// done on every request.
+func (Noop) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	return false, nil
+}
+
+func (Noop) Get(ctx context.Context, key string) (string, error) {
+	return "", redis.Nil
+}
+
+func (Noop) GetEntry(ctx context.Context, key string) (Entry, error) {
+	return Entry{}, redis.Nil
+}
+
+func (Noop) PFAdd(ctx context.Context, key string, els ...interface{}) error {
+	return nil
+}
+
+func (Noop) PFCount(ctx context.Context, keys ...string) (int64, error) {
+	return 0, nil
+}
+
+func (Noop) PFCountEach(ctx context.Context, keys []string) ([]int64, error) {
+	return make([]int64, len(keys)), nil
+}
+
+func (Noop) Delete(ctx context.Context, key string) error {
+	return nil
+}
+
+func (Noop) DeleteMany(ctx context.Context, keys []string) error {
+	return nil
+}
+
+// IncrWindow counts every hit as the first of a window, so nothing is
+// ever rate limited.
+func (Noop) IncrWindow(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	return 1, window, nil
+}
+
+// Ping fails, so health checks report the cache as down.
+func (Noop) Ping(ctx context.Context) error {
+	return ErrNoop
+}
+
+func (Noop) Close() error {
+	return nil
+}
@@ -0,0 +1,34 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNoop(t *testing.T) {
+	c := NewNoop()
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "google", Entry{URL: "https://google.com", Enabled: true}, time.Minute))
+
+	// writes are dropped, reads miss like an empty Redis
+	_, err := c.GetEntry(ctx, "google")
+	assert.ErrorIs(t, err, redis.Nil)
+	_, err = c.Get(ctx, "google")
+	assert.ErrorIs(t, err, redis.Nil)
+
+	ok, err := c.SetNX(ctx, "last_access:google", 1, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	counts, err := c.PFCountEach(ctx, []string{"visitors:a", "visitors:b"})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{0, 0}, counts)
+
+	assert.ErrorIs(t, c.Ping(ctx), ErrNoop)
+}
@@ -2,6 +2,10 @@ package cache
 
 import (
 	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -9,8 +13,19 @@ import (
 
 type Cache struct {
 	client *redis.Client
+
+	log          *slog.Logger
+	maxValueSize int
+	evictOnClose []string
 }
 
+// evictTimeout bounds the eviction Close runs before disconnecting.
+const evictTimeout = 5 * time.Second
+
+// scanCount is the number of keys DeleteMatching asks SCAN for and
+// deletes at once.
+const scanCount = 500
+
 func New(address string, password string, db int) (*Cache, error) {
 	rdb := redis.NewClient(&redis.Options{
 		Addr:     address,
@@ -25,14 +40,204 @@ func New(address string, password string, db int) (*Cache, error) {
 	return &Cache{client: rdb}, nil
 }
 
+// LimitValueSize makes Set skip values larger than maxBytes, so a
+// pathological target can't take a large share of Redis memory. Skipped
+// keys are logged to log at debug level and keep being read from storage.
+func (c *Cache) LimitValueSize(log *slog.Logger, maxBytes int) {
+	c.log = log
+	c.maxValueSize = maxBytes
+}
+
+// EvictOnClose makes Close delete the keys matching patterns before it
+// disconnects, "*" deleting every key of the database. Entries cached by
+// one version are then never read by the next, whose format may differ.
+func (c *Cache) EvictOnClose(patterns []string) {
+	c.evictOnClose = patterns
+}
+
 func (c *Cache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	if c.maxValueSize > 0 {
+		if size, ok := valueSize(value); ok && size > c.maxValueSize {
+			c.log.Debug("value too large to cache, skipping",
+				slog.String("key", key),
+				slog.Int("size", size),
+				slog.Int("max_size", c.maxValueSize),
+			)
+			return nil
+		}
+	}
+
 	return c.client.Set(ctx, key, value, expiration).Err()
 }
 
+// valueSize returns the encoded size of value. Numbers and other small
+// values aren't measured.
+func valueSize(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case string:
+		return len(v), true
+	case []byte:
+		return len(v), true
+	case encoding.BinaryMarshaler:
+		b, err := v.MarshalBinary()
+		if err != nil {
+			// let Set report the error
+			return 0, false
+		}
+		return len(b), true
+	default:
+		return 0, false
+	}
+}
+
+// SetNX sets key only if it doesn't exist yet and reports whether it did.
+func (c *Cache) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	return c.client.SetNX(ctx, key, value, expiration).Result()
+}
+
 func (c *Cache) Get(ctx context.Context, key string) (string, error) {
 	return c.client.Get(ctx, key).Result()
 }
 
+// GetEntry reads a link cached as an Entry.
+func (c *Cache) GetEntry(ctx context.Context, key string) (Entry, error) {
+	var e Entry
+	if err := c.client.Get(ctx, key).Scan(&e); err != nil {
+		return Entry{}, err
+	}
+
+	return e, nil
+}
+
+// VisitorsKey is the HyperLogLog key counting unique visitors of alias.
+func VisitorsKey(alias string) string {
+	return "visitors:" + alias
+}
+
+// PFAdd adds elements to the HyperLogLog at key.
+func (c *Cache) PFAdd(ctx context.Context, key string, els ...interface{}) error {
+	return c.client.PFAdd(ctx, key, els...).Err()
+}
+
+// PFCount returns the approximate number of distinct elements added to
+// the HyperLogLogs at keys.
+func (c *Cache) PFCount(ctx context.Context, keys ...string) (int64, error) {
+	return c.client.PFCount(ctx, keys...).Result()
+}
+
+// PFCountEach returns the approximate number of distinct elements added
+// to the HyperLogLog at every key, in a single round-trip.
+func (c *Cache) PFCountEach(ctx context.Context, keys []string) ([]int64, error) {
+	pipe := c.client.Pipeline()
+	cmds := make([]*redis.IntCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.PFCount(ctx, key)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	counts := make([]int64, len(keys))
+	for i, cmd := range cmds {
+		counts[i] = cmd.Val()
+	}
+
+	return counts, nil
+}
+
+// Delete evicts key.
+func (c *Cache) Delete(ctx context.Context, key string) error {
+	return c.client.Del(ctx, key).Err()
+}
+
+// DeleteMany evicts keys in a single round-trip.
+func (c *Cache) DeleteMany(ctx context.Context, keys []string) error {
+	pipe := c.client.Pipeline()
+	for _, key := range keys {
+		pipe.Del(ctx, key)
+	}
+
+	_, err := pipe.Exec(ctx)
+
+	return err
+}
+
+// DeleteMatching evicts the keys matching the glob-style pattern and
+// returns how many were deleted. Keys are scanned in batches, so Redis
+// isn't blocked the way KEYS would block it.
+func (c *Cache) DeleteMatching(ctx context.Context, pattern string) (int64, error) {
+	var (
+		deleted int64
+		batch   = make([]string, 0, scanCount)
+	)
+	unlink := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		n, err := c.client.Unlink(ctx, batch...).Result()
+		deleted += n
+		batch = batch[:0]
+		return err
+	}
+
+	iter := c.client.Scan(ctx, 0, pattern, scanCount).Iterator()
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) == scanCount {
+			if err := unlink(); err != nil {
+				return deleted, err
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return deleted, err
+	}
+
+	return deleted, unlink()
+}
+
+// incrWindow counts a hit in the window of key, starting the window on
+// the first hit, and returns the count and the time left in the window.
+var incrWindow = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if n == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return {n, redis.call('PTTL', KEYS[1])}
+`)
+
+// IncrWindow counts a hit at key in fixed windows of the given length.
+// It returns the hits so far in the current window and the time until it
+// ends.
+func (c *Cache) IncrWindow(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	res, err := incrWindow.Run(ctx, c.client, []string{key}, window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return res[0], time.Duration(res[1]) * time.Millisecond, nil
+}
+
+func (c *Cache) Ping(ctx context.Context) error {
+	return c.client.Ping(ctx).Err()
+}
+
+// Close disconnects from Redis, first evicting the keys set by
+// EvictOnClose.
 func (c *Cache) Close() error {
-	return c.client.Close()
+	var evictErr error
+	if len(c.evictOnClose) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), evictTimeout)
+		defer cancel()
+
+		for _, pattern := range c.evictOnClose {
+			if _, err := c.DeleteMatching(ctx, pattern); err != nil {
+				evictErr = fmt.Errorf("evict %q: %w", pattern, err)
+				break
+			}
+		}
+	}
+
+	return errors.Join(evictErr, c.client.Close())
 }
@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestCache_LimitValueSize(t *testing.T) {
+	// nothing listens here, so any write that reaches Redis fails
+	c := &Cache{client: redis.NewClient(&redis.Options{
+		Addr:        "127.0.0.1:1",
+		MaxRetries:  -1,
+		DialTimeout: 100 * time.Millisecond,
+	})}
+	defer c.Close()
+
+	c.LimitValueSize(slogdiscard.NewDiscardLogger(), 64)
+
+	ctx := context.Background()
+	long := "https://example.com/" + strings.Repeat("a", 64)
+
+	assert.NoError(t, c.Set(ctx, "huge", Entry{URL: long, Enabled: true}, time.Minute), "oversized entry must be skipped")
+	assert.NoError(t, c.Set(ctx, "huge", long, time.Minute), "oversized string must be skipped")
+
+	assert.Error(t, c.Set(ctx, "small", Entry{URL: "https://example.com", Enabled: true}, time.Minute), "small entry must be written")
+	assert.Error(t, c.Set(ctx, "counter", 1, time.Minute), "unmeasured values must be written")
+}
+
+func TestCache_EvictOnClose(t *testing.T) {
+	unreachable := func() *Cache {
+		// nothing listens here, so any command that reaches Redis fails
+		return &Cache{client: redis.NewClient(&redis.Options{
+			Addr:        "127.0.0.1:1",
+			MaxRetries:  -1,
+			DialTimeout: 100 * time.Millisecond,
+		})}
+	}
+
+	assert.NoError(t, unreachable().Close(), "close without eviction must not reach Redis")
+
+	c := unreachable()
+	c.EvictOnClose([]string{"*"})
+	assert.Error(t, c.Close(), "close must run the eviction")
+}
@@ -1,58 +1,622 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
+
+	"url-shortener/internal/lib/random"
+	"url-shortener/internal/lib/validate"
 )
 
 type Config struct {
-	Env        string         `yaml:"env" env-default:"local"`
-	Postgres   PostgresConfig `yaml:"postgres"`
-	Redis      RedisConfig    `yaml:"redis"`
-	HTTPServer `yaml:"http_server"`
+	Env         string            `yaml:"env" env:"ENV" env-default:"local"`
+	Storage     StorageConfig     `yaml:"storage" env-prefix:"STORAGE_"`
+	Fallback    FallbackConfig    `yaml:"fallback" env-prefix:"FALLBACK_"`
+	Postgres    PostgresConfig    `yaml:"postgres" env-prefix:"POSTGRES_"`
+	Redis       RedisConfig       `yaml:"redis" env-prefix:"REDIS_"`
+	Health      HealthConfig      `yaml:"health" env-prefix:"HEALTH_"`
+	Metrics     MetricsConfig     `yaml:"metrics" env-prefix:"METRICS_"`
+	Alias       AliasConfig       `yaml:"alias" env-prefix:"ALIAS_"`
+	Signing     SigningConfig     `yaml:"signing" env-prefix:"SIGNING_"`
+	LastAccess  LastAccessConfig  `yaml:"last_access" env-prefix:"LAST_ACCESS_"`
+	Latency     LatencyConfig     `yaml:"latency" env-prefix:"LATENCY_"`
+	URL         URLConfig         `yaml:"url" env-prefix:"URL_"`
+	Ads         AdsConfig         `yaml:"ads" env-prefix:"ADS_"`
+	Redirect    RedirectConfig    `yaml:"redirect" env-prefix:"REDIRECT_"`
+	Backup      BackupConfig      `yaml:"backup" env-prefix:"BACKUP_"`
+	Responses   ResponsesConfig   `yaml:"responses" env-prefix:"RESPONSES_"`
+	GRPC        GRPCConfig        `yaml:"grpc" env-prefix:"GRPC_"`
+	Fingerprint FingerprintConfig `yaml:"fingerprint" env-prefix:"FINGERPRINT_"`
+	Startup     StartupConfig     `yaml:"startup" env-prefix:"STARTUP_"`
+	Audit       AuditConfig       `yaml:"audit" env-prefix:"AUDIT_"`
+	Export      ExportConfig      `yaml:"export" env-prefix:"EXPORT_"`
+	Stats       StatsConfig       `yaml:"stats" env-prefix:"STATS_"`
+	Maintenance MaintenanceConfig `yaml:"maintenance" env-prefix:"MAINTENANCE_"`
+	Shedding    SheddingConfig    `yaml:"shedding" env-prefix:"SHEDDING_"`
+	Folders     FoldersConfig     `yaml:"folders" env-prefix:"FOLDERS_"`
+	HTTPServer  `yaml:"http_server" env-prefix:"HTTP_SERVER_"`
+
+	// MigrationsPath is the directory of the Postgres schema migrations.
+	MigrationsPath string `yaml:"migrations_path" env:"MIGRATIONS_PATH" env-default:"./migrations"`
+
+	// Features turns features on or off by name, overriding the defaults
+	// of Env. See Feature.
+	Features map[string]bool `yaml:"features" env:"FEATURES"`
+
+	// Domains maps vanity domains to alias namespaces, so the same alias
+	// resolves to different links on each of them. Other hosts share the
+	// default namespace.
+	Domains map[string]string `yaml:"domains" env:"DOMAINS"`
+}
+
+// Features that can be toggled in Config.Features. A disabled feature
+// stays off whatever its own section says.
+const (
+	// FeatureInterstitials shows the ad interstitial before sponsored links.
+	FeatureInterstitials = "interstitials"
+	// FeatureAnalytics counts clicks and unique visitors, and records
+	// click events.
+	FeatureAnalytics = "analytics"
+	// FeaturePasswords lets new links be password protected. Links
+	// protected before it was turned off keep asking for their password.
+	FeaturePasswords = "passwords"
+)
+
+// Actions on the links of a deleted folder, see FoldersConfig.OnDelete.
+const (
+	// FolderDeleteOrphan keeps the links and moves them out of any folder.
+	FolderDeleteOrphan = "orphan"
+	// FolderDeleteCascade deletes the links along with the folder.
+	FolderDeleteCascade = "cascade"
+)
+
+// DefaultShutdownTimeout is the shutdown timeout when none is set.
+const DefaultShutdownTimeout = 10 * time.Second
+
+// RedirectStatuses are the status codes redirects may be configured with.
+var RedirectStatuses = []int{
+	http.StatusMovedPermanently,
+	http.StatusFound,
+	http.StatusTemporaryRedirect,
+	http.StatusPermanentRedirect,
+}
+
+// featureDefaults are the features enabled in every environment. Local
+// runs skip ads and analytics, which only make sense with real traffic.
+var featureDefaults = map[string]map[string]bool{
+	"local": {
+		FeatureInterstitials: false,
+		FeatureAnalytics:     false,
+		FeaturePasswords:     true,
+	},
+	"dev": {
+		FeatureInterstitials: true,
+		FeatureAnalytics:     true,
+		FeaturePasswords:     true,
+	},
+	"prod": {
+		FeatureInterstitials: true,
+		FeatureAnalytics:     true,
+		FeaturePasswords:     true,
+	},
+}
+
+// Feature reports whether the named feature is enabled, as set in
+// Features or else by default in Env. Unknown environments get the
+// defaults of prod.
+func (c *Config) Feature(name string) bool {
+	if enabled, ok := c.Features[name]; ok {
+		return enabled
+	}
+
+	defaults, ok := featureDefaults[c.Env]
+	if !ok {
+		defaults = featureDefaults["prod"]
+	}
+
+	return defaults[name]
+}
+
+type AliasConfig struct {
+	// Fold treats aliases case- and accent-insensitively ("Café" == "cafe").
+	Fold bool `yaml:"fold" env:"FOLD" env-default:"false"`
+	// FoldCheck logs stored aliases that fold to the same alias at startup
+	// when Fold is enabled: only the one already folded still resolves.
+	// Run with -check-alias-folding to list them before enabling Fold.
+	FoldCheck bool `yaml:"fold_check" env:"FOLD_CHECK" env-default:"true"`
+	// CaseInsensitive lowercases aliases on save and lookup, so "MyLink"
+	// and "mylink" are the same link. Custom aliases are ASCII, so it
+	// stores aliases as Fold does, which it turns on. Links saved with
+	// uppercase letters before it was enabled no longer resolve, find
+	// the colliding ones with -check-alias-folding.
+	CaseInsensitive bool `yaml:"case_insensitive" env:"CASE_INSENSITIVE" env-default:"false"`
+	// MinUserLength reserves shorter custom aliases for admins.
+	MinUserLength int `yaml:"min_user_length" env:"MIN_USER_LENGTH" env-default:"0"`
+	// Length is the length of random aliases, the minimum length when
+	// AutoScale is enabled.
+	Length int `yaml:"length" env:"LENGTH" env-default:"6"`
+	// Alphabet is the characters random aliases are drawn from: one of
+	// the AlphabetBase62 or AlphabetUnambiguous presets, or the characters
+	// themselves, e.g. "0123456789abcdef". They must be valid in custom
+	// aliases too.
+	Alphabet string `yaml:"alphabet" env:"ALPHABET" env-default:"base62"`
+	// SaveAttempts is the number of generated aliases tried before a save
+	// fails because they were all taken.
+	SaveAttempts int `yaml:"save_attempts" env:"SAVE_ATTEMPTS" env-default:"3"`
+	// GeneratorURL delegates alias generation to an external ID service,
+	// aliases are generated locally when it is unset or unavailable.
+	GeneratorURL     string        `yaml:"generator_url" env:"GENERATOR_URL"`
+	GeneratorTimeout time.Duration `yaml:"generator_timeout" env:"GENERATOR_TIMEOUT" env-default:"500ms"`
+	// RejectURLs rejects custom aliases that are URLs, e.g. "http://x".
+	RejectURLs bool `yaml:"reject_urls" env:"REJECT_URLS" env-default:"true"`
+	// Reserved are custom aliases refused because they would shadow routes.
+	Reserved []string `yaml:"reserved" env:"RESERVED" env-default:"health,ready,metrics,url,admin,i,robots.txt,style.css,script.js,capabilities"`
+	// AutoScale grows locally generated aliases as the keyspace fills up.
+	AutoScale AutoScaleConfig `yaml:"auto_scale" env-prefix:"AUTO_SCALE_"`
+	// Bloom keeps an in-memory Bloom filter of the stored aliases.
+	Bloom BloomConfig `yaml:"bloom" env-prefix:"BLOOM_"`
+}
+
+// Alphabet presets of AliasConfig.Alphabet.
+const (
+	// AlphabetBase62 is the ASCII letters and digits.
+	AlphabetBase62 = "base62"
+	// AlphabetUnambiguous leaves out 0, O, 1, I and l, which are easily
+	// mistaken for one another when aliases are read or retyped.
+	AlphabetUnambiguous = "unambiguous"
+)
+
+// alphabets are the characters of the Alphabet presets.
+var alphabets = map[string]string{
+	AlphabetBase62:      random.Base62,
+	AlphabetUnambiguous: random.Unambiguous,
+}
+
+// Chars returns the characters random aliases are drawn from, those of
+// the Alphabet preset or else Alphabet itself.
+func (c AliasConfig) Chars() string {
+	if chars, ok := alphabets[c.Alphabet]; ok {
+		return chars
+	}
+
+	return c.Alphabet
+}
+
+// checkAlias checks that random aliases of c can be generated and saved.
+func checkAlias(c AliasConfig) error {
+	if c.Length < 1 || c.Length > validate.AliasMaxLength {
+		return fmt.Errorf("length must be between 1 and %d: %d", validate.AliasMaxLength, c.Length)
+	}
+
+	chars := c.Chars()
+	if chars == "" {
+		return errors.New("alphabet must not be empty")
+	}
+	for i, r := range chars {
+		if !validate.AliasRune(r) {
+			return fmt.Errorf("alphabet contains %q, which is not valid in aliases", r)
+		}
+		// repeated characters would be drawn more often
+		if strings.ContainsRune(chars[:i], r) {
+			return fmt.Errorf("alphabet repeats %q", r)
+		}
+	}
+
+	return nil
+}
+
+// Folded reports whether aliases are folded to their canonical form on
+// save and lookup, which either Fold or CaseInsensitive asks for.
+func (c AliasConfig) Folded() bool {
+	return c.Fold || c.CaseInsensitive
+}
+
+// BloomConfig configures the alias Bloom filter, which lets redirects of
+// unknown aliases skip the storage lookup. It is filled at startup and
+// learns aliases saved by this instance only, so it is meant for single
+// instance deployments: links saved elsewhere would not be found.
+type BloomConfig struct {
+	Enabled bool `yaml:"enabled" env:"ENABLED" env-default:"false"`
+	// Capacity is the expected number of aliases, more degrade the
+	// false positive rate.
+	Capacity          int     `yaml:"capacity" env:"CAPACITY" env-default:"1000000"`
+	FalsePositiveRate float64 `yaml:"false_positive_rate" env:"FALSE_POSITIVE_RATE" env-default:"0.01"`
+}
+
+type AutoScaleConfig struct {
+	Enabled bool `yaml:"enabled" env:"ENABLED" env-default:"false"`
+	// MaxLength caps the generated alias length.
+	MaxLength int `yaml:"max_length" env:"MAX_LENGTH" env-default:"12"`
+	// Window is the number of generated aliases the collision rate is taken over.
+	Window int `yaml:"window" env:"WINDOW" env-default:"1000"`
+	// Threshold is the collision rate above which aliases get one character longer.
+	Threshold float64 `yaml:"threshold" env:"THRESHOLD" env-default:"0.01"`
+}
+
+type URLConfig struct {
+	// AllowedSchemes of target URLs, e.g. mailto, tel and geo besides http(s).
+	AllowedSchemes []string `yaml:"allowed_schemes" env:"ALLOWED_SCHEMES" env-default:"http,https"`
+	// NumericIDs returns link ids on save and resolves them on /i/{id}.
+	NumericIDs bool `yaml:"numeric_ids" env:"NUMERIC_IDS" env-default:"false"`
+	// DeduplicateSaves collapses identical concurrent saves into one insert.
+	DeduplicateSaves bool `yaml:"deduplicate_saves" env:"DEDUPLICATE_SAVES" env-default:"false"`
+	// ExpiryWarning adds X-Link-Expires-In to the redirects and info of
+	// links expiring within it, so clients can prompt for a fresh link.
+	// Zero disables it.
+	ExpiryWarning time.Duration `yaml:"expiry_warning" env:"EXPIRY_WARNING" env-default:"0"`
+	// BatchLimit caps the links of a POST /url/batch request.
+	BatchLimit int `yaml:"batch_limit" env:"BATCH_LIMIT" env-default:"500"`
+	// CanonicalQuery sorts the query parameters of targets before they are
+	// stored, so "?b=2&a=1" and "?a=1&b=2" are the same link. Repeated
+	// parameters keep their order.
+	CanonicalQuery bool `yaml:"canonical_query" env:"CANONICAL_QUERY" env-default:"false"`
+	// ReferrerAllowlists lets links restrict the Referer domains they may
+	// be used from, other referrers get 403.
+	ReferrerAllowlists bool `yaml:"referrer_allowlists" env:"REFERRER_ALLOWLISTS" env-default:"false"`
+	// SearchLimit caps the aliases returned by /admin/url/search.
+	SearchLimit int `yaml:"search_limit" env:"SEARCH_LIMIT" env-default:"20"`
+	// RecordCreator stores the IP, user agent and identity of whoever saves
+	// a link, shown on /admin/url/{alias}. Off for privacy by default.
+	RecordCreator bool `yaml:"record_creator" env:"RECORD_CREATOR" env-default:"false"`
+	// ExternalIDs lets links be saved under a unique external_id of the
+	// client, resolved back to the link on /url/by-external/{id}.
+	ExternalIDs bool `yaml:"external_ids" env:"EXTERNAL_IDS" env-default:"false"`
+	// ExternalIDsPerCreator makes external ids unique per admin or API key
+	// instead of per namespace, each only resolving its own.
+	ExternalIDsPerCreator bool `yaml:"external_ids_per_creator" env:"EXTERNAL_IDS_PER_CREATOR" env-default:"false"`
+	// SplitTargets lets links be saved with weighted variants, each
+	// redirect being sent to one drawn by weight.
+	SplitTargets bool `yaml:"split_targets" env:"SPLIT_TARGETS" env-default:"false"`
+	// NormalizeURLs stores the targets of POST /url in a canonical form,
+	// lowercasing hosts, dropping default ports, the trailing slash of
+	// empty paths and sorting query parameters.
+	NormalizeURLs bool `yaml:"normalize_urls" env:"NORMALIZE_URLS" env-default:"false"`
+	// ReuseAliases answers saves of plain links without a custom alias
+	// with the alias of an existing plain link to the same target.
+	ReuseAliases bool `yaml:"reuse_aliases" env:"REUSE_ALIASES" env-default:"false"`
+	// RequireOwner rejects links created without the email of an owner,
+	// sent as "owner" in the body, in X-Link-Owner or as x-link-owner
+	// gRPC metadata. Owners are shown to admins and in audit events.
+	RequireOwner bool `yaml:"require_owner" env:"REQUIRE_OWNER" env-default:"false"`
+}
+
+type SigningConfig struct {
+	// Key enables signed links when set.
+	Key    string `yaml:"key" env:"KEY" secret:"true"`
+	Length int    `yaml:"length" env:"LENGTH" env-default:"8"`
+}
+
+type LastAccessConfig struct {
+	Enabled bool `yaml:"enabled" env:"ENABLED" env-default:"false"`
+	// Interval is the minimum time between two updates of the same alias.
+	Interval time.Duration `yaml:"interval" env:"INTERVAL" env-default:"1m"`
+}
+
+type LatencyConfig struct {
+	Enabled bool `yaml:"enabled" env:"ENABLED" env-default:"false"`
+	// Window is the number of most recent requests kept per route.
+	Window int `yaml:"window" env:"WINDOW" env-default:"1024"`
+}
+
+type AdsConfig struct {
+	// Enabled lets links opt into the sponsored interstitial.
+	Enabled   bool          `yaml:"enabled" env:"ENABLED" env-default:"false"`
+	SkipAfter time.Duration `yaml:"skip_after" env:"SKIP_AFTER" env-default:"5s"`
+	// Snippet is the ad markup shown on the interstitial.
+	Snippet string `yaml:"snippet" env:"SNIPPET"`
+}
+
+type RedirectConfig struct {
+	// LoopHosts are the hosts the shortener is served on. Targets on them
+	// are resolved up to MaxHops deep to detect redirect loops.
+	LoopHosts []string `yaml:"loop_hosts" env:"LOOP_HOSTS"`
+	MaxHops   int      `yaml:"max_hops" env:"MAX_HOPS" env-default:"5"`
+	// LinkHeaders exposes link metadata in X-Link-* headers on redirects.
+	// Cached targets are bypassed to read it, so it is off by default.
+	LinkHeaders bool `yaml:"link_headers" env:"LINK_HEADERS" env-default:"false"`
+	// DelayHosts holds back redirects to abused target hosts,
+	// e.g. {"victim.example": 2s}. Delays are capped at MaxDelay.
+	DelayHosts map[string]time.Duration `yaml:"delay_hosts" env:"DELAY_HOSTS"`
+	MaxDelay   time.Duration            `yaml:"max_delay" env:"MAX_DELAY" env-default:"5s"`
+	// StaleWhileRevalidate serves cached links past SoftTTL while refreshing
+	// them in the background, and drops them from the cache after HardTTL.
+	StaleWhileRevalidate bool          `yaml:"stale_while_revalidate" env:"STALE_WHILE_REVALIDATE" env-default:"false"`
+	SoftTTL              time.Duration `yaml:"soft_ttl" env:"SOFT_TTL" env-default:"1m"`
+	HardTTL              time.Duration `yaml:"hard_ttl" env:"HARD_TTL" env-default:"1h"`
+	// Splash serves a loading page branded SplashBrand when resolving a link
+	// from storage takes longer than SplashThreshold, and redirects from it.
+	Splash          bool          `yaml:"splash" env:"SPLASH" env-default:"false"`
+	SplashThreshold time.Duration `yaml:"splash_threshold" env:"SPLASH_THRESHOLD" env-default:"500ms"`
+	SplashBrand     string        `yaml:"splash_brand" env:"SPLASH_BRAND" env-default:"URL Shortener"`
+	// CSP sends a Content-Security-Policy with the interstitial and splash
+	// pages that only lets their own inline scripts run, by a nonce fresh
+	// for every page. Scripts in ads.snippet are blocked.
+	CSP bool `yaml:"csp" env:"CSP" env-default:"false"`
+	// StickySplit keeps visitors of split links on the variant they were
+	// first sent to, remembered in a cookie for SplitCookieTTL.
+	StickySplit    bool          `yaml:"sticky_split" env:"STICKY_SPLIT" env-default:"false"`
+	SplitCookieTTL time.Duration `yaml:"split_cookie_ttl" env:"SPLIT_COOKIE_TTL" env-default:"720h"`
+}
+
+// BackupConfig is the S3-compatible bucket exports are uploaded to.
+// Backups are disabled when Endpoint is unset.
+type BackupConfig struct {
+	Endpoint  string `yaml:"endpoint" env:"ENDPOINT"`
+	Bucket    string `yaml:"bucket" env:"BUCKET"`
+	AccessKey string `yaml:"access_key" env:"ACCESS_KEY" secret:"true"`
+	SecretKey string `yaml:"secret_key" env:"SECRET_KEY" secret:"true"`
+	UseSSL    bool   `yaml:"use_ssl" env:"USE_SSL" env-default:"true"`
+	Prefix    string `yaml:"prefix" env:"PREFIX" env-default:"backups/"`
+}
+
+type ResponsesConfig struct {
+	// Cache serves repeated admin GET requests from Redis for TTL.
+	Cache bool          `yaml:"cache" env:"CACHE" env-default:"false"`
+	TTL   time.Duration `yaml:"ttl" env:"TTL" env-default:"10s"`
+}
+
+type MaintenanceConfig struct {
+	// Enabled starts the server in maintenance mode: writes to /url are
+	// rejected while redirects keep working. It can be toggled at runtime
+	// on /admin/maintenance.
+	Enabled    bool          `yaml:"enabled" env:"ENABLED" env-default:"false"`
+	RetryAfter time.Duration `yaml:"retry_after" env:"RETRY_AFTER" env-default:"5m"`
+}
+
+type MetricsConfig struct {
+	// Enabled serves Prometheus metrics on Path.
+	Enabled bool   `yaml:"enabled" env:"ENABLED" env-default:"false"`
+	Path    string `yaml:"path" env:"PATH" env-default:"/metrics"`
+	// MaxHosts caps the target hosts redirects are labeled with, the
+	// rest are counted as "other".
+	MaxHosts int `yaml:"max_hosts" env:"MAX_HOSTS" env-default:"100"`
+}
+
+type SheddingConfig struct {
+	// Enabled answers requests past MaxInFlight with 503. Writes are shed
+	// first, ReservedForReads of the capacity is kept for redirects.
+	Enabled          bool          `yaml:"enabled" env:"ENABLED" env-default:"false"`
+	MaxInFlight      int           `yaml:"max_in_flight" env:"MAX_IN_FLIGHT" env-default:"1000"`
+	ReservedForReads int           `yaml:"reserved_for_reads" env:"RESERVED_FOR_READS" env-default:"200"`
+	RetryAfter       time.Duration `yaml:"retry_after" env:"RETRY_AFTER" env-default:"1s"`
+}
+
+type FoldersConfig struct {
+	// Enabled serves folder management on /folders and lets links be
+	// filed in a folder.
+	Enabled bool `yaml:"enabled" env:"ENABLED" env-default:"false"`
+	// OnDelete is what happens to the links of a deleted folder and its
+	// subfolders, FolderDeleteOrphan or FolderDeleteCascade.
+	OnDelete string `yaml:"on_delete" env:"ON_DELETE" env-default:"orphan"`
+}
+
+type StatsConfig struct {
+	// Clicks counts the redirects of every alias in storage and serves
+	// the count on /url/{alias}/stats.
+	Clicks bool `yaml:"clicks" env:"CLICKS" env-default:"true"`
+	// UniqueVisitors estimates the distinct visitors of every alias in
+	// Redis and serves the estimate on /url/{alias}/stats.
+	UniqueVisitors bool `yaml:"unique_visitors" env:"UNIQUE_VISITORS" env-default:"false"`
+	// BatchLimit caps the aliases of a single POST /admin/url/stats.
+	BatchLimit int `yaml:"batch_limit" env:"BATCH_LIMIT" env-default:"100"`
+	// Events records the time, referrer, user agent and IP of every
+	// redirect and serves daily counts on /url/{alias}/analytics.
+	Events bool `yaml:"events" env:"EVENTS" env-default:"false"`
+	// EventDays caps the days reported by /url/{alias}/analytics.
+	EventDays int `yaml:"event_days" env:"EVENT_DAYS" env-default:"30"`
+}
+
+type ExportConfig struct {
+	// Enabled serves a JSON lines download of all links on /admin/export.
+	Enabled bool `yaml:"enabled" env:"ENABLED" env-default:"false"`
+	// Gzip compresses downloads for clients accepting gzip.
+	Gzip bool `yaml:"gzip" env:"GZIP" env-default:"true"`
+	// FlushEvery is the number of links streamed between flushes.
+	FlushEvery int `yaml:"flush_every" env:"FLUSH_EVERY" env-default:"1000"`
+}
+
+type AuditConfig struct {
+	// WebhookURL receives every redirect of links saved with "audited": true.
+	// Audited links are rejected when it is empty.
+	WebhookURL  string        `yaml:"webhook_url" env:"WEBHOOK_URL" secret:"true"`
+	Timeout     time.Duration `yaml:"timeout" env:"TIMEOUT" env-default:"5s"`
+	QueueSize   int           `yaml:"queue_size" env:"QUEUE_SIZE" env-default:"1000"`
+	MaxAttempts int           `yaml:"max_attempts" env:"MAX_ATTEMPTS" env-default:"5"`
+	Backoff     time.Duration `yaml:"backoff" env:"BACKOFF" env-default:"1s"`
+}
+
+type StartupConfig struct {
+	// MaxAttempts and Timeout bound how long startup waits for Postgres
+	// and Redis to accept connections, each, before giving up.
+	MaxAttempts int           `yaml:"max_attempts" env:"MAX_ATTEMPTS" env-default:"10"`
+	Timeout     time.Duration `yaml:"timeout" env:"TIMEOUT" env-default:"1m"`
+	Backoff     time.Duration `yaml:"backoff" env:"BACKOFF" env-default:"500ms"`
+	MaxBackoff  time.Duration `yaml:"max_backoff" env:"MAX_BACKOFF" env-default:"10s"`
+}
+
+type FingerprintConfig struct {
+	// Enabled fetches link targets at save time and stores a hash of their
+	// body, to group aliases serving the same content. Only public
+	// addresses are fetched.
+	Enabled  bool          `yaml:"enabled" env:"ENABLED" env-default:"false"`
+	Timeout  time.Duration `yaml:"timeout" env:"TIMEOUT" env-default:"5s"`
+	MaxBytes int64         `yaml:"max_bytes" env:"MAX_BYTES" env-default:"10485760"`
+}
+
+type GRPCConfig struct {
+	// Enabled serves alias resolution and shortening over gRPC on Address.
+	Enabled bool   `yaml:"enabled" env:"ENABLED" env-default:"false"`
+	Address string `yaml:"address" env:"ADDRESS" env-default:":9090"`
+}
+
+type HealthConfig struct {
+	Dependencies bool          `yaml:"dependencies" env:"DEPENDENCIES" env-default:"false"`
+	Assets       bool          `yaml:"assets" env:"ASSETS" env-default:"false"`
+	Timeout      time.Duration `yaml:"timeout" env:"TIMEOUT" env-default:"2s"`
+	// JSON reports status, uptime and version on GET /health
+	// instead of a plain "OK".
+	JSON bool `yaml:"json" env:"JSON" env-default:"false"`
 }
 
 type RedisConfig struct {
-	Address  string `yaml:"address" env-required:"true"`
-	Password string `yaml:"password"`
-	DB       int    `yaml:"db" env-default:"0"`
+	Address  string `yaml:"address" env:"ADDRESS" env-required:"true"`
+	Password string `yaml:"password" env:"PASSWORD" secret:"true"`
+	DB       int    `yaml:"db" env:"DB" env-default:"0"`
+	// TTL is how long links are cached, unless redirect.stale_while_revalidate
+	// sets soft and hard TTLs.
+	TTL time.Duration `yaml:"ttl" env:"TTL" env-default:"5m"`
+	// Required fails startup when Redis can't be reached. Otherwise the
+	// service starts without a cache and resolves every link from storage.
+	Required bool `yaml:"required" env:"REQUIRED" env-default:"true"`
+	// MaxValueSize is the largest value cached, in bytes. Larger links are
+	// always read from Postgres. No limit when zero.
+	MaxValueSize int `yaml:"max_value_size" env:"MAX_VALUE_SIZE" env-default:"0"`
+	// EvictOnShutdown are key patterns deleted on graceful shutdown, "*"
+	// flushing the whole database, so the next version doesn't read
+	// entries in a format it no longer expects. Off by default, as the
+	// database may be shared.
+	EvictOnShutdown []string `yaml:"evict_on_shutdown" env:"EVICT_ON_SHUTDOWN"`
+}
+
+type StorageConfig struct {
+	// Driver is "postgres" or "sqlite". SQLite needs no database server,
+	// for local development.
+	Driver string `yaml:"driver" env:"DRIVER" env-default:"postgres"`
+	// SQLitePath is the database file of the sqlite driver.
+	SQLitePath string `yaml:"sqlite_path" env:"SQLITE_PATH" env-default:"./storage/storage.db"`
+}
+
+type FallbackConfig struct {
+	// Path is a JSON object of alias to URL, redirected to when the cache
+	// and storage can't resolve an alias. It is reloaded on SIGHUP.
+	Path string `yaml:"path" env:"PATH"`
 }
 
 type PostgresConfig struct {
-	Host     string `yaml:"host" env-required:"true"`
-	Port     string `yaml:"port" env-required:"true"`
-	User     string `yaml:"user" env-required:"true"`
-	Password string `yaml:"password" env-required:"true" env:"POSTGRES_PASSWORD"`
-	DBName   string `yaml:"dbname" env-required:"true"`
+	Host     string `yaml:"host" env:"HOST" env-required:"true"`
+	Port     string `yaml:"port" env:"PORT" env-required:"true"`
+	User     string `yaml:"user" env:"USER" env-required:"true"`
+	Password string `yaml:"password" env:"PASSWORD" env-required:"true" secret:"true"`
+	DBName   string `yaml:"dbname" env:"DBNAME" env-required:"true"`
+	// QueryTimeout bounds the storage queries of redirects and saves, which
+	// then fail with 503 instead of holding the request. Zero disables it.
+	QueryTimeout time.Duration `yaml:"query_timeout" env:"QUERY_TIMEOUT" env-default:"3s"`
+	// MaxOpenConns caps the connections to Postgres, in use or idle, so
+	// load can't exhaust the server's connection slots.
+	MaxOpenConns int `yaml:"max_open_conns" env:"MAX_OPEN_CONNS" env-default:"25"`
+	// MaxIdleConns caps the connections kept open between requests.
+	MaxIdleConns int `yaml:"max_idle_conns" env:"MAX_IDLE_CONNS" env-default:"5"`
+	// ConnMaxLifetime recycles connections once they are this old.
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"CONN_MAX_LIFETIME" env-default:"5m"`
 }
 
 type HTTPServer struct {
-	Address     string        `yaml:"address" env-default:"localhost:8080"`
-	Timeout     time.Duration `yaml:"timeout" env-default:"4s"`
-	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"60s"`
-	User        string        `yaml:"user" env-required:"true"`
-	Password    string        `yaml:"password" env-required:"true" env:"HTTP_SERVER_PASSWORD"`
+	Address     string        `yaml:"address" env:"ADDRESS" env-default:"localhost:8080"`
+	Timeout     time.Duration `yaml:"timeout" env:"TIMEOUT" env-default:"4s"`
+	IdleTimeout time.Duration `yaml:"idle_timeout" env:"IDLE_TIMEOUT" env-default:"60s"`
+	// ShutdownTimeout is how long in-flight requests may take to finish
+	// once the server is stopping, DefaultShutdownTimeout when zero.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" env-default:"10s"`
+	Robots          string        `yaml:"robots" env:"ROBOTS"`
+	// Capabilities serves GET /capabilities, describing the enabled
+	// features, alias and URL rules and rate limits to clients.
+	Capabilities bool `yaml:"capabilities" env:"CAPABILITIES" env-default:"false"`
+	// ProblemDetails renders all errors as RFC 7807 application/problem+json.
+	// Clients can still ask for it with the Accept header when it is off.
+	ProblemDetails bool `yaml:"problem_details" env:"PROBLEM_DETAILS" env-default:"false"`
+	// BaseURL is the public address of the service, e.g. https://sho.rt,
+	// that saved links are returned under. The scheme and Host of the
+	// request are used when it is unset.
+	BaseURL string `yaml:"base_url" env:"BASE_URL"`
+	// RateLimit limits the links every client IP may save.
+	RateLimit RateLimitConfig `yaml:"rate_limit" env-prefix:"RATE_LIMIT_"`
+	// RequireAPIKey rejects /url requests without a valid X-API-Key, create
+	// keys with -create-api-key. Admins may still use basic auth instead.
+	RequireAPIKey bool `yaml:"require_api_key" env:"REQUIRE_API_KEY" env-default:"false"`
+	// RedirectStatus is the status code of redirects to links that don't
+	// set their own, one of RedirectStatuses. 301 lets browsers and search
+	// engines treat links as permanent.
+	RedirectStatus int `yaml:"redirect_status" env:"REDIRECT_STATUS" env-default:"302"`
+	// CorrelationHeader, e.g. X-Correlation-ID, carries request IDs from
+	// upstream callers. IDs are generated when it is unset or absent.
+	CorrelationHeader string `yaml:"correlation_header" env:"CORRELATION_HEADER"`
+	User              string `yaml:"user" env:"USER" env-required:"true"`
+	Password          string `yaml:"password" env:"PASSWORD" env-required:"true" secret:"true"`
+}
+
+type RateLimitConfig struct {
+	// Enabled answers clients past Requests per Window with 429. Clients
+	// are told apart by X-Forwarded-For, so the server must sit behind a
+	// proxy that sets it.
+	Enabled  bool          `yaml:"enabled" env:"ENABLED" env-default:"false"`
+	Requests int           `yaml:"requests" env:"REQUESTS" env-default:"60"`
+	Window   time.Duration `yaml:"window" env:"WINDOW" env-default:"1m"`
+	// PerKey limits clients authenticated with an API key (see
+	// require_api_key) by key instead of IP, counted in Redis so every
+	// instance shares the limit.
+	PerKey bool `yaml:"per_key" env:"PER_KEY" env-default:"false"`
+	// KeyRequests are the requests per Window of every API key, unless
+	// KeyLimits, by SHA-256 hex of the key, has its own.
+	KeyRequests int            `yaml:"key_requests" env:"KEY_REQUESTS" env-default:"600"`
+	KeyLimits   map[string]int `yaml:"key_limits" env:"KEY_LIMITS"`
 }
 
 func MustLoad() *Config {
+	// without CONFIG_PATH the config comes from the environment alone
 	configPath := os.Getenv("CONFIG_PATH")
-	if configPath == "" {
-		log.Fatal("CONFIG_PATH is not set")
-	}
-
-	// check if file exists
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		log.Fatalf("config file does not exist: %s", configPath)
+	if configPath != "" {
+		if _, err := os.Stat(configPath); os.IsNotExist(err) {
+			log.Fatalf("config file does not exist: %s", configPath)
+		}
 	}
 
 	var cfg Config
 
-	if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
+	if err := read(configPath, &cfg); err != nil {
 		log.Fatalf("cannot read config: %s", err)
 	}
 
+	// a misspelt feature would silently keep its default
+	for name := range cfg.Features {
+		if _, ok := featureDefaults["prod"][name]; !ok {
+			log.Fatalf("unknown feature: %s", name)
+		}
+	}
+
+	if !slices.Contains(RedirectStatuses, cfg.HTTPServer.RedirectStatus) {
+		log.Fatalf("invalid redirect status: %d", cfg.HTTPServer.RedirectStatus)
+	}
+
+	if err := checkAlias(cfg.Alias); err != nil {
+		log.Fatalf("invalid alias config: %s", err)
+	}
+
+	if cfg.Folders.OnDelete != FolderDeleteOrphan && cfg.Folders.OnDelete != FolderDeleteCascade {
+		log.Fatalf("invalid folders on_delete: %s", cfg.Folders.OnDelete)
+	}
+
+	// a zero timeout would cut off every in-flight request
+	if cfg.HTTPServer.ShutdownTimeout <= 0 {
+		cfg.HTTPServer.ShutdownTimeout = DefaultShutdownTimeout
+	}
+
 	return &cfg
 }
+
+// read fills cfg from the YAML file at path, with environment variables
+// taking precedence, or from the environment alone when path is empty.
+func read(path string, cfg *Config) error {
+	if path == "" {
+		return cleanenv.ReadEnv(cfg)
+	}
+
+	return cleanenv.ReadConfig(path, cfg)
+}
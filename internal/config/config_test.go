@@ -0,0 +1,238 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/random"
+)
+
+func TestConfig_Feature(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		feature  string
+		expected bool
+	}{
+		{name: "local default off", cfg: Config{Env: "local"}, feature: FeatureInterstitials, expected: false},
+		{name: "prod default on", cfg: Config{Env: "prod"}, feature: FeatureInterstitials, expected: true},
+		{name: "local default on", cfg: Config{Env: "local"}, feature: FeaturePasswords, expected: true},
+		{
+			name:     "disabled in prod",
+			cfg:      Config{Env: "prod", Features: map[string]bool{FeatureAnalytics: false}},
+			feature:  FeatureAnalytics,
+			expected: false,
+		},
+		{
+			name:     "enabled locally",
+			cfg:      Config{Env: "local", Features: map[string]bool{FeatureAnalytics: true}},
+			feature:  FeatureAnalytics,
+			expected: true,
+		},
+		{name: "unknown env", cfg: Config{Env: "staging"}, feature: FeatureAnalytics, expected: true},
+		{name: "unknown feature", cfg: Config{Env: "prod"}, feature: "teleport", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.cfg.Feature(tt.feature))
+		})
+	}
+}
+
+func TestAliasConfig_Folded(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      AliasConfig
+		expected bool
+	}{
+		{name: "default", expected: false},
+		{name: "fold", cfg: AliasConfig{Fold: true}, expected: true},
+		{name: "case insensitive", cfg: AliasConfig{CaseInsensitive: true}, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.cfg.Folded())
+		})
+	}
+}
+
+func TestAliasConfig_Chars(t *testing.T) {
+	tests := []struct {
+		name     string
+		alphabet string
+		expected string
+	}{
+		{name: "base62", alphabet: AlphabetBase62, expected: random.Base62},
+		{name: "unambiguous", alphabet: AlphabetUnambiguous, expected: random.Unambiguous},
+		{name: "literal", alphabet: "0123456789abcdef", expected: "0123456789abcdef"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, AliasConfig{Alphabet: tt.alphabet}.Chars())
+		})
+	}
+}
+
+func TestCheckAlias(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     AliasConfig
+		wantErr string
+	}{
+		{name: "default", cfg: AliasConfig{Length: 6, Alphabet: AlphabetBase62}},
+		{name: "literal", cfg: AliasConfig{Length: 64, Alphabet: "abc-_"}},
+		{name: "zero length", cfg: AliasConfig{Length: 0, Alphabet: AlphabetBase62}, wantErr: "length must be between 1 and 64: 0"},
+		{name: "too long", cfg: AliasConfig{Length: 65, Alphabet: AlphabetBase62}, wantErr: "length must be between 1 and 64: 65"},
+		{name: "empty alphabet", cfg: AliasConfig{Length: 6}, wantErr: "alphabet must not be empty"},
+		{name: "unsafe character", cfg: AliasConfig{Length: 6, Alphabet: "ab/"}, wantErr: `alphabet contains '/', which is not valid in aliases`},
+		{name: "repeated character", cfg: AliasConfig{Length: 6, Alphabet: "abca"}, wantErr: `alphabet repeats 'a'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkAlias(tt.cfg)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestMustLoad_ShutdownTimeout(t *testing.T) {
+	const base = `
+redis:
+  address: "localhost:6379"
+postgres:
+  host: "localhost"
+  port: "5432"
+  user: "postgres"
+  password: "postgres"
+  dbname: "url_shortener"
+http_server:
+  user: "admin"
+  password: "admin"
+`
+
+	tests := []struct {
+		name     string
+		yaml     string
+		expected time.Duration
+	}{
+		{name: "unset", expected: DefaultShutdownTimeout},
+		{name: "zero", yaml: "  shutdown_timeout: 0s\n", expected: DefaultShutdownTimeout},
+		{name: "set", yaml: "  shutdown_timeout: 30s\n", expected: 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(base+tt.yaml), 0o600))
+			t.Setenv("CONFIG_PATH", path)
+
+			assert.Equal(t, tt.expected, MustLoad().HTTPServer.ShutdownTimeout)
+		})
+	}
+}
+
+func TestMustLoad_Env(t *testing.T) {
+	env := map[string]string{
+		"REDIS_ADDRESS":           "redis:6379",
+		"POSTGRES_HOST":           "db",
+		"POSTGRES_PORT":           "5432",
+		"POSTGRES_USER":           "postgres",
+		"POSTGRES_PASSWORD":       "postgres",
+		"POSTGRES_DBNAME":         "url_shortener",
+		"HTTP_SERVER_USER":        "admin",
+		"HTTP_SERVER_PASSWORD":    "admin",
+		"HTTP_SERVER_ADDRESS":     "0.0.0.0:8080",
+		"ALIAS_LENGTH":            "8",
+		"ALIAS_AUTO_SCALE_WINDOW": "500",
+		"FEATURES":                "analytics:false",
+	}
+
+	t.Run("without file", func(t *testing.T) {
+		t.Setenv("CONFIG_PATH", "")
+		for name, value := range env {
+			t.Setenv(name, value)
+		}
+
+		cfg := MustLoad()
+		assert.Equal(t, "redis:6379", cfg.Redis.Address)
+		assert.Equal(t, "db", cfg.Postgres.Host)
+		assert.Equal(t, "0.0.0.0:8080", cfg.HTTPServer.Address)
+		assert.Equal(t, 8, cfg.Alias.Length)
+		assert.Equal(t, 500, cfg.Alias.AutoScale.Window)
+		assert.Equal(t, map[string]bool{FeatureAnalytics: false}, cfg.Features)
+		assert.Equal(t, "local", cfg.Env)
+	})
+
+	t.Run("overrides file", func(t *testing.T) {
+		const yaml = `
+env: "prod"
+redis:
+  address: "localhost:6379"
+postgres:
+  host: "localhost"
+  port: "5432"
+  user: "postgres"
+  password: "postgres"
+  dbname: "url_shortener"
+http_server:
+  address: "localhost:8082"
+  user: "admin"
+  password: "admin"
+alias:
+  length: 6
+`
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(yaml), 0o600))
+		t.Setenv("CONFIG_PATH", path)
+		t.Setenv("HTTP_SERVER_ADDRESS", "0.0.0.0:8080")
+		t.Setenv("ALIAS_LENGTH", "8")
+
+		cfg := MustLoad()
+		assert.Equal(t, "prod", cfg.Env)
+		assert.Equal(t, "localhost:6379", cfg.Redis.Address)
+		assert.Equal(t, "0.0.0.0:8080", cfg.HTTPServer.Address)
+		assert.Equal(t, 8, cfg.Alias.Length)
+	})
+}
+
+func TestDefaults(t *testing.T) {
+	cfg := Config{
+		Env:      "prod",
+		Features: map[string]bool{FeaturePasswords: true, FeatureAnalytics: false},
+		Postgres: PostgresConfig{Host: "db", Password: "hunter2"},
+		Redis:    RedisConfig{TTL: 5 * time.Minute},
+		Alias:    AliasConfig{Reserved: []string{"health", "url"}},
+	}
+
+	vars := Defaults(&cfg)
+
+	values := make(map[string]string, len(vars))
+	for _, v := range vars {
+		_, dup := values[v.Name]
+		require.False(t, dup, "duplicate env var %s", v.Name)
+		values[v.Name] = v.Value
+	}
+
+	assert.Equal(t, "prod", values["ENV"])
+	assert.Equal(t, "analytics:false,passwords:true", values["FEATURES"])
+	assert.Equal(t, "db", values["POSTGRES_HOST"])
+	assert.Equal(t, redacted, values["POSTGRES_PASSWORD"])
+	assert.Equal(t, "", values["HTTP_SERVER_PASSWORD"])
+	assert.Equal(t, "5m0s", values["REDIS_TTL"])
+	assert.Equal(t, "health,url", values["ALIAS_RESERVED"])
+	assert.Contains(t, values, "ALIAS_AUTO_SCALE_WINDOW")
+	assert.Contains(t, values, "HTTP_SERVER_RATE_LIMIT_REQUESTS")
+}
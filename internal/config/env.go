@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// redacted replaces the value of secret fields in Defaults.
+const redacted = "<redacted>"
+
+// Var is an environment variable that sets a config field, with the
+// value resolved from defaults, the config file and the environment.
+type Var struct {
+	Name  string
+	Value string
+}
+
+// Defaults lists every environment variable cfg can be set with, in
+// field order, together with its resolved value. Secrets are redacted.
+func Defaults(cfg *Config) []Var {
+	var vars []Var
+	collect(reflect.ValueOf(cfg).Elem(), "", &vars)
+
+	return vars
+}
+
+func collect(v reflect.Value, prefix string, vars *[]Var) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+
+		if nested, ok := field.Tag.Lookup("env-prefix"); ok {
+			collect(value, prefix+nested, vars)
+			continue
+		}
+
+		name, ok := field.Tag.Lookup("env")
+		if !ok {
+			continue
+		}
+
+		s := format(value)
+		if field.Tag.Get("secret") == "true" && s != "" {
+			s = redacted
+		}
+
+		*vars = append(*vars, Var{Name: prefix + name, Value: s})
+	}
+}
+
+// format renders v the way cleanenv parses it back from the environment.
+func format(v reflect.Value) string {
+	if d, ok := v.Interface().(time.Duration); ok {
+		return d.String()
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = format(v.Index(i))
+		}
+
+		return strings.Join(items, ",")
+	case reflect.Map:
+		items := make([]string, 0, v.Len())
+		for _, key := range v.MapKeys() {
+			items = append(items, format(key)+":"+format(v.MapIndex(key)))
+		}
+		slices.Sort(items)
+
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
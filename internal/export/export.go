@@ -0,0 +1,77 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"url-shortener/internal/storage"
+)
+
+// Source streams all stored links.
+type Source interface {
+	ExportURLs(ctx context.Context, fn func(storage.URL) error) error
+}
+
+// Record is an exported link.
+type Record struct {
+	ID             int64      `json:"id"`
+	Alias          string     `json:"alias"`
+	URL            string     `json:"url"`
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
+	Sponsored      bool       `json:"sponsored,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// Progress reports on an export in flight.
+type Progress struct {
+	// Every is the number of records between calls to Func.
+	Every int
+	// Func is called with the number of records written so far.
+	Func func(written int) error
+}
+
+// Write writes every link of src to w as JSON lines, one record per link.
+func Write(ctx context.Context, w io.Writer, src Source) error {
+	_, err := WriteWithProgress(ctx, w, src, Progress{})
+
+	return err
+}
+
+// WriteWithProgress is Write calling p.Func every p.Every records. It
+// returns the number of records written.
+func WriteWithProgress(ctx context.Context, w io.Writer, src Source, p Progress) (int, error) {
+	const op = "export.Write"
+
+	enc := json.NewEncoder(w)
+
+	var written int
+
+	err := src.ExportURLs(ctx, func(u storage.URL) error {
+		err := enc.Encode(Record{
+			ID:             u.ID,
+			Alias:          u.Alias,
+			URL:            u.URL,
+			LastAccessedAt: u.LastAccessedAt,
+			Sponsored:      u.Sponsored,
+			CreatedAt:      u.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		written++
+		if p.Func != nil && p.Every > 0 && written%p.Every == 0 {
+			return p.Func(written)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return written, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return written, nil
+}
@@ -0,0 +1,130 @@
+package export
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/storage"
+)
+
+type sliceSource []storage.URL
+
+func (s sliceSource) ExportURLs(_ context.Context, fn func(storage.URL) error) error {
+	for _, u := range s {
+		if err := fn(u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type failingSource struct{}
+
+func (failingSource) ExportURLs(_ context.Context, fn func(storage.URL) error) error {
+	return errors.New("connection reset")
+}
+
+var createdAt = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+var links = sliceSource{
+	{ID: 1, Alias: "google", URL: "https://google.com", CreatedAt: createdAt},
+	{ID: 2, Alias: "promo", URL: "https://example.com", Sponsored: true, CreatedAt: createdAt},
+}
+
+func decode(t *testing.T, r io.Reader) []Record {
+	t.Helper()
+
+	var records []Record
+
+	dec := json.NewDecoder(r)
+	for {
+		var rec Record
+		err := dec.Decode(&rec)
+		if errors.Is(err, io.EOF) {
+			return records
+		}
+		require.NoError(t, err)
+		records = append(records, rec)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(context.Background(), &buf, links))
+
+	assert.Equal(t, 2, strings.Count(buf.String(), "\n"))
+	assert.Equal(t, []Record{
+		{ID: 1, Alias: "google", URL: "https://google.com", CreatedAt: createdAt},
+		{ID: 2, Alias: "promo", URL: "https://example.com", Sponsored: true, CreatedAt: createdAt},
+	}, decode(t, &buf))
+}
+
+// newFakeS3 serves an in-memory bucket over TLS; minio-go only signs
+// payloads in aws-chunked encoding over plain HTTP, which the fake lacks.
+func newFakeS3(t *testing.T, bucket string) (*S3, *minio.Client) {
+	t.Helper()
+
+	backend := s3mem.New()
+	require.NoError(t, backend.CreateBucket(bucket))
+
+	ts := httptest.NewTLSServer(gofakes3.New(backend).Server())
+	t.Cleanup(ts.Close)
+
+	client, err := minio.New(strings.TrimPrefix(ts.URL, "https://"), &minio.Options{
+		Creds:     credentials.NewStaticV4("key", "secret", ""),
+		Secure:    true,
+		Transport: ts.Client().Transport,
+	})
+	require.NoError(t, err)
+
+	return &S3{client: client, bucket: bucket, prefix: "backups/"}, client
+}
+
+func TestS3_Upload(t *testing.T) {
+	ctx := context.Background()
+
+	s, client := newFakeS3(t, "links")
+
+	key, err := s.Upload(ctx, links)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key, "backups/urls-"))
+	assert.True(t, strings.HasSuffix(key, ".jsonl.gz"))
+
+	obj, err := client.GetObject(ctx, "links", key, minio.GetObjectOptions{})
+	require.NoError(t, err)
+	defer obj.Close()
+
+	gz, err := gzip.NewReader(obj)
+	require.NoError(t, err)
+
+	assert.Len(t, decode(t, gz), 2)
+}
+
+func TestS3_Upload_SourceError(t *testing.T) {
+	ctx := context.Background()
+
+	s, client := newFakeS3(t, "links")
+
+	_, err := s.Upload(ctx, failingSource{})
+	require.Error(t, err)
+
+	// a failed export must not leave a truncated backup behind
+	for obj := range client.ListObjects(ctx, "links", minio.ListObjectsOptions{Recursive: true}) {
+		t.Errorf("unexpected object %q", obj.Key)
+	}
+}
@@ -0,0 +1,85 @@
+package export
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// partSize bounds how much of an upload is buffered at once.
+const partSize = 5 << 20
+
+// S3Options configures the bucket backups are written to.
+type S3Options struct {
+	Endpoint  string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	// Prefix is prepended to object keys, e.g. "backups/".
+	Prefix string
+}
+
+// S3 uploads gzipped exports to an S3-compatible object store.
+type S3 struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+func NewS3(opts S3Options) (*S3, error) {
+	const op = "export.NewS3"
+
+	client, err := minio.New(opts.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(opts.AccessKey, opts.SecretKey, ""),
+		Secure: opts.UseSSL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &S3{
+		client: client,
+		bucket: opts.Bucket,
+		prefix: opts.Prefix,
+	}, nil
+}
+
+// Upload exports all links of src as a gzipped JSON lines object and
+// returns its key. The export is streamed into a multipart upload, so
+// only one part is held in memory at a time.
+func (s *S3) Upload(ctx context.Context, src Source) (string, error) {
+	const op = "export.S3.Upload"
+
+	key := s.prefix + "urls-" + time.Now().UTC().Format("20060102T150405Z") + ".jsonl.gz"
+
+	pr, pw := io.Pipe()
+
+	go func() {
+		gz := gzip.NewWriter(pw)
+
+		err := Write(ctx, gz, src)
+		if err == nil {
+			err = gz.Close()
+		}
+
+		_ = pw.CloseWithError(err)
+	}()
+
+	_, err := s.client.PutObject(ctx, s.bucket, key, pr, -1, minio.PutObjectOptions{
+		ContentType: "application/gzip",
+		PartSize:    partSize,
+	})
+	// unblocks the writer if the upload gave up early
+	_ = pr.CloseWithError(err)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,262 @@
+package shortener
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	shortenerv1 "url-shortener/api/shortener/v1"
+	"url-shortener/internal/cache"
+	"url-shortener/internal/lib/generator"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/normalize"
+	"url-shortener/internal/lib/signing"
+	"url-shortener/internal/lib/urlnorm"
+	"url-shortener/internal/lib/validate"
+	"url-shortener/internal/lib/weighted"
+	"url-shortener/internal/storage"
+)
+
+// aliasLength matches the aliases generated by the HTTP API.
+const aliasLength = 6
+
+// OwnerMetadata is the metadata key carrying the owner of shortened
+// links, the counterpart of the HTTP API's X-Link-Owner header.
+const OwnerMetadata = "x-link-owner"
+
+// Storage is an interface for reading and saving links.
+type Storage interface {
+	GetURLInfo(alias string) (storage.URL, error)
+	SaveURL(urlToSave string, alias string, opts storage.SaveOptions) (int64, error)
+}
+
+// VariantCounter is an interface for counting the resolutions to the
+// variants of a split link.
+type VariantCounter interface {
+	IncrementVariantClicks(alias string, variant int) error
+}
+
+// Maintenance reports whether maintenance mode is on.
+type Maintenance interface {
+	Enabled() bool
+}
+
+// AliasSet is an interface for learning saved aliases.
+type AliasSet interface {
+	Add(alias string)
+}
+
+type URLCache interface {
+	GetEntry(ctx context.Context, key string) (cache.Entry, error)
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+}
+
+// Options holds the optional behaviour of the gRPC API, mirroring the
+// HTTP handlers' options.
+type Options struct {
+	FoldAliases    bool
+	Signer         *signing.Signer
+	AllowedSchemes []string
+	// Generator generates aliases for links shortened without one.
+	Generator generator.Generator
+	// ReservedAliases are custom aliases refused because they would shadow
+	// routes of the HTTP API.
+	ReservedAliases []string
+	// CanonicalQuery sorts the query parameters of targets before they
+	// are stored.
+	CanonicalQuery bool
+	// NormalizeURLs saves targets in the form of urlnorm.Normalize, as
+	// the HTTP save handler does.
+	NormalizeURLs bool
+	// CacheTTL is how long new links are cached, cache.DefaultTTL when zero.
+	CacheTTL time.Duration
+	// Aliases learns every saved alias, see the HTTP save handler.
+	Aliases AliasSet
+	// RequireOwner rejects links shortened without an owner.
+	RequireOwner bool
+	// VariantClicks counts the resolutions to every variant of split
+	// links, see the HTTP redirect handler. They are not counted when it
+	// is nil.
+	VariantClicks VariantCounter
+	// Maintenance refuses new links while it is enabled, like the write
+	// routes of the HTTP API. Links are always accepted when it is nil.
+	Maintenance Maintenance
+}
+
+// Server implements the Shortener gRPC service on the same storage and
+// cache as the HTTP API.
+type Server struct {
+	shortenerv1.UnimplementedShortenerServer
+
+	log     *slog.Logger
+	storage Storage
+	cache   URLCache
+	opts    Options
+}
+
+func New(log *slog.Logger, storage Storage, urlCache URLCache, opts Options) *Server {
+	if opts.Generator == nil {
+		opts.Generator = generator.Random{Length: aliasLength}
+	}
+	if opts.CacheTTL == 0 {
+		opts.CacheTTL = cache.DefaultTTL
+	}
+
+	return &Server{
+		log: log.With(
+			slog.String("component", "grpc/shortener"),
+		),
+		storage: storage,
+		cache:   urlCache,
+		opts:    opts,
+	}
+}
+
+func (s *Server) Resolve(ctx context.Context, req *shortenerv1.ResolveRequest) (*shortenerv1.ResolveResponse, error) {
+	const op = "grpc.shortener.Resolve"
+
+	log := s.log.With(slog.String("op", op))
+
+	alias := req.GetAlias()
+	if alias == "" {
+		return nil, status.Error(codes.InvalidArgument, "alias is required")
+	}
+
+	switch {
+	case s.opts.Signer != nil && signing.IsSigned(alias):
+		if err := s.opts.Signer.Verify(alias); err != nil {
+			return nil, status.Error(codes.NotFound, "not found")
+		}
+	case s.opts.FoldAliases:
+		alias = normalize.Alias(alias)
+	}
+
+	entry, err := s.cache.GetEntry(ctx, alias)
+	if err == nil && entry.Usable(time.Now()) {
+		return &shortenerv1.ResolveResponse{Url: entry.URL}, nil
+	}
+	if err != nil && err != redis.Nil {
+		log.Error("failed to get url from cache", sl.Err(err))
+	}
+
+	link, err := s.storage.GetURLInfo(alias)
+	if errors.Is(err, storage.ErrURLNotFound) {
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+	if err != nil {
+		log.Error("failed to get url", sl.Err(err))
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	if link.Expired(time.Now()) {
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+
+	// there is no header to carry the link password over gRPC
+	if link.PasswordHash != "" {
+		return nil, status.Error(codes.PermissionDenied, "link is password protected")
+	}
+
+	// split links are never cached, so cache hits have a single target
+	if len(link.Variants) > 0 {
+		variant := weighted.Draw(link.Variants)
+		s.countVariant(log, alias, variant)
+
+		return &shortenerv1.ResolveResponse{Url: link.Variants[variant].URL}, nil
+	}
+
+	return &shortenerv1.ResolveResponse{Url: link.URL}, nil
+}
+
+// countVariant increments the clicks of a variant of alias in the
+// background, so the write never holds back the response.
+func (s *Server) countVariant(log *slog.Logger, alias string, variant int) {
+	if s.opts.VariantClicks == nil {
+		return
+	}
+
+	go func() {
+		if err := s.opts.VariantClicks.IncrementVariantClicks(alias, variant); err != nil {
+			log.Error("failed to count variant click", sl.Err(err))
+		}
+	}()
+}
+
+func (s *Server) Shorten(ctx context.Context, req *shortenerv1.ShortenRequest) (*shortenerv1.ShortenResponse, error) {
+	const op = "grpc.shortener.Shorten"
+
+	log := s.log.With(slog.String("op", op))
+
+	if s.opts.Maintenance != nil && s.opts.Maintenance.Enabled() {
+		return nil, status.Error(codes.Unavailable, "service is under maintenance")
+	}
+
+	target := req.GetUrl()
+	if err := validate.URL(target, s.opts.AllowedSchemes); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	target = urlnorm.Target(target, s.opts.NormalizeURLs, s.opts.CanonicalQuery)
+
+	var owner string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(OwnerMetadata); len(values) > 0 {
+			owner = values[0]
+		}
+	}
+	if err := validate.Owner(owner, s.opts.RequireOwner); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// signed aliases are recognised by the separator on redirect
+	if s.opts.Signer != nil && signing.IsSigned(req.GetAlias()) {
+		return nil, status.Error(codes.InvalidArgument, "alias must not contain "+signing.Separator)
+	}
+
+	if custom := req.GetAlias(); custom != "" {
+		if s.opts.FoldAliases {
+			custom = normalize.Alias(custom)
+		}
+
+		if err := validate.Alias(custom, s.opts.ReservedAliases); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	alias := req.GetAlias()
+	if alias == "" {
+		var err error
+		alias, err = s.opts.Generator.Generate(ctx)
+		if err != nil {
+			log.Error("failed to generate alias", sl.Err(err))
+			return nil, status.Error(codes.Internal, "failed to add url")
+		}
+	}
+	if s.opts.FoldAliases {
+		alias = normalize.Alias(alias)
+	}
+
+	_, err := s.storage.SaveURL(target, alias, storage.SaveOptions{Owner: owner})
+	if errors.Is(err, storage.ErrURLExists) {
+		return nil, status.Error(codes.AlreadyExists, "url already exists")
+	}
+	if err != nil {
+		log.Error("failed to add url", sl.Err(err))
+		return nil, status.Error(codes.Internal, "failed to add url")
+	}
+
+	if s.opts.Aliases != nil {
+		s.opts.Aliases.Add(alias)
+	}
+
+	if err := s.cache.Set(ctx, alias, cache.Entry{URL: target, Enabled: true}, s.opts.CacheTTL); err != nil {
+		log.Error("failed to set url to cache", sl.Err(err))
+	}
+
+	return &shortenerv1.ShortenResponse{Alias: alias}, nil
+}
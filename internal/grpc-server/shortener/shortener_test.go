@@ -0,0 +1,244 @@
+package shortener_test
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	shortenerv1 "url-shortener/api/shortener/v1"
+	"url-shortener/internal/cache"
+	"url-shortener/internal/grpc-server/shortener"
+	"url-shortener/internal/http-server/middleware/maintenance"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+type memStorage struct {
+	mu    sync.Mutex
+	links map[string]storage.URL
+}
+
+func (s *memStorage) GetURLInfo(alias string) (storage.URL, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	link, ok := s.links[alias]
+	if !ok {
+		return storage.URL{}, storage.ErrURLNotFound
+	}
+	return link, nil
+}
+
+func (s *memStorage) SaveURL(urlToSave string, alias string, opts storage.SaveOptions) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.links[alias]; ok {
+		return 0, storage.ErrURLExists
+	}
+	s.links[alias] = storage.URL{ID: int64(len(s.links) + 1), Alias: alias, URL: urlToSave, PasswordHash: opts.PasswordHash, Owner: opts.Owner}
+	return int64(len(s.links)), nil
+}
+
+type nopCache struct{}
+
+func (nopCache) GetEntry(context.Context, string) (cache.Entry, error) {
+	return cache.Entry{}, redis.Nil
+}
+
+func (nopCache) Set(context.Context, string, interface{}, time.Duration) error {
+	return nil
+}
+
+func newClient(t *testing.T, st *memStorage, opts shortener.Options) shortenerv1.ShortenerClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+
+	srv := grpc.NewServer()
+	shortenerv1.RegisterShortenerServer(srv, shortener.New(slogdiscard.NewDiscardLogger(), st, nopCache{}, opts))
+
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return shortenerv1.NewShortenerClient(conn)
+}
+
+func TestShortener(t *testing.T) {
+	ctx := context.Background()
+
+	st := &memStorage{links: map[string]storage.URL{
+		"secret": {Alias: "secret", URL: "https://example.com", PasswordHash: "$2a$10$hash"},
+	}}
+	client := newClient(t, st, shortener.Options{})
+
+	shortened, err := client.Shorten(ctx, &shortenerv1.ShortenRequest{Url: "https://google.com", Alias: "google"})
+	require.NoError(t, err)
+	assert.Equal(t, "google", shortened.GetAlias())
+
+	resolved, err := client.Resolve(ctx, &shortenerv1.ResolveRequest{Alias: "google"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://google.com", resolved.GetUrl())
+
+	generated, err := client.Shorten(ctx, &shortenerv1.ShortenRequest{Url: "https://example.org"})
+	require.NoError(t, err)
+	assert.Len(t, generated.GetAlias(), 6)
+
+	resolved, err = client.Resolve(ctx, &shortenerv1.ResolveRequest{Alias: generated.GetAlias()})
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.org", resolved.GetUrl())
+}
+
+func TestShortener_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	st := &memStorage{links: map[string]storage.URL{
+		"google": {Alias: "google", URL: "https://google.com"},
+		"secret": {Alias: "secret", URL: "https://example.com", PasswordHash: "$2a$10$hash"},
+	}}
+	client := newClient(t, st, shortener.Options{})
+
+	cases := []struct {
+		name string
+		call func() error
+		code codes.Code
+	}{
+		{
+			name: "Unknown alias",
+			call: func() error {
+				_, err := client.Resolve(ctx, &shortenerv1.ResolveRequest{Alias: "missing"})
+				return err
+			},
+			code: codes.NotFound,
+		},
+		{
+			name: "Empty alias",
+			call: func() error {
+				_, err := client.Resolve(ctx, &shortenerv1.ResolveRequest{})
+				return err
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "Protected link",
+			call: func() error {
+				_, err := client.Resolve(ctx, &shortenerv1.ResolveRequest{Alias: "secret"})
+				return err
+			},
+			code: codes.PermissionDenied,
+		},
+		{
+			name: "Alias taken",
+			call: func() error {
+				_, err := client.Shorten(ctx, &shortenerv1.ShortenRequest{Url: "https://example.com", Alias: "google"})
+				return err
+			},
+			code: codes.AlreadyExists,
+		},
+		{
+			name: "Invalid URL",
+			call: func() error {
+				_, err := client.Shorten(ctx, &shortenerv1.ShortenRequest{Url: "javascript:alert(1)"})
+				return err
+			},
+			code: codes.InvalidArgument,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.code, status.Code(tc.call()))
+		})
+	}
+}
+
+func TestShortener_RequireOwner(t *testing.T) {
+	st := &memStorage{links: map[string]storage.URL{}}
+	client := newClient(t, st, shortener.Options{RequireOwner: true})
+
+	_, err := client.Shorten(context.Background(), &shortenerv1.ShortenRequest{Url: "https://google.com", Alias: "google"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), shortener.OwnerMetadata, "jane")
+	_, err = client.Shorten(ctx, &shortenerv1.ShortenRequest{Url: "https://google.com", Alias: "google"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), shortener.OwnerMetadata, "jane@example.com")
+	_, err = client.Shorten(ctx, &shortenerv1.ShortenRequest{Url: "https://google.com", Alias: "google"})
+	require.NoError(t, err)
+
+	link, err := st.GetURLInfo("google")
+	require.NoError(t, err)
+	assert.Equal(t, "jane@example.com", link.Owner)
+}
+
+func TestShortener_ResolveVariants(t *testing.T) {
+	st := &memStorage{links: map[string]storage.URL{
+		"split": {Alias: "split", URL: "https://example.com", Variants: []storage.Variant{
+			{URL: "https://a.example.com", Weight: 1},
+			{URL: "https://b.example.com", Weight: 0},
+		}},
+	}}
+	client := newClient(t, st, shortener.Options{})
+
+	resolved, err := client.Resolve(context.Background(), &shortenerv1.ResolveRequest{Alias: "split"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://a.example.com", resolved.GetUrl())
+}
+
+func TestShortener_Maintenance(t *testing.T) {
+	ctx := context.Background()
+
+	st := &memStorage{links: map[string]storage.URL{
+		"google": {Alias: "google", URL: "https://google.com"},
+	}}
+	mode := maintenance.NewMode(true, time.Minute)
+	client := newClient(t, st, shortener.Options{Maintenance: mode})
+
+	_, err := client.Shorten(ctx, &shortenerv1.ShortenRequest{Url: "https://example.com", Alias: "example"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	// links keep resolving during maintenance
+	resolved, err := client.Resolve(ctx, &shortenerv1.ResolveRequest{Alias: "google"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://google.com", resolved.GetUrl())
+
+	mode.Set(false)
+
+	_, err = client.Shorten(ctx, &shortenerv1.ShortenRequest{Url: "https://example.com", Alias: "example"})
+	require.NoError(t, err)
+}
+
+func TestShortener_NormalizeURLs(t *testing.T) {
+	st := &memStorage{links: map[string]storage.URL{}}
+	client := newClient(t, st, shortener.Options{NormalizeURLs: true})
+
+	_, err := client.Shorten(context.Background(), &shortenerv1.ShortenRequest{Url: "HTTPS://Example.com:443/?b=2&a=1", Alias: "example"})
+	require.NoError(t, err)
+
+	// stored as the HTTP save handler would store it
+	link, err := st.GetURLInfo("example")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com?a=1&b=2", link.URL)
+}
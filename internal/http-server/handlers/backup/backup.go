@@ -0,0 +1,53 @@
+package backup
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"url-shortener/internal/export"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+)
+
+type Response struct {
+	resp.Response
+	Key string `json:"key,omitempty"`
+}
+
+// Uploader is an interface for uploading an export of all links.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=Uploader
+type Uploader interface {
+	Upload(ctx context.Context, src export.Source) (string, error)
+}
+
+// New returns a handler exporting all links of src to the backup store,
+// for scheduled off-box backups.
+func New(log *slog.Logger, uploader Uploader, src export.Source) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.backup.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		key, err := uploader.Upload(r.Context(), src)
+		if err != nil {
+			log.Error("failed to upload backup", sl.Err(err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("failed to upload backup"))
+			return
+		}
+
+		log.Info("backup uploaded", slog.String("key", key))
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Key:      key,
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package backup_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/backup"
+	"url-shortener/internal/http-server/handlers/backup/mocks"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestBackupHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		key        string
+		mockError  error
+		respError  string
+		statusCode int
+	}{
+		{
+			name:       "Success",
+			key:        "backups/urls-20240301T120000Z.jsonl.gz",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Upload error",
+			mockError:  errors.New("access denied"),
+			respError:  "failed to upload backup",
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			uploaderMock := mocks.NewUploader(t)
+			uploaderMock.On("Upload", mock.Anything, nil).Return(tc.key, tc.mockError).Once()
+
+			handler := backup.New(slogdiscard.NewDiscardLogger(), uploaderMock, nil)
+
+			req, err := http.NewRequest(http.MethodPost, "/admin/backup", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp backup.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+			require.Equal(t, tc.key, resp.Key)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package invalidate
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+
+	"url-shortener/internal/http-server/middleware/namespace"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/normalize"
+)
+
+type Request struct {
+	Aliases []string `json:"aliases" validate:"required,min=1,dive,required"`
+}
+
+type Response struct {
+	resp.Response
+	Invalidated int `json:"invalidated"`
+}
+
+// URLCache is an interface for evicting cached aliases.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLCache
+type URLCache interface {
+	DeleteMany(ctx context.Context, keys []string) error
+}
+
+// Options holds the optional behaviour of the invalidate handler.
+type Options struct {
+	// FoldAliases folds aliases the same way the save handler stores them.
+	FoldAliases bool
+	// RelatedKeys returns further cache keys derived from an alias,
+	// such as cached responses about it, to evict along with it.
+	RelatedKeys func(alias string) []string
+}
+
+// New returns a handler evicting the given aliases from the cache, for
+// tooling that edits links in the database directly.
+func New(log *slog.Logger, urlCache URLCache, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.cache.invalidate.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.Error("invalid request", sl.Err(err))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.ValidationError(validateErr))
+			return
+		}
+
+		aliases := req.Aliases
+		if opts.FoldAliases {
+			aliases = make([]string, len(req.Aliases))
+			for i, alias := range req.Aliases {
+				aliases[i] = normalize.Alias(alias)
+			}
+		}
+
+		keys := slices.Clone(aliases)
+		if opts.RelatedKeys != nil {
+			for _, alias := range aliases {
+				keys = append(keys, opts.RelatedKeys(alias)...)
+			}
+		}
+		// the keys of vanity domains are scoped to their namespace
+		for i, key := range keys {
+			keys[i] = namespace.Qualify(r.Context(), key)
+		}
+
+		if err := urlCache.DeleteMany(r.Context(), keys); err != nil {
+			log.Error("failed to invalidate cache", sl.Err(err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("failed to invalidate cache"))
+			return
+		}
+
+		log.Info("cache invalidated", slog.Int("count", len(req.Aliases)))
+
+		render.JSON(w, r, Response{
+			Response:    resp.OK(),
+			Invalidated: len(req.Aliases),
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package invalidate_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/cache/invalidate"
+	"url-shortener/internal/http-server/handlers/cache/invalidate/mocks"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestInvalidateHandler(t *testing.T) {
+	cases := []struct {
+		name        string
+		input       string
+		fold        bool
+		related     bool
+		keys        []string
+		mockError   error
+		respError   string
+		invalidated int
+		statusCode  int
+	}{
+		{
+			name:        "Success",
+			input:       `{"aliases": ["abc", "Promo"]}`,
+			keys:        []string{"abc", "Promo"},
+			invalidated: 2,
+			statusCode:  http.StatusOK,
+		},
+		{
+			name:        "Folded aliases",
+			input:       `{"aliases": ["abc", "Café"]}`,
+			fold:        true,
+			keys:        []string{"abc", "cafe"},
+			invalidated: 2,
+			statusCode:  http.StatusOK,
+		},
+		{
+			name:        "Related keys",
+			input:       `{"aliases": ["abc", "Promo"]}`,
+			related:     true,
+			keys:        []string{"abc", "Promo", "response:/admin/url/abc", "response:/admin/url/Promo"},
+			invalidated: 2,
+			statusCode:  http.StatusOK,
+		},
+		{
+			name:       "No aliases",
+			input:      `{"aliases": []}`,
+			respError:  "field Aliases is not valid",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Empty request",
+			input:      "",
+			respError:  "empty request",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Cache error",
+			input:      `{"aliases": ["abc"]}`,
+			keys:       []string{"abc"},
+			mockError:  errors.New("connection refused"),
+			respError:  "failed to invalidate cache",
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlCacheMock := mocks.NewURLCache(t)
+
+			if tc.keys != nil {
+				urlCacheMock.On("DeleteMany", mock.Anything, tc.keys).Return(tc.mockError).Once()
+			}
+
+			opts := invalidate.Options{
+				FoldAliases: tc.fold,
+			}
+			if tc.related {
+				opts.RelatedKeys = func(alias string) []string {
+					return []string{"response:/admin/url/" + alias}
+				}
+			}
+
+			handler := invalidate.New(slogdiscard.NewDiscardLogger(), urlCacheMock, opts)
+
+			req, err := http.NewRequest(http.MethodPost, "/admin/cache/invalidate", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp invalidate.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+			require.Equal(t, tc.invalidated, resp.Invalidated)
+		})
+	}
+}
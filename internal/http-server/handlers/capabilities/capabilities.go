@@ -0,0 +1,167 @@
+package capabilities
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+
+	"url-shortener/internal/lib/validate"
+)
+
+// Features that can be reported enabled in Response.Features.
+const (
+	FeatureSponsored          = "sponsored"
+	FeatureAnalytics          = "analytics"
+	FeaturePasswords          = "passwords"
+	FeatureSignedLinks        = "signed_links"
+	FeatureSplitTargets       = "split_targets"
+	FeatureExternalIDs        = "external_ids"
+	FeatureReferrerAllowlists = "referrer_allowlists"
+	FeatureNumericIDs         = "numeric_ids"
+	FeatureFolders            = "folders"
+	FeatureAudit              = "audit"
+)
+
+// Response describes what clients may send, so they can validate links
+// before saving them.
+type Response struct {
+	Features map[string]bool `json:"features"`
+	Alias    Alias           `json:"alias"`
+	URL      URL             `json:"url"`
+	// RateLimit is the limit of link saves, there is none when it is nil.
+	RateLimit *RateLimit `json:"rate_limit,omitempty"`
+	// RequireAPIKey reports that /url requests need an X-API-Key.
+	RequireAPIKey bool `json:"require_api_key"`
+}
+
+// Alias describes the accepted custom aliases.
+type Alias struct {
+	MinLength int `json:"min_length"`
+	MaxLength int `json:"max_length"`
+	// Pattern is a regular expression matching valid custom aliases.
+	Pattern string `json:"pattern"`
+	// GeneratedLength is the length of the aliases generated for links
+	// saved without one, the shortest when they grow as the keyspace fills.
+	GeneratedLength int `json:"generated_length"`
+	// CaseInsensitive reports that aliases differing in case or accents
+	// are the same alias.
+	CaseInsensitive bool     `json:"case_insensitive"`
+	Reserved        []string `json:"reserved"`
+}
+
+// URL describes the accepted target URLs.
+type URL struct {
+	MaxLength      int      `json:"max_length"`
+	AllowedSchemes []string `json:"allowed_schemes"`
+	// BatchLimit is the most links of a single batch save.
+	BatchLimit   int  `json:"batch_limit"`
+	RequireOwner bool `json:"require_owner"`
+}
+
+// RateLimit describes the link saves allowed per client.
+type RateLimit struct {
+	Requests      int `json:"requests"`
+	WindowSeconds int `json:"window_seconds"`
+	// KeyRequests are the saves per window of clients authenticated with
+	// an API key, when they are limited by key rather than by IP.
+	KeyRequests int `json:"key_requests,omitempty"`
+}
+
+// Options is the active configuration reported by the handler.
+type Options struct {
+	Sponsored          bool
+	Analytics          bool
+	Passwords          bool
+	SignedLinks        bool
+	SplitTargets       bool
+	ExternalIDs        bool
+	ReferrerAllowlists bool
+	NumericIDs         bool
+	Folders            bool
+	Audit              bool
+
+	// AliasLength is the length of generated aliases.
+	AliasLength int
+	// MinUserAliasLength reserves shorter custom aliases for admins.
+	MinUserAliasLength int
+	FoldAliases        bool
+	ReservedAliases    []string
+
+	// AllowedSchemes are the accepted target URL schemes,
+	// validate.DefaultSchemes when empty.
+	AllowedSchemes []string
+	BatchLimit     int
+	RequireOwner   bool
+	RequireAPIKey  bool
+
+	// RateLimitRequests per RateLimitWindow are allowed, saves are not
+	// limited when it is zero.
+	RateLimitRequests int
+	RateLimitWindow   time.Duration
+	// RateLimitKeyRequests limits API keys by key when it is not zero.
+	RateLimitKeyRequests int
+}
+
+// New returns a GET /capabilities handler. The response is built once,
+// it only changes with the configuration.
+func New(opts Options) http.HandlerFunc {
+	res := describe(opts)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, res)
+	}
+}
+
+func describe(opts Options) Response {
+	schemes := opts.AllowedSchemes
+	if len(schemes) == 0 {
+		schemes = validate.DefaultSchemes
+	}
+
+	reserved := opts.ReservedAliases
+	if reserved == nil {
+		reserved = []string{}
+	}
+
+	res := Response{
+		Features: map[string]bool{
+			FeatureSponsored:          opts.Sponsored,
+			FeatureAnalytics:          opts.Analytics,
+			FeaturePasswords:          opts.Passwords,
+			FeatureSignedLinks:        opts.SignedLinks,
+			FeatureSplitTargets:       opts.SplitTargets,
+			FeatureExternalIDs:        opts.ExternalIDs,
+			FeatureReferrerAllowlists: opts.ReferrerAllowlists,
+			FeatureNumericIDs:         opts.NumericIDs,
+			FeatureFolders:            opts.Folders,
+			FeatureAudit:              opts.Audit,
+		},
+		Alias: Alias{
+			// admins may go below MinUserAliasLength, clients can't tell
+			MinLength:       max(validate.AliasMinLength, opts.MinUserAliasLength),
+			MaxLength:       validate.AliasMaxLength,
+			Pattern:         "^[" + validate.AliasCharset + "]+$",
+			GeneratedLength: opts.AliasLength,
+			CaseInsensitive: opts.FoldAliases,
+			Reserved:        reserved,
+		},
+		URL: URL{
+			MaxLength:      validate.MaxURLLength,
+			AllowedSchemes: schemes,
+			BatchLimit:     opts.BatchLimit,
+			RequireOwner:   opts.RequireOwner,
+		},
+		RequireAPIKey: opts.RequireAPIKey,
+	}
+
+	if opts.RateLimitRequests > 0 {
+		res.RateLimit = &RateLimit{
+			Requests:      opts.RateLimitRequests,
+			WindowSeconds: int(opts.RateLimitWindow / time.Second),
+			KeyRequests:   opts.RateLimitKeyRequests,
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,147 @@
+package capabilities_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/capabilities"
+	"url-shortener/internal/lib/validate"
+)
+
+func TestCapabilitiesHandler(t *testing.T) {
+	cases := []struct {
+		name string
+		opts capabilities.Options
+		want capabilities.Response
+	}{
+		{
+			name: "Defaults",
+			opts: capabilities.Options{AliasLength: 6, BatchLimit: 500},
+			want: capabilities.Response{
+				Features: map[string]bool{
+					capabilities.FeatureSponsored:          false,
+					capabilities.FeatureAnalytics:          false,
+					capabilities.FeaturePasswords:          false,
+					capabilities.FeatureSignedLinks:        false,
+					capabilities.FeatureSplitTargets:       false,
+					capabilities.FeatureExternalIDs:        false,
+					capabilities.FeatureReferrerAllowlists: false,
+					capabilities.FeatureNumericIDs:         false,
+					capabilities.FeatureFolders:            false,
+					capabilities.FeatureAudit:              false,
+				},
+				Alias: capabilities.Alias{
+					MinLength:       validate.AliasMinLength,
+					MaxLength:       validate.AliasMaxLength,
+					Pattern:         "^[a-zA-Z0-9_-]+$",
+					GeneratedLength: 6,
+					Reserved:        []string{},
+				},
+				URL: capabilities.URL{
+					MaxLength:      validate.MaxURLLength,
+					AllowedSchemes: []string{"http", "https"},
+					BatchLimit:     500,
+				},
+			},
+		},
+		{
+			name: "Configured",
+			opts: capabilities.Options{
+				Analytics:            true,
+				Passwords:            true,
+				SplitTargets:         true,
+				Folders:              true,
+				AliasLength:          8,
+				MinUserAliasLength:   5,
+				FoldAliases:          true,
+				ReservedAliases:      []string{"admin"},
+				AllowedSchemes:       []string{"https", "mailto"},
+				BatchLimit:           100,
+				RequireOwner:         true,
+				RequireAPIKey:        true,
+				RateLimitRequests:    60,
+				RateLimitWindow:      time.Minute,
+				RateLimitKeyRequests: 600,
+			},
+			want: capabilities.Response{
+				Features: map[string]bool{
+					capabilities.FeatureSponsored:          false,
+					capabilities.FeatureAnalytics:          true,
+					capabilities.FeaturePasswords:          true,
+					capabilities.FeatureSignedLinks:        false,
+					capabilities.FeatureSplitTargets:       true,
+					capabilities.FeatureExternalIDs:        false,
+					capabilities.FeatureReferrerAllowlists: false,
+					capabilities.FeatureNumericIDs:         false,
+					capabilities.FeatureFolders:            true,
+					capabilities.FeatureAudit:              false,
+				},
+				Alias: capabilities.Alias{
+					MinLength:       5,
+					MaxLength:       validate.AliasMaxLength,
+					Pattern:         "^[a-zA-Z0-9_-]+$",
+					GeneratedLength: 8,
+					CaseInsensitive: true,
+					Reserved:        []string{"admin"},
+				},
+				URL: capabilities.URL{
+					MaxLength:      validate.MaxURLLength,
+					AllowedSchemes: []string{"https", "mailto"},
+					BatchLimit:     100,
+					RequireOwner:   true,
+				},
+				RateLimit: &capabilities.RateLimit{
+					Requests:      60,
+					WindowSeconds: 60,
+					KeyRequests:   600,
+				},
+				RequireAPIKey: true,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := capabilities.New(tc.opts)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/capabilities", nil))
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp capabilities.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			assert.Equal(t, tc.want, resp)
+		})
+	}
+}
+
+// The advertised pattern must accept exactly the aliases the save handler
+// accepts, within the advertised lengths.
+func TestCapabilitiesHandler_PatternMatchesValidation(t *testing.T) {
+	rr := httptest.NewRecorder()
+	capabilities.New(capabilities.Options{}).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/capabilities", nil))
+
+	var resp capabilities.Response
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	pattern := regexp.MustCompile(resp.Alias.Pattern)
+	for _, alias := range []string{"abc", "my-link_2", "ab", "with space", "ünï", "a/b"} {
+		inLength := len(alias) >= resp.Alias.MinLength && len(alias) <= resp.Alias.MaxLength
+		assert.Equal(t, validate.Alias(alias, nil) == nil, pattern.MatchString(alias) && inLength, alias)
+	}
+}
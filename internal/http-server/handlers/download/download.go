@@ -0,0 +1,116 @@
+package download
+
+import (
+	"compress/gzip"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"url-shortener/internal/export"
+	"url-shortener/internal/lib/logger/sl"
+)
+
+// TrailerRecords is the trailer carrying the number of exported links,
+// so clients can tell a complete download from a truncated one.
+const TrailerRecords = "X-Export-Records"
+
+// Options holds the optional behaviour of the download handler.
+type Options struct {
+	// Gzip compresses the download for clients accepting gzip.
+	Gzip bool
+	// FlushEvery flushes the response every FlushEvery links, so clients
+	// see progress and nothing piles up in buffers. Only the end of the
+	// export is flushed when it is zero.
+	FlushEvery int
+}
+
+// New returns a handler streaming all links of src as a JSON lines
+// download. Rows are streamed, memory stays flat however large the export.
+func New(log *slog.Logger, src export.Source, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.download.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// large exports outlive the server write timeout
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+			log.Debug("failed to lift write deadline", sl.Err(err))
+		}
+
+		filename := "urls-" + time.Now().UTC().Format("20060102T150405Z") + ".jsonl"
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+		w.Header().Set("Trailer", TrailerRecords)
+		if opts.Gzip {
+			w.Header().Add("Vary", "Accept-Encoding")
+		}
+
+		var (
+			out   io.Writer = w
+			gz    *gzip.Writer
+			flush = func() {}
+		)
+
+		flusher, _ := w.(http.Flusher)
+
+		if opts.Gzip && acceptsGzip(r) {
+			w.Header().Set("Content-Encoding", "gzip")
+
+			gz = gzip.NewWriter(w)
+			out = gz
+			flush = func() {
+				_ = gz.Flush()
+				if flusher != nil {
+					flusher.Flush()
+				}
+			}
+		} else if flusher != nil {
+			flush = flusher.Flush
+		}
+
+		written, err := export.WriteWithProgress(r.Context(), out, src, export.Progress{
+			Every: opts.FlushEvery,
+			Func: func(int) error {
+				flush()
+				return nil
+			},
+		})
+		if err != nil {
+			// the status is sent already, abort so the client sees a
+			// broken download instead of a short one
+			log.Error("failed to export urls", slog.Int("written", written), sl.Err(err))
+			panic(http.ErrAbortHandler)
+		}
+
+		if gz != nil {
+			if err := gz.Close(); err != nil {
+				log.Error("failed to finish gzip stream", sl.Err(err))
+				panic(http.ErrAbortHandler)
+			}
+		}
+
+		w.Header().Set(TrailerRecords, strconv.Itoa(written))
+
+		log.Info("urls exported", slog.Int("written", written))
+	}
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		enc, params, _ := strings.Cut(strings.TrimSpace(enc), ";")
+		if strings.EqualFold(strings.TrimSpace(enc), "gzip") && strings.ReplaceAll(params, " ", "") != "q=0" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,153 @@
+package download_test
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/export"
+	"url-shortener/internal/http-server/handlers/download"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+type sliceSource []storage.URL
+
+func (s sliceSource) ExportURLs(_ context.Context, fn func(storage.URL) error) error {
+	for _, u := range s {
+		if err := fn(u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var createdAt = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+var links = sliceSource{
+	{ID: 1, Alias: "google", URL: "https://google.com", CreatedAt: createdAt},
+	{ID: 2, Alias: "promo", URL: "https://example.com", Sponsored: true, CreatedAt: createdAt},
+	{ID: 3, Alias: "docs", URL: "https://example.org/docs", CreatedAt: createdAt},
+}
+
+var want = []export.Record{
+	{ID: 1, Alias: "google", URL: "https://google.com", CreatedAt: createdAt},
+	{ID: 2, Alias: "promo", URL: "https://example.com", Sponsored: true, CreatedAt: createdAt},
+	{ID: 3, Alias: "docs", URL: "https://example.org/docs", CreatedAt: createdAt},
+}
+
+func decode(t *testing.T, r io.Reader) []export.Record {
+	t.Helper()
+
+	var records []export.Record
+
+	dec := json.NewDecoder(r)
+	for {
+		var rec export.Record
+		err := dec.Decode(&rec)
+		if errors.Is(err, io.EOF) {
+			return records
+		}
+		require.NoError(t, err)
+		records = append(records, rec)
+	}
+}
+
+func TestDownloadHandler(t *testing.T) {
+	cases := []struct {
+		name           string
+		gzip           bool
+		acceptEncoding string
+		wantGzip       bool
+	}{
+		{
+			name:           "Gzip",
+			gzip:           true,
+			acceptEncoding: "gzip, deflate",
+			wantGzip:       true,
+		},
+		{
+			name:           "Client without gzip",
+			gzip:           true,
+			acceptEncoding: "identity",
+		},
+		{
+			name:           "Gzip refused",
+			gzip:           true,
+			acceptEncoding: "gzip;q=0",
+		},
+		{
+			name:           "Gzip disabled",
+			acceptEncoding: "gzip",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := download.New(slogdiscard.NewDiscardLogger(), links, download.Options{
+				Gzip:       tc.gzip,
+				FlushEvery: 2,
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/export", nil)
+			req.Header.Set("Accept-Encoding", tc.acceptEncoding)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			res := rr.Result()
+			defer res.Body.Close()
+
+			require.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, "application/x-ndjson", res.Header.Get("Content-Type"))
+			assert.True(t, rr.Flushed)
+
+			var body io.Reader = res.Body
+			if tc.wantGzip {
+				require.Equal(t, "gzip", res.Header.Get("Content-Encoding"))
+
+				gz, err := gzip.NewReader(res.Body)
+				require.NoError(t, err)
+				body = gz
+			} else {
+				require.Empty(t, res.Header.Get("Content-Encoding"))
+			}
+
+			assert.Equal(t, want, decode(t, body))
+			assert.Equal(t, "3", res.Trailer.Get(download.TrailerRecords))
+		})
+	}
+}
+
+func TestDownloadHandler_OverHTTP(t *testing.T) {
+	srv := httptest.NewServer(download.New(slogdiscard.NewDiscardLogger(), links, download.Options{
+		Gzip:       true,
+		FlushEvery: 1,
+	}))
+	defer srv.Close()
+
+	// the default transport asks for gzip and decompresses transparently
+	res, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.True(t, res.Uncompressed)
+	assert.Equal(t, []string{"chunked"}, res.TransferEncoding)
+
+	assert.Equal(t, want, decode(t, res.Body))
+	assert.Equal(t, "3", res.Trailer.Get(download.TrailerRecords))
+}
@@ -0,0 +1,92 @@
+package create
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+type Request struct {
+	Name string `json:"name" validate:"required,max=128"`
+	// ParentID nests the folder in another, it is created at the top
+	// level when zero.
+	ParentID int64 `json:"parent_id,omitempty" validate:"omitempty,min=1"`
+}
+
+type Response struct {
+	resp.Response
+	ID int64 `json:"id,omitempty"`
+}
+
+// FolderCreator is an interface for creating folders.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=FolderCreator
+type FolderCreator interface {
+	CreateFolder(name string, parentID int64) (int64, error)
+}
+
+// New returns a handler creating the folder in the request body.
+func New(log *slog.Logger, folderCreator FolderCreator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.folder.create.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.Error("invalid request", sl.Err(err))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.ValidationError(validateErr))
+			return
+		}
+
+		id, err := folderCreator.CreateFolder(req.Name, req.ParentID)
+		if errors.Is(err, storage.ErrFolderNotFound) {
+			log.Info("parent folder not found", slog.Int64("parent_id", req.ParentID))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("parent folder not found"))
+			return
+		}
+		if errors.Is(err, storage.ErrFolderExists) {
+			log.Info("folder already exists", slog.String("name", req.Name))
+			resp.RenderError(w, r, http.StatusConflict, resp.Error("folder already exists"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to create folder", sl.Err(err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("failed to create folder"))
+			return
+		}
+
+		log.Info("folder created", slog.Int64("id", id))
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			ID:       id,
+		})
+	}
+}
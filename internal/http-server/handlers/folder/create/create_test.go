@@ -0,0 +1,104 @@
+package create_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/folder/create"
+	"url-shortener/internal/http-server/handlers/folder/create/mocks"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestCreateHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		input      string
+		mockName   string
+		mockParent int64
+		mockError  error
+		respError  string
+		statusCode int
+	}{
+		{
+			name:       "Top level",
+			input:      `{"name": "marketing"}`,
+			mockName:   "marketing",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Nested",
+			input:      `{"name": "campaigns", "parent_id": 1}`,
+			mockName:   "campaigns",
+			mockParent: 1,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Empty name",
+			input:      `{"name": ""}`,
+			respError:  "field Name is a required field",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Parent not found",
+			input:      `{"name": "campaigns", "parent_id": 9}`,
+			mockName:   "campaigns",
+			mockParent: 9,
+			mockError:  storage.ErrFolderNotFound,
+			respError:  "parent folder not found",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Exists",
+			input:      `{"name": "marketing"}`,
+			mockName:   "marketing",
+			mockError:  storage.ErrFolderExists,
+			respError:  "folder already exists",
+			statusCode: http.StatusConflict,
+		},
+		{
+			name:       "Storage error",
+			input:      `{"name": "marketing"}`,
+			mockName:   "marketing",
+			mockError:  errors.New("unexpected error"),
+			respError:  "failed to create folder",
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			folderCreatorMock := mocks.NewFolderCreator(t)
+			if tc.mockName != "" {
+				folderCreatorMock.On("CreateFolder", tc.mockName, tc.mockParent).Return(int64(3), tc.mockError).Once()
+			}
+
+			handler := create.New(slogdiscard.NewDiscardLogger(), folderCreatorMock)
+
+			req := httptest.NewRequest(http.MethodPost, "/folders", strings.NewReader(tc.input))
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp create.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+			if tc.respError == "" {
+				require.Equal(t, int64(3), resp.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package delete
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+type Response struct {
+	resp.Response
+	// Deleted is the number of links removed along with the folder, it
+	// stays zero when links are orphaned.
+	Deleted int `json:"deleted"`
+}
+
+// FolderDeleter is an interface for deleting a folder and its subfolders.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=FolderDeleter
+type FolderDeleter interface {
+	DeleteFolder(id int64, cascade bool) ([]string, error)
+}
+
+// URLCache is an interface for evicting a cached alias.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLCache
+type URLCache interface {
+	Delete(ctx context.Context, key string) error
+}
+
+type Options struct {
+	// Cascade deletes the links of the folder tree, otherwise they are
+	// kept and moved out of any folder.
+	Cascade bool
+}
+
+// New returns a handler deleting the folder in the {id} path param
+// together with its subfolders.
+func New(log *slog.Logger, folderDeleter FolderDeleter, urlCache URLCache, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.folder.delete.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil || id <= 0 {
+			log.Info("invalid id", slog.String("id", chi.URLParam(r, "id")))
+			resp.RenderError(w, r, http.StatusNotFound, resp.Error("not found"))
+			return
+		}
+
+		aliases, err := folderDeleter.DeleteFolder(id, opts.Cascade)
+		if errors.Is(err, storage.ErrFolderNotFound) {
+			log.Info("folder not found", slog.Int64("id", id))
+			resp.RenderError(w, r, http.StatusNotFound, resp.Error("not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to delete folder", sl.Err(err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("failed to delete folder"))
+			return
+		}
+
+		log.Info("folder deleted", slog.Int64("id", id), slog.Int("links", len(aliases)))
+
+		// stale redirects must stop right away
+		for _, alias := range aliases {
+			if err := urlCache.Delete(r.Context(), alias); err != nil {
+				log.Error("failed to evict url from cache", slog.String("alias", alias), sl.Err(err))
+			}
+		}
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Deleted:  len(aliases),
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package delete_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/folder/delete"
+	"url-shortener/internal/http-server/handlers/folder/delete/mocks"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestDeleteHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		cascade    bool
+		aliases    []string
+		mock       bool
+		mockError  error
+		respError  string
+		deleted    int
+		statusCode int
+	}{
+		{
+			name:       "Cascade",
+			id:         "1",
+			cascade:    true,
+			aliases:    []string{"first", "second"},
+			mock:       true,
+			deleted:    2,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Orphan",
+			id:         "1",
+			mock:       true,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Invalid id",
+			id:         "0",
+			respError:  "not found",
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name:       "Not found",
+			id:         "1",
+			mock:       true,
+			mockError:  storage.ErrFolderNotFound,
+			respError:  "not found",
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name:       "Storage error",
+			id:         "1",
+			mock:       true,
+			mockError:  errors.New("unexpected error"),
+			respError:  "failed to delete folder",
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			folderDeleterMock := mocks.NewFolderDeleter(t)
+			urlCacheMock := mocks.NewURLCache(t)
+			if tc.mock {
+				folderDeleterMock.On("DeleteFolder", int64(1), tc.cascade).Return(tc.aliases, tc.mockError).Once()
+			}
+			for _, alias := range tc.aliases {
+				urlCacheMock.On("Delete", mock.Anything, alias).Return(nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Delete("/folders/{id}", delete.New(slogdiscard.NewDiscardLogger(), folderDeleterMock, urlCacheMock, delete.Options{
+				Cascade: tc.cascade,
+			}))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/folders/"+tc.id, nil))
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp delete.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+			require.Equal(t, tc.deleted, resp.Deleted)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package list
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+type Folder struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	ParentID  int64     `json:"parent_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Response struct {
+	resp.Response
+	Folders []Folder `json:"folders"`
+}
+
+// FolderLister is an interface for listing every folder.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=FolderLister
+type FolderLister interface {
+	ListFolders() ([]storage.Folder, error)
+}
+
+// New returns a handler listing all folders, parents are linked by
+// parent_id so clients can rebuild the tree.
+func New(log *slog.Logger, folderLister FolderLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.folder.list.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		folders, err := folderLister.ListFolders()
+		if err != nil {
+			log.Error("failed to list folders", sl.Err(err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("failed to list folders"))
+			return
+		}
+
+		items := make([]Folder, 0, len(folders))
+		for _, f := range folders {
+			items = append(items, Folder{
+				ID:        f.ID,
+				Name:      f.Name,
+				ParentID:  f.ParentID,
+				CreatedAt: f.CreatedAt,
+			})
+		}
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Folders:  items,
+		})
+	}
+}
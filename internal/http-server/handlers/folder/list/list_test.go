@@ -0,0 +1,78 @@
+package list_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/folder/list"
+	"url-shortener/internal/http-server/handlers/folder/list/mocks"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestListHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		folders    []storage.Folder
+		mockError  error
+		want       []list.Folder
+		respError  string
+		statusCode int
+	}{
+		{
+			name: "Tree",
+			folders: []storage.Folder{
+				{ID: 1, Name: "marketing"},
+				{ID: 2, Name: "campaigns", ParentID: 1},
+			},
+			want: []list.Folder{
+				{ID: 1, Name: "marketing"},
+				{ID: 2, Name: "campaigns", ParentID: 1},
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Empty",
+			want:       []list.Folder{},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Storage error",
+			mockError:  errors.New("unexpected error"),
+			respError:  "failed to list folders",
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			folderListerMock := mocks.NewFolderLister(t)
+			folderListerMock.On("ListFolders").Return(tc.folders, tc.mockError).Once()
+
+			handler := list.New(slogdiscard.NewDiscardLogger(), folderListerMock)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/folders", nil))
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp list.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+			if tc.respError == "" {
+				require.Equal(t, tc.want, resp.Folders)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package rename
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+type Request struct {
+	Name string `json:"name" validate:"required,max=128"`
+}
+
+// FolderRenamer is an interface for renaming a folder.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=FolderRenamer
+type FolderRenamer interface {
+	RenameFolder(id int64, name string) error
+}
+
+// New returns a handler renaming the folder in the {id} path param.
+func New(log *slog.Logger, folderRenamer FolderRenamer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.folder.rename.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil || id <= 0 {
+			log.Info("invalid id", slog.String("id", chi.URLParam(r, "id")))
+			resp.RenderError(w, r, http.StatusNotFound, resp.Error("not found"))
+			return
+		}
+
+		var req Request
+
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.Error("invalid request", sl.Err(err))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.ValidationError(validateErr))
+			return
+		}
+
+		err = folderRenamer.RenameFolder(id, req.Name)
+		if errors.Is(err, storage.ErrFolderNotFound) {
+			log.Info("folder not found", slog.Int64("id", id))
+			resp.RenderError(w, r, http.StatusNotFound, resp.Error("not found"))
+			return
+		}
+		if errors.Is(err, storage.ErrFolderExists) {
+			log.Info("folder already exists", slog.String("name", req.Name))
+			resp.RenderError(w, r, http.StatusConflict, resp.Error("folder already exists"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to rename folder", sl.Err(err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("failed to rename folder"))
+			return
+		}
+
+		log.Info("folder renamed", slog.Int64("id", id))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,108 @@
+package rename_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/folder/rename"
+	"url-shortener/internal/http-server/handlers/folder/rename/mocks"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestRenameHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		input      string
+		mock       bool
+		mockError  error
+		respError  string
+		statusCode int
+	}{
+		{
+			name:       "Success",
+			id:         "1",
+			input:      `{"name": "sales"}`,
+			mock:       true,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Invalid id",
+			id:         "abc",
+			input:      `{"name": "sales"}`,
+			respError:  "not found",
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name:       "Empty name",
+			id:         "1",
+			input:      `{"name": ""}`,
+			respError:  "field Name is a required field",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Not found",
+			id:         "1",
+			input:      `{"name": "sales"}`,
+			mock:       true,
+			mockError:  storage.ErrFolderNotFound,
+			respError:  "not found",
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name:       "Name taken",
+			id:         "1",
+			input:      `{"name": "sales"}`,
+			mock:       true,
+			mockError:  storage.ErrFolderExists,
+			respError:  "folder already exists",
+			statusCode: http.StatusConflict,
+		},
+		{
+			name:       "Storage error",
+			id:         "1",
+			input:      `{"name": "sales"}`,
+			mock:       true,
+			mockError:  errors.New("unexpected error"),
+			respError:  "failed to rename folder",
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			folderRenamerMock := mocks.NewFolderRenamer(t)
+			if tc.mock {
+				folderRenamerMock.On("RenameFolder", int64(1), "sales").Return(tc.mockError).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Put("/folders/{id}", rename.New(slogdiscard.NewDiscardLogger(), folderRenamerMock))
+
+			req := httptest.NewRequest(http.MethodPut, "/folders/"+tc.id, strings.NewReader(tc.input))
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var body resp.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+			require.Equal(t, tc.respError, body.Error)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"url-shortener/internal/lib/logger/sl"
+)
+
+const (
+	statusUp   = "ok"
+	statusDown = "down"
+)
+
+// Pinger is an interface for checking that a dependency is reachable.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=Pinger
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Check is a named dependency reported by the health handler.
+type Check struct {
+	Name   string
+	Pinger Pinger
+}
+
+// Files is a Pinger that checks static files exist and are readable.
+type Files []string
+
+func (f Files) Ping(_ context.Context) error {
+	for _, name := range f {
+		file, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		_ = file.Close()
+	}
+
+	return nil
+}
+
+// New returns a HEAD /health handler. Each check is reported in an
+// X-<Name> header and the status is 503 if any of them is down.
+func New(log *slog.Logger, timeout time.Duration, checks ...Check) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.health.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		status := http.StatusOK
+
+		for _, check := range checks {
+			state := statusUp
+			if err := check.Pinger.Ping(ctx); err != nil {
+				log.Error("dependency is down", slog.String("dependency", check.Name), sl.Err(err))
+				state = statusDown
+				status = http.StatusServiceUnavailable
+			}
+
+			w.Header().Set("X-"+check.Name, state)
+		}
+
+		w.WriteHeader(status)
+	}
+}
@@ -0,0 +1,239 @@
+package health_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/health"
+	"url-shortener/internal/http-server/handlers/health/mocks"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestHealthHandler(t *testing.T) {
+	cases := []struct {
+		name          string
+		postgresError error
+		redisError    error
+		postgres      string
+		redis         string
+		statusCode    int
+	}{
+		{
+			name:       "All up",
+			postgres:   "ok",
+			redis:      "ok",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Redis down",
+			redisError: errors.New("connection refused"),
+			postgres:   "ok",
+			redis:      "down",
+			statusCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:          "Postgres down",
+			postgresError: errors.New("connection refused"),
+			postgres:      "down",
+			redis:         "ok",
+			statusCode:    http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			postgresMock := mocks.NewPinger(t)
+			redisMock := mocks.NewPinger(t)
+
+			postgresMock.On("Ping", mock.Anything).Return(tc.postgresError).Once()
+			redisMock.On("Ping", mock.Anything).Return(tc.redisError).Once()
+
+			handler := health.New(slogdiscard.NewDiscardLogger(), time.Second,
+				health.Check{Name: "Postgres", Pinger: postgresMock},
+				health.Check{Name: "Redis", Pinger: redisMock},
+			)
+
+			req, err := http.NewRequest(http.MethodHead, "/health", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+			require.Equal(t, tc.postgres, rr.Header().Get("X-Postgres"))
+			require.Equal(t, tc.redis, rr.Header().Get("X-Redis"))
+			require.Empty(t, rr.Body.String())
+		})
+	}
+}
+
+func TestReadyHandler(t *testing.T) {
+	cases := []struct {
+		name          string
+		postgresError error
+		redisError    error
+		status        string
+		failed        []string
+		statusCode    int
+	}{
+		{
+			name:       "All up",
+			status:     "ok",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Redis down",
+			redisError: errors.New("connection refused"),
+			status:     "down",
+			failed:     []string{"Redis"},
+			statusCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:          "Both down",
+			postgresError: errors.New("connection refused"),
+			redisError:    errors.New("connection refused"),
+			status:        "down",
+			failed:        []string{"Postgres", "Redis"},
+			statusCode:    http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			postgresMock := mocks.NewPinger(t)
+			redisMock := mocks.NewPinger(t)
+
+			postgresMock.On("Ping", mock.Anything).Return(tc.postgresError).Once()
+			redisMock.On("Ping", mock.Anything).Return(tc.redisError).Once()
+
+			handler := health.Ready(slogdiscard.NewDiscardLogger(), time.Second,
+				health.Check{Name: "Postgres", Pinger: postgresMock},
+				health.Check{Name: "Redis", Pinger: redisMock},
+			)
+
+			req, err := http.NewRequest(http.MethodGet, "/ready", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp health.ReadyResponse
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.status, resp.Status)
+			require.Equal(t, tc.failed, resp.Failed)
+			require.Len(t, resp.Dependencies, 2)
+		})
+	}
+}
+
+func TestHealthHandler_NoChecks(t *testing.T) {
+	handler := health.New(slogdiscard.NewDiscardLogger(), time.Second)
+
+	req, err := http.NewRequest(http.MethodHead, "/health", nil)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestHealthHandler_Assets(t *testing.T) {
+	dir := t.TempDir()
+
+	index := filepath.Join(dir, "index.html")
+	require.NoError(t, os.WriteFile(index, []byte("<html></html>"), 0o644))
+
+	cases := []struct {
+		name       string
+		files      health.Files
+		assets     string
+		statusCode int
+	}{
+		{
+			name:       "Assets present",
+			files:      health.Files{index},
+			assets:     "ok",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Asset missing",
+			files:      health.Files{index, filepath.Join(dir, "style.css")},
+			assets:     "down",
+			statusCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := health.Status(health.StatusOptions{JSON: true, Assets: tc.files})
+
+			rr := httptest.NewRecorder()
+			handler(rr, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp health.StatusResponse
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.assets, resp.Assets)
+		})
+	}
+}
+
+func TestStatusHandler(t *testing.T) {
+	t.Run("plain", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		health.Status(health.StatusOptions{})(rr, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, "OK", rr.Body.String())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		handler := health.Status(health.StatusOptions{
+			JSON:    true,
+			Version: "1.2.3",
+			Started: time.Now().Add(-90 * time.Minute),
+		})
+
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp health.StatusResponse
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+		require.Equal(t, "ok", resp.Status)
+		require.Equal(t, "1.2.3", resp.Version)
+		require.Equal(t, "1h30m0s", resp.Uptime)
+	})
+}
@@ -0,0 +1,60 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"url-shortener/internal/lib/logger/sl"
+)
+
+type ReadyResponse struct {
+	Status string `json:"status"`
+	// Dependencies maps every checked dependency to "ok" or "down".
+	Dependencies map[string]string `json:"dependencies"`
+	// Failed lists the dependencies that are down.
+	Failed []string `json:"failed,omitempty"`
+}
+
+// Ready returns a GET /ready handler for readiness probes. Unlike the
+// liveness check on /health it pings every check, answering 200 only if
+// all of them are up and 503 otherwise.
+func Ready(log *slog.Logger, timeout time.Duration, checks ...Check) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.health.Ready"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		res := ReadyResponse{
+			Status:       statusUp,
+			Dependencies: make(map[string]string, len(checks)),
+		}
+
+		for _, check := range checks {
+			state := statusUp
+			if err := check.Pinger.Ping(ctx); err != nil {
+				log.Error("dependency is down", slog.String("dependency", check.Name), sl.Err(err))
+				state = statusDown
+				res.Status = statusDown
+				res.Failed = append(res.Failed, check.Name)
+			}
+
+			res.Dependencies[check.Name] = state
+		}
+
+		if res.Status != statusUp {
+			render.Status(r, http.StatusServiceUnavailable)
+		}
+		render.JSON(w, r, res)
+	}
+}
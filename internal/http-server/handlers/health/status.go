@@ -0,0 +1,64 @@
+package health
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+type StatusResponse struct {
+	Status  string `json:"status"`
+	Uptime  string `json:"uptime"`
+	Version string `json:"version"`
+	// Assets is "ok" or "down" when the frontend assets are checked.
+	Assets string `json:"assets,omitempty"`
+}
+
+// StatusOptions holds the optional behaviour of the status handler.
+type StatusOptions struct {
+	// JSON renders a StatusResponse instead of the plain "OK" body
+	// simple probes expect.
+	JSON    bool
+	Version string
+	// Started is when the process started, uptime is measured from it.
+	Started time.Time
+	// Assets checks the frontend assets are servable, the service is
+	// reported down without them. They are not checked when it is nil.
+	Assets Pinger
+}
+
+// Status returns a GET /health handler reporting the service is up.
+func Status(opts StatusOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status, assets := statusUp, ""
+		if opts.Assets != nil {
+			assets = statusUp
+			if err := opts.Assets.Ping(r.Context()); err != nil {
+				status, assets = statusDown, statusDown
+			}
+		}
+
+		if !opts.JSON {
+			if status != statusUp {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = w.Write([]byte("DOWN"))
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte("OK"))
+			return
+		}
+
+		if status != statusUp {
+			render.Status(r, http.StatusServiceUnavailable)
+		}
+		render.JSON(w, r, StatusResponse{
+			Status:  status,
+			Uptime:  time.Since(opts.Started).Round(time.Second).String(),
+			Version: opts.Version,
+			Assets:  assets,
+		})
+	}
+}
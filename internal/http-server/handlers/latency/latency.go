@@ -0,0 +1,64 @@
+package latency
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/latency"
+)
+
+type Route struct {
+	Count int     `json:"count"`
+	P50   float64 `json:"p50_ms"`
+	P95   float64 `json:"p95_ms"`
+	P99   float64 `json:"p99_ms"`
+}
+
+type Response struct {
+	resp.Response
+	Routes map[string]Route `json:"routes"`
+}
+
+// Summarizer is an interface for getting latency summaries per route.
+type Summarizer interface {
+	Summaries() map[string]latency.Summary
+}
+
+func New(log *slog.Logger, summarizer Summarizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.latency.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		summaries := summarizer.Summaries()
+
+		routes := make(map[string]Route, len(summaries))
+		for route, s := range summaries {
+			routes[route] = Route{
+				Count: s.Count,
+				P50:   milliseconds(s.P50),
+				P95:   milliseconds(s.P95),
+				P99:   milliseconds(s.P99),
+			}
+		}
+
+		log.Debug("latency summaries collected", slog.Int("routes", len(routes)))
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Routes:   routes,
+		})
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
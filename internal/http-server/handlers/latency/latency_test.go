@@ -0,0 +1,55 @@
+package latency_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	handler "url-shortener/internal/http-server/handlers/latency"
+	mwLatency "url-shortener/internal/http-server/middleware/latency"
+	"url-shortener/internal/lib/latency"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestLatencyHandler(t *testing.T) {
+	log := slogdiscard.NewDiscardLogger()
+	recorder := latency.NewRecorder(100)
+
+	r := chi.NewRouter()
+	r.Use(mwLatency.New(log, recorder))
+	r.Get("/{alias}", func(w http.ResponseWriter, r *http.Request) {})
+	r.Get("/admin/latency", handler.New(log, recorder))
+
+	for _, alias := range []string{"abc", "def", "ghi"} {
+		req := httptest.NewRequest(http.MethodGet, "/"+alias, nil)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// known latencies on top of the three real requests
+	for i := 1; i <= 97; i++ {
+		recorder.Observe("POST /url/", time.Duration(i)*time.Millisecond)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/latency", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp handler.Response
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	require.Equal(t, 3, resp.Routes["GET /{alias}"].Count)
+
+	save := resp.Routes["POST /url/"]
+	require.Equal(t, 97, save.Count)
+	require.InDelta(t, 49, save.P50, 1)
+	require.InDelta(t, 93, save.P95, 1)
+	require.InDelta(t, 97, save.P99, 1)
+}
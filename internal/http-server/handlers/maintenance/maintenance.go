@@ -0,0 +1,79 @@
+package maintenance
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+)
+
+type Request struct {
+	Enabled *bool `json:"enabled"`
+}
+
+type Response struct {
+	resp.Response
+	Enabled bool `json:"enabled"`
+}
+
+// Mode is an interface for reading and toggling maintenance mode.
+type Mode interface {
+	Enabled() bool
+	Set(enabled bool)
+}
+
+// Get returns a handler reporting whether maintenance mode is on.
+func Get(mode Mode) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Enabled:  mode.Enabled(),
+		})
+	}
+}
+
+// Set returns a handler switching maintenance mode on or off.
+func Set(log *slog.Logger, mode Mode) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.maintenance.Set"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("failed to decode request"))
+			return
+		}
+		if req.Enabled == nil {
+			log.Info("enabled is missing")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("field enabled is required"))
+			return
+		}
+
+		mode.Set(*req.Enabled)
+
+		log.Warn("maintenance mode toggled", slog.Bool("enabled", *req.Enabled))
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Enabled:  *req.Enabled,
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package maintenance_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/maintenance"
+	mwMaintenance "url-shortener/internal/http-server/middleware/maintenance"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestMaintenanceHandlers(t *testing.T) {
+	cases := []struct {
+		name       string
+		input      string
+		enabled    bool
+		respError  string
+		statusCode int
+	}{
+		{
+			name:       "Enable",
+			input:      `{"enabled": true}`,
+			enabled:    true,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Disable",
+			input:      `{"enabled": false}`,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Missing flag",
+			input:      `{}`,
+			enabled:    true,
+			respError:  "field enabled is required",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Empty body",
+			enabled:    true,
+			respError:  "empty request",
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// starts on, so failed requests must leave it on
+			mode := mwMaintenance.NewMode(true, time.Minute)
+
+			req, err := http.NewRequest(http.MethodPut, "/admin/maintenance", strings.NewReader(tc.input))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			maintenance.Set(slogdiscard.NewDiscardLogger(), mode).ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp maintenance.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.respError, resp.Error)
+
+			require.Equal(t, tc.enabled, mode.Enabled())
+
+			rr = httptest.NewRecorder()
+			maintenance.Get(mode).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/maintenance", nil))
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.enabled, resp.Enabled)
+		})
+	}
+}
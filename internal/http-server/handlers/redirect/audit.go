@@ -0,0 +1,42 @@
+package redirect
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// AuditEvent is sent to the audit webhook for every redirect of an
+// audited link.
+type AuditEvent struct {
+	Alias    string    `json:"alias"`
+	URL      string    `json:"url"`
+	Time     time.Time `json:"time"`
+	Referrer string    `json:"referrer,omitempty"`
+	IP       string    `json:"ip,omitempty"`
+	// Owner is who is accountable for the link, if it has an owner.
+	Owner string `json:"owner,omitempty"`
+}
+
+// Auditor is an interface for queueing audit events for async delivery.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=Auditor
+type Auditor interface {
+	Send(event any) bool
+}
+
+func newAuditEvent(r *http.Request, alias, target, owner string) AuditEvent {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	return AuditEvent{
+		Alias:    alias,
+		URL:      target,
+		Time:     time.Now().UTC(),
+		Referrer: r.Referer(),
+		IP:       ip,
+		Owner:    owner,
+	}
+}
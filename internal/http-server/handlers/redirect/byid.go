@@ -0,0 +1,75 @@
+package redirect
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"url-shortener/internal/http-server/middleware/namespace"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/signing"
+	"url-shortener/internal/storage"
+)
+
+// URLByIDGetter is an interface for getting a link by its numeric id.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLByIDGetter
+type URLByIDGetter interface {
+	URLGetter
+	GetURLByID(id int64) (storage.URL, error)
+}
+
+// NewByID returns a handler resolving links by their numeric id, as a
+// stable alternative to the alias. The link is served like on its alias.
+func NewByID(log *slog.Logger, urlGetter URLByIDGetter, urlCache URLCache, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.redirect.NewByID"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil || id <= 0 {
+			log.Info("invalid id", slog.String("id", chi.URLParam(r, "id")))
+			resp.RenderError(w, r, http.StatusNotFound, resp.Error("not found"))
+			return
+		}
+
+		link, err := urlGetter.GetURLByID(id)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url not found", slog.Int64("id", id))
+			resp.RenderError(w, r, http.StatusNotFound, resp.Error("not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get url", sl.Err(err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+			return
+		}
+
+		// ids are shared by all namespaces, their links are not
+		if !namespace.Holds(r.Context(), link.Alias) {
+			log.Info("link of another namespace requested by id", slog.Int64("id", id))
+			resp.RenderError(w, r, http.StatusNotFound, resp.Error("not found"))
+			return
+		}
+
+		// sequential ids must not bypass the signature of signed links
+		if opts.Signer != nil && signing.IsSigned(link.Alias) {
+			log.Info("signed link requested by id", slog.Int64("id", id))
+			resp.RenderError(w, r, http.StatusNotFound, resp.Error("not found"))
+			return
+		}
+
+		log.Info("got url from storage", slog.String("url", link.URL))
+
+		serveLink(w, r, log, urlGetter, urlCache, link.Alias, link, opts)
+	}
+}
@@ -0,0 +1,146 @@
+package redirect_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/cache"
+	"url-shortener/internal/http-server/handlers/redirect"
+	"url-shortener/internal/http-server/handlers/redirect/mocks"
+	"url-shortener/internal/http-server/middleware/namespace"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/lib/signing"
+	"url-shortener/internal/storage"
+)
+
+func TestRedirectByIDHandler(t *testing.T) {
+	const url = "https://www.google.com/"
+
+	signer := signing.New("secret", 8)
+	signed := signer.Sign("promo")
+
+	cases := []struct {
+		name       string
+		id         string
+		link       *storage.URL
+		mockError  error
+		statusCode int
+	}{
+		{
+			name:       "Success",
+			id:         "7",
+			link:       &storage.URL{ID: 7, Alias: "test_alias", URL: url},
+			statusCode: http.StatusFound,
+		},
+		{
+			name:       "Not found",
+			id:         "8",
+			mockError:  storage.ErrURLNotFound,
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name:       "Not a number",
+			id:         "abc",
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name:       "Signed link",
+			id:         "9",
+			link:       &storage.URL{ID: 9, Alias: signed, URL: url},
+			statusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLByIDGetter(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			switch {
+			case tc.link != nil:
+				urlGetterMock.On("GetURLByID", tc.link.ID).Return(*tc.link, nil).Once()
+			case tc.mockError != nil:
+				urlGetterMock.On("GetURLByID", mock.AnythingOfType("int64")).Return(storage.URL{}, tc.mockError).Once()
+			}
+			if tc.statusCode == http.StatusFound {
+				urlCacheMock.On("Set", mock.Anything, tc.link.Alias, cache.Entry{URL: url, Enabled: true}, cacheTTL).Return(nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/i/{id}", redirect.NewByID(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+				CacheTTL: cacheTTL,
+				Signer:   signer,
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/i/"+tc.id, nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+			if tc.statusCode == http.StatusFound {
+				assert.Equal(t, url, rr.Header().Get("Location"))
+			}
+		})
+	}
+}
+
+func TestRedirectByIDHandler_MatchesAlias(t *testing.T) {
+	link := storage.URL{ID: 7, Alias: "test_alias", URL: "https://www.google.com/"}
+
+	urlGetterMock := mocks.NewURLByIDGetter(t)
+	urlCacheMock := mocks.NewURLCache(t)
+
+	urlGetterMock.On("GetURLByID", link.ID).Return(link, nil).Once()
+	urlGetterMock.On("GetURLInfoContext", mock.Anything, link.Alias).Return(link, nil).Once()
+	urlCacheMock.On("GetEntry", mock.Anything, link.Alias).Return(cache.Entry{}, redis.Nil).Once()
+	urlCacheMock.On("Set", mock.Anything, link.Alias, cache.Entry{URL: link.URL, Enabled: true}, cacheTTL).Return(nil).Twice()
+
+	r := chi.NewRouter()
+	r.Get("/i/{id}", redirect.NewByID(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{CacheTTL: cacheTTL}))
+	r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{CacheTTL: cacheTTL}))
+
+	locations := make([]string, 0, 2)
+	for _, path := range []string{"/i/7", "/test_alias"} {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+
+		require.Equal(t, http.StatusFound, rr.Code)
+		locations = append(locations, rr.Header().Get("Location"))
+	}
+
+	assert.Equal(t, locations[0], locations[1])
+}
+
+func TestRedirectByIDHandler_Namespaces(t *testing.T) {
+	link := storage.URL{ID: 7, Alias: "a~docs", URL: "https://a.example.org/"}
+
+	urlGetterMock := mocks.NewURLByIDGetter(t)
+	urlCacheMock := mocks.NewURLCache(t)
+
+	urlGetterMock.On("GetURLByID", link.ID).Return(link, nil).Twice()
+	urlCacheMock.On("Set", mock.Anything, link.Alias, cache.Entry{URL: link.URL, Enabled: true}, cacheTTL).Return(nil).Once()
+
+	r := chi.NewRouter()
+	r.Use(namespace.New(map[string]string{"a.example.com": "a", "b.example.com": "b"}))
+	r.Get("/i/{id}", redirect.NewByID(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{CacheTTL: cacheTTL}))
+
+	for host, code := range map[string]int{"a.example.com": http.StatusFound, "b.example.com": http.StatusNotFound} {
+		req := httptest.NewRequest(http.MethodGet, "/i/7", nil)
+		req.Host = host
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, code, rr.Code, host)
+	}
+}
@@ -0,0 +1,80 @@
+package redirect
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+// ClickCounter is an interface for counting the redirects of an alias.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=ClickCounter
+type ClickCounter interface {
+	IncrementClicks(alias string) error
+}
+
+// countClick increments the clicks of alias in the background, so the
+// write never holds back the redirect.
+func countClick(log *slog.Logger, alias string, opts Options) {
+	if opts.Clicks == nil {
+		return
+	}
+
+	go func() {
+		if err := opts.Clicks.IncrementClicks(alias); err != nil {
+			log.Error("failed to count click", sl.Err(err))
+		}
+	}()
+}
+
+// ClickRecorder is an interface for recording redirects for analytics.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=ClickRecorder
+type ClickRecorder interface {
+	RecordClick(event storage.ClickEvent) error
+}
+
+// maxRecordedHeaderLength caps the referrer and user agent of recorded
+// clicks.
+const maxRecordedHeaderLength = 512
+
+// recordClick records the redirect of alias in the background. Analytics
+// are best-effort, a failed write is only logged.
+func recordClick(r *http.Request, log *slog.Logger, alias string, opts Options) {
+	if opts.Analytics == nil {
+		return
+	}
+
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	event := storage.ClickEvent{
+		Alias:     alias,
+		Time:      time.Now().UTC(),
+		Referrer:  truncate(r.Referer(), maxRecordedHeaderLength),
+		UserAgent: truncate(r.UserAgent(), maxRecordedHeaderLength),
+		IP:        ip,
+	}
+
+	go func() {
+		if err := opts.Analytics.RecordClick(event); err != nil {
+			log.Error("failed to record click", sl.Err(err))
+		}
+	}()
+}
+
+// truncate cuts s to at most n bytes without splitting a character.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	return strings.ToValidUTF8(s[:n], "")
+}
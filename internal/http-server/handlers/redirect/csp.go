@@ -0,0 +1,24 @@
+package redirect
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+)
+
+// newNonce returns a random CSP nonce for the inline scripts of a page.
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// setCSP allows only the scripts carrying nonce to run on the page.
+// Other content, such as the images and frames of ad snippets, is not
+// restricted.
+func setCSP(h http.Header, nonce string) {
+	h.Set("Content-Security-Policy", "script-src 'nonce-"+nonce+"'; object-src 'none'; base-uri 'none'")
+}
@@ -0,0 +1,48 @@
+package redirect
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Delay slows down redirects to flagged target hosts, so the shortener
+// can't be used to amplify traffic against them.
+type Delay struct {
+	// Hosts maps lower-case target hosts to the delay before redirecting.
+	Hosts map[string]time.Duration
+	// Max caps every delay, uncapped when zero.
+	Max time.Duration
+	// After waits for the delay, time.After when nil.
+	After func(d time.Duration) <-chan time.Time
+}
+
+// wait blocks for the delay of target's host. It returns early with the
+// context's error if the client goes away.
+func (d *Delay) wait(ctx context.Context, target string) error {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil
+	}
+
+	delay := d.Hosts[strings.ToLower(u.Hostname())]
+	if delay <= 0 {
+		return nil
+	}
+	if d.Max > 0 && delay > d.Max {
+		delay = d.Max
+	}
+
+	after := d.After
+	if after == nil {
+		after = time.After
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-after(delay):
+		return nil
+	}
+}
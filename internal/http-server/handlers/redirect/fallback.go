@@ -0,0 +1,33 @@
+package redirect
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// Fallback is an interface for the last-resort links served when an
+// alias can't be resolved from the cache or storage.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=Fallback
+type Fallback interface {
+	Lookup(alias string) (string, bool)
+}
+
+// serveFallback redirects to the fallback link of alias, if there is
+// one. It returns false if nothing was served.
+func serveFallback(w http.ResponseWriter, r *http.Request, log *slog.Logger, alias string, opts Options) bool {
+	if opts.Fallback == nil {
+		return false
+	}
+
+	target, ok := opts.Fallback.Lookup(alias)
+	if !ok {
+		return false
+	}
+
+	log.Warn("serving fallback url", slog.String("alias", alias), slog.String("url", target))
+	observeHost(target, opts)
+	http.Redirect(w, r, target, http.StatusFound)
+
+	return true
+}
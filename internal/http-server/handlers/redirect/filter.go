@@ -0,0 +1,10 @@
+package redirect
+
+// AliasFilter is an interface for a probabilistic set of the stored
+// aliases, such as a Bloom filter. MayContain must never report false
+// for a stored alias.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=AliasFilter
+type AliasFilter interface {
+	MayContain(alias string) bool
+}
@@ -0,0 +1,17 @@
+package redirect
+
+// HostObserver is an interface for counting redirects by target host.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=HostObserver
+type HostObserver interface {
+	ObserveRedirect(target string)
+}
+
+// observeHost counts a redirect to target.
+func observeHost(target string, opts Options) {
+	if opts.Hosts == nil {
+		return
+	}
+
+	opts.Hosts.ObserveRedirect(target)
+}
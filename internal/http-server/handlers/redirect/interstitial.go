@@ -0,0 +1,78 @@
+package redirect
+
+import (
+	"html/template"
+	"net/http"
+	"time"
+)
+
+// Interstitial is the ad page shown before redirecting to sponsored links.
+type Interstitial struct {
+	// SkipAfter is how long the ad is shown before the visitor moves on.
+	SkipAfter time.Duration
+	// Snippet is the ad markup configured by the operator.
+	Snippet template.HTML
+	// CSP only allows the countdown script to run, with a nonce fresh for
+	// every page. Scripts of the snippet are blocked.
+	CSP bool
+}
+
+var interstitialTmpl = template.Must(template.New("interstitial").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>Sponsored</title>
+</head>
+<body>
+<div class="ad">{{.Snippet}}</div>
+<p>You will be redirected in <span id="countdown">{{.Seconds}}</span> seconds.</p>
+<noscript><p><a href="{{.Href}}">Continue</a></p></noscript>
+<script{{if .Nonce}} nonce="{{.Nonce}}"{{end}}>
+(function () {
+	var target = {{.Target}};
+	var left = {{.Seconds}};
+	var countdown = document.getElementById("countdown");
+	var timer = setInterval(function () {
+		left--;
+		countdown.textContent = left;
+		if (left <= 0) {
+			clearInterval(timer);
+			window.location.replace(target);
+		}
+	}, 1000);
+})();
+</script>
+</body>
+</html>
+`))
+
+func (i *Interstitial) serve(w http.ResponseWriter, target string) error {
+	var nonce string
+	if i.CSP {
+		var err error
+		nonce, err = newNonce()
+		if err != nil {
+			return err
+		}
+		setCSP(w.Header(), nonce)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+
+	return interstitialTmpl.Execute(w, struct {
+		Snippet template.HTML
+		Seconds int
+		Target  string
+		// target passed validation on save, so non-http schemes are safe here
+		Href  template.URL
+		Nonce string
+	}{
+		Snippet: i.Snippet,
+		Seconds: int(i.SkipAfter.Seconds()),
+		Target:  target,
+		Href:    template.URL(target),
+		Nonce:   nonce,
+	})
+}
@@ -0,0 +1,84 @@
+package redirect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"url-shortener/internal/lib/normalize"
+	"url-shortener/internal/lib/signing"
+	"url-shortener/internal/storage"
+)
+
+var errRedirectLoop = errors.New("redirect loop detected")
+
+// LoopDetection resolves targets pointing back at the shortener before
+// redirecting, so misconfigured aliases (A -> B -> A) fail instead of
+// sending clients around in circles.
+type LoopDetection struct {
+	// Hosts the shortener is reachable on, e.g. "sho.rt" or "localhost:8080".
+	Hosts []string
+	// MaxHops is how many aliases of a chain are resolved before the
+	// chain is treated as a loop.
+	MaxHops int
+}
+
+// check follows the chain starting at alias, which resolved to target.
+// It returns errRedirectLoop when an alias repeats or the chain is longer
+// than MaxHops.
+func (d *LoopDetection) check(ctx context.Context, urlGetter URLGetter, alias, target string, opts Options) error {
+	const op = "handlers.url.redirect.LoopDetection.check"
+
+	seen := map[string]bool{alias: true}
+
+	for hops := 0; ; hops++ {
+		next, ok := d.alias(target)
+		if !ok {
+			return nil
+		}
+
+		if opts.FoldAliases && !(opts.Signer != nil && signing.IsSigned(next)) {
+			next = normalize.Alias(next)
+		}
+
+		if seen[next] || hops >= d.MaxHops {
+			return fmt.Errorf("%s: %w", op, errRedirectLoop)
+		}
+		seen[next] = true
+
+		link, err := getURLInfo(ctx, urlGetter, next, opts)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			// the chain ends in a 404, which is not a loop
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		target = link.URL
+	}
+}
+
+// alias returns the alias target points at if it is a link on the shortener.
+func (d *LoopDetection) alias(target string) (string, bool) {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", false
+	}
+
+	if !slices.ContainsFunc(d.Hosts, func(host string) bool {
+		return strings.EqualFold(host, u.Host)
+	}) {
+		return "", false
+	}
+
+	alias := strings.Trim(u.Path, "/")
+	if alias == "" || strings.Contains(alias, "/") {
+		return "", false
+	}
+
+	return alias, true
+}
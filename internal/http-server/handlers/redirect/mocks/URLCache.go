@@ -5,15 +5,17 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/mock"
+
+	"url-shortener/internal/cache"
 )
 
 type URLCache struct {
 	mock.Mock
 }
 
-func (m *URLCache) Get(ctx context.Context, key string) (string, error) {
+func (m *URLCache) GetEntry(ctx context.Context, key string) (cache.Entry, error) {
 	args := m.Called(ctx, key)
-	return args.String(0), args.Error(1)
+	return args.Get(0).(cache.Entry), args.Error(1)
 }
 
 func (m *URLCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
@@ -21,6 +23,11 @@ func (m *URLCache) Set(ctx context.Context, key string, value interface{}, expir
 	return args.Error(0)
 }
 
+func (m *URLCache) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	args := m.Called(ctx, key, value, expiration)
+	return args.Bool(0), args.Error(1)
+}
+
 type mockConstructorTestingTNewURLCache interface {
 	mock.TestingT
 	Cleanup(func())
@@ -9,27 +9,105 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/go-chi/render"
 	"github.com/go-redis/redis/v8"
 
+	"url-shortener/internal/cache"
+	"url-shortener/internal/http-server/middleware/namespace"
 	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/expiry"
 	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/normalize"
+	"url-shortener/internal/lib/password"
+	"url-shortener/internal/lib/signing"
 	"url-shortener/internal/storage"
 )
 
-// URLGetter is an interface for getting url by alias.
+// URLGetter is an interface for getting a link by alias.
 //
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLGetter
 type URLGetter interface {
-	GetURL(alias string) (string, error)
+	GetURLInfoContext(ctx context.Context, alias string) (storage.URL, error)
 }
 
 type URLCache interface {
-	Get(ctx context.Context, key string) (string, error)
+	GetEntry(ctx context.Context, key string) (cache.Entry, error)
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error)
 }
 
-func New(log *slog.Logger, urlGetter URLGetter, urlCache URLCache) http.HandlerFunc {
+// URLToucher is an interface for recording when an alias was last accessed.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLToucher
+type URLToucher interface {
+	TouchURL(alias string, at time.Time) error
+}
+
+// Options holds the optional behaviour of the redirect handler.
+type Options struct {
+	// FoldAliases looks aliases up case- and accent-insensitively.
+	FoldAliases bool
+	// Signer verifies signed aliases before they are looked up.
+	Signer *signing.Signer
+	// Toucher records the last access time of resolved aliases,
+	// at most once per TouchInterval. Tracking is off when it is nil.
+	Toucher       URLToucher
+	TouchInterval time.Duration
+	// Interstitial is shown before sponsored links. When it is nil
+	// sponsored links redirect directly.
+	Interstitial *Interstitial
+	// LoopDetection rejects aliases whose chain of shortener links loops.
+	// Targets are not followed when it is nil.
+	LoopDetection *LoopDetection
+	// LinkHeaders adds X-Link-Created to redirects. The cache only holds
+	// targets, so redirects are resolved from storage when it is set.
+	LinkHeaders bool
+	// Delay holds back redirects to flagged target hosts.
+	Delay *Delay
+	// Auditor receives an AuditEvent for every redirect of an audited
+	// link. Audited links redirect silently when it is nil.
+	Auditor Auditor
+	// CacheTTL is how long links resolved from storage are cached,
+	// cache.DefaultTTL when zero.
+	CacheTTL time.Duration
+	// StaleWhileRevalidate refreshes cached links in the background once
+	// they are past a soft TTL, replacing CacheTTL.
+	StaleWhileRevalidate *StaleWhileRevalidate
+	// Visitors counts the unique visitors of every alias. Visitors are not
+	// counted when it is nil.
+	Visitors VisitorCounter
+	// Clicks counts the redirects of every alias, cache hits included.
+	// Clicks are not counted when it is nil.
+	Clicks ClickCounter
+	// Analytics records the time, referrer, user agent and IP of every
+	// redirect, cache hits included. Nothing is recorded when it is nil.
+	Analytics ClickRecorder
+	// Splash is served while links slow to resolve from storage are
+	// looked up. Clients wait for the redirect when it is nil.
+	Splash *Splash
+	// Fallback resolves aliases missing from or failing in storage,
+	// so curated links keep working through an outage.
+	Fallback Fallback
+	// Hosts counts redirects by target host. Hosts are not counted when
+	// it is nil.
+	Hosts HostObserver
+	// Aliases skips the storage lookup of aliases it reports absent.
+	// Aliases it reports present, false positives included, are looked up.
+	Aliases AliasFilter
+	// ExpiryWarning adds expiry.Header to redirects of links expiring
+	// within it. No header is added when it is zero.
+	ExpiryWarning time.Duration
+	// QueryTimeout bounds every storage lookup. Lookups run as long as
+	// the request when it is zero.
+	QueryTimeout time.Duration
+	// RedirectStatus is the status code of redirects to links without one
+	// of their own, http.StatusFound when zero.
+	RedirectStatus int
+	// Split divides the redirects of split links between their variants.
+	// Variants are drawn by weight on every redirect when it is nil.
+	Split *Split
+}
+
+func New(log *slog.Logger, urlGetter URLGetter, urlCache URLCache, opts Options) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.url.redirect.New"
 
@@ -41,7 +119,7 @@ func New(log *slog.Logger, urlGetter URLGetter, urlCache URLCache) http.HandlerF
 		alias := chi.URLParam(r, "alias")
 		if alias == "" {
 			log.Info("alias is empty")
-			render.JSON(w, r, resp.Error("invalid request"))
+			resp.RenderError(w, r, http.StatusNotFound, resp.Error("invalid request"))
 			return
 		}
 
@@ -55,38 +133,271 @@ func New(log *slog.Logger, urlGetter URLGetter, urlCache URLCache) http.HandlerF
 			}
 		}
 
+		switch {
+		case opts.Signer != nil && signing.IsSigned(alias):
+			// tampered or guessed aliases never reach the cache or storage
+			if err := opts.Signer.Verify(alias); err != nil {
+				log.Info("invalid alias signature", slog.String("alias", alias))
+				resp.RenderError(w, r, http.StatusNotFound, resp.Error("not found"))
+				return
+			}
+		case opts.FoldAliases:
+			// signed aliases are stored folded already, and the signature is case-sensitive
+			alias = normalize.Alias(alias)
+		}
+		alias = namespace.Qualify(r.Context(), alias)
+
 		// Check cache first
-		resURL, err := urlCache.Get(r.Context(), alias)
-		if err == nil {
-			log.Info("got url from cache", slog.String("url", resURL))
-			http.Redirect(w, r, resURL, http.StatusFound)
+		var (
+			entry cache.Entry
+			err   error = redis.Nil
+		)
+		if !opts.LinkHeaders {
+			entry, err = urlCache.GetEntry(r.Context(), alias)
+		}
+		// expired or disabled entries are left for storage to decide
+		if now := time.Now(); err == nil && entry.Usable(now) {
+			log.Info("got url from cache", slog.String("url", entry.URL))
+
+			if opts.StaleWhileRevalidate != nil && entry.Stale(now) {
+				revalidate(r.Context(), log, urlGetter, urlCache, alias, opts)
+			}
+
+			if !checkLoop(w, r, log, urlGetter, alias, entry.URL, opts) {
+				return
+			}
+			touch(r.Context(), log, urlCache, alias, opts)
+			countVisitor(r, log, alias, opts)
+			countClick(log, alias, opts)
+			recordClick(r, log, alias, opts)
+			observeHost(entry.URL, opts)
+
+			if !delay(r.Context(), log, entry.URL, opts) {
+				return
+			}
+			expiry.Warn(w.Header(), entry.ExpiresAt, opts.ExpiryWarning, now)
+			http.Redirect(w, r, entry.URL, redirectStatus(entry.Code, opts))
 			return
 		}
-		if err != redis.Nil {
+		if err != nil && err != redis.Nil {
 			log.Error("failed to get url from cache", sl.Err(err))
 		}
 
+		// definitely absent aliases aren't worth a storage lookup
+		if opts.Aliases != nil && !opts.Aliases.MayContain(alias) {
+			notFound(w, r, log, alias, opts)
+			return
+		}
+
 		// If not in cache, get from storage
-		resURL, err = urlGetter.GetURL(alias)
-		if errors.Is(err, storage.ErrURLNotFound) {
-			log.Info("url not found", "alias", alias)
-			render.JSON(w, r, resp.Error("not found"))
+		if opts.Splash != nil {
+			opts.Splash.serve(w, log, func(w http.ResponseWriter) {
+				resolve(w, r, log, urlGetter, urlCache, alias, opts)
+			})
 			return
 		}
-		if err != nil {
-			log.Error("failed to get url", sl.Err(err))
-			render.JSON(w, r, resp.Error("internal error"))
+		resolve(w, r, log, urlGetter, urlCache, alias, opts)
+	}
+}
+
+// resolve looks alias up in storage and serves its link.
+func resolve(w http.ResponseWriter, r *http.Request, log *slog.Logger, urlGetter URLGetter, urlCache URLCache, alias string, opts Options) {
+	link, err := getURLInfo(r.Context(), urlGetter, alias, opts)
+	if errors.Is(err, storage.ErrURLNotFound) {
+		notFound(w, r, log, alias, opts)
+		return
+	}
+	if err != nil {
+		log.Error("failed to get url", sl.Err(err))
+		if serveFallback(w, r, log, alias, opts) {
 			return
 		}
+		if storage.Unavailable(err) {
+			resp.RenderError(w, r, http.StatusServiceUnavailable, resp.Error("storage unavailable"))
+			return
+		}
+		resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+		return
+	}
+
+	log.Info("got url from storage", slog.String("url", link.URL))
+
+	serveLink(w, r, log, urlGetter, urlCache, alias, link, opts)
+}
+
+// getURLInfo looks alias up in storage within opts.QueryTimeout.
+func getURLInfo(ctx context.Context, urlGetter URLGetter, alias string, opts Options) (storage.URL, error) {
+	ctx, cancel := storage.WithTimeout(ctx, opts.QueryTimeout)
+	defer cancel()
+
+	return urlGetter.GetURLInfoContext(ctx, alias)
+}
+
+// notFound serves the fallback link of an alias missing from storage,
+// if there is one.
+func notFound(w http.ResponseWriter, r *http.Request, log *slog.Logger, alias string, opts Options) {
+	if serveFallback(w, r, log, alias, opts) {
+		return
+	}
+
+	log.Info("url not found", "alias", alias)
+	resp.RenderError(w, r, http.StatusNotFound, resp.Error("not found"))
+}
+
+// serveLink redirects to a link resolved from storage, or serves its
+// interstitial, after checking the per-link access rules.
+func serveLink(w http.ResponseWriter, r *http.Request, log *slog.Logger, urlGetter URLGetter, urlCache URLCache, alias string, link storage.URL, opts Options) {
+	resURL := link.URL
+
+	// The client is gone, nobody will follow the redirect
+	if err := r.Context().Err(); err != nil {
+		log.Debug("request cancelled", sl.Err(err))
+		return
+	}
+
+	// Cache entries never outlive their link, so cache hits need no check
+	if link.Expired(time.Now()) {
+		log.Info("url has expired", slog.String("alias", alias))
+		resp.RenderError(w, r, http.StatusNotFound, resp.Error("not found"))
+		return
+	}
+
+	// Protected links are never cached, so cache hits need no check
+	if link.PasswordHash != "" && !password.Matches(link.PasswordHash, r.Header.Get("X-Link-Password")) {
+		log.Info("invalid link password", slog.String("alias", alias))
+		resp.RenderError(w, r, http.StatusUnauthorized, resp.Error("invalid link password"))
+		return
+	}
+
+	// Restricted links are never cached, so cache hits need no check
+	if !referrerAllowed(link.AllowedReferrers, r.Referer()) {
+		log.Info("referrer not allowed", slog.String("alias", alias), slog.String("referer", r.Referer()))
+		resp.RenderError(w, r, http.StatusForbidden, resp.Error("referrer not allowed"))
+		return
+	}
+
+	// Split links are never cached, so cache hits have a single target
+	variant := -1
+	if len(link.Variants) > 0 {
+		variant = pickVariant(w, r, link.Variants, opts)
+		resURL = link.Variants[variant].URL
+	}
+
+	if !checkLoop(w, r, log, urlGetter, alias, resURL, opts) {
+		return
+	}
+
+	if link.Audited && opts.Auditor != nil {
+		opts.Auditor.Send(newAuditEvent(r, alias, resURL, link.Owner))
+	}
+	countVisitor(r, log, alias, opts)
+	countClick(log, alias, opts)
+	recordClick(r, log, alias, opts)
+	if variant >= 0 {
+		countVariant(log, alias, variant, opts)
+	}
+	observeHost(resURL, opts)
+
+	if opts.LinkHeaders && !link.CreatedAt.IsZero() {
+		w.Header().Set("X-Link-Created", link.CreatedAt.UTC().Format(http.TimeFormat))
+	}
+	expiry.Warn(w.Header(), link.ExpiresAt, opts.ExpiryWarning, time.Now())
 
-		log.Info("got url from storage", slog.String("url", resURL))
+	// Sponsored links are never cached, so cache hits can redirect directly
+	if link.Sponsored && opts.Interstitial != nil {
+		touch(r.Context(), log, urlCache, alias, opts)
 
-		// Set to cache
-		if err := urlCache.Set(r.Context(), alias, resURL, 5*time.Minute); err != nil {
-			log.Error("failed to set url to cache", sl.Err(err))
+		if err := opts.Interstitial.serve(w, resURL); err != nil {
+			log.Error("failed to render interstitial", sl.Err(err))
 		}
+		return
+	}
+
+	// Set to cache
+	if cacheable(link) {
+		if entry, ttl, ok := cacheEntry(link, time.Now(), opts); ok {
+			if err := urlCache.Set(r.Context(), alias, entry, ttl); err != nil {
+				log.Error("failed to set url to cache", sl.Err(err))
+			}
+		}
+	}
+
+	touch(r.Context(), log, urlCache, alias, opts)
+
+	if !delay(r.Context(), log, resURL, opts) {
+		return
+	}
+
+	// redirect to found url
+	http.Redirect(w, r, resURL, redirectStatus(link.RedirectStatus, opts))
+}
+
+// redirectStatus returns the status code of a redirect to a link with
+// the given code of its own, zero if it has none.
+func redirectStatus(code int, opts Options) int {
+	if code != 0 {
+		return code
+	}
+	if opts.RedirectStatus != 0 {
+		return opts.RedirectStatus
+	}
+
+	return http.StatusFound
+}
+
+// checkLoop renders an error and returns false if redirecting alias to
+// target would send the client into a loop.
+func checkLoop(w http.ResponseWriter, r *http.Request, log *slog.Logger, urlGetter URLGetter, alias, target string, opts Options) bool {
+	if opts.LoopDetection == nil {
+		return true
+	}
+
+	err := opts.LoopDetection.check(r.Context(), urlGetter, alias, target, opts)
+	if errors.Is(err, errRedirectLoop) {
+		log.Warn("redirect loop detected", slog.String("alias", alias), slog.String("url", target))
+		resp.RenderError(w, r, http.StatusLoopDetected, resp.Error("redirect loop detected"))
+		return false
+	}
+	if err != nil {
+		// a failed check must not take the link down
+		log.Error("failed to check for redirect loop", sl.Err(err))
+	}
+
+	return true
+}
+
+// delay holds the redirect to target back if its host is flagged. It
+// returns false if the client went away meanwhile.
+func delay(ctx context.Context, log *slog.Logger, target string, opts Options) bool {
+	if opts.Delay == nil {
+		return true
+	}
+
+	if err := opts.Delay.wait(ctx, target); err != nil {
+		log.Debug("request cancelled during redirect delay", sl.Err(err))
+		return false
+	}
+
+	return true
+}
+
+// touch updates the last access time of alias. A Redis key throttles it
+// to one write per TouchInterval, so hot aliases don't write on every redirect.
+func touch(ctx context.Context, log *slog.Logger, urlCache URLCache, alias string, opts Options) {
+	if opts.Toucher == nil || ctx.Err() != nil {
+		return
+	}
+
+	ok, err := urlCache.SetNX(ctx, "last_access:"+alias, 1, opts.TouchInterval)
+	if err != nil {
+		log.Error("failed to throttle last access update", sl.Err(err))
+		return
+	}
+	if !ok {
+		return
+	}
 
-		// redirect to found url
-		http.Redirect(w, r, resURL, http.StatusFound)
+	if err := opts.Toucher.TouchURL(alias, time.Now()); err != nil {
+		log.Error("failed to update last access time", sl.Err(err))
 	}
 }
@@ -1,7 +1,14 @@
 package redirect_test
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
 	"net/http/httptest"
+	"regexp"
+	"strconv"
 	"testing"
 	"time"
 
@@ -11,12 +18,22 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"url-shortener/internal/cache"
 	"url-shortener/internal/http-server/handlers/redirect"
 	"url-shortener/internal/http-server/handlers/redirect/mocks"
+	"url-shortener/internal/http-server/middleware/namespace"
 	"url-shortener/internal/lib/api"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/expiry"
 	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/lib/password"
+	"url-shortener/internal/lib/signing"
+	"url-shortener/internal/storage"
 )
 
+// cacheTTL is the configured cache TTL, unlike cache.DefaultTTL.
+const cacheTTL = 10 * time.Minute
+
 func TestRedirectHandler(t *testing.T) {
 	cases := []struct {
 		name      string
@@ -30,6 +47,16 @@ func TestRedirectHandler(t *testing.T) {
 			alias: "test_alias",
 			url:   "https://www.google.com/",
 		},
+		{
+			name:  "mailto",
+			alias: "contact",
+			url:   "mailto:sales@example.com",
+		},
+		{
+			name:  "tel",
+			alias: "call",
+			url:   "tel:+1-555-0100",
+		},
 	}
 
 	for _, tc := range cases {
@@ -38,14 +65,14 @@ func TestRedirectHandler(t *testing.T) {
 			urlCacheMock := mocks.NewURLCache(t)
 
 			if tc.respError == "" || tc.mockError != nil {
-				urlCacheMock.On("Get", mock.Anything, tc.alias).Return("", redis.Nil).Once()
-				urlGetterMock.On("GetURL", tc.alias).
-					Return(tc.url, tc.mockError).Once()
-				urlCacheMock.On("Set", mock.Anything, tc.alias, tc.url, 5*time.Minute).Return(nil).Once()
+				urlCacheMock.On("GetEntry", mock.Anything, tc.alias).Return(cache.Entry{}, redis.Nil).Once()
+				urlGetterMock.On("GetURLInfoContext", mock.Anything, tc.alias).
+					Return(storage.URL{Alias: tc.alias, URL: tc.url}, tc.mockError).Once()
+				urlCacheMock.On("Set", mock.Anything, tc.alias, cache.Entry{URL: tc.url, Enabled: true}, cacheTTL).Return(nil).Once()
 			}
 
 			r := chi.NewRouter()
-			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock))
+			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{CacheTTL: cacheTTL}))
 
 			ts := httptest.NewServer(r)
 			defer ts.Close()
@@ -58,3 +85,1764 @@ func TestRedirectHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestRedirectHandler_Errors(t *testing.T) {
+	cases := []struct {
+		name       string
+		alias      string
+		getError   error
+		respError  string
+		statusCode int
+	}{
+		{
+			name:       "Empty alias",
+			respError:  "invalid request",
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name:       "Not found",
+			alias:      "missing",
+			getError:   storage.ErrURLNotFound,
+			respError:  "not found",
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name:       "Storage error",
+			alias:      "broken",
+			getError:   errors.New("connection refused"),
+			respError:  "internal error",
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name:       "Storage timeout",
+			alias:      "slow",
+			getError:   context.DeadlineExceeded,
+			respError:  "storage unavailable",
+			statusCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			if tc.alias != "" {
+				urlCacheMock.On("GetEntry", mock.Anything, tc.alias).Return(cache.Entry{}, redis.Nil).Once()
+				urlGetterMock.On("GetURLInfoContext", mock.Anything, tc.alias).Return(storage.URL{}, tc.getError).Once()
+			}
+
+			// served without a router, the alias param is empty
+			handler := redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{})
+			if tc.alias != "" {
+				r := chi.NewRouter()
+				r.Get("/{alias}", handler)
+				handler = r.ServeHTTP
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/"+tc.alias, nil))
+
+			require.Equal(t, tc.statusCode, rr.Code)
+			assert.Equal(t, "application/json; charset=utf-8", rr.Header().Get("Content-Type"))
+
+			var body resp.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+			assert.Equal(t, resp.StatusError, body.Status)
+			assert.Equal(t, tc.respError, body.Error)
+		})
+	}
+}
+
+func TestRedirectHandler_FoldAliases(t *testing.T) {
+	const url = "https://www.google.com/"
+
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlCacheMock := mocks.NewURLCache(t)
+
+	urlCacheMock.On("GetEntry", mock.Anything, "cafe").Return(cache.Entry{}, redis.Nil).Once()
+	urlGetterMock.On("GetURLInfoContext", mock.Anything, "cafe").Return(storage.URL{Alias: "cafe", URL: url}, nil).Once()
+	urlCacheMock.On("Set", mock.Anything, "cafe", cache.Entry{URL: url, Enabled: true}, cacheTTL).Return(nil).Once()
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+		CacheTTL:    cacheTTL,
+		FoldAliases: true,
+	}))
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	redirectedToURL, err := api.GetRedirect(ts.URL + "/CAF%C3%89")
+	require.NoError(t, err)
+
+	assert.Equal(t, url, redirectedToURL)
+}
+
+func TestRedirectHandler_Signed(t *testing.T) {
+	const url = "https://www.google.com/"
+
+	signer := signing.New("secret", 8)
+	signed := signer.Sign("abc123")
+
+	cases := []struct {
+		name       string
+		alias      string
+		signer     *signing.Signer
+		statusCode int
+	}{
+		{
+			name:       "Valid signature",
+			alias:      signed,
+			signer:     signer,
+			statusCode: http.StatusFound,
+		},
+		{
+			name:       "Tampered alias",
+			alias:      "abc124" + signed[len("abc123"):],
+			signer:     signer,
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name:       "Wrong key",
+			alias:      signed,
+			signer:     signing.New("other-secret", 8),
+			statusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			// invalid signatures must not reach the cache or storage
+			if tc.statusCode == http.StatusFound {
+				urlCacheMock.On("GetEntry", mock.Anything, tc.alias).Return(cache.Entry{}, redis.Nil).Once()
+				urlGetterMock.On("GetURLInfoContext", mock.Anything, tc.alias).Return(storage.URL{Alias: tc.alias, URL: url}, nil).Once()
+				urlCacheMock.On("Set", mock.Anything, tc.alias, cache.Entry{URL: url, Enabled: true}, cacheTTL).Return(nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+				CacheTTL: cacheTTL,
+				Signer:   tc.signer,
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/"+tc.alias, nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+		})
+	}
+}
+
+func TestRedirectHandler_LastAccess(t *testing.T) {
+	const (
+		alias    = "test_alias"
+		url      = "https://www.google.com/"
+		interval = time.Minute
+	)
+
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlCacheMock := mocks.NewURLCache(t)
+	urlToucherMock := mocks.NewURLToucher(t)
+
+	urlCacheMock.On("GetEntry", mock.Anything, alias).Return(cache.Entry{URL: url, Enabled: true}, nil).Times(3)
+
+	// the throttle key is only free on the first redirect within the interval
+	urlCacheMock.On("SetNX", mock.Anything, "last_access:"+alias, 1, interval).Return(true, nil).Once()
+	urlCacheMock.On("SetNX", mock.Anything, "last_access:"+alias, 1, interval).Return(false, nil).Twice()
+	urlToucherMock.On("TouchURL", alias, mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+		Toucher:       urlToucherMock,
+		TouchInterval: interval,
+	}))
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	for i := 0; i < 3; i++ {
+		redirectedToURL, err := api.GetRedirect(ts.URL + "/" + alias)
+		require.NoError(t, err)
+
+		assert.Equal(t, url, redirectedToURL)
+	}
+}
+
+func TestRedirectHandler_Cancelled(t *testing.T) {
+	const (
+		alias = "test_alias"
+		url   = "https://www.google.com/"
+	)
+
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlCacheMock := mocks.NewURLCache(t)
+	urlToucherMock := mocks.NewURLToucher(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// the client disconnects while the handler waits for storage
+	urlCacheMock.On("GetEntry", mock.Anything, alias).Return(cache.Entry{}, redis.Nil).Once()
+	urlGetterMock.On("GetURLInfoContext", mock.Anything, alias).Return(storage.URL{Alias: alias, URL: url}, nil).Run(func(mock.Arguments) { cancel() }).Once()
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+		Toucher:       urlToucherMock,
+		TouchInterval: time.Minute,
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/"+alias, nil).WithContext(ctx)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	// no cache Set, no touch and no redirect
+	assert.Empty(t, rr.Header().Get("Location"))
+	assert.Empty(t, rr.Body.String())
+}
+
+func TestRedirectHandler_Sponsored(t *testing.T) {
+	const url = "https://www.google.com/"
+
+	interstitial := &redirect.Interstitial{
+		SkipAfter: 5 * time.Second,
+		Snippet:   `<img src="/ad.png" alt="Ad">`,
+	}
+
+	cases := []struct {
+		name         string
+		sponsored    bool
+		interstitial *redirect.Interstitial
+		statusCode   int
+	}{
+		{
+			name:         "Sponsored link",
+			sponsored:    true,
+			interstitial: interstitial,
+			statusCode:   http.StatusOK,
+		},
+		{
+			name:         "Regular link",
+			interstitial: interstitial,
+			statusCode:   http.StatusFound,
+		},
+		{
+			name:       "Sponsored link with ads disabled",
+			sponsored:  true,
+			statusCode: http.StatusFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			urlCacheMock.On("GetEntry", mock.Anything, "promo").Return(cache.Entry{}, redis.Nil).Once()
+			urlGetterMock.On("GetURLInfoContext", mock.Anything, "promo").
+				Return(storage.URL{Alias: "promo", URL: url, Sponsored: tc.sponsored}, nil).Once()
+
+			// sponsored links are never cached
+			if !tc.sponsored {
+				urlCacheMock.On("Set", mock.Anything, "promo", cache.Entry{URL: url, Enabled: true}, cacheTTL).Return(nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+				CacheTTL:     cacheTTL,
+				Interstitial: tc.interstitial,
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/promo", nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			if tc.statusCode == http.StatusFound {
+				assert.Equal(t, url, rr.Header().Get("Location"))
+				return
+			}
+
+			body := rr.Body.String()
+
+			assert.Equal(t, "text/html; charset=utf-8", rr.Header().Get("Content-Type"))
+			assert.Empty(t, rr.Header().Get("Location"))
+			assert.Contains(t, body, `<img src="/ad.png" alt="Ad">`)
+			assert.Contains(t, body, `var target = "https://www.google.com/";`)
+			assert.Contains(t, body, `var left =  5 ;`)
+		})
+	}
+}
+
+var cspNonce = regexp.MustCompile(`^script-src 'nonce-([A-Za-z0-9_-]+)'`)
+
+func TestRedirectHandler_InterstitialCSP(t *testing.T) {
+	const url = "https://www.google.com/"
+
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlCacheMock := mocks.NewURLCache(t)
+
+	urlCacheMock.On("GetEntry", mock.Anything, "promo").Return(cache.Entry{}, redis.Nil).Twice()
+	urlGetterMock.On("GetURLInfoContext", mock.Anything, "promo").
+		Return(storage.URL{Alias: "promo", URL: url, Sponsored: true}, nil).Twice()
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+		Interstitial: &redirect.Interstitial{SkipAfter: 5 * time.Second, CSP: true},
+	}))
+
+	var nonces []string
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/promo", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		m := cspNonce.FindStringSubmatch(rr.Header().Get("Content-Security-Policy"))
+		require.NotNil(t, m, "csp header carries no nonce")
+		assert.Contains(t, rr.Body.String(), `<script nonce="`+m[1]+`">`)
+
+		nonces = append(nonces, m[1])
+	}
+
+	assert.NotEqual(t, nonces[0], nonces[1], "nonces must not be reused")
+}
+
+func TestRedirectHandler_SplashCSP(t *testing.T) {
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlCacheMock := mocks.NewURLCache(t)
+
+	urlCacheMock.On("GetEntry", mock.Anything, "test_alias").Return(cache.Entry{}, redis.Nil).Once()
+	urlGetterMock.On("GetURLInfoContext", mock.Anything, "test_alias").
+		Return(storage.URL{Alias: "test_alias", URL: "https://www.google.com/"}, nil).After(100 * time.Millisecond).Once()
+	urlCacheMock.On("Set", mock.Anything, "test_alias", mock.Anything, mock.Anything).Return(nil).Once()
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+		Splash: &redirect.Splash{Threshold: 20 * time.Millisecond, Brand: "Shorty", CSP: true},
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/test_alias", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	m := cspNonce.FindStringSubmatch(rr.Header().Get("Content-Security-Policy"))
+	require.NotNil(t, m, "csp header carries no nonce")
+	assert.Contains(t, rr.Body.String(), `<script nonce="`+m[1]+`">window.location.replace("https://www.google.com/");</script>`)
+}
+
+func TestRedirectHandler_LoopDetection(t *testing.T) {
+	cases := []struct {
+		name       string
+		links      map[string]string
+		statusCode int
+		location   string
+	}{
+		{
+			name: "Loop",
+			links: map[string]string{
+				"a": "https://sho.rt/b",
+				"b": "https://sho.rt/a",
+			},
+			statusCode: http.StatusLoopDetected,
+		},
+		{
+			name: "Self loop",
+			links: map[string]string{
+				"a": "https://sho.rt/a/",
+			},
+			statusCode: http.StatusLoopDetected,
+		},
+		{
+			name: "Too many hops",
+			links: map[string]string{
+				"a": "https://sho.rt/b",
+				"b": "https://sho.rt/c",
+				"c": "https://sho.rt/d",
+				"d": "https://www.google.com/",
+			},
+			statusCode: http.StatusLoopDetected,
+		},
+		{
+			name: "Single hop",
+			links: map[string]string{
+				"a": "https://sho.rt/b",
+				"b": "https://www.google.com/",
+			},
+			statusCode: http.StatusFound,
+			location:   "https://sho.rt/b",
+		},
+		{
+			name: "Missing hop",
+			links: map[string]string{
+				"a": "https://sho.rt/b",
+			},
+			statusCode: http.StatusFound,
+			location:   "https://sho.rt/b",
+		},
+		{
+			name: "Other host",
+			links: map[string]string{
+				"a": "https://example.com/a",
+			},
+			statusCode: http.StatusFound,
+			location:   "https://example.com/a",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			urlCacheMock.On("GetEntry", mock.Anything, "a").Return(cache.Entry{}, redis.Nil).Once()
+			urlGetterMock.On("GetURLInfoContext", mock.Anything, mock.AnythingOfType("string")).Return(func(_ context.Context, alias string) (storage.URL, error) {
+				url, ok := tc.links[alias]
+				if !ok {
+					return storage.URL{}, storage.ErrURLNotFound
+				}
+				return storage.URL{Alias: alias, URL: url}, nil
+			})
+			if tc.statusCode == http.StatusFound {
+				urlCacheMock.On("Set", mock.Anything, "a", cache.Entry{URL: tc.location, Enabled: true}, cacheTTL).Return(nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+				CacheTTL: cacheTTL,
+				LoopDetection: &redirect.LoopDetection{
+					Hosts:   []string{"sho.rt"},
+					MaxHops: 2,
+				},
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/a", nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+			assert.Equal(t, tc.location, rr.Header().Get("Location"))
+		})
+	}
+}
+
+func TestRedirectHandler_LinkHeaders(t *testing.T) {
+	const url = "https://www.google.com/"
+
+	createdAt := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		name        string
+		linkHeaders bool
+		created     string
+	}{
+		{
+			name:        "Enabled",
+			linkHeaders: true,
+			created:     "Fri, 01 Mar 2024 12:30:00 GMT",
+		},
+		{
+			name: "Disabled",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			// the cache holds no metadata, so it is not read when headers are on
+			if !tc.linkHeaders {
+				urlCacheMock.On("GetEntry", mock.Anything, "test_alias").Return(cache.Entry{}, redis.Nil).Once()
+			}
+			urlGetterMock.On("GetURLInfoContext", mock.Anything, "test_alias").
+				Return(storage.URL{Alias: "test_alias", URL: url, CreatedAt: createdAt}, nil).Once()
+			urlCacheMock.On("Set", mock.Anything, "test_alias", cache.Entry{URL: url, Enabled: true}, cacheTTL).Return(nil).Once()
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+				CacheTTL:    cacheTTL,
+				LinkHeaders: tc.linkHeaders,
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/test_alias", nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusFound, rr.Code)
+			assert.Equal(t, url, rr.Header().Get("Location"))
+			assert.Equal(t, tc.created, rr.Header().Get("X-Link-Created"))
+		})
+	}
+}
+
+func TestRedirectHandler_CacheEntry(t *testing.T) {
+	const url = "https://www.google.com/"
+
+	expired := time.Now().Add(-time.Minute)
+
+	cases := []struct {
+		name       string
+		entry      cache.Entry
+		fromStore  bool
+		statusCode int
+	}{
+		{
+			name:       "Cached",
+			entry:      cache.Entry{URL: url, Enabled: true},
+			statusCode: http.StatusFound,
+		},
+		{
+			name:       "Cached code",
+			entry:      cache.Entry{URL: url, Code: http.StatusMovedPermanently, Enabled: true},
+			statusCode: http.StatusMovedPermanently,
+		},
+		{
+			name:       "Expired",
+			entry:      cache.Entry{URL: url, ExpiresAt: &expired, Enabled: true},
+			fromStore:  true,
+			statusCode: http.StatusFound,
+		},
+		{
+			name:       "Disabled",
+			entry:      cache.Entry{URL: url},
+			fromStore:  true,
+			statusCode: http.StatusFound,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			urlCacheMock.On("GetEntry", mock.Anything, "test_alias").Return(tc.entry, nil).Once()
+			if tc.fromStore {
+				urlGetterMock.On("GetURLInfoContext", mock.Anything, "test_alias").
+					Return(storage.URL{Alias: "test_alias", URL: url}, nil).Once()
+				urlCacheMock.On("Set", mock.Anything, "test_alias", cache.Entry{URL: url, Enabled: true}, cacheTTL).Return(nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{CacheTTL: cacheTTL}))
+
+			req := httptest.NewRequest(http.MethodGet, "/test_alias", nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+			assert.Equal(t, url, rr.Header().Get("Location"))
+		})
+	}
+}
+
+func TestRedirectHandler_Password(t *testing.T) {
+	const url = "https://www.google.com/"
+
+	hash, err := password.Hash("s3cret")
+	require.NoError(t, err)
+
+	cases := []struct {
+		name       string
+		password   string
+		statusCode int
+	}{
+		{
+			name:       "Correct password",
+			password:   "s3cret",
+			statusCode: http.StatusFound,
+		},
+		{
+			name:       "Wrong password",
+			password:   "guess",
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "Missing password",
+			statusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			// protected links are never cached
+			urlCacheMock.On("GetEntry", mock.Anything, "secret").Return(cache.Entry{}, redis.Nil).Once()
+			urlGetterMock.On("GetURLInfoContext", mock.Anything, "secret").
+				Return(storage.URL{Alias: "secret", URL: url, PasswordHash: hash}, nil).Once()
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{}))
+
+			req := httptest.NewRequest(http.MethodGet, "/secret", nil)
+			if tc.password != "" {
+				req.Header.Set("X-Link-Password", tc.password)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			if tc.statusCode == http.StatusFound {
+				assert.Equal(t, url, rr.Header().Get("Location"))
+				return
+			}
+			assert.Empty(t, rr.Header().Get("Location"))
+			assert.Contains(t, rr.Body.String(), "invalid link password")
+		})
+	}
+}
+
+func TestRedirectHandler_Delay(t *testing.T) {
+	cases := []struct {
+		name   string
+		url    string
+		waited time.Duration
+	}{
+		{
+			name:   "Flagged target",
+			url:    "https://victim.example/login",
+			waited: 2 * time.Second,
+		},
+		{
+			name:   "Capped delay",
+			url:    "https://slow.example/",
+			waited: 3 * time.Second,
+		},
+		{
+			name: "Other target",
+			url:  "https://www.google.com/",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			urlCacheMock.On("GetEntry", mock.Anything, "test_alias").Return(cache.Entry{URL: tc.url, Enabled: true}, nil).Once()
+
+			var waited time.Duration
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+				Delay: &redirect.Delay{
+					Hosts: map[string]time.Duration{
+						"victim.example": 2 * time.Second,
+						"slow.example":   time.Minute,
+					},
+					Max: 3 * time.Second,
+					After: func(d time.Duration) <-chan time.Time {
+						waited = d
+						ch := make(chan time.Time, 1)
+						ch <- time.Time{}
+						return ch
+					},
+				},
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/test_alias", nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusFound, rr.Code)
+			assert.Equal(t, tc.url, rr.Header().Get("Location"))
+			assert.Equal(t, tc.waited, waited)
+		})
+	}
+}
+
+func TestRedirectHandler_DelayCancelled(t *testing.T) {
+	const url = "https://victim.example/"
+
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlCacheMock := mocks.NewURLCache(t)
+
+	urlCacheMock.On("GetEntry", mock.Anything, "test_alias").Return(cache.Entry{URL: url, Enabled: true}, nil).Once()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+		Delay: &redirect.Delay{
+			Hosts: map[string]time.Duration{"victim.example": time.Hour},
+			After: func(time.Duration) <-chan time.Time {
+				// the client disconnects while the redirect is held back
+				cancel()
+				return nil
+			},
+		},
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/test_alias", nil).WithContext(ctx)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Empty(t, rr.Header().Get("Location"))
+}
+
+func TestRedirectHandler_Audit(t *testing.T) {
+	const url = "https://www.google.com/"
+
+	cases := []struct {
+		name    string
+		audited bool
+	}{
+		{name: "Audited link", audited: true},
+		{name: "Regular link"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlCacheMock := mocks.NewURLCache(t)
+			auditorMock := mocks.NewAuditor(t)
+
+			urlCacheMock.On("GetEntry", mock.Anything, "vip").Return(cache.Entry{}, redis.Nil).Once()
+			urlGetterMock.On("GetURLInfoContext", mock.Anything, "vip").
+				Return(storage.URL{Alias: "vip", URL: url, Audited: tc.audited, Owner: "jane@example.com"}, nil).Once()
+
+			if tc.audited {
+				// audited links are never cached, every redirect must be reported
+				auditorMock.On("Send", mock.MatchedBy(func(e redirect.AuditEvent) bool {
+					return e.Alias == "vip" && e.URL == url &&
+						e.Referrer == "https://news.example.com/" && e.IP == "203.0.113.7" &&
+						e.Owner == "jane@example.com" && !e.Time.IsZero()
+				})).Return(true).Once()
+			} else {
+				urlCacheMock.On("Set", mock.Anything, "vip", cache.Entry{URL: url, Enabled: true}, cacheTTL).Return(nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+				CacheTTL: cacheTTL,
+				Auditor:  auditorMock,
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/vip", nil)
+			req.RemoteAddr = "203.0.113.7:51234"
+			req.Header.Set("Referer", "https://news.example.com/")
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusFound, rr.Code)
+			assert.Equal(t, url, rr.Header().Get("Location"))
+		})
+	}
+}
+
+func TestRedirectHandler_StaleWhileRevalidate(t *testing.T) {
+	const (
+		oldURL = "https://www.google.com/"
+		newURL = "https://www.google.com/new"
+	)
+
+	swr := &redirect.StaleWhileRevalidate{SoftTTL: time.Minute, HardTTL: time.Hour}
+
+	now := time.Now()
+	past, future := now.Add(-time.Second), now.Add(time.Hour)
+
+	// freshly cached entries carry both TTL markers
+	isRefreshed := func(e cache.Entry) bool {
+		return e.URL == newURL && e.Enabled &&
+			e.FreshUntil != nil && e.FreshUntil.After(now) &&
+			e.StaleUntil != nil && e.StaleUntil.After(*e.FreshUntil)
+	}
+
+	t.Run("Fresh", func(t *testing.T) {
+		t.Parallel()
+
+		urlGetterMock := mocks.NewURLGetter(t)
+		urlCacheMock := mocks.NewURLCache(t)
+
+		urlCacheMock.On("GetEntry", mock.Anything, "test_alias").
+			Return(cache.Entry{URL: oldURL, Enabled: true, FreshUntil: &future, StaleUntil: &future}, nil).Once()
+
+		r := chi.NewRouter()
+		r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+			StaleWhileRevalidate: swr,
+		}))
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/test_alias", nil))
+
+		require.Equal(t, http.StatusFound, rr.Code)
+		assert.Equal(t, oldURL, rr.Header().Get("Location"))
+	})
+
+	t.Run("Stale", func(t *testing.T) {
+		t.Parallel()
+
+		urlGetterMock := mocks.NewURLGetter(t)
+		urlCacheMock := mocks.NewURLCache(t)
+
+		revalidated := make(chan struct{})
+
+		urlCacheMock.On("GetEntry", mock.Anything, "test_alias").
+			Return(cache.Entry{URL: oldURL, Enabled: true, FreshUntil: &past, StaleUntil: &future}, nil).Once()
+		urlCacheMock.On("SetNX", mock.Anything, "revalidate:test_alias", 1, mock.Anything).Return(true, nil).Once()
+		urlGetterMock.On("GetURLInfoContext", mock.Anything, "test_alias").
+			Return(storage.URL{Alias: "test_alias", URL: newURL}, nil).Once()
+		urlCacheMock.On("Set", mock.Anything, "test_alias", mock.MatchedBy(isRefreshed), time.Hour).
+			Return(nil).Once().
+			Run(func(mock.Arguments) { close(revalidated) })
+
+		r := chi.NewRouter()
+		r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+			StaleWhileRevalidate: swr,
+		}))
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/test_alias", nil))
+
+		// the stale target is served without waiting for storage
+		require.Equal(t, http.StatusFound, rr.Code)
+		assert.Equal(t, oldURL, rr.Header().Get("Location"))
+
+		select {
+		case <-revalidated:
+		case <-time.After(time.Second):
+			t.Fatal("stale entry was not revalidated")
+		}
+	})
+
+	t.Run("Revalidation in progress", func(t *testing.T) {
+		t.Parallel()
+
+		urlGetterMock := mocks.NewURLGetter(t)
+		urlCacheMock := mocks.NewURLCache(t)
+
+		urlCacheMock.On("GetEntry", mock.Anything, "test_alias").
+			Return(cache.Entry{URL: oldURL, Enabled: true, FreshUntil: &past, StaleUntil: &future}, nil).Once()
+		urlCacheMock.On("SetNX", mock.Anything, "revalidate:test_alias", 1, mock.Anything).Return(false, nil).Once()
+
+		r := chi.NewRouter()
+		r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+			StaleWhileRevalidate: swr,
+		}))
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/test_alias", nil))
+
+		require.Equal(t, http.StatusFound, rr.Code)
+		assert.Equal(t, oldURL, rr.Header().Get("Location"))
+	})
+
+	t.Run("Past hard TTL", func(t *testing.T) {
+		t.Parallel()
+
+		urlGetterMock := mocks.NewURLGetter(t)
+		urlCacheMock := mocks.NewURLCache(t)
+
+		urlCacheMock.On("GetEntry", mock.Anything, "test_alias").
+			Return(cache.Entry{URL: oldURL, Enabled: true, FreshUntil: &past, StaleUntil: &past}, nil).Once()
+		urlGetterMock.On("GetURLInfoContext", mock.Anything, "test_alias").
+			Return(storage.URL{Alias: "test_alias", URL: newURL}, nil).Once()
+		urlCacheMock.On("Set", mock.Anything, "test_alias", mock.MatchedBy(isRefreshed), time.Hour).Return(nil).Once()
+
+		r := chi.NewRouter()
+		r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+			StaleWhileRevalidate: swr,
+		}))
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/test_alias", nil))
+
+		require.Equal(t, http.StatusFound, rr.Code)
+		assert.Equal(t, newURL, rr.Header().Get("Location"))
+	})
+}
+
+func TestRedirectHandler_Expiry(t *testing.T) {
+	const url = "https://www.google.com/"
+
+	t.Run("Cache TTL is the remaining link TTL", func(t *testing.T) {
+		t.Parallel()
+
+		expiresAt := time.Now().Add(2 * time.Minute)
+
+		urlGetterMock := mocks.NewURLGetter(t)
+		urlCacheMock := mocks.NewURLCache(t)
+
+		urlCacheMock.On("GetEntry", mock.Anything, "brief").Return(cache.Entry{}, redis.Nil).Once()
+		urlGetterMock.On("GetURLInfoContext", mock.Anything, "brief").
+			Return(storage.URL{Alias: "brief", URL: url, ExpiresAt: &expiresAt}, nil).Once()
+		urlCacheMock.On("Set", mock.Anything, "brief",
+			cache.Entry{URL: url, Enabled: true, ExpiresAt: &expiresAt},
+			mock.MatchedBy(func(ttl time.Duration) bool {
+				return ttl > 2*time.Minute-time.Second && ttl <= 2*time.Minute
+			}),
+		).Return(nil).Once()
+
+		r := chi.NewRouter()
+		r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{}))
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/brief", nil))
+
+		require.Equal(t, http.StatusFound, rr.Code)
+		assert.Equal(t, url, rr.Header().Get("Location"))
+	})
+
+	t.Run("Expired link", func(t *testing.T) {
+		t.Parallel()
+
+		expiredAt := time.Now().Add(-time.Second)
+
+		urlGetterMock := mocks.NewURLGetter(t)
+		urlCacheMock := mocks.NewURLCache(t)
+
+		// an expired entry is not served from the cache, nor from storage
+		urlCacheMock.On("GetEntry", mock.Anything, "brief").
+			Return(cache.Entry{URL: url, Enabled: true, ExpiresAt: &expiredAt}, nil).Once()
+		urlGetterMock.On("GetURLInfoContext", mock.Anything, "brief").
+			Return(storage.URL{Alias: "brief", URL: url, ExpiresAt: &expiredAt}, nil).Once()
+
+		r := chi.NewRouter()
+		r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{}))
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/brief", nil))
+
+		require.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Empty(t, rr.Header().Get("Location"))
+	})
+}
+
+func TestRedirectHandler_ExpiryWarning(t *testing.T) {
+	const url = "https://www.google.com/"
+
+	cases := []struct {
+		name      string
+		cached    bool
+		expiresIn time.Duration
+		warned    bool
+	}{
+		{
+			name:      "Stored link within window",
+			expiresIn: 10 * time.Minute,
+			warned:    true,
+		},
+		{
+			name:      "Stored link outside window",
+			expiresIn: 2 * time.Hour,
+		},
+		{
+			name:      "Cached link within window",
+			cached:    true,
+			expiresIn: 10 * time.Minute,
+			warned:    true,
+		},
+		{
+			name:      "Cached link outside window",
+			cached:    true,
+			expiresIn: 2 * time.Hour,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			expiresAt := time.Now().Add(tc.expiresIn)
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			if tc.cached {
+				urlCacheMock.On("GetEntry", mock.Anything, "brief").
+					Return(cache.Entry{URL: url, Enabled: true, ExpiresAt: &expiresAt}, nil).Once()
+			} else {
+				urlCacheMock.On("GetEntry", mock.Anything, "brief").Return(cache.Entry{}, redis.Nil).Once()
+				urlGetterMock.On("GetURLInfoContext", mock.Anything, "brief").
+					Return(storage.URL{Alias: "brief", URL: url, ExpiresAt: &expiresAt}, nil).Once()
+				urlCacheMock.On("Set", mock.Anything, "brief", mock.Anything, mock.Anything).Return(nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+				ExpiryWarning: time.Hour,
+			}))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/brief", nil))
+
+			require.Equal(t, http.StatusFound, rr.Code)
+
+			header := rr.Header().Get(expiry.Header)
+			if !tc.warned {
+				assert.Empty(t, header)
+				return
+			}
+
+			left, err := strconv.Atoi(header)
+			require.NoError(t, err)
+			assert.InDelta(t, tc.expiresIn.Seconds(), left, 2)
+		})
+	}
+}
+
+func TestRedirectHandler_Visitors(t *testing.T) {
+	const url = "https://www.google.com/"
+
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlCacheMock := mocks.NewURLCache(t)
+	visitorsMock := mocks.NewVisitorCounter(t)
+
+	urlCacheMock.On("GetEntry", mock.Anything, "test_alias").Return(cache.Entry{URL: url, Enabled: true}, nil)
+
+	// stands in for the HyperLogLog
+	visitors := map[string]struct{}{}
+	visitorsMock.On("PFAdd", mock.Anything, "visitors:test_alias", mock.AnythingOfType("string")).
+		Return(nil).
+		Run(func(args mock.Arguments) { visitors[args.String(2)] = struct{}{} })
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+		Visitors: visitorsMock,
+	}))
+
+	visit := func(remoteAddr, userAgent string) {
+		req := httptest.NewRequest(http.MethodGet, "/test_alias", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("User-Agent", userAgent)
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusFound, rr.Code)
+	}
+
+	visit("203.0.113.7:1000", "Firefox")
+	visit("203.0.113.7:2000", "Firefox")
+	assert.Len(t, visitors, 1, "repeat visits must not count")
+
+	visit("203.0.113.7:1000", "Chrome")
+	visit("198.51.100.1:1000", "Firefox")
+	assert.Len(t, visitors, 3, "distinct visitors must count")
+
+	for id := range visitors {
+		assert.NotContains(t, id, "203.0.113.7", "visitors are stored hashed")
+	}
+}
+
+func TestRedirectHandler_AllowedReferrers(t *testing.T) {
+	const url = "https://www.google.com/"
+
+	cases := []struct {
+		name       string
+		referer    string
+		statusCode int
+	}{
+		{
+			name:       "Allowed referrer",
+			referer:    "https://example.com/post/1",
+			statusCode: http.StatusFound,
+		},
+		{
+			name:       "Allowed subdomain",
+			referer:    "https://Blog.Example.com/",
+			statusCode: http.StatusFound,
+		},
+		{
+			name:       "Disallowed referrer",
+			referer:    "https://evil.com/",
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "Lookalike domain",
+			referer:    "https://notexample.com/",
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "Missing referrer",
+			statusCode: http.StatusFound,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			// restricted links are never cached
+			urlCacheMock.On("GetEntry", mock.Anything, "embed").Return(cache.Entry{}, redis.Nil).Once()
+			urlGetterMock.On("GetURLInfoContext", mock.Anything, "embed").
+				Return(storage.URL{Alias: "embed", URL: url, AllowedReferrers: []string{"example.com"}}, nil).Once()
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{}))
+
+			req := httptest.NewRequest(http.MethodGet, "/embed", nil)
+			if tc.referer != "" {
+				req.Header.Set("Referer", tc.referer)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			if tc.statusCode == http.StatusFound {
+				assert.Equal(t, url, rr.Header().Get("Location"))
+				return
+			}
+			assert.Empty(t, rr.Header().Get("Location"))
+			assert.Contains(t, rr.Body.String(), "referrer not allowed")
+		})
+	}
+}
+
+func TestRedirectHandler_Clicks(t *testing.T) {
+	const url = "https://www.google.com/"
+
+	cases := []struct {
+		name     string
+		cacheHit bool
+	}{
+		{
+			name:     "Cache hit",
+			cacheHit: true,
+		},
+		{
+			name: "Storage hit",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlCacheMock := mocks.NewURLCache(t)
+			clicksMock := mocks.NewClickCounter(t)
+
+			if tc.cacheHit {
+				urlCacheMock.On("GetEntry", mock.Anything, "test_alias").Return(cache.Entry{URL: url, Enabled: true}, nil).Once()
+			} else {
+				urlCacheMock.On("GetEntry", mock.Anything, "test_alias").Return(cache.Entry{}, redis.Nil).Once()
+				urlGetterMock.On("GetURLInfoContext", mock.Anything, "test_alias").Return(storage.URL{Alias: "test_alias", URL: url}, nil).Once()
+				urlCacheMock.On("Set", mock.Anything, "test_alias", mock.Anything, mock.Anything).Return(nil).Once()
+			}
+
+			// clicks are counted after the redirect is sent
+			counted := make(chan struct{})
+			clicksMock.On("IncrementClicks", "test_alias").Return(nil).Once().
+				Run(func(mock.Arguments) { close(counted) })
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+				Clicks: clicksMock,
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/test_alias", nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusFound, rr.Code)
+
+			select {
+			case <-counted:
+			case <-time.After(time.Second):
+				t.Fatal("click was not counted")
+			}
+		})
+	}
+}
+
+func TestRedirectHandler_Analytics(t *testing.T) {
+	const url = "https://www.google.com/"
+
+	cases := []struct {
+		name      string
+		cacheHit  bool
+		mockError error
+	}{
+		{
+			name:     "Cache hit",
+			cacheHit: true,
+		},
+		{
+			name: "Storage hit",
+		},
+		{
+			name:      "Recording fails",
+			cacheHit:  true,
+			mockError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlCacheMock := mocks.NewURLCache(t)
+			analyticsMock := mocks.NewClickRecorder(t)
+
+			if tc.cacheHit {
+				urlCacheMock.On("GetEntry", mock.Anything, "test_alias").Return(cache.Entry{URL: url, Enabled: true}, nil).Once()
+			} else {
+				urlCacheMock.On("GetEntry", mock.Anything, "test_alias").Return(cache.Entry{}, redis.Nil).Once()
+				urlGetterMock.On("GetURLInfoContext", mock.Anything, "test_alias").Return(storage.URL{Alias: "test_alias", URL: url}, nil).Once()
+				urlCacheMock.On("Set", mock.Anything, "test_alias", mock.Anything, mock.Anything).Return(nil).Once()
+			}
+
+			// clicks are recorded after the redirect is sent
+			recorded := make(chan storage.ClickEvent, 1)
+			analyticsMock.On("RecordClick", mock.Anything).Return(tc.mockError).Once().
+				Run(func(args mock.Arguments) { recorded <- args.Get(0).(storage.ClickEvent) })
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+				Analytics: analyticsMock,
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/test_alias", nil)
+			req.RemoteAddr = "203.0.113.7:54321"
+			req.Header.Set("Referer", "https://example.org/post")
+			req.Header.Set("User-Agent", "curl/8.0")
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusFound, rr.Code)
+
+			select {
+			case event := <-recorded:
+				assert.Equal(t, "test_alias", event.Alias)
+				assert.Equal(t, "https://example.org/post", event.Referrer)
+				assert.Equal(t, "curl/8.0", event.UserAgent)
+				assert.Equal(t, "203.0.113.7", event.IP)
+				assert.WithinDuration(t, time.Now(), event.Time, time.Minute)
+			case <-time.After(time.Second):
+				t.Fatal("click was not recorded")
+			}
+		})
+	}
+}
+
+func TestRedirectHandler_RedirectStatus(t *testing.T) {
+	const url = "https://www.google.com/"
+
+	cases := []struct {
+		name       string
+		configured int
+		entry      *cache.Entry
+		link       storage.URL
+		statusCode int
+	}{
+		{
+			name:       "Default",
+			link:       storage.URL{Alias: "test_alias", URL: url},
+			statusCode: http.StatusFound,
+		},
+		{
+			name:       "Configured",
+			configured: http.StatusMovedPermanently,
+			link:       storage.URL{Alias: "test_alias", URL: url},
+			statusCode: http.StatusMovedPermanently,
+		},
+		{
+			name:       "Configured cache hit",
+			configured: http.StatusMovedPermanently,
+			entry:      &cache.Entry{URL: url, Enabled: true},
+			statusCode: http.StatusMovedPermanently,
+		},
+		{
+			name:       "Link override",
+			configured: http.StatusFound,
+			link:       storage.URL{Alias: "test_alias", URL: url, RedirectStatus: http.StatusPermanentRedirect},
+			statusCode: http.StatusPermanentRedirect,
+		},
+		{
+			name:       "Link override cache hit",
+			configured: http.StatusFound,
+			entry:      &cache.Entry{URL: url, Code: http.StatusMovedPermanently, Enabled: true},
+			statusCode: http.StatusMovedPermanently,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			if tc.entry != nil {
+				urlCacheMock.On("GetEntry", mock.Anything, "test_alias").Return(*tc.entry, nil).Once()
+			} else {
+				urlCacheMock.On("GetEntry", mock.Anything, "test_alias").Return(cache.Entry{}, redis.Nil).Once()
+				urlGetterMock.On("GetURLInfoContext", mock.Anything, "test_alias").Return(tc.link, nil).Once()
+				// the override is cached along with the link, the default is not
+				urlCacheMock.On("Set", mock.Anything, "test_alias", cache.Entry{URL: url, Code: tc.link.RedirectStatus, Enabled: true}, mock.Anything).Return(nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+				RedirectStatus: tc.configured,
+			}))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/test_alias", nil))
+
+			require.Equal(t, tc.statusCode, rr.Code)
+			assert.Equal(t, url, rr.Header().Get("Location"))
+		})
+	}
+}
+
+func TestRedirectHandler_SplitDistribution(t *testing.T) {
+	const requests = 10000
+
+	variants := []storage.Variant{
+		{URL: "https://a.example.com/", Weight: 1},
+		{URL: "https://b.example.com/", Weight: 3},
+		{URL: "https://c.example.com/", Weight: 6},
+	}
+
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlCacheMock := mocks.NewURLCache(t)
+
+	// split links are never cached, every request reaches storage
+	urlCacheMock.On("GetEntry", mock.Anything, "split").Return(cache.Entry{}, redis.Nil).Times(requests)
+	urlGetterMock.On("GetURLInfoContext", mock.Anything, "split").Return(storage.URL{Alias: "split", URL: "https://example.com/", Variants: variants}, nil).Times(requests)
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{}))
+
+	counts := make(map[string]int)
+	for i := 0; i < requests; i++ {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/split", nil))
+
+		require.Equal(t, http.StatusFound, rr.Code)
+		counts[rr.Header().Get("Location")]++
+	}
+
+	for _, v := range variants {
+		want := float64(requests * v.Weight / 10)
+		assert.InDelta(t, want, counts[v.URL], requests*0.03, v.URL)
+	}
+}
+
+func TestRedirectHandler_SplitSticky(t *testing.T) {
+	variants := []storage.Variant{
+		{URL: "https://a.example.com/", Weight: 1},
+		{URL: "https://b.example.com/", Weight: 1},
+	}
+
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlCacheMock := mocks.NewURLCache(t)
+	variantsMock := mocks.NewVariantCounter(t)
+
+	urlCacheMock.On("GetEntry", mock.Anything, "split").Return(cache.Entry{}, redis.Nil)
+	urlGetterMock.On("GetURLInfoContext", mock.Anything, "split").Return(storage.URL{Alias: "split", URL: "https://example.com/", Variants: variants}, nil)
+
+	// variant clicks are counted after the redirect is sent
+	counted := make(chan int, 10)
+	variantsMock.On("IncrementVariantClicks", "split", mock.Anything).Return(nil).
+		Run(func(args mock.Arguments) { counted <- args.Int(1) })
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+		Split: &redirect.Split{Sticky: true, CookieTTL: time.Hour, Clicks: variantsMock},
+	}))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/split", nil))
+
+	require.Equal(t, http.StatusFound, rr.Code)
+	first := rr.Header().Get("Location")
+
+	cookies := rr.Result().Cookies()
+	require.Len(t, cookies, 1)
+	require.Equal(t, redirect.VariantCookie, cookies[0].Name)
+	require.Equal(t, "/split", cookies[0].Path)
+
+	// returning visitors keep their variant
+	for i := 0; i < 9; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/split", nil)
+		req.AddCookie(cookies[0])
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusFound, rr.Code)
+		require.Equal(t, first, rr.Header().Get("Location"))
+	}
+
+	for i := 0; i < 10; i++ {
+		select {
+		case variant := <-counted:
+			require.Equal(t, first, variants[variant].URL)
+		case <-time.After(time.Second):
+			t.Fatal("variant click was not counted")
+		}
+	}
+}
+
+func TestRedirectHandler_Splash(t *testing.T) {
+	const url = "https://www.google.com/"
+
+	cases := []struct {
+		name       string
+		delay      time.Duration
+		link       storage.URL
+		getError   error
+		splash     bool
+		wantInBody string
+	}{
+		{
+			name: "Fast resolution",
+			link: storage.URL{Alias: "test_alias", URL: url},
+		},
+		{
+			name:       "Slow resolution",
+			delay:      100 * time.Millisecond,
+			link:       storage.URL{Alias: "test_alias", URL: url},
+			splash:     true,
+			wantInBody: `window.location.replace("https://www.google.com/")`,
+		},
+		{
+			name:       "Slow resolution of a missing link",
+			delay:      100 * time.Millisecond,
+			getError:   storage.ErrURLNotFound,
+			splash:     true,
+			wantInBody: "This link could not be opened.",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			urlCacheMock.On("GetEntry", mock.Anything, "test_alias").Return(cache.Entry{}, redis.Nil).Once()
+			urlGetterMock.On("GetURLInfoContext", mock.Anything, "test_alias").Return(tc.link, tc.getError).After(tc.delay).Once()
+			if tc.getError == nil {
+				urlCacheMock.On("Set", mock.Anything, "test_alias", mock.Anything, mock.Anything).Return(nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+				Splash: &redirect.Splash{Threshold: 20 * time.Millisecond, Brand: "Shorty"},
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/test_alias", nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if !tc.splash {
+				require.Equal(t, http.StatusFound, rr.Code)
+				assert.Equal(t, url, rr.Header().Get("Location"))
+				return
+			}
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			assert.Empty(t, rr.Header().Get("Location"))
+			assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+			assert.Contains(t, rr.Body.String(), "Shorty is opening your link")
+			assert.Contains(t, rr.Body.String(), tc.wantInBody)
+		})
+	}
+}
+
+func TestRedirectHandler_Fallback(t *testing.T) {
+	const fallbackURL = "https://status.example.com/"
+
+	cases := []struct {
+		name       string
+		getError   error
+		known      bool
+		statusCode int
+		location   string
+	}{
+		{
+			name:       "Storage error",
+			getError:   errors.New("connection refused"),
+			known:      true,
+			statusCode: http.StatusFound,
+			location:   fallbackURL,
+		},
+		{
+			name:       "Storage miss",
+			getError:   storage.ErrURLNotFound,
+			known:      true,
+			statusCode: http.StatusFound,
+			location:   fallbackURL,
+		},
+		{
+			name:       "Unknown to fallback",
+			getError:   errors.New("connection refused"),
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlCacheMock := mocks.NewURLCache(t)
+			fallbackMock := mocks.NewFallback(t)
+
+			urlCacheMock.On("GetEntry", mock.Anything, "status").Return(cache.Entry{}, errors.New("connection refused")).Once()
+			urlGetterMock.On("GetURLInfoContext", mock.Anything, "status").Return(storage.URL{}, tc.getError).Once()
+			if tc.known {
+				fallbackMock.On("Lookup", "status").Return(fallbackURL, true).Once()
+			} else {
+				fallbackMock.On("Lookup", "status").Return("", false).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+				Fallback: fallbackMock,
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/status", nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+			assert.Equal(t, tc.location, rr.Header().Get("Location"))
+		})
+	}
+}
+
+func TestRedirectHandler_Hosts(t *testing.T) {
+	const url = "https://www.google.com/search"
+
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlCacheMock := mocks.NewURLCache(t)
+	hostsMock := mocks.NewHostObserver(t)
+
+	urlCacheMock.On("GetEntry", mock.Anything, "cached").Return(cache.Entry{URL: url, Enabled: true}, nil).Once()
+	urlCacheMock.On("GetEntry", mock.Anything, "stored").Return(cache.Entry{}, redis.Nil).Once()
+	urlGetterMock.On("GetURLInfoContext", mock.Anything, "stored").Return(storage.URL{Alias: "stored", URL: url}, nil).Once()
+	urlCacheMock.On("Set", mock.Anything, "stored", mock.Anything, mock.Anything).Return(nil).Once()
+
+	hostsMock.On("ObserveRedirect", url).Return().Twice()
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+		Hosts: hostsMock,
+	}))
+
+	for _, alias := range []string{"cached", "stored"} {
+		req := httptest.NewRequest(http.MethodGet, "/"+alias, nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusFound, rr.Code)
+	}
+}
+
+func TestRedirectHandler_DefaultCacheTTL(t *testing.T) {
+	const url = "https://www.google.com/"
+
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlCacheMock := mocks.NewURLCache(t)
+
+	urlCacheMock.On("GetEntry", mock.Anything, "google").Return(cache.Entry{}, redis.Nil).Once()
+	urlGetterMock.On("GetURLInfoContext", mock.Anything, "google").Return(storage.URL{Alias: "google", URL: url}, nil).Once()
+	urlCacheMock.On("Set", mock.Anything, "google", cache.Entry{URL: url, Enabled: true}, cache.DefaultTTL).Return(nil).Once()
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{}))
+
+	req := httptest.NewRequest(http.MethodGet, "/google", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusFound, rr.Code)
+}
+
+func TestRedirectHandler_Aliases(t *testing.T) {
+	const url = "https://www.google.com/"
+
+	cases := []struct {
+		name       string
+		mayContain bool
+		getError   error
+		statusCode int
+		wantInBody string
+	}{
+		{
+			name:       "Definitely absent",
+			statusCode: http.StatusNotFound,
+			wantInBody: "not found",
+		},
+		{
+			name:       "False positive",
+			mayContain: true,
+			getError:   storage.ErrURLNotFound,
+			statusCode: http.StatusNotFound,
+			wantInBody: "not found",
+		},
+		{
+			name:       "Present",
+			mayContain: true,
+			statusCode: http.StatusFound,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlCacheMock := mocks.NewURLCache(t)
+			aliasesMock := mocks.NewAliasFilter(t)
+
+			urlCacheMock.On("GetEntry", mock.Anything, "google").Return(cache.Entry{}, redis.Nil).Once()
+			aliasesMock.On("MayContain", "google").Return(tc.mayContain).Once()
+			// storage is only asked about aliases that may exist
+			if tc.mayContain {
+				urlGetterMock.On("GetURLInfoContext", mock.Anything, "google").Return(storage.URL{Alias: "google", URL: url}, tc.getError).Once()
+			}
+			if tc.mayContain && tc.getError == nil {
+				urlCacheMock.On("Set", mock.Anything, "google", mock.Anything, mock.Anything).Return(nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+				Aliases: aliasesMock,
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/google", nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+			assert.Contains(t, rr.Body.String(), tc.wantInBody)
+		})
+	}
+}
+
+func TestRedirectHandler_Namespaces(t *testing.T) {
+	targets := map[string]string{
+		"a.example.com": "https://a.example.org/",
+		"b.example.com": "https://b.example.org/",
+		"example.com":   "https://example.org/",
+	}
+
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlCacheMock := mocks.NewURLCache(t)
+
+	for host, alias := range map[string]string{"a.example.com": "a~abc", "b.example.com": "b~abc", "example.com": "abc"} {
+		urlCacheMock.On("GetEntry", mock.Anything, alias).Return(cache.Entry{}, redis.Nil).Once()
+		urlGetterMock.On("GetURLInfoContext", mock.Anything, alias).Return(storage.URL{Alias: alias, URL: targets[host]}, nil).Once()
+		urlCacheMock.On("Set", mock.Anything, alias, cache.Entry{URL: targets[host], Enabled: true}, cacheTTL).Return(nil).Once()
+	}
+
+	r := chi.NewRouter()
+	r.Use(namespace.New(map[string]string{"a.example.com": "a", "B.example.com": "b"}))
+	r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+		CacheTTL: cacheTTL,
+	}))
+
+	for host, target := range targets {
+		req := httptest.NewRequest(http.MethodGet, "/abc", nil)
+		req.Host = host + ":8082"
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusFound, rr.Code, host)
+		assert.Equal(t, target, rr.Header().Get("Location"), host)
+	}
+
+	// the default namespace can't reach into the others
+	req := httptest.NewRequest(http.MethodGet, "/a~abc", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestRedirectHandler_QueryTimeout(t *testing.T) {
+	const alias = "slow"
+
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlCacheMock := mocks.NewURLCache(t)
+
+	hasDeadline := mock.MatchedBy(func(ctx context.Context) bool {
+		_, ok := ctx.Deadline()
+		return ok
+	})
+
+	urlCacheMock.On("GetEntry", mock.Anything, alias).Return(cache.Entry{}, redis.Nil).Once()
+	urlGetterMock.On("GetURLInfoContext", hasDeadline, alias).
+		Return(storage.URL{}, fmt.Errorf("storage.postgres.GetURLInfoContext: %w", context.DeadlineExceeded)).Once()
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, urlCacheMock, redirect.Options{
+		QueryTimeout: time.Second,
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/"+alias, nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Empty(t, rr.Header().Get("Location"))
+}
@@ -0,0 +1,30 @@
+package redirect
+
+import (
+	"net/url"
+	"strings"
+)
+
+// referrerAllowed reports whether a link restricted to the allowed
+// domains may be used from referer. Subdomains of an allowed domain are
+// allowed too. Requests without a Referer are allowed, browsers drop it
+// for direct visits and under strict referrer policies.
+func referrerAllowed(allowed []string, referer string) bool {
+	if len(allowed) == 0 || referer == "" {
+		return true
+	}
+
+	u, err := url.Parse(referer)
+	if err != nil || u.Hostname() == "" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+
+	for _, domain := range allowed {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,152 @@
+package redirect
+
+import (
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"url-shortener/internal/lib/logger/sl"
+)
+
+// Splash is a loading page served while slow links are resolved from
+// storage. The page is flushed once resolution takes longer than
+// Threshold, and the redirect is appended to it as a script when
+// resolution completes.
+type Splash struct {
+	Threshold time.Duration
+	// Brand is the name shown on the page.
+	Brand string
+	// CSP only allows the redirect script to run, with a nonce fresh for
+	// every page.
+	CSP bool
+}
+
+var splashHeadTmpl = template.Must(template.New("splash-head").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>{{.}}</title>
+</head>
+<body>
+<p>{{.}} is opening your link&hellip;</p>
+`))
+
+var splashTailTmpl = template.Must(template.New("splash-tail").Parse(`{{if .Target}}<noscript><p><a href="{{.Href}}">Continue</a></p></noscript>
+<script{{if .Nonce}} nonce="{{.Nonce}}"{{end}}>window.location.replace({{.Target}});</script>
+{{else if .Reload}}<script{{if .Nonce}} nonce="{{.Nonce}}"{{end}}>window.location.reload();</script>
+{{else}}<p>{{.Message}}</p>
+{{end}}</body>
+</html>
+`))
+
+// serve runs resolve, buffering its response. Fast responses are passed
+// through as they are, slow ones are finished on the splash page.
+func (s *Splash) serve(w http.ResponseWriter, log *slog.Logger, resolve func(w http.ResponseWriter)) {
+	rec := &recorder{header: http.Header{}}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		resolve(rec)
+	}()
+
+	timer := time.NewTimer(s.Threshold)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		rec.copyTo(w)
+		return
+	case <-timer.C:
+	}
+
+	log.Info("resolution is slow, serving splash")
+
+	// the header is sent with the head, before the script is known
+	var nonce string
+	if s.CSP {
+		var err error
+		nonce, err = newNonce()
+		if err != nil {
+			log.Error("failed to generate csp nonce", sl.Err(err))
+			<-done
+			rec.copyTo(w)
+			return
+		}
+		setCSP(w.Header(), nonce)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := splashHeadTmpl.Execute(w, s.Brand); err != nil {
+		log.Error("failed to render splash", sl.Err(err))
+		return
+	}
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+
+	<-done
+
+	data := struct {
+		Target  string
+		Href    template.URL
+		Reload  bool
+		Message string
+		Nonce   string
+	}{
+		Message: "This link could not be opened.",
+		Nonce:   nonce,
+	}
+	switch location := rec.header.Get("Location"); {
+	case location != "":
+		data.Target = location
+		// target passed validation on save, so non-http schemes are safe here
+		data.Href = template.URL(location)
+	case strings.HasPrefix(rec.header.Get("Content-Type"), "text/html"):
+		// interstitials are pages of their own, the reload serves them
+		data.Reload = true
+	}
+
+	if err := splashTailTmpl.Execute(w, data); err != nil {
+		log.Error("failed to render splash", sl.Err(err))
+	}
+}
+
+// recorder buffers a response until it is known whether the splash is
+// needed.
+type recorder struct {
+	header http.Header
+	code   int
+	body   strings.Builder
+}
+
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	if r.code == 0 {
+		r.code = http.StatusOK
+	}
+	return r.body.Write(b)
+}
+
+func (r *recorder) WriteHeader(code int) {
+	if r.code == 0 {
+		r.code = code
+	}
+}
+
+func (r *recorder) copyTo(w http.ResponseWriter) {
+	for k, v := range r.header {
+		w.Header()[k] = v
+	}
+	if r.code != 0 {
+		w.WriteHeader(r.code)
+	}
+	_, _ = w.Write([]byte(r.body.String()))
+}
@@ -0,0 +1,81 @@
+package redirect
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/weighted"
+	"url-shortener/internal/storage"
+)
+
+// VariantCookie remembers the variant of a split link a visitor was sent
+// to. It is scoped to the path of the link, so every link has its own.
+const VariantCookie = "variant"
+
+// Split divides the redirects of split links between their variants.
+type Split struct {
+	// Sticky sends returning visitors to the variant they were first sent
+	// to, remembered in VariantCookie for CookieTTL.
+	Sticky    bool
+	CookieTTL time.Duration
+	// Clicks counts the redirects to every variant. Variant clicks are
+	// not counted when it is nil.
+	Clicks VariantCounter
+}
+
+// VariantCounter is an interface for counting the redirects to the
+// variants of a split link.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=VariantCounter
+type VariantCounter interface {
+	IncrementVariantClicks(alias string, variant int) error
+}
+
+// pickVariant returns the index of the variant r is sent to: the one in
+// its cookie when sticky, or else one drawn by weight.
+func pickVariant(w http.ResponseWriter, r *http.Request, variants []storage.Variant, opts Options) int {
+	split := opts.Split
+	if split == nil {
+		split = &Split{}
+	}
+
+	if split.Sticky {
+		if cookie, err := r.Cookie(VariantCookie); err == nil {
+			if i, err := strconv.Atoi(cookie.Value); err == nil && i >= 0 && i < len(variants) {
+				return i
+			}
+		}
+	}
+
+	i := weighted.Draw(variants)
+
+	if split.Sticky {
+		http.SetCookie(w, &http.Cookie{
+			Name:     VariantCookie,
+			Value:    strconv.Itoa(i),
+			Path:     r.URL.Path,
+			MaxAge:   int(split.CookieTTL.Seconds()),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+
+	return i
+}
+
+// countVariant increments the clicks of a variant of alias in the
+// background, so the write never holds back the redirect.
+func countVariant(log *slog.Logger, alias string, variant int, opts Options) {
+	if opts.Split == nil || opts.Split.Clicks == nil {
+		return
+	}
+
+	go func() {
+		if err := opts.Split.Clicks.IncrementVariantClicks(alias, variant); err != nil {
+			log.Error("failed to count variant click", sl.Err(err))
+		}
+	}()
+}
@@ -0,0 +1,104 @@
+package redirect
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"url-shortener/internal/cache"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+const (
+	// revalidateLockTTL keeps concurrent requests for a stale link from
+	// revalidating it more than once.
+	revalidateLockTTL = 10 * time.Second
+	revalidateTimeout = 5 * time.Second
+)
+
+// StaleWhileRevalidate serves cached links past their soft TTL right away
+// while refreshing them from storage in the background. Links past their
+// hard TTL are resolved from storage before redirecting.
+type StaleWhileRevalidate struct {
+	SoftTTL time.Duration
+	HardTTL time.Duration
+}
+
+// cacheable reports whether link may be served from the cache. Sponsored
+// links must go through the interstitial, protected links through the
+// password check, audited links through the auditor, restricted links
+// through the referrer check and split links through the variant draw.
+func cacheable(link storage.URL) bool {
+	return !link.Sponsored && link.PasswordHash == "" && !link.Audited && len(link.AllowedReferrers) == 0 && len(link.Variants) == 0
+}
+
+// cacheEntry returns the cache entry redirecting to link and its TTL,
+// which never outlives the link itself. ok is false when the link has
+// no time left to be cached.
+func cacheEntry(link storage.URL, now time.Time, opts Options) (_ cache.Entry, _ time.Duration, ok bool) {
+	entry := cache.Entry{URL: link.URL, Code: link.RedirectStatus, Enabled: true, ExpiresAt: link.ExpiresAt}
+
+	swr := opts.StaleWhileRevalidate
+	if swr == nil {
+		ttl := opts.CacheTTL
+		if ttl == 0 {
+			ttl = cache.DefaultTTL
+		}
+		ttl, ok = entry.CapTTL(ttl, now)
+		return entry, ttl, ok
+	}
+
+	freshUntil, staleUntil := now.Add(swr.SoftTTL), now.Add(swr.HardTTL)
+	entry.FreshUntil, entry.StaleUntil = &freshUntil, &staleUntil
+
+	ttl, ok := entry.CapTTL(swr.HardTTL, now)
+	return entry, ttl, ok
+}
+
+// revalidate refreshes the cached link of alias from storage in the
+// background, unless another request is doing so already.
+func revalidate(ctx context.Context, log *slog.Logger, urlGetter URLGetter, urlCache URLCache, alias string, opts Options) {
+	ok, err := urlCache.SetNX(ctx, "revalidate:"+alias, 1, revalidateLockTTL)
+	if err != nil {
+		log.Error("failed to lock revalidation", sl.Err(err))
+		return
+	}
+	if !ok {
+		return
+	}
+
+	// the redirect is sent before revalidation ends
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), revalidateTimeout)
+
+	go func() {
+		defer cancel()
+
+		entry, ttl := cache.Entry{}, opts.StaleWhileRevalidate.HardTTL
+
+		link, err := getURLInfo(ctx, urlGetter, alias, opts)
+		switch {
+		case errors.Is(err, storage.ErrURLNotFound):
+			// a disabled entry sends the next request to storage
+			log.Info("stale url was removed", slog.String("alias", alias))
+		case err != nil:
+			log.Error("failed to revalidate url", sl.Err(err))
+			return
+		case link.Expired(time.Now()):
+			log.Info("stale url has expired", slog.String("alias", alias))
+		case cacheable(link):
+			// a link expiring right now keeps the disabled entry
+			if e, t, ok := cacheEntry(link, time.Now(), opts); ok {
+				entry, ttl = e, t
+			}
+		}
+
+		if err := urlCache.Set(ctx, alias, entry, ttl); err != nil {
+			log.Error("failed to set url to cache", sl.Err(err))
+			return
+		}
+
+		log.Debug("url revalidated", slog.String("alias", alias))
+	}()
+}
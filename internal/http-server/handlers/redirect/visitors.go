@@ -0,0 +1,45 @@
+package redirect
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"url-shortener/internal/cache"
+	"url-shortener/internal/lib/logger/sl"
+)
+
+// VisitorCounter is an interface for counting distinct visitors in a
+// HyperLogLog.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=VisitorCounter
+type VisitorCounter interface {
+	PFAdd(ctx context.Context, key string, els ...interface{}) error
+}
+
+// visitorID identifies a visitor by client IP and user agent, hashed so
+// the counter never holds either.
+func visitorID(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	sum := sha256.Sum256([]byte(ip + "\x00" + r.UserAgent()))
+
+	return hex.EncodeToString(sum[:16])
+}
+
+// countVisitor adds the client to the unique visitors of alias.
+func countVisitor(r *http.Request, log *slog.Logger, alias string, opts Options) {
+	if opts.Visitors == nil {
+		return
+	}
+
+	if err := opts.Visitors.PFAdd(r.Context(), cache.VisitorsKey(alias), visitorID(r)); err != nil {
+		log.Error("failed to count visitor", sl.Err(err))
+	}
+}
@@ -0,0 +1,21 @@
+package robots
+
+import (
+	"net/http"
+)
+
+// DefaultPolicy keeps crawlers away from every alias.
+const DefaultPolicy = "User-agent: *\nDisallow: /\n"
+
+// New returns a handler serving policy as robots.txt,
+// falling back to DefaultPolicy when policy is empty.
+func New(policy string) http.HandlerFunc {
+	if policy == "" {
+		policy = DefaultPolicy
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte(policy))
+	}
+}
@@ -0,0 +1,52 @@
+package robots_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/robots"
+)
+
+func TestRobotsHandler(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy string
+		body   string
+	}{
+		{
+			name: "Default policy",
+			body: robots.DefaultPolicy,
+		},
+		{
+			name:   "Custom policy",
+			policy: "User-agent: *\nAllow: /\n",
+			body:   "User-agent: *\nAllow: /\n",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := chi.NewRouter()
+			r.Get("/robots.txt", robots.New(tc.policy))
+			r.Get("/{alias}", func(w http.ResponseWriter, r *http.Request) {
+				t.Errorf("robots.txt handled as alias %q", chi.URLParam(r, "alias"))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/robots.txt", nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.Equal(t, "text/plain; charset=utf-8", rr.Header().Get("Content-Type"))
+			require.Equal(t, tc.body, rr.Body.String())
+		})
+	}
+}
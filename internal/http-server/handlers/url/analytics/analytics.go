@@ -0,0 +1,128 @@
+package analytics
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"url-shortener/internal/http-server/middleware/namespace"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/normalize"
+	"url-shortener/internal/storage"
+)
+
+// DefaultDays is the number of days reported when Options.MaxDays is zero.
+const DefaultDays = 30
+
+type Response struct {
+	resp.Response
+	Alias string `json:"alias,omitempty"`
+	// Days holds the days with clicks, oldest first.
+	Days []Day `json:"days"`
+}
+
+// Day is the number of clicks of an alias on a UTC day.
+type Day struct {
+	Date   string `json:"date"`
+	Clicks int64  `json:"clicks"`
+}
+
+// ClicksGetter is an interface for getting a stored link and its
+// recorded clicks.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=ClicksGetter
+type ClicksGetter interface {
+	GetURLInfo(alias string) (storage.URL, error)
+	GetDailyClicks(alias string, since time.Time) ([]storage.DailyClicks, error)
+}
+
+// Options holds the optional behaviour of the analytics handler.
+type Options struct {
+	// FoldAliases looks aliases up case- and accent-insensitively.
+	FoldAliases bool
+	// MaxDays caps the days reported, and is the default when the days
+	// parameter is missing.
+	MaxDays int
+}
+
+// New returns a handler reporting the daily clicks of an alias over the
+// last days, today included.
+func New(log *slog.Logger, clicksGetter ClicksGetter, opts Options) http.HandlerFunc {
+	if opts.MaxDays <= 0 {
+		opts.MaxDays = DefaultDays
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.analytics.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("invalid request"))
+			return
+		}
+		if opts.FoldAliases {
+			alias = normalize.Alias(alias)
+		}
+		alias = namespace.Qualify(r.Context(), alias)
+
+		days := opts.MaxDays
+		if raw := r.URL.Query().Get("days"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 {
+				log.Info("invalid days", slog.String("days", raw))
+				resp.RenderError(w, r, http.StatusBadRequest, resp.Error("days must be a positive number"))
+				return
+			}
+			days = min(n, opts.MaxDays)
+		}
+
+		link, err := clicksGetter.GetURLInfo(alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url not found", slog.String("alias", alias))
+			resp.RenderError(w, r, http.StatusNotFound, resp.Error("not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get url", sl.Err(err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+			return
+		}
+
+		now := time.Now().UTC()
+		since := time.Date(now.Year(), now.Month(), now.Day()-(days-1), 0, 0, 0, 0, time.UTC)
+
+		clicks, err := clicksGetter.GetDailyClicks(link.Alias, since)
+		if err != nil {
+			log.Error("failed to get clicks", sl.Err(err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+			return
+		}
+
+		res := Response{
+			Response: resp.OK(),
+			Alias:    namespace.Unqualify(r.Context(), link.Alias),
+			Days:     make([]Day, 0, len(clicks)),
+		}
+		for _, c := range clicks {
+			res.Days = append(res.Days, Day{
+				Date:   c.Day.Format(time.DateOnly),
+				Clicks: c.Clicks,
+			})
+		}
+
+		render.JSON(w, r, res)
+	}
+}
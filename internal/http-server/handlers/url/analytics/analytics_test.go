@@ -0,0 +1,125 @@
+package analytics_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/analytics"
+	"url-shortener/internal/http-server/handlers/url/analytics/mocks"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestAnalyticsHandler(t *testing.T) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	clicks := []storage.DailyClicks{
+		{Day: today.AddDate(0, 0, -1), Clicks: 3},
+		{Day: today, Clicks: 5},
+	}
+
+	cases := []struct {
+		name       string
+		query      string
+		since      time.Time
+		linkError  error
+		mockError  error
+		respError  string
+		days       []analytics.Day
+		statusCode int
+	}{
+		{
+			name:  "Success",
+			since: today.AddDate(0, 0, -6),
+			days: []analytics.Day{
+				{Date: today.AddDate(0, 0, -1).Format(time.DateOnly), Clicks: 3},
+				{Date: today.Format(time.DateOnly), Clicks: 5},
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:  "Days",
+			query: "?days=2",
+			since: today.AddDate(0, 0, -1),
+			days: []analytics.Day{
+				{Date: today.AddDate(0, 0, -1).Format(time.DateOnly), Clicks: 3},
+				{Date: today.Format(time.DateOnly), Clicks: 5},
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:  "Days capped",
+			query: "?days=365",
+			since: today.AddDate(0, 0, -6),
+			days: []analytics.Day{
+				{Date: today.AddDate(0, 0, -1).Format(time.DateOnly), Clicks: 3},
+				{Date: today.Format(time.DateOnly), Clicks: 5},
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Invalid days",
+			query:      "?days=0",
+			respError:  "days must be a positive number",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Not found",
+			linkError:  storage.ErrURLNotFound,
+			respError:  "not found",
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name:       "Storage error",
+			since:      today.AddDate(0, 0, -6),
+			mockError:  errors.New("unexpected error"),
+			respError:  "internal error",
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			clicksGetterMock := mocks.NewClicksGetter(t)
+
+			if tc.statusCode != http.StatusBadRequest {
+				clicksGetterMock.On("GetURLInfo", "google").Return(storage.URL{Alias: "google"}, tc.linkError).Once()
+			}
+			if !tc.since.IsZero() {
+				var days []storage.DailyClicks
+				if tc.mockError == nil {
+					days = clicks
+				}
+				clicksGetterMock.On("GetDailyClicks", "google", mock.MatchedBy(tc.since.Equal)).Return(days, tc.mockError).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/url/{alias}/analytics", analytics.New(slogdiscard.NewDiscardLogger(), clicksGetterMock, analytics.Options{
+				MaxDays: 7,
+			}))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/url/google/analytics"+tc.query, nil))
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp analytics.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+			require.Equal(t, tc.days, resp.Days)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package available
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"url-shortener/internal/http-server/middleware/namespace"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/normalize"
+	"url-shortener/internal/lib/validate"
+	"url-shortener/internal/storage"
+)
+
+type Response struct {
+	resp.Response
+	Available bool `json:"available"`
+	// Reason tells why an alias no link uses is unavailable all the same,
+	// e.g. it is reserved.
+	Reason string `json:"reason,omitempty"`
+}
+
+// URLGetter is an interface for getting the target of an alias.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLGetter
+type URLGetter interface {
+	GetURL(alias string) (string, error)
+}
+
+// Options holds the optional behaviour of the available handler.
+type Options struct {
+	// FoldAliases checks aliases the way they are stored when aliases
+	// are case- and accent-insensitive.
+	FoldAliases bool
+	// ReservedAliases are refused by the save handler, so never available.
+	ReservedAliases []string
+}
+
+// New returns a handler reporting whether a custom alias is free to be
+// saved. Only its availability is answered, never the target of a taken
+// alias.
+func New(log *slog.Logger, urlGetter URLGetter, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.available.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("invalid request"))
+			return
+		}
+		if opts.FoldAliases {
+			alias = normalize.Alias(alias)
+		}
+
+		if err := validate.Alias(alias, opts.ReservedAliases); err != nil {
+			log.Info("invalid alias", slog.String("alias", alias), sl.Err(err))
+			render.JSON(w, r, Response{
+				Response:  resp.OK(),
+				Available: false,
+				Reason:    err.Error(),
+			})
+			return
+		}
+
+		_, err := urlGetter.GetURL(namespace.Qualify(r.Context(), alias))
+		if err != nil && !errors.Is(err, storage.ErrURLNotFound) {
+			log.Error("failed to get url", sl.Err(err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response:  resp.OK(),
+			Available: errors.Is(err, storage.ErrURLNotFound),
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package available_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/available"
+	"url-shortener/internal/http-server/handlers/url/available/mocks"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestAvailableHandler(t *testing.T) {
+	const target = "https://secret.example.com/private"
+
+	cases := []struct {
+		name       string
+		alias      string
+		lookup     string
+		foldAlias  bool
+		mockError  error
+		available  bool
+		reason     string
+		respError  string
+		statusCode int
+	}{
+		{
+			name:       "Taken",
+			alias:      "google",
+			lookup:     "google",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Available",
+			alias:      "fresh",
+			lookup:     "fresh",
+			mockError:  storage.ErrURLNotFound,
+			available:  true,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Folded",
+			alias:      "Google",
+			lookup:     "google",
+			foldAlias:  true,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Reserved",
+			alias:      "admin",
+			reason:     "alias is reserved",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Invalid",
+			alias:      "ab",
+			reason:     "alias contains invalid characters",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Storage error",
+			alias:      "google",
+			lookup:     "google",
+			mockError:  errors.New("unexpected error"),
+			respError:  "internal error",
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			if tc.lookup != "" {
+				urlGetterMock.On("GetURL", tc.lookup).Return(target, tc.mockError).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/url/{alias}/available", available.New(slogdiscard.NewDiscardLogger(), urlGetterMock, available.Options{
+				FoldAliases:     tc.foldAlias,
+				ReservedAliases: []string{"admin"},
+			}))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/url/"+tc.alias+"/available", nil))
+
+			require.Equal(t, tc.statusCode, rr.Code)
+			assert.NotContains(t, rr.Body.String(), target)
+
+			var resp available.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+			require.Equal(t, tc.available, resp.Available)
+			require.Equal(t, tc.reason, resp.Reason)
+		})
+	}
+}
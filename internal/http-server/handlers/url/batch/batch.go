@@ -0,0 +1,292 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+
+	"url-shortener/internal/cache"
+	"url-shortener/internal/http-server/handlers/url/save"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/http-server/middleware/namespace"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/generator"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/normalize"
+	"url-shortener/internal/lib/signing"
+	"url-shortener/internal/lib/validate"
+	"url-shortener/internal/storage"
+)
+
+// Item is a link to shorten, the alias is generated when empty.
+type Item struct {
+	URL   string `json:"url" validate:"required,url"`
+	Alias string `json:"alias,omitempty"`
+	// Owner is the email of who is accountable for the link, it is read
+	// from save.OwnerHeader when empty.
+	Owner string `json:"owner,omitempty"`
+}
+
+// Result is the outcome of an Item, in request order.
+type Result struct {
+	Alias    string `json:"alias,omitempty"`
+	ShortURL string `json:"short_url,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+type Response struct {
+	resp.Response
+	Results []Result `json:"results"`
+}
+
+// URLBatchSaver is an interface for saving many links at once.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLBatchSaver
+type URLBatchSaver interface {
+	SaveURLBatch(items []storage.URLItem) ([]storage.SaveResult, error)
+}
+
+type URLCache interface {
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+}
+
+// Options holds the optional behaviour of the batch handler. Items are
+// checked like links of the save handler with the same options.
+type Options struct {
+	// MaxItems caps the links of a batch, unlimited when zero.
+	MaxItems int
+
+	FoldAliases        bool
+	MinUserAliasLength int
+	AllowedSchemes     []string
+	RejectURLAliases   bool
+	ReservedAliases    []string
+	CanonicalQuery     bool
+	// Signer reserves the signature separator in aliases. Batches can't
+	// create signed links.
+	Signer    *signing.Signer
+	Generator generator.Generator
+	BaseURL   string
+	CacheTTL  time.Duration
+	Aliases   save.AliasSet
+	// RequireOwner rejects links without an owner.
+	RequireOwner bool
+	// SaveAttempts is the number of generated aliases tried while storage
+	// reports them taken, save.DefaultSaveAttempts when zero.
+	SaveAttempts int
+}
+
+func New(log *slog.Logger, urlSaver URLBatchSaver, urlCache URLCache, opts Options) http.HandlerFunc {
+	if opts.Generator == nil {
+		opts.Generator = generator.Random{Length: save.AliasLength}
+	}
+	if opts.CacheTTL == 0 {
+		opts.CacheTTL = cache.DefaultTTL
+	}
+	if opts.SaveAttempts <= 0 {
+		opts.SaveAttempts = save.DefaultSaveAttempts
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.batch.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var items []Item
+
+		err := render.DecodeJSON(r.Body, &items)
+		if errors.Is(err, io.EOF) || err == nil && len(items) == 0 {
+			log.Error("request body is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("failed to decode request"))
+			return
+		}
+
+		if opts.MaxItems > 0 && len(items) > opts.MaxItems {
+			log.Info("batch is too large", slog.Int("items", len(items)))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error(fmt.Sprintf("batch must not exceed %d links", opts.MaxItems)))
+			return
+		}
+
+		results := make([]Result, len(items))
+
+		// only valid items are saved
+		var pending []link
+		for i, item := range items {
+			valid, errMsg := check(r, item, opts)
+			if errMsg != "" {
+				results[i].Error = errMsg
+				continue
+			}
+
+			l := link{item: valid, index: i, generated: valid.Alias == ""}
+			if err := l.setAlias(r.Context(), opts); err != nil {
+				log.Error("failed to generate alias", sl.Err(err))
+				resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("failed to add urls"))
+				return
+			}
+
+			pending = append(pending, l)
+		}
+
+		// generated aliases the client didn't pick are drawn again when
+		// taken, custom ones are tried once
+		var saved int
+		for attempt := 1; len(pending) > 0; attempt++ {
+			toSave := make([]storage.URLItem, len(pending))
+			for i, l := range pending {
+				toSave[i] = l.item
+			}
+
+			res, err := urlSaver.SaveURLBatch(toSave)
+			if err != nil {
+				log.Error("failed to add urls", sl.Err(err))
+				resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("failed to add urls"))
+				return
+			}
+
+			var retry []link
+			for i, l := range pending {
+				result := &results[l.index]
+
+				if errors.Is(res[i].Err, storage.ErrURLExists) {
+					switch {
+					case !l.generated:
+						result.Error = "url already exists"
+					case attempt == opts.SaveAttempts:
+						log.Error("generated aliases are taken", slog.Int("attempts", attempt))
+						result.Error = "failed to add url"
+					default:
+						log.Info("generated alias is taken, retrying", slog.String("alias", l.alias))
+						if err := l.setAlias(r.Context(), opts); err != nil {
+							log.Error("failed to generate alias", sl.Err(err))
+							result.Error = "failed to add url"
+							continue
+						}
+						retry = append(retry, l)
+					}
+					continue
+				}
+
+				saved++
+				result.Alias = l.alias
+				result.ShortURL = save.ShortURL(r, opts.BaseURL, l.alias)
+
+				if opts.Aliases != nil {
+					opts.Aliases.Add(l.item.Alias)
+				}
+
+				entry := cache.Entry{URL: l.item.URL, Enabled: true}
+				if err := urlCache.Set(r.Context(), l.item.Alias, entry, opts.CacheTTL); err != nil {
+					log.Error("failed to set url to cache", sl.Err(err))
+				}
+			}
+			pending = retry
+		}
+
+		log.Info("batch added", slog.Int("items", len(items)), slog.Int("saved", saved))
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Results:  results,
+		})
+	}
+}
+
+// link is a valid item on its way to storage, the item of results[index].
+type link struct {
+	item  storage.URLItem
+	index int
+	// alias is what the link is known by in the namespace of the request,
+	// item.Alias what it is stored as.
+	alias string
+	// generated is set for links saved without a custom alias.
+	generated bool
+}
+
+// setAlias sets the alias of l the way it is stored, drawing a new one
+// for links without a custom alias.
+func (l *link) setAlias(ctx context.Context, opts Options) error {
+	alias := l.item.Alias
+	if l.generated {
+		var err error
+		alias, err = opts.Generator.Generate(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	if opts.FoldAliases {
+		alias = normalize.Alias(alias)
+	}
+
+	l.alias = alias
+	l.item.Alias = namespace.Qualify(ctx, alias)
+
+	return nil
+}
+
+// check validates item like the save handler validates a link, and
+// returns it the way it is saved or why it is rejected.
+func check(r *http.Request, item Item, opts Options) (storage.URLItem, string) {
+	if err := validator.New().Struct(item); err != nil {
+		return storage.URLItem{}, resp.ValidationError(err.(validator.ValidationErrors)).Error
+	}
+
+	if err := validate.URL(item.URL, opts.AllowedSchemes); err != nil {
+		return storage.URLItem{}, err.Error()
+	}
+
+	if opts.CanonicalQuery {
+		item.URL = normalize.Query(item.URL)
+	}
+
+	if item.Owner == "" {
+		item.Owner = r.Header.Get(save.OwnerHeader)
+	}
+	if err := validate.Owner(item.Owner, opts.RequireOwner); err != nil {
+		return storage.URLItem{}, err.Error()
+	}
+
+	if item.Alias != "" {
+		if opts.RejectURLAliases {
+			if err := validate.AliasNotURL(item.Alias); err != nil {
+				return storage.URLItem{}, err.Error()
+			}
+		}
+
+		if utf8.RuneCountInString(item.Alias) < opts.MinUserAliasLength && !auth.IsAdmin(r.Context()) {
+			return storage.URLItem{}, fmt.Sprintf("alias must be at least %d characters", opts.MinUserAliasLength)
+		}
+
+		// signed aliases are recognised by the separator on redirect
+		if opts.Signer != nil && signing.IsSigned(item.Alias) {
+			return storage.URLItem{}, "alias must not contain " + signing.Separator
+		}
+
+		alias := item.Alias
+		if opts.FoldAliases {
+			alias = normalize.Alias(alias)
+		}
+		if err := validate.Alias(alias, opts.ReservedAliases); err != nil {
+			return storage.URLItem{}, err.Error()
+		}
+	}
+
+	return storage.URLItem{URL: item.URL, Alias: item.Alias, Owner: item.Owner}, ""
+}
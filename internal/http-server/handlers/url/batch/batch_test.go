@@ -0,0 +1,311 @@
+package batch_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/cache"
+	"url-shortener/internal/http-server/handlers/url/batch"
+	"url-shortener/internal/http-server/handlers/url/batch/mocks"
+	"url-shortener/internal/http-server/middleware/namespace"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestBatchHandler(t *testing.T) {
+	urlSaverMock := mocks.NewURLBatchSaver(t)
+	urlCacheMock := mocks.NewURLCache(t)
+
+	// invalid items never reach storage
+	urlSaverMock.On("SaveURLBatch", []storage.URLItem{
+		{URL: "https://google.com", Alias: "google"},
+		{URL: "https://example.com", Alias: "taken"},
+	}).Return([]storage.SaveResult{
+		{ID: 1},
+		{Err: storage.ErrURLExists},
+	}, nil).Once()
+	urlCacheMock.On("Set", mock.Anything, "google", cache.Entry{URL: "https://google.com", Enabled: true}, cache.DefaultTTL).Return(nil).Once()
+
+	handler := batch.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, batch.Options{
+		BaseURL:         "https://sho.rt",
+		ReservedAliases: []string{"admin"},
+	})
+
+	input := `[
+		{"url": "https://google.com", "alias": "google"},
+		{"url": "not a url", "alias": "broken"},
+		{"url": "https://example.com", "alias": "taken"},
+		{"url": "https://example.com", "alias": "admin"}
+	]`
+
+	req, err := http.NewRequest(http.MethodPost, "/url/batch", strings.NewReader(input))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp batch.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	assert.Equal(t, []batch.Result{
+		{Alias: "google", ShortURL: "https://sho.rt/google"},
+		{Error: "field URL is not a valid URL"},
+		{Error: "url already exists"},
+		{Error: "alias is reserved"},
+	}, resp.Results)
+}
+
+func TestBatchHandler_Errors(t *testing.T) {
+	cases := []struct {
+		name       string
+		input      string
+		saveError  error
+		statusCode int
+		respError  string
+	}{
+		{
+			name:       "Empty body",
+			statusCode: http.StatusBadRequest,
+			respError:  "empty request",
+		},
+		{
+			name:       "Empty batch",
+			input:      `[]`,
+			statusCode: http.StatusBadRequest,
+			respError:  "empty request",
+		},
+		{
+			name:       "Not an array",
+			input:      `{"url": "https://google.com"}`,
+			statusCode: http.StatusBadRequest,
+			respError:  "failed to decode request",
+		},
+		{
+			name:       "Too many links",
+			input:      `[{"url": "https://a.com"}, {"url": "https://b.com"}, {"url": "https://c.com"}]`,
+			statusCode: http.StatusBadRequest,
+			respError:  "batch must not exceed 2 links",
+		},
+		{
+			name:       "Storage error",
+			input:      `[{"url": "https://a.com", "alias": "first"}]`,
+			saveError:  errors.New("connection refused"),
+			statusCode: http.StatusInternalServerError,
+			respError:  "failed to add urls",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLBatchSaver(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			if tc.saveError != nil {
+				urlSaverMock.On("SaveURLBatch", mock.Anything).Return(nil, tc.saveError).Once()
+			}
+
+			handler := batch.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, batch.Options{
+				MaxItems: 2,
+			})
+
+			req, err := http.NewRequest(http.MethodPost, "/url/batch", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp batch.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
+
+func TestBatchHandler_RequireOwner(t *testing.T) {
+	urlSaverMock := mocks.NewURLBatchSaver(t)
+	urlCacheMock := mocks.NewURLCache(t)
+
+	// items without an owner of their own fall back to the header
+	urlSaverMock.On("SaveURLBatch", []storage.URLItem{
+		{URL: "https://google.com", Alias: "google", Owner: "jane@example.com"},
+		{URL: "https://example.com", Alias: "example", Owner: "team@example.com"},
+	}).Return([]storage.SaveResult{{ID: 1}, {ID: 2}}, nil).Once()
+	urlCacheMock.On("Set", mock.Anything, mock.Anything, mock.Anything, cache.DefaultTTL).Return(nil).Twice()
+
+	handler := batch.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, batch.Options{
+		BaseURL:      "https://sho.rt",
+		RequireOwner: true,
+	})
+
+	input := `[
+		{"url": "https://google.com", "alias": "google", "owner": "jane@example.com"},
+		{"url": "https://example.com", "alias": "example"},
+		{"url": "https://example.org", "alias": "invalid", "owner": "jane"}
+	]`
+
+	req, err := http.NewRequest(http.MethodPost, "/url/batch", strings.NewReader(input))
+	require.NoError(t, err)
+	req.Header.Set("X-Link-Owner", "team@example.com")
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp batch.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	assert.Equal(t, []batch.Result{
+		{Alias: "google", ShortURL: "https://sho.rt/google"},
+		{Alias: "example", ShortURL: "https://sho.rt/example"},
+		{Error: "owner must be an email address"},
+	}, resp.Results)
+
+	// without the header, items must carry their own owner
+	req, err = http.NewRequest(http.MethodPost, "/url/batch", strings.NewReader(`[{"url": "https://example.com"}]`))
+	require.NoError(t, err)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var missing batch.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &missing))
+	assert.Equal(t, []batch.Result{{Error: "owner is required"}}, missing.Results)
+}
+
+func TestBatchHandler_Namespaces(t *testing.T) {
+	urlSaverMock := mocks.NewURLBatchSaver(t)
+	urlCacheMock := mocks.NewURLCache(t)
+
+	for host, alias := range map[string]string{"a.example.com": "a~docs", "example.com": "docs"} {
+		urlSaverMock.On("SaveURLBatch", []storage.URLItem{{URL: "https://" + host, Alias: alias}}).
+			Return([]storage.SaveResult{{ID: 1}}, nil).Once()
+		urlCacheMock.On("Set", mock.Anything, alias, cache.Entry{URL: "https://" + host, Enabled: true}, cache.DefaultTTL).Return(nil).Once()
+	}
+
+	r := chi.NewRouter()
+	r.Use(namespace.New(map[string]string{"a.example.com": "a"}))
+	r.Post("/url/batch", batch.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, batch.Options{}))
+
+	for _, host := range []string{"a.example.com", "example.com"} {
+		input := `[{"url": "https://` + host + `", "alias": "docs"}]`
+
+		req := httptest.NewRequest(http.MethodPost, "/url/batch", strings.NewReader(input))
+		req.Host = host
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code, host)
+
+		var resp batch.Response
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+		assert.Equal(t, []batch.Result{{Alias: "docs", ShortURL: "http://" + host + "/docs"}}, resp.Results, host)
+	}
+}
+
+// sequenceGenerator generates the aliases in order.
+type sequenceGenerator struct {
+	aliases []string
+	next    int
+}
+
+func (g *sequenceGenerator) Generate(context.Context) (string, error) {
+	alias := g.aliases[g.next]
+	g.next++
+
+	return alias, nil
+}
+
+func TestBatchHandler_RetryGeneratedAlias(t *testing.T) {
+	cases := []struct {
+		name     string
+		attempts int
+		results  []batch.Result
+	}{
+		{
+			name: "Taken once",
+			results: []batch.Result{
+				{Alias: "second", ShortURL: "https://sho.rt/second"},
+				{Error: "url already exists"},
+			},
+		},
+		{
+			name:     "Out of attempts",
+			attempts: 1,
+			results: []batch.Result{
+				{Error: "failed to add url"},
+				{Error: "url already exists"},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLBatchSaver(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			// a custom alias that is taken is not retried
+			urlSaverMock.On("SaveURLBatch", []storage.URLItem{
+				{URL: "https://a.com", Alias: "first"},
+				{URL: "https://b.com", Alias: "taken"},
+			}).Return([]storage.SaveResult{
+				{Err: storage.ErrURLExists},
+				{Err: storage.ErrURLExists},
+			}, nil).Once()
+			if tc.attempts != 1 {
+				urlSaverMock.On("SaveURLBatch", []storage.URLItem{{URL: "https://a.com", Alias: "second"}}).
+					Return([]storage.SaveResult{{ID: 2}}, nil).Once()
+				urlCacheMock.On("Set", mock.Anything, "second", cache.Entry{URL: "https://a.com", Enabled: true}, cache.DefaultTTL).
+					Return(nil).Once()
+			}
+
+			handler := batch.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, batch.Options{
+				BaseURL:      "https://sho.rt",
+				Generator:    &sequenceGenerator{aliases: []string{"first", "second"}},
+				SaveAttempts: tc.attempts,
+			})
+
+			input := `[{"url": "https://a.com"}, {"url": "https://b.com", "alias": "taken"}]`
+
+			req, err := http.NewRequest(http.MethodPost, "/url/batch", strings.NewReader(input))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp batch.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			assert.Equal(t, tc.results, resp.Results)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package delete
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"url-shortener/internal/http-server/middleware/namespace"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/normalize"
+	"url-shortener/internal/storage"
+)
+
+// URLDeleter is an interface for deleting a stored link.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLDeleter
+type URLDeleter interface {
+	DeleteURL(alias string) error
+}
+
+// URLCache is an interface for evicting a cached alias.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLCache
+type URLCache interface {
+	Delete(ctx context.Context, key string) error
+}
+
+// Options holds the optional behaviour of the delete handler.
+type Options struct {
+	// FoldAliases looks aliases up case- and accent-insensitively.
+	FoldAliases bool
+	// RelatedKeys returns further cache keys derived from an alias,
+	// such as cached responses about it, to evict along with it.
+	RelatedKeys func(alias string) []string
+}
+
+func New(log *slog.Logger, urlDeleter URLDeleter, urlCache URLCache, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.delete.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("invalid request"))
+			return
+		}
+
+		if opts.FoldAliases {
+			alias = normalize.Alias(alias)
+		}
+		stored := namespace.Qualify(r.Context(), alias)
+
+		err := urlDeleter.DeleteURL(stored)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url not found", slog.String("alias", stored))
+			resp.RenderError(w, r, http.StatusNotFound, resp.Error("not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to delete url", sl.Err(err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("failed to delete url"))
+			return
+		}
+
+		log.Info("url deleted", slog.String("alias", stored))
+
+		// stale redirects must stop right away
+		if err := urlCache.Delete(r.Context(), stored); err != nil {
+			log.Error("failed to evict url from cache", sl.Err(err))
+		}
+		if opts.RelatedKeys != nil {
+			for _, key := range opts.RelatedKeys(alias) {
+				if err := urlCache.Delete(r.Context(), namespace.Qualify(r.Context(), key)); err != nil {
+					log.Error("failed to evict related key from cache", slog.String("key", key), sl.Err(err))
+				}
+			}
+		}
+
+		render.JSON(w, r, resp.OK())
+	}
+}
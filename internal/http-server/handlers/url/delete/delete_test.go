@@ -0,0 +1,132 @@
+package delete_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/delete"
+	"url-shortener/internal/http-server/handlers/url/delete/mocks"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestDeleteHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		alias      string
+		mockError  error
+		respStatus string
+		respError  string
+		statusCode int
+	}{
+		{
+			name:       "Success",
+			alias:      "test_alias",
+			respStatus: resp.StatusOK,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Not found",
+			alias:      "missing",
+			mockError:  storage.ErrURLNotFound,
+			respStatus: resp.StatusError,
+			respError:  "not found",
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name:       "DeleteURL error",
+			alias:      "test_alias",
+			mockError:  errors.New("unexpected error"),
+			respStatus: resp.StatusError,
+			respError:  "failed to delete url",
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlDeleterMock := mocks.NewURLDeleter(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			urlDeleterMock.On("DeleteURL", tc.alias).Return(tc.mockError).Once()
+			if tc.mockError == nil {
+				urlCacheMock.On("Delete", mock.Anything, tc.alias).Return(nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Delete("/url/{alias}", delete.New(slogdiscard.NewDiscardLogger(), urlDeleterMock, urlCacheMock, delete.Options{}))
+
+			req, err := http.NewRequest(http.MethodDelete, "/url/"+tc.alias, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var res resp.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+
+			require.Equal(t, tc.respStatus, res.Status)
+			require.Equal(t, tc.respError, res.Error)
+		})
+	}
+}
+
+func TestDeleteHandler_FoldAliases(t *testing.T) {
+	urlDeleterMock := mocks.NewURLDeleter(t)
+	urlCacheMock := mocks.NewURLCache(t)
+
+	urlDeleterMock.On("DeleteURL", "mylink").Return(nil).Once()
+	urlCacheMock.On("Delete", mock.Anything, "mylink").Return(nil).Once()
+
+	r := chi.NewRouter()
+	r.Delete("/url/{alias}", delete.New(slogdiscard.NewDiscardLogger(), urlDeleterMock, urlCacheMock, delete.Options{
+		FoldAliases: true,
+	}))
+
+	req, err := http.NewRequest(http.MethodDelete, "/url/MyLink", nil)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestDeleteHandler_RelatedKeys(t *testing.T) {
+	urlDeleterMock := mocks.NewURLDeleter(t)
+	urlCacheMock := mocks.NewURLCache(t)
+
+	urlDeleterMock.On("DeleteURL", "test_alias").Return(nil).Once()
+	urlCacheMock.On("Delete", mock.Anything, "test_alias").Return(nil).Once()
+	urlCacheMock.On("Delete", mock.Anything, "response:/admin/url/test_alias").Return(nil).Once()
+
+	r := chi.NewRouter()
+	r.Delete("/url/{alias}", delete.New(slogdiscard.NewDiscardLogger(), urlDeleterMock, urlCacheMock, delete.Options{
+		RelatedKeys: func(alias string) []string {
+			return []string{"response:/admin/url/" + alias}
+		},
+	}))
+
+	req, err := http.NewRequest(http.MethodDelete, "/url/test_alias", nil)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+}
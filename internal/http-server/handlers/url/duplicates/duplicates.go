@@ -0,0 +1,64 @@
+package duplicates
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+type Group struct {
+	ContentHash string   `json:"content_hash"`
+	Aliases     []string `json:"aliases"`
+}
+
+type Response struct {
+	resp.Response
+	Groups []Group `json:"groups"`
+}
+
+// ContentGrouper is an interface for grouping links by content fingerprint.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=ContentGrouper
+type ContentGrouper interface {
+	GroupByContentHash(ctx context.Context) ([]storage.ContentGroup, error)
+}
+
+// New returns a handler listing the aliases whose targets served
+// identical content when they were saved.
+func New(log *slog.Logger, grouper ContentGrouper) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.duplicates.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		groups, err := grouper.GroupByContentHash(r.Context())
+		if err != nil {
+			log.Error("failed to group urls by content", sl.Err(err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+			return
+		}
+
+		res := Response{
+			Response: resp.OK(),
+			Groups:   make([]Group, 0, len(groups)),
+		}
+		for _, g := range groups {
+			res.Groups = append(res.Groups, Group{
+				ContentHash: g.Hash,
+				Aliases:     g.Aliases,
+			})
+		}
+
+		render.JSON(w, r, res)
+	}
+}
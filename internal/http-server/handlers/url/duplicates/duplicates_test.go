@@ -0,0 +1,78 @@
+package duplicates_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/duplicates"
+	"url-shortener/internal/http-server/handlers/url/duplicates/mocks"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestDuplicatesHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		groups     []storage.ContentGroup
+		mockError  error
+		respGroups []duplicates.Group
+		respError  string
+		statusCode int
+	}{
+		{
+			name: "Groups",
+			groups: []storage.ContentGroup{
+				{Hash: "abc", Aliases: []string{"first", "second"}},
+			},
+			respGroups: []duplicates.Group{
+				{ContentHash: "abc", Aliases: []string{"first", "second"}},
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "No duplicates",
+			respGroups: []duplicates.Group{},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Storage error",
+			mockError:  errors.New("connection refused"),
+			respError:  "internal error",
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			grouperMock := mocks.NewContentGrouper(t)
+			grouperMock.On("GroupByContentHash", mock.Anything).Return(tc.groups, tc.mockError).Once()
+
+			handler := duplicates.New(slogdiscard.NewDiscardLogger(), grouperMock)
+
+			req, err := http.NewRequest(http.MethodGet, "/admin/duplicates", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp duplicates.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+			require.Equal(t, tc.respGroups, resp.Groups)
+		})
+	}
+}
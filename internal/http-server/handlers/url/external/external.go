@@ -0,0 +1,82 @@
+package external
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"url-shortener/internal/http-server/handlers/url/save"
+	"url-shortener/internal/http-server/middleware/namespace"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+type Response struct {
+	resp.Response
+	Alias    string `json:"alias,omitempty"`
+	URL      string `json:"url,omitempty"`
+	ShortURL string `json:"short_url,omitempty"`
+}
+
+// URLGetter is an interface for getting a stored link by external id.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLGetter
+type URLGetter interface {
+	GetURLByExternalID(externalID string) (storage.URL, error)
+}
+
+// Options holds the optional behaviour of the external id handler.
+type Options struct {
+	// PerCreator looks external ids up among those saved by the client
+	// of the request, as save.Options.ExternalIDsPerCreator stores them.
+	PerCreator bool
+	// BaseURL prefixes the alias in the short_url of the response, as
+	// save.Options.BaseURL does.
+	BaseURL string
+}
+
+// New returns a handler resolving the external id a link was saved under
+// to its alias.
+func New(log *slog.Logger, urlGetter URLGetter, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.external.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id := chi.URLParam(r, "id")
+		if id == "" {
+			log.Info("external id is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("invalid request"))
+			return
+		}
+
+		link, err := urlGetter.GetURLByExternalID(save.ExternalKey(r, id, opts.PerCreator))
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("external id not found", slog.String("external_id", id))
+			resp.RenderError(w, r, http.StatusNotFound, resp.Error("not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get url by external id", sl.Err(err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+			return
+		}
+
+		alias := namespace.Unqualify(r.Context(), link.Alias)
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Alias:    alias,
+			URL:      link.URL,
+			ShortURL: save.ShortURL(r, opts.BaseURL, alias),
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package external_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/external"
+	"url-shortener/internal/http-server/handlers/url/external/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/http-server/middleware/namespace"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestExternalHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		link       storage.URL
+		mockError  error
+		respError  string
+		statusCode int
+	}{
+		{
+			name:       "Success",
+			id:         "order-42",
+			link:       storage.URL{Alias: "google", URL: "https://google.com", ExternalID: "order-42"},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Not found",
+			id:         "missing",
+			mockError:  storage.ErrURLNotFound,
+			respError:  "not found",
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name:       "Storage error",
+			id:         "order-42",
+			mockError:  errors.New("unexpected error"),
+			respError:  "internal error",
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetURLByExternalID", tc.id).Return(tc.link, tc.mockError).Once()
+
+			r := chi.NewRouter()
+			r.Get("/url/by-external/{id}", external.New(slogdiscard.NewDiscardLogger(), urlGetterMock, external.Options{
+				BaseURL: "https://sho.rt",
+			}))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/url/by-external/"+tc.id, nil))
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp external.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+			require.Equal(t, tc.link.Alias, resp.Alias)
+			require.Equal(t, tc.link.URL, resp.URL)
+			if tc.respError == "" {
+				require.Equal(t, "https://sho.rt/"+tc.link.Alias, resp.ShortURL)
+			}
+		})
+	}
+}
+
+func TestExternalHandler_Scoped(t *testing.T) {
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetURLByExternalID", "acme~admin~order-42").Return(storage.URL{Alias: "acme~google", URL: "https://google.com"}, nil).Once()
+
+	r := chi.NewRouter()
+	r.Use(namespace.New(map[string]string{"go.acme.com": "acme"}))
+	r.Use(auth.Admin("admin", "password"))
+	r.Get("/url/by-external/{id}", external.New(slogdiscard.NewDiscardLogger(), urlGetterMock, external.Options{
+		PerCreator: true,
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "http://go.acme.com/url/by-external/order-42", nil)
+	req.SetBasicAuth("admin", "password")
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp external.Response
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	require.Equal(t, "google", resp.Alias)
+	require.Equal(t, "http://go.acme.com/google", resp.ShortURL)
+}
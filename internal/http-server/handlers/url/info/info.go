@@ -0,0 +1,130 @@
+package info
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"url-shortener/internal/http-server/middleware/namespace"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/expiry"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/normalize"
+	"url-shortener/internal/storage"
+)
+
+type Response struct {
+	resp.Response
+	ID             int64      `json:"id,omitempty"`
+	Alias          string     `json:"alias,omitempty"`
+	URL            string     `json:"url,omitempty"`
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
+	Sponsored      bool       `json:"sponsored,omitempty"`
+	ContentHash    string     `json:"content_hash,omitempty"`
+	Audited        bool       `json:"audited,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	// AllowedReferrers are the Referer domains the link may be used from.
+	AllowedReferrers []string `json:"allowed_referrers,omitempty"`
+	// Creator is who saved the link, if it was recorded.
+	Creator *Creator `json:"creator,omitempty"`
+	// RedirectStatus is the status code the link redirects with, if it
+	// overrides the configured one.
+	RedirectStatus int `json:"redirect_status,omitempty"`
+	// Variants are the weighted targets of a split link.
+	Variants []storage.Variant `json:"variants,omitempty"`
+	// Owner is who is accountable for the link, if it has an owner.
+	Owner string `json:"owner,omitempty"`
+	// FolderID is the folder the link is filed in, if it is in one.
+	FolderID int64 `json:"folder_id,omitempty"`
+}
+
+// Creator is the client that saved a link.
+type Creator struct {
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	Identity  string `json:"identity,omitempty"`
+}
+
+// URLInfoGetter is an interface for getting a stored link by alias.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLInfoGetter
+type URLInfoGetter interface {
+	GetURLInfo(alias string) (storage.URL, error)
+}
+
+// Options holds the optional behaviour of the info handler.
+type Options struct {
+	// FoldAliases looks aliases up case- and accent-insensitively.
+	FoldAliases bool
+	// ExpiryWarning adds expiry.Header to the info of links expiring
+	// within it. No header is added when it is zero.
+	ExpiryWarning time.Duration
+}
+
+func New(log *slog.Logger, urlInfoGetter URLInfoGetter, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.info.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("invalid request"))
+			return
+		}
+		if opts.FoldAliases {
+			alias = normalize.Alias(alias)
+		}
+		alias = namespace.Qualify(r.Context(), alias)
+
+		info, err := urlInfoGetter.GetURLInfo(alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url not found", slog.String("alias", alias))
+			resp.RenderError(w, r, http.StatusNotFound, resp.Error("not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get url info", sl.Err(err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+			return
+		}
+
+		var creator *Creator
+		if info.Creator != (storage.Creator{}) {
+			creator = &Creator{
+				IP:        info.Creator.IP,
+				UserAgent: info.Creator.UserAgent,
+				Identity:  info.Creator.Identity,
+			}
+		}
+
+		expiry.Warn(w.Header(), info.ExpiresAt, opts.ExpiryWarning, time.Now())
+
+		render.JSON(w, r, Response{
+			Response:         resp.OK(),
+			ID:               info.ID,
+			Alias:            namespace.Unqualify(r.Context(), info.Alias),
+			URL:              info.URL,
+			LastAccessedAt:   info.LastAccessedAt,
+			Sponsored:        info.Sponsored,
+			ContentHash:      info.ContentHash,
+			Audited:          info.Audited,
+			ExpiresAt:        info.ExpiresAt,
+			AllowedReferrers: info.AllowedReferrers,
+			Creator:          creator,
+			RedirectStatus:   info.RedirectStatus,
+			Variants:         info.Variants,
+			Owner:            info.Owner,
+			FolderID:         info.FolderID,
+		})
+	}
+}
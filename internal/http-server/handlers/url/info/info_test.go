@@ -0,0 +1,199 @@
+package info_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/info"
+	"url-shortener/internal/http-server/handlers/url/info/mocks"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/expiry"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestInfoHandler(t *testing.T) {
+	lastAccessed := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name       string
+		alias      string
+		info       storage.URL
+		mockError  error
+		respError  string
+		statusCode int
+	}{
+		{
+			name:  "Success",
+			alias: "test_alias",
+			info: storage.URL{
+				ID:             1,
+				Alias:          "test_alias",
+				URL:            "https://google.com",
+				LastAccessedAt: &lastAccessed,
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:  "Never accessed",
+			alias: "test_alias",
+			info: storage.URL{
+				ID:    1,
+				Alias: "test_alias",
+				URL:   "https://google.com",
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:  "With creator",
+			alias: "test_alias",
+			info: storage.URL{
+				ID:      1,
+				Alias:   "test_alias",
+				URL:     "https://google.com",
+				Creator: storage.Creator{IP: "203.0.113.7", UserAgent: "curl/8.0", Identity: "admin"},
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:  "With owner",
+			alias: "test_alias",
+			info: storage.URL{
+				ID:    1,
+				Alias: "test_alias",
+				URL:   "https://google.com",
+				Owner: "jane@example.com",
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:  "In folder",
+			alias: "test_alias",
+			info: storage.URL{
+				ID:       1,
+				Alias:    "test_alias",
+				URL:      "https://google.com",
+				FolderID: 3,
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Not found",
+			alias:      "missing",
+			mockError:  storage.ErrURLNotFound,
+			respError:  "not found",
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name:       "Storage error",
+			alias:      "test_alias",
+			mockError:  errors.New("unexpected error"),
+			respError:  "internal error",
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlInfoGetterMock := mocks.NewURLInfoGetter(t)
+			urlInfoGetterMock.On("GetURLInfo", tc.alias).Return(tc.info, tc.mockError).Once()
+
+			r := chi.NewRouter()
+			r.Get("/admin/url/{alias}", info.New(slogdiscard.NewDiscardLogger(), urlInfoGetterMock, info.Options{}))
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/url/"+tc.alias, nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp info.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+			require.Equal(t, tc.info.URL, resp.URL)
+			require.Equal(t, tc.info.Owner, resp.Owner)
+			require.Equal(t, tc.info.FolderID, resp.FolderID)
+
+			if tc.info.LastAccessedAt == nil {
+				require.Nil(t, resp.LastAccessedAt)
+			} else {
+				require.True(t, tc.info.LastAccessedAt.Equal(*resp.LastAccessedAt))
+			}
+
+			if tc.info.Creator == (storage.Creator{}) {
+				require.Nil(t, resp.Creator)
+			} else {
+				require.Equal(t, &info.Creator{
+					IP:        tc.info.Creator.IP,
+					UserAgent: tc.info.Creator.UserAgent,
+					Identity:  tc.info.Creator.Identity,
+				}, resp.Creator)
+			}
+		})
+	}
+}
+
+func TestInfoHandler_ExpiryWarning(t *testing.T) {
+	soon := time.Now().Add(10 * time.Minute)
+	later := time.Now().Add(48 * time.Hour)
+
+	urlInfoGetterMock := mocks.NewURLInfoGetter(t)
+	urlInfoGetterMock.On("GetURLInfo", "soon").Return(storage.URL{Alias: "soon", URL: "https://google.com", ExpiresAt: &soon}, nil).Once()
+	urlInfoGetterMock.On("GetURLInfo", "later").Return(storage.URL{Alias: "later", URL: "https://google.com", ExpiresAt: &later}, nil).Once()
+	urlInfoGetterMock.On("GetURLInfo", "never").Return(storage.URL{Alias: "never", URL: "https://google.com"}, nil).Once()
+
+	r := chi.NewRouter()
+	r.Get("/admin/url/{alias}", info.New(slogdiscard.NewDiscardLogger(), urlInfoGetterMock, info.Options{
+		ExpiryWarning: 24 * time.Hour,
+	}))
+
+	for alias, warned := range map[string]bool{"soon": true, "later": false, "never": false} {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/url/"+alias, nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, warned, rr.Header().Get(expiry.Header) != "", alias)
+	}
+}
+
+func TestInfoHandler_ProblemDetails(t *testing.T) {
+	urlInfoGetterMock := mocks.NewURLInfoGetter(t)
+	urlInfoGetterMock.On("GetURLInfo", "missing").Return(storage.URL{}, storage.ErrURLNotFound).Once()
+
+	r := chi.NewRouter()
+	r.Get("/admin/url/{alias}", info.New(slogdiscard.NewDiscardLogger(), urlInfoGetterMock, info.Options{}))
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/url/missing", nil)
+	req.Header.Set("Accept", "application/problem+json")
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusNotFound, rr.Code)
+	require.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+
+	var problem resp.Problem
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+
+	require.Equal(t, resp.Problem{
+		Type:     "about:blank",
+		Title:    "Not Found",
+		Status:   http.StatusNotFound,
+		Detail:   "not found",
+		Instance: "/admin/url/missing",
+	}, problem)
+}
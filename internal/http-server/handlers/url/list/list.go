@@ -0,0 +1,173 @@
+package list
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+const (
+	// DefaultLimit is the page size when the limit parameter is missing.
+	DefaultLimit = 20
+	// MaxLimit is the largest page size accepted.
+	MaxLimit = 100
+)
+
+// Count modes of the count query parameter.
+const (
+	// CountExact counts every stored link.
+	CountExact = "exact"
+	// CountApprox estimates the stored links from table statistics,
+	// which stays fast on huge tables.
+	CountApprox = "approx"
+)
+
+type Response struct {
+	resp.Response
+	URLs  []URL `json:"urls"`
+	Total int   `json:"total"`
+	// TotalApproximate reports that Total is an estimate, requested with
+	// count=approx.
+	TotalApproximate bool `json:"total_approximate"`
+	Limit            int  `json:"limit"`
+	Offset           int  `json:"offset"`
+}
+
+// URL is a listed link.
+type URL struct {
+	ID     int64  `json:"id"`
+	Alias  string `json:"alias"`
+	URL    string `json:"url"`
+	Clicks int64  `json:"clicks"`
+	// FolderID is the folder the link is filed in, zero when it has none.
+	FolderID int64 `json:"folder_id,omitempty"`
+}
+
+// URLLister is an interface for paging through the stored links.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLLister
+type URLLister interface {
+	ListURLs(limit, offset int) ([]storage.URL, error)
+	CountURLs() (int, error)
+	ListURLsInFolder(folderID int64, limit, offset int) ([]storage.URL, error)
+	CountURLsInFolder(folderID int64) (int, error)
+	EstimateURLs() (int, error)
+}
+
+// New returns a handler listing a page of the stored links in id order,
+// selected with the limit and offset query parameters. The total is
+// counted exactly unless the count parameter is CountApprox. The folder
+// parameter narrows the page to the links filed directly in that folder,
+// whose total is always exact.
+func New(log *slog.Logger, lister URLLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.list.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		limit := DefaultLimit
+		if raw := r.URL.Query().Get("limit"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 || n > MaxLimit {
+				log.Info("invalid limit", slog.String("limit", raw))
+				resp.RenderError(w, r, http.StatusBadRequest, resp.Error("limit must be between 1 and "+strconv.Itoa(MaxLimit)))
+				return
+			}
+			limit = n
+		}
+
+		var offset int
+		if raw := r.URL.Query().Get("offset"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 0 {
+				log.Info("invalid offset", slog.String("offset", raw))
+				resp.RenderError(w, r, http.StatusBadRequest, resp.Error("offset must not be negative"))
+				return
+			}
+			offset = n
+		}
+
+		count := CountExact
+		if raw := r.URL.Query().Get("count"); raw != "" {
+			if raw != CountExact && raw != CountApprox {
+				log.Info("invalid count", slog.String("count", raw))
+				resp.RenderError(w, r, http.StatusBadRequest, resp.Error("count must be "+CountExact+" or "+CountApprox))
+				return
+			}
+			count = raw
+		}
+
+		var folderID int64
+		if raw := r.URL.Query().Get("folder"); raw != "" {
+			n, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil || n <= 0 {
+				log.Info("invalid folder", slog.String("folder", raw))
+				resp.RenderError(w, r, http.StatusBadRequest, resp.Error("folder must be a positive id"))
+				return
+			}
+			folderID = n
+			// the estimate covers the whole table, not one folder
+			count = CountExact
+		}
+
+		var (
+			links []storage.URL
+			err   error
+		)
+		if folderID != 0 {
+			links, err = lister.ListURLsInFolder(folderID, limit, offset)
+		} else {
+			links, err = lister.ListURLs(limit, offset)
+		}
+		if err != nil {
+			log.Error("failed to list urls", sl.Err(err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+			return
+		}
+
+		var total int
+		switch {
+		case folderID != 0:
+			total, err = lister.CountURLsInFolder(folderID)
+		case count == CountApprox:
+			total, err = lister.EstimateURLs()
+		default:
+			total, err = lister.CountURLs()
+		}
+		if err != nil {
+			log.Error("failed to count urls", sl.Err(err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+			return
+		}
+
+		urls := make([]URL, 0, len(links))
+		for _, link := range links {
+			urls = append(urls, URL{
+				ID:       link.ID,
+				Alias:    link.Alias,
+				URL:      link.URL,
+				Clicks:   link.Clicks,
+				FolderID: link.FolderID,
+			})
+		}
+
+		render.JSON(w, r, Response{
+			Response:         resp.OK(),
+			URLs:             urls,
+			Total:            total,
+			TotalApproximate: count == CountApprox,
+			Limit:            limit,
+			Offset:           offset,
+		})
+	}
+}
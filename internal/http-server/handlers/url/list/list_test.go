@@ -0,0 +1,287 @@
+package list_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/list"
+	"url-shortener/internal/http-server/handlers/url/list/mocks"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestListHandler(t *testing.T) {
+	links := []storage.URL{
+		{ID: 1, Alias: "google", URL: "https://google.com", Clicks: 3},
+		{ID: 2, Alias: "example", URL: "https://example.com"},
+	}
+
+	cases := []struct {
+		name       string
+		query      string
+		limit      int
+		offset     int
+		links      []storage.URL
+		listError  error
+		countError error
+		respURLs   []list.URL
+		respError  string
+		statusCode int
+	}{
+		{
+			name:  "Default page",
+			limit: 20,
+			links: links,
+			respURLs: []list.URL{
+				{ID: 1, Alias: "google", URL: "https://google.com", Clicks: 3},
+				{ID: 2, Alias: "example", URL: "https://example.com"},
+			},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Custom page",
+			query:      "?limit=1&offset=1",
+			limit:      1,
+			offset:     1,
+			links:      links[1:],
+			respURLs:   []list.URL{{ID: 2, Alias: "example", URL: "https://example.com"}},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Past the end",
+			query:      "?offset=100",
+			limit:      20,
+			offset:     100,
+			respURLs:   []list.URL{},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Limit too large",
+			query:      "?limit=101",
+			respError:  "limit must be between 1 and 100",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Zero limit",
+			query:      "?limit=0",
+			respError:  "limit must be between 1 and 100",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Negative offset",
+			query:      "?offset=-1",
+			respError:  "offset must not be negative",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "List error",
+			limit:      20,
+			listError:  errors.New("connection refused"),
+			respError:  "internal error",
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name:       "Count error",
+			limit:      20,
+			links:      links,
+			countError: errors.New("connection refused"),
+			respError:  "internal error",
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			listerMock := mocks.NewURLLister(t)
+			if tc.limit != 0 {
+				listerMock.On("ListURLs", tc.limit, tc.offset).Return(tc.links, tc.listError).Once()
+				if tc.listError == nil {
+					listerMock.On("CountURLs").Return(len(links), tc.countError).Once()
+				}
+			}
+
+			handler := list.New(slogdiscard.NewDiscardLogger(), listerMock)
+
+			req, err := http.NewRequest(http.MethodGet, "/url"+tc.query, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp list.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+			require.Equal(t, tc.respURLs, resp.URLs)
+			if tc.respError == "" {
+				require.Equal(t, len(links), resp.Total)
+				require.Equal(t, tc.limit, resp.Limit)
+				require.Equal(t, tc.offset, resp.Offset)
+			}
+		})
+	}
+}
+
+func TestListHandler_Count(t *testing.T) {
+	links := []storage.URL{{ID: 1, Alias: "google", URL: "https://google.com"}}
+
+	cases := []struct {
+		name        string
+		query       string
+		method      string
+		total       int
+		approximate bool
+		respError   string
+		statusCode  int
+	}{
+		{
+			name:       "Exact by default",
+			method:     "CountURLs",
+			total:      1,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Exact",
+			query:      "?count=exact",
+			method:     "CountURLs",
+			total:      1,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:        "Approximate",
+			query:       "?count=approx",
+			method:      "EstimateURLs",
+			total:       1000,
+			approximate: true,
+			statusCode:  http.StatusOK,
+		},
+		{
+			name:       "Unknown mode",
+			query:      "?count=fast",
+			respError:  "count must be exact or approx",
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			listerMock := mocks.NewURLLister(t)
+			if tc.method != "" {
+				listerMock.On("ListURLs", list.DefaultLimit, 0).Return(links, nil).Once()
+				listerMock.On(tc.method).Return(tc.total, nil).Once()
+			}
+
+			handler := list.New(slogdiscard.NewDiscardLogger(), listerMock)
+
+			req, err := http.NewRequest(http.MethodGet, "/url"+tc.query, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp list.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+			require.Equal(t, tc.total, resp.Total)
+			require.Equal(t, tc.approximate, resp.TotalApproximate)
+		})
+	}
+}
+
+func TestListHandler_Folder(t *testing.T) {
+	links := []storage.URL{{ID: 1, Alias: "google", URL: "https://google.com", FolderID: 3}}
+
+	cases := []struct {
+		name       string
+		query      string
+		mock       bool
+		want       []list.URL
+		total      int
+		respError  string
+		statusCode int
+	}{
+		{
+			name:       "Filtered",
+			query:      "?folder=3",
+			mock:       true,
+			want:       []list.URL{{ID: 1, Alias: "google", URL: "https://google.com", FolderID: 3}},
+			total:      1,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Approximate count is exact",
+			query:      "?folder=3&count=approx",
+			mock:       true,
+			want:       []list.URL{{ID: 1, Alias: "google", URL: "https://google.com", FolderID: 3}},
+			total:      1,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Zero",
+			query:      "?folder=0",
+			respError:  "folder must be a positive id",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Not a number",
+			query:      "?folder=abc",
+			respError:  "folder must be a positive id",
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			listerMock := mocks.NewURLLister(t)
+			if tc.mock {
+				listerMock.On("ListURLsInFolder", int64(3), list.DefaultLimit, 0).Return(links, nil).Once()
+				listerMock.On("CountURLsInFolder", int64(3)).Return(tc.total, nil).Once()
+			}
+
+			handler := list.New(slogdiscard.NewDiscardLogger(), listerMock)
+
+			req, err := http.NewRequest(http.MethodGet, "/url"+tc.query, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp list.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+			require.Equal(t, tc.total, resp.Total)
+			require.False(t, resp.TotalApproximate)
+			if tc.respError == "" {
+				require.Equal(t, tc.want, resp.URLs)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package qr
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"url-shortener/internal/http-server/handlers/url/save"
+	"url-shortener/internal/http-server/middleware/namespace"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/normalize"
+	libqr "url-shortener/internal/lib/qr"
+	"url-shortener/internal/storage"
+)
+
+// URLGetter is an interface for getting a stored link by alias.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLGetter
+type URLGetter interface {
+	GetURLInfo(alias string) (storage.URL, error)
+}
+
+// Options holds the optional behaviour of the qr handler.
+type Options struct {
+	// FoldAliases looks aliases up case- and accent-insensitively.
+	FoldAliases bool
+	// BaseURL prefixes the alias in the encoded short URL, as in save
+	// responses.
+	BaseURL string
+}
+
+// New returns a handler serving a PNG QR code of the short URL of an
+// alias, size pixels wide with the size query parameter clamped between
+// libqr.MinSize and libqr.MaxSize.
+func New(log *slog.Logger, urlGetter URLGetter, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.qr.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("invalid request"))
+			return
+		}
+		if opts.FoldAliases {
+			alias = normalize.Alias(alias)
+		}
+		alias = namespace.Qualify(r.Context(), alias)
+
+		size := libqr.DefaultSize
+		if raw := r.URL.Query().Get("size"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil {
+				log.Info("invalid size", slog.String("size", raw))
+				resp.RenderError(w, r, http.StatusBadRequest, resp.Error("size must be a number"))
+				return
+			}
+			size = libqr.ClampSize(n)
+		}
+
+		link, err := urlGetter.GetURLInfo(alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url not found", slog.String("alias", alias))
+			resp.RenderError(w, r, http.StatusNotFound, resp.Error("not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get url", sl.Err(err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+			return
+		}
+
+		png, err := libqr.Generate(save.ShortURL(r, opts.BaseURL, namespace.Unqualify(r.Context(), link.Alias)), size)
+		if err != nil {
+			log.Error("failed to generate qr code", sl.Err(err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+			return
+		}
+
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Content-Length", strconv.Itoa(len(png)))
+		if _, err := w.Write(png); err != nil {
+			log.Error("failed to write qr code", sl.Err(err))
+		}
+	}
+}
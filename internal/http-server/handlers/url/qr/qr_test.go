@@ -0,0 +1,122 @@
+package qr_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/qr"
+	"url-shortener/internal/http-server/handlers/url/qr/mocks"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestQRHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		alias      string
+		query      string
+		mockError  error
+		noLookup   bool
+		size       int
+		respError  string
+		statusCode int
+	}{
+		{
+			name:       "Default size",
+			alias:      "test_alias",
+			size:       256,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Custom size",
+			alias:      "test_alias",
+			query:      "?size=300",
+			size:       300,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Size clamped up",
+			alias:      "test_alias",
+			query:      "?size=10",
+			size:       64,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Size clamped down",
+			alias:      "test_alias",
+			query:      "?size=5000",
+			size:       1024,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Invalid size",
+			alias:      "test_alias",
+			query:      "?size=big",
+			noLookup:   true,
+			respError:  "size must be a number",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Not found",
+			alias:      "missing",
+			mockError:  storage.ErrURLNotFound,
+			respError:  "not found",
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name:       "Storage error",
+			alias:      "test_alias",
+			mockError:  errors.New("unexpected error"),
+			respError:  "internal error",
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			if !tc.noLookup {
+				urlGetterMock.On("GetURLInfo", tc.alias).
+					Return(storage.URL{Alias: tc.alias, URL: "https://google.com"}, tc.mockError).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/url/{alias}/qr", qr.New(slogdiscard.NewDiscardLogger(), urlGetterMock, qr.Options{
+				BaseURL: "https://sho.rt",
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/url/"+tc.alias+"/qr"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			if tc.respError != "" {
+				var res resp.Response
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+				require.Equal(t, tc.respError, res.Error)
+				return
+			}
+
+			require.Equal(t, "image/png", rr.Header().Get("Content-Type"))
+			require.NotEmpty(t, rr.Body.Bytes())
+
+			img, err := png.Decode(bytes.NewReader(rr.Body.Bytes()))
+			require.NoError(t, err)
+			require.Equal(t, tc.size, img.Bounds().Dx())
+		})
+	}
+}
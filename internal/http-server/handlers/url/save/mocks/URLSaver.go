@@ -2,30 +2,36 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	storage "url-shortener/internal/storage"
+)
 
 // URLSaver is an autogenerated mock type for the URLSaver type
 type URLSaver struct {
 	mock.Mock
 }
 
-// SaveURL provides a mock function with given fields: urlToSave, alias
-func (_m *URLSaver) SaveURL(urlToSave string, alias string) (int64, error) {
-	ret := _m.Called(urlToSave, alias)
+// ClaimURLContext provides a mock function with given fields: ctx, urlToSave, alias, opts
+func (_m *URLSaver) ClaimURLContext(ctx context.Context, urlToSave string, alias string, opts storage.SaveOptions) (storage.Claim, error) {
+	ret := _m.Called(ctx, urlToSave, alias, opts)
 
-	var r0 int64
+	var r0 storage.Claim
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string) (int64, error)); ok {
-		return rf(urlToSave, alias)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, storage.SaveOptions) (storage.Claim, error)); ok {
+		return rf(ctx, urlToSave, alias, opts)
 	}
-	if rf, ok := ret.Get(0).(func(string, string) int64); ok {
-		r0 = rf(urlToSave, alias)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, storage.SaveOptions) storage.Claim); ok {
+		r0 = rf(ctx, urlToSave, alias, opts)
 	} else {
-		r0 = ret.Get(0).(int64)
+		r0 = ret.Get(0).(storage.Claim)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(urlToSave, alias)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, storage.SaveOptions) error); ok {
+		r1 = rf(ctx, urlToSave, alias, opts)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -2,45 +2,253 @@ package save
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator/v10"
+	"golang.org/x/sync/singleflight"
 
+	"url-shortener/internal/cache"
+	"url-shortener/internal/http-server/middleware/apikey"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/http-server/middleware/namespace"
+	"url-shortener/internal/http-server/middleware/ratelimit"
 	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/generator"
 	"url-shortener/internal/lib/logger/sl"
-	"url-shortener/internal/lib/random"
+	"url-shortener/internal/lib/normalize"
+	"url-shortener/internal/lib/password"
+	"url-shortener/internal/lib/signing"
+	"url-shortener/internal/lib/urlnorm"
+	"url-shortener/internal/lib/validate"
 	"url-shortener/internal/storage"
 )
 
 type Request struct {
-	URL   string `json:"url" validate:"required,url"`
-	Alias string `json:"alias,omitempty"`
+	URL       string `json:"url" validate:"required,url"`
+	Alias     string `json:"alias,omitempty"`
+	Signed    bool   `json:"signed,omitempty"`
+	Sponsored bool   `json:"sponsored,omitempty"`
+	// Audited reports every redirect of the link to the audit webhook.
+	Audited bool `json:"audited,omitempty"`
+	// TTL is the lifetime of the link in seconds, it never expires when zero.
+	// It is capped at the longest time.Duration, math.MaxInt64 nanoseconds.
+	TTL int64 `json:"ttl,omitempty" validate:"omitempty,min=1,max=9223372036"`
+	// ExpiresAt is when the link stops resolving, an alternative to TTL.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// AllowedReferrers restricts the Referer domains the link may be used from.
+	AllowedReferrers []string `json:"allowed_referrers,omitempty" validate:"omitempty,max=32,dive,hostname_rfc1123"`
+	// Password protects the link, clients must send it in X-Link-Password.
+	Password string `json:"password,omitempty" validate:"omitempty,max=72"`
+	// ExternalID is a reference of the client, e.g. a campaign or order
+	// id, the link can be looked up by.
+	ExternalID string `json:"external_id,omitempty" validate:"omitempty,max=128,excludes=~"`
+	// RedirectStatus overrides the configured redirect status code of the
+	// link, e.g. 301 to redirect permanently.
+	RedirectStatus int `json:"redirect_status,omitempty" validate:"omitempty,oneof=301 302 307 308"`
+	// Variants split the redirects of the link between weighted targets,
+	// URL is then only the fallback shown in listings.
+	Variants []Variant `json:"variants,omitempty" validate:"omitempty,min=2,max=10,dive"`
+	// Owner is the email of who is accountable for the link, it is read
+	// from OwnerHeader when empty.
+	Owner string `json:"owner,omitempty"`
+	// FolderID files the link in a folder.
+	FolderID int64 `json:"folder_id,omitempty" validate:"omitempty,min=1"`
+}
+
+// Variant is a weighted target of a split link.
+type Variant struct {
+	URL    string `json:"url" validate:"required,url"`
+	Weight int    `json:"weight" validate:"required,min=1,max=1000"`
+}
+
+// LogValue keeps the link password out of the logs.
+func (r Request) LogValue() slog.Value {
+	if r.Password != "" {
+		r.Password = "REDACTED"
+	}
+
+	type plain Request
+
+	return slog.AnyValue(plain(r))
 }
 
 type Response struct {
 	resp.Response
 	Alias string `json:"alias,omitempty"`
+	// ShortURL is the full link to the alias, e.g. https://sho.rt/abc123.
+	ShortURL string `json:"short_url,omitempty"`
+	// ID is the numeric handle of the link, resolvable on /i/{id}.
+	ID int64 `json:"id,omitempty"`
 }
 
-// TODO: move to config if needed
-const aliasLength = 6
+// OwnerHeader carries the owner of links saved without one in the body.
+const OwnerHeader = "X-Link-Owner"
+
+// AliasLength is the default length of random aliases.
+const AliasLength = 6
+
+// DefaultSaveAttempts is the number of generated aliases tried when
+// Options.SaveAttempts is zero.
+const DefaultSaveAttempts = 3
+
+// maxUserAgentLength caps the recorded user agent of link creators.
+const maxUserAgentLength = 512
+
+// maxExistingLinks bounds the links to a target looked through for one
+// whose alias can be reused.
+const maxExistingLinks = 10
 
+// maxGenerateAttempts bounds the aliases drawn while Options.Aliases
+// reports them taken.
+const maxGenerateAttempts = 3
+
+// URLSaver is an interface for claiming an alias for a new link. A taken
+// alias is reported in the claim, in the same round-trip as the insert.
+//
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLSaver
 type URLSaver interface {
-	SaveURL(urlToSave string, alias string) (int64, error)
+	ClaimURLContext(ctx context.Context, urlToSave string, alias string, opts storage.SaveOptions) (storage.Claim, error)
+}
+
+// URLFinder is an interface for finding the links saved for a target.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLFinder
+type URLFinder interface {
+	GetURLsByTarget(ctx context.Context, target string, limit int) ([]storage.URL, error)
+}
+
+// FolderGetter is an interface for looking up the folder a link is
+// filed in.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=FolderGetter
+type FolderGetter interface {
+	GetFolder(id int64) (storage.Folder, error)
+}
+
+// Fingerprinter hashes the content served at a link target.
+type Fingerprinter interface {
+	Fingerprint(ctx context.Context, target string) (string, error)
 }
 
 type URLCache interface {
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
 }
 
-func New(log *slog.Logger, urlSaver URLSaver, urlCache URLCache) http.HandlerFunc {
+// AliasSet is an interface for a probabilistic set of the stored aliases,
+// such as a Bloom filter.
+type AliasSet interface {
+	MayContain(alias string) bool
+	Add(alias string)
+}
+
+// Options holds the optional behaviour of the save handler.
+type Options struct {
+	// FoldAliases stores aliases case- and accent-insensitively.
+	FoldAliases bool
+	// Signer signs aliases of links saved with "signed": true.
+	// Signed links are rejected when it is nil.
+	Signer *signing.Signer
+	// MinUserAliasLength reserves shorter custom aliases for admins.
+	MinUserAliasLength int
+	// AllowedSchemes are the accepted target URL schemes,
+	// validate.DefaultSchemes when empty.
+	AllowedSchemes []string
+	// Sponsored allows links to opt into the ad interstitial.
+	Sponsored bool
+	// SaveAttempts is the number of generated aliases tried while storage
+	// reports them taken, DefaultSaveAttempts when zero.
+	SaveAttempts int
+	// Generator generates aliases for links saved without one,
+	// random aliases of AliasLength when nil. Generators implementing
+	// generator.CollisionObserver learn whether their aliases were taken.
+	Generator generator.Generator
+	// RejectURLAliases rejects custom aliases that are URLs themselves.
+	RejectURLAliases bool
+	// ReturnIDs includes the numeric id of new links in the response.
+	ReturnIDs bool
+	// Deduplicate collapses identical concurrent requests into a single
+	// save whose result they all share.
+	Deduplicate bool
+	// Fingerprinter fetches targets once to store a hash of their content.
+	// Targets are not fetched when it is nil.
+	Fingerprinter Fingerprinter
+	// Audit allows links to opt into the audit webhook.
+	Audit bool
+	// Passwords allows links to be password protected.
+	Passwords bool
+	// ReferrerAllowlists allows links to restrict the referrers they may
+	// be used from.
+	ReferrerAllowlists bool
+	// ReservedAliases are custom aliases refused because they would shadow
+	// routes of the service.
+	ReservedAliases []string
+	// CanonicalQuery sorts the query parameters of targets before they are
+	// stored, so reordered duplicates are deduplicated and fingerprinted alike.
+	CanonicalQuery bool
+	// BaseURL prefixes aliases in the short_url of responses, e.g.
+	// https://sho.rt. The scheme and Host of the request are used when empty.
+	BaseURL string
+	// CacheTTL is how long new links are cached, cache.DefaultTTL when zero.
+	CacheTTL time.Duration
+	// Aliases learns every saved alias. Generated aliases it reports taken
+	// are drawn again, sparing a failed insert.
+	Aliases AliasSet
+	// RecordCreator stores the IP, user agent and admin or API key identity
+	// of the client saving a link.
+	RecordCreator bool
+	// QueryTimeout bounds the storage query saving a link. Queries run
+	// as long as the request when it is zero.
+	QueryTimeout time.Duration
+	// ExternalIDs allows links to be saved under an external id.
+	ExternalIDs bool
+	// ExternalIDsPerCreator scopes external ids to the admin or API key
+	// saving them, rather than to the whole namespace.
+	ExternalIDsPerCreator bool
+	// SplitTargets allows links to be split between weighted variants.
+	SplitTargets bool
+	// NormalizeURLs saves targets in the form of urlnorm.Normalize, so
+	// that equivalent targets are stored alike.
+	NormalizeURLs bool
+	// Existing finds the links saved for a target. Plain links saved
+	// without a custom alias reuse the alias of a plain link to the same
+	// target instead of creating another. Nothing is reused when nil.
+	Existing URLFinder
+	// RequireOwner rejects links saved without an owner.
+	RequireOwner bool
+	// Folders looks up the folders links are filed in. Links are saved
+	// without a folder when it is nil.
+	Folders FolderGetter
+}
+
+func New(log *slog.Logger, urlSaver URLSaver, urlCache URLCache, opts Options) http.HandlerFunc {
+	if opts.Generator == nil {
+		opts.Generator = generator.Random{Length: AliasLength}
+	}
+	if opts.SaveAttempts <= 0 {
+		opts.SaveAttempts = DefaultSaveAttempts
+	}
+	if opts.CacheTTL == 0 {
+		opts.CacheTTL = cache.DefaultTTL
+	}
+
+	var group *singleflight.Group
+	if opts.Deduplicate {
+		group = &singleflight.Group{}
+	}
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.url.save.New"
 
@@ -54,14 +262,12 @@ func New(log *slog.Logger, urlSaver URLSaver, urlCache URLCache) http.HandlerFun
 		err := render.DecodeJSON(r.Body, &req)
 		if errors.Is(err, io.EOF) {
 			log.Error("request body is empty")
-			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, resp.Error("empty request"))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("empty request"))
 			return
 		}
 		if err != nil {
 			log.Error("failed to decode request body", sl.Err(err))
-			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, resp.Error("failed to decode request"))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("failed to decode request"))
 			return
 		}
 
@@ -70,44 +276,535 @@ func New(log *slog.Logger, urlSaver URLSaver, urlCache URLCache) http.HandlerFun
 		if err := validator.New().Struct(req); err != nil {
 			validateErr := err.(validator.ValidationErrors)
 			log.Error("invalid request", sl.Err(err))
-			render.Status(r, http.StatusBadRequest)
-			render.JSON(w, r, resp.ValidationError(validateErr))
+			// for diagnosing client integrations
+			for _, fe := range validateErr {
+				log.Debug("request field failed validation", sl.Validation(fe))
+			}
+			resp.RenderError(w, r, http.StatusBadRequest, resp.ValidationError(validateErr))
 			return
 		}
 
-		alias := req.Alias
-		if alias == "" {
-			alias = random.NewRandomString(aliasLength)
+		if err := validate.URL(req.URL, opts.AllowedSchemes); err != nil {
+			log.Error("invalid url", sl.Err(err))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error(err.Error()))
+			return
 		}
 
-		id, err := urlSaver.SaveURL(req.URL, alias)
-		if errors.Is(err, storage.ErrURLExists) {
-			log.Info("url already exists", slog.String("url", req.URL))
-			render.Status(r, http.StatusConflict)
-			render.JSON(w, r, resp.Error("url already exists"))
+		if req.Owner == "" {
+			req.Owner = r.Header.Get(OwnerHeader)
+		}
+		if err := validate.Owner(req.Owner, opts.RequireOwner); err != nil {
+			log.Info("invalid owner", sl.Err(err))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error(err.Error()))
 			return
 		}
-		if err != nil {
-			log.Error("failed to add url", sl.Err(err))
-			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("failed to add url"))
+
+		if len(req.Variants) > 0 && !opts.SplitTargets {
+			log.Info("split targets are disabled")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("split targets are not enabled"))
 			return
 		}
 
-		log.Info("url added", slog.Int64("id", id))
+		for _, v := range req.Variants {
+			if err := validate.URL(v.URL, opts.AllowedSchemes); err != nil {
+				log.Error("invalid variant url", sl.Err(err))
+				resp.RenderError(w, r, http.StatusBadRequest, resp.Error(err.Error()))
+				return
+			}
+		}
+
+		req.URL = urlnorm.Target(req.URL, opts.NormalizeURLs, opts.CanonicalQuery)
+		for i := range req.Variants {
+			req.Variants[i].URL = urlnorm.Target(req.Variants[i].URL, opts.NormalizeURLs, opts.CanonicalQuery)
+		}
+
+		if req.ExpiresAt != nil {
+			if req.TTL > 0 {
+				log.Info("both ttl and expires_at are set")
+				resp.RenderError(w, r, http.StatusBadRequest, resp.Error("ttl and expires_at are mutually exclusive"))
+				return
+			}
+			if !req.ExpiresAt.After(time.Now()) {
+				log.Info("expires_at is in the past", slog.Time("expires_at", *req.ExpiresAt))
+				resp.RenderError(w, r, http.StatusBadRequest, resp.Error("expires_at must be in the future"))
+				return
+			}
+		}
+
+		if opts.RejectURLAliases && req.Alias != "" {
+			if err := validate.AliasNotURL(req.Alias); err != nil {
+				log.Info("alias is a url", slog.String("alias", req.Alias))
+				resp.RenderError(w, r, http.StatusBadRequest, resp.Error(err.Error()))
+				return
+			}
+		}
+
+		if req.Alias != "" && utf8.RuneCountInString(req.Alias) < opts.MinUserAliasLength && !auth.IsAdmin(r.Context()) {
+			log.Info("alias is too short", slog.String("alias", req.Alias))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error(fmt.Sprintf("alias must be at least %d characters", opts.MinUserAliasLength)))
+			return
+		}
+
+		if req.Sponsored && !opts.Sponsored {
+			log.Info("sponsored links are disabled")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("sponsored links are not enabled"))
+			return
+		}
+
+		if req.Audited && !opts.Audit {
+			log.Info("audited links are disabled")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("audited links are not enabled"))
+			return
+		}
+
+		if req.Password != "" && !opts.Passwords {
+			log.Info("password protected links are disabled")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("password protected links are not enabled"))
+			return
+		}
+
+		if req.ExternalID != "" && !opts.ExternalIDs {
+			log.Info("external ids are disabled")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("external ids are not enabled"))
+			return
+		}
+
+		if len(req.AllowedReferrers) > 0 && !opts.ReferrerAllowlists {
+			log.Info("referrer allowlists are disabled")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("referrer allowlists are not enabled"))
+			return
+		}
+
+		if req.Signed && opts.Signer == nil {
+			log.Info("signed links are disabled")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("signed links are not enabled"))
+			return
+		}
+
+		// signed aliases are recognised by the separator on redirect
+		if opts.Signer != nil && signing.IsSigned(req.Alias) {
+			log.Info("alias contains signature separator", slog.String("alias", req.Alias))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("alias must not contain "+signing.Separator))
+			return
+		}
+
+		if req.Alias != "" {
+			// aliases are checked the way they will be stored
+			alias := req.Alias
+			if opts.FoldAliases {
+				alias = normalize.Alias(alias)
+			}
+
+			if err := validate.Alias(alias, opts.ReservedAliases); err != nil {
+				log.Info("invalid alias", slog.String("alias", req.Alias), sl.Err(err))
+				resp.RenderError(w, r, http.StatusBadRequest, resp.Error(err.Error()))
+				return
+			}
+		}
+
+		if req.FolderID != 0 {
+			if opts.Folders == nil {
+				log.Info("folders are disabled")
+				resp.RenderError(w, r, http.StatusBadRequest, resp.Error("folders are not enabled"))
+				return
+			}
+
+			_, err := opts.Folders.GetFolder(req.FolderID)
+			if errors.Is(err, storage.ErrFolderNotFound) {
+				log.Info("folder not found", slog.Int64("folder_id", req.FolderID))
+				resp.RenderError(w, r, http.StatusBadRequest, resp.Error("folder not found"))
+				return
+			}
+			if err != nil {
+				log.Error("failed to get folder", sl.Err(err))
+				resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("failed to add url"))
+				return
+			}
+		}
+
+		if opts.Existing != nil && req.Alias == "" && isPlain(req) {
+			if link, ok := existingLink(r.Context(), log, opts, req.URL, req.Owner); ok {
+				alias := namespace.Unqualify(r.Context(), link.Alias)
+				log.Info("existing alias reused", slog.String("alias", alias))
+
+				id := link.ID
+				if !opts.ReturnIDs {
+					id = 0
+				}
+
+				responseOK(w, r, alias, ShortURL(r, opts.BaseURL, alias), id)
+				return
+			}
+		}
+
+		var creator storage.Creator
+		if opts.RecordCreator {
+			creator = creatorOf(r)
+		}
+
+		var externalKey string
+		if req.ExternalID != "" {
+			externalKey = ExternalKey(r, req.ExternalID, opts.ExternalIDsPerCreator)
+		}
+
+		var (
+			res    created
+			shared bool
+		)
+		if group != nil {
+			// the first request's cancellation must not fail the others
+			var v any
+			key := requestKey(r, req, creator, externalKey)
+			v, err, shared = group.Do(key, func() (any, error) {
+				return create(context.WithoutCancel(r.Context()), log, urlSaver, urlCache, req, creator, externalKey, opts)
+			})
+			res, _ = v.(created)
+		} else {
+			res, err = create(r.Context(), log, urlSaver, urlCache, req, creator, externalKey, opts)
+		}
+
+		var createErr *createError
+		if errors.As(err, &createErr) {
+			resp.RenderError(w, r, createErr.status, resp.Error(createErr.msg))
+			return
+		}
+		if shared {
+			log.Info("identical concurrent save collapsed", slog.String("alias", res.alias))
+		}
+
+		id := res.id
+		if !opts.ReturnIDs {
+			id = 0
+		}
+
+		responseOK(w, r, res.alias, ShortURL(r, opts.BaseURL, res.alias), id)
+	}
+}
+
+// created is a link saved by create.
+type created struct {
+	alias string
+	id    int64
+}
+
+// createError is a failed save, rendered with status and msg.
+type createError struct {
+	status int
+	msg    string
+}
+
+func (e *createError) Error() string {
+	return e.msg
+}
+
+// requestKey identifies identical save requests. Requests of different
+// clients or domains save different links even with the same body, so
+// the namespace, identity, recorded creator and external id scope are
+// part of the key, as is the owner resolved into req.
+func requestKey(r *http.Request, req Request, creator storage.Creator, externalKey string) string {
+	b, _ := json.Marshal(struct {
+		Namespace   string
+		Identity    string
+		Creator     storage.Creator
+		ExternalKey string
+		Request     Request
+	}{
+		Namespace:   namespace.FromContext(r.Context()),
+		Identity:    identityOf(r),
+		Creator:     creator,
+		ExternalKey: externalKey,
+		Request:     req,
+	})
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// isPlain reports whether req saves a link without any of the options
+// that set links apart, which is all a reused link can offer.
+func isPlain(req Request) bool {
+	return !req.Signed && !req.Sponsored && !req.Audited && req.TTL == 0 && req.ExpiresAt == nil &&
+		len(req.AllowedReferrers) == 0 && req.Password == "" && req.ExternalID == "" &&
+		req.RedirectStatus == 0 && len(req.Variants) == 0 && req.FolderID == 0
+}
+
+// existingLink returns a plain link to target of owner in the namespace
+// of ctx whose alias can be reused. Lookup failures are logged and reported as
+// no link, the save then creates one.
+func existingLink(ctx context.Context, log *slog.Logger, opts Options, target, owner string) (storage.URL, bool) {
+	queryCtx, cancel := storage.WithTimeout(ctx, opts.QueryTimeout)
+	defer cancel()
+
+	links, err := opts.Existing.GetURLsByTarget(queryCtx, target, maxExistingLinks)
+	if err != nil {
+		log.Error("failed to find existing links", sl.Err(err))
+		return storage.URL{}, false
+	}
+
+	for _, link := range links {
+		if !namespace.Holds(ctx, link.Alias) {
+			continue
+		}
+		alias := namespace.Unqualify(ctx, link.Alias)
+
+		if link.Sponsored || link.Audited || link.ExpiresAt != nil || len(link.AllowedReferrers) > 0 ||
+			link.PasswordHash != "" || link.ExternalID != "" || link.RedirectStatus != 0 ||
+			len(link.Variants) > 0 || link.FolderID != 0 || link.Owner != owner || signing.IsSigned(alias) {
+			continue
+		}
+
+		return link, true
+	}
+
+	return storage.URL{}, false
+}
+
+// creatorOf returns the client that sent r.
+func creatorOf(r *http.Request) storage.Creator {
+	creator := storage.Creator{
+		IP:        ratelimit.ClientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+	if len(creator.UserAgent) > maxUserAgentLength {
+		creator.UserAgent = strings.ToValidUTF8(creator.UserAgent[:maxUserAgentLength], "")
+	}
 
-		// Set to cache
-		if err := urlCache.Set(r.Context(), alias, req.URL, 5*time.Minute); err != nil {
-			log.Error("failed to set url to cache", sl.Err(err))
+	creator.Identity = identityOf(r)
+
+	return creator
+}
+
+// identityOf returns "admin" or the hash of the API key r was sent
+// with, or an empty string for anonymous clients.
+func identityOf(r *http.Request) string {
+	if auth.IsAdmin(r.Context()) {
+		return "admin"
+	}
+	if hash, ok := apikey.KeyHash(r); ok {
+		return "key:" + hash
+	}
+
+	return ""
+}
+
+// ExternalKey returns the external id as stored for the link saved or
+// looked up by r: scoped to the namespace of r, and to the identity of
+// its client when perCreator is set.
+func ExternalKey(r *http.Request, id string, perCreator bool) string {
+	if perCreator {
+		id = identityOf(r) + namespace.Separator + id
+	}
+
+	return namespace.Qualify(r.Context(), id)
+}
+
+// create saves the link of a validated request and caches it.
+func create(ctx context.Context, log *slog.Logger, urlSaver URLSaver, urlCache URLCache, req Request, creator storage.Creator, externalKey string, opts Options) (created, error) {
+	var err error
+
+	var passwordHash string
+	if req.Password != "" {
+		passwordHash, err = password.Hash(req.Password)
+		if err != nil {
+			log.Error("failed to hash password", sl.Err(err))
+			return created{}, &createError{http.StatusInternalServerError, "failed to add url"}
 		}
+	}
+
+	expiresAt := req.ExpiresAt
+	if req.TTL > 0 {
+		t := time.Now().Add(time.Duration(req.TTL) * time.Second)
+		expiresAt = &t
+	}
+
+	// referrers are matched case-insensitively
+	var allowedReferrers []string
+	for _, domain := range req.AllowedReferrers {
+		allowedReferrers = append(allowedReferrers, strings.ToLower(domain))
+	}
+
+	var contentHash string
+	if opts.Fingerprinter != nil {
+		// an unreachable target is no reason to refuse the link
+		contentHash, err = opts.Fingerprinter.Fingerprint(ctx, req.URL)
+		if err != nil {
+			log.Warn("failed to fingerprint url", slog.String("url", req.URL), sl.Err(err))
+		}
+	}
 
-		responseOK(w, r, alias)
+	// a generated alias the client didn't pick is drawn again when taken,
+	// a custom one is tried once
+	attempts := 1
+	if req.Alias == "" {
+		attempts = opts.SaveAttempts
 	}
+
+	var (
+		alias string
+		claim storage.Claim
+	)
+	for attempt := 1; ; attempt++ {
+		alias = req.Alias
+		if alias == "" {
+			alias, err = generate(ctx, opts)
+			if err != nil {
+				log.Error("failed to generate alias", sl.Err(err))
+				return created{}, &createError{http.StatusInternalServerError, "failed to add url"}
+			}
+		}
+		if opts.FoldAliases {
+			alias = normalize.Alias(alias)
+		}
+		if req.Signed {
+			alias = opts.Signer.Sign(alias)
+		}
+		stored := namespace.Qualify(ctx, alias)
+
+		queryCtx, cancel := storage.WithTimeout(ctx, opts.QueryTimeout)
+		claim, err = urlSaver.ClaimURLContext(queryCtx, req.URL, stored, storage.SaveOptions{
+			Sponsored:        req.Sponsored,
+			PasswordHash:     passwordHash,
+			ContentHash:      contentHash,
+			Audited:          req.Audited,
+			ExpiresAt:        expiresAt,
+			AllowedReferrers: allowedReferrers,
+			Creator:          creator,
+			ExternalID:       externalKey,
+			RedirectStatus:   req.RedirectStatus,
+			Variants:         variants(req.Variants),
+			Owner:            req.Owner,
+			FolderID:         req.FolderID,
+		})
+		cancel()
+		if observer, ok := opts.Generator.(generator.CollisionObserver); ok && req.Alias == "" && err == nil {
+			observer.ObserveCollision(ctx, !claim.Created)
+		}
+		if req.Alias != "" || err != nil || claim.Created {
+			break
+		}
+		if attempt == attempts {
+			log.Error("generated aliases are taken", slog.Int("attempts", attempts))
+			return created{}, &createError{http.StatusInternalServerError, "failed to add url"}
+		}
+
+		log.Info("generated alias is taken, retrying", slog.String("alias", alias))
+	}
+	if err == nil && !claim.Created {
+		log.Info("url already exists", slog.String("url", req.URL), slog.String("existing_url", claim.URL))
+		return created{}, &createError{http.StatusConflict, "url already exists"}
+	}
+	if errors.Is(err, storage.ErrFolderNotFound) {
+		// the folder was deleted since it was looked up
+		log.Info("folder not found", slog.Int64("folder_id", req.FolderID))
+		return created{}, &createError{http.StatusBadRequest, "folder not found"}
+	}
+	if errors.Is(err, storage.ErrExternalIDExists) {
+		log.Info("external id already exists", slog.String("external_id", req.ExternalID))
+		return created{}, &createError{http.StatusConflict, "external id already exists"}
+	}
+	if storage.Unavailable(err) {
+		log.Error("storage did not answer in time", sl.Err(err))
+		return created{}, &createError{http.StatusServiceUnavailable, "storage unavailable"}
+	}
+	if err != nil {
+		log.Error("failed to add url", sl.Err(err))
+		return created{}, &createError{http.StatusInternalServerError, "failed to add url"}
+	}
+
+	log.Info("url added", slog.Int64("id", claim.ID))
+
+	if opts.Aliases != nil {
+		opts.Aliases.Add(namespace.Qualify(ctx, alias))
+	}
+
+	// Set to cache, sponsored links must go through the interstitial,
+	// protected links through the password check, audited links through
+	// the webhook, restricted links through the referrer check and split
+	// links must draw a variant per request
+	if !req.Sponsored && req.Password == "" && !req.Audited && len(allowedReferrers) == 0 && len(req.Variants) == 0 {
+		// the entry must not outlive the link
+		entry := cache.Entry{URL: req.URL, Code: req.RedirectStatus, Enabled: true, ExpiresAt: expiresAt}
+		if ttl, ok := entry.CapTTL(opts.CacheTTL, time.Now()); ok {
+			if err := urlCache.Set(ctx, namespace.Qualify(ctx, alias), entry, ttl); err != nil {
+				log.Error("failed to set url to cache", sl.Err(err))
+			}
+		}
+	}
+
+	return created{alias: alias, id: claim.ID}, nil
 }
 
-func responseOK(w http.ResponseWriter, r *http.Request, alias string) {
+// generate generates an alias, drawing again while opts.Aliases reports
+// it taken. The filter has false positives, so the last draw is kept
+// either way and storage has the final say.
+func generate(ctx context.Context, opts Options) (string, error) {
+	var alias string
+	for attempt := 0; attempt < maxGenerateAttempts; attempt++ {
+		var err error
+		alias, err = opts.Generator.Generate(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		if opts.Aliases == nil {
+			break
+		}
+		// the filter holds aliases the way they are stored
+		stored := alias
+		if opts.FoldAliases {
+			stored = normalize.Alias(stored)
+		}
+		if !opts.Aliases.MayContain(namespace.Qualify(ctx, stored)) {
+			break
+		}
+	}
+
+	return alias, nil
+}
+
+// ShortURL returns the full link to alias under baseURL, or under the
+// address the request was made to. Behind a reverse proxy the scheme
+// comes from X-Forwarded-Proto, if it is http or https.
+func ShortURL(r *http.Request, baseURL, alias string) string {
+	if baseURL == "" {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+			// proxies chained behind each other append theirs
+			proto, _, _ = strings.Cut(proto, ",")
+			// clients may send the header too, any other scheme is theirs
+			if proto = strings.ToLower(strings.TrimSpace(proto)); proto == "http" || proto == "https" {
+				scheme = proto
+			}
+		}
+
+		baseURL = scheme + "://" + r.Host
+	}
+
+	return strings.TrimRight(baseURL, "/") + "/" + url.PathEscape(alias)
+}
+
+func responseOK(w http.ResponseWriter, r *http.Request, alias, shortURL string, id int64) {
 	render.JSON(w, r, Response{
 		Response: resp.OK(),
 		Alias:    alias,
+		ShortURL: shortURL,
+		ID:       id,
 	})
 }
+
+// variants converts the requested variants for storage.
+func variants(reqVariants []Variant) []storage.Variant {
+	if len(reqVariants) == 0 {
+		return nil
+	}
+
+	out := make([]storage.Variant, len(reqVariants))
+	for i, v := range reqVariants {
+		out[i] = storage.Variant{URL: v.URL, Weight: v.Weight}
+	}
+
+	return out
+}
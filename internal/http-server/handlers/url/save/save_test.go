@@ -2,23 +2,44 @@ package save_test
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"url-shortener/internal/cache"
 	"url-shortener/internal/http-server/handlers/url/save"
 	"url-shortener/internal/http-server/handlers/url/save/mocks"
+	mwAPIKey "url-shortener/internal/http-server/middleware/apikey"
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/apikey"
+	"url-shortener/internal/lib/bloom"
+	"url-shortener/internal/lib/fingerprint"
+	"url-shortener/internal/lib/generator"
 	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/lib/password"
+	"url-shortener/internal/lib/signing"
 	"url-shortener/internal/storage"
 )
 
+// cacheTTL is the configured cache TTL, unlike cache.DefaultTTL.
+const cacheTTL = 10 * time.Minute
+
 func TestSaveHandler(t *testing.T) {
 	cases := []struct {
 		name       string
@@ -54,6 +75,13 @@ func TestSaveHandler(t *testing.T) {
 			respError:  "field URL is not a valid URL",
 			statusCode: http.StatusBadRequest,
 		},
+		{
+			name:       "Invalid scheme",
+			url:        "ftp://example.com/file",
+			alias:      "some_alias",
+			respError:  "url scheme is not allowed",
+			statusCode: http.StatusBadRequest,
+		},
 		{
 			name:       "SaveURL Error",
 			alias:      "test_alias",
@@ -82,18 +110,18 @@ func TestSaveHandler(t *testing.T) {
 			urlCacheMock := mocks.NewURLCache(t)
 
 			if tc.respError == "" || tc.mockError != nil {
-				urlSaverMock.On("SaveURL", tc.url, mock.AnythingOfType("string")).
-					Return(int64(1), tc.mockError).
+				urlSaverMock.On("ClaimURLContext", mock.Anything, tc.url, mock.AnythingOfType("string"), storage.SaveOptions{}).
+					Return(claimed(1, tc.mockError)).
 					Once()
 			}
 
 			if tc.mockError == nil && tc.respError == "" {
 				// alias can be random, so we use mock.AnythingOfType
-				urlCacheMock.On("Set", mock.Anything, mock.AnythingOfType("string"), tc.url, 5*time.Minute).
+				urlCacheMock.On("Set", mock.Anything, mock.AnythingOfType("string"), cache.Entry{URL: tc.url, Enabled: true}, cacheTTL).
 					Return(nil).Once()
 			}
 
-			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock)
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{CacheTTL: cacheTTL})
 
 			input := fmt.Sprintf(`{"url": "%s", "alias": "%s"}`, tc.url, tc.alias)
 
@@ -117,3 +145,2207 @@ func TestSaveHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestSaveHandler_DefaultCacheTTL(t *testing.T) {
+	const url = "https://google.com"
+
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlCacheMock := mocks.NewURLCache(t)
+
+	urlSaverMock.On("ClaimURLContext", mock.Anything, url, "google", storage.SaveOptions{}).Return(claimed(1, nil)).Once()
+	urlCacheMock.On("Set", mock.Anything, "google", cache.Entry{URL: url, Enabled: true}, cache.DefaultTTL).Return(nil).Once()
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{})
+
+	input := fmt.Sprintf(`{"url": "%s", "alias": "google"}`, url)
+
+	req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestSaveHandler_FoldAliases(t *testing.T) {
+	const url = "https://google.com"
+
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlCacheMock := mocks.NewURLCache(t)
+
+	// "cafe" is stored first, "Café" folds to the same alias and collides
+	urlSaverMock.On("ClaimURLContext", mock.Anything, url, "cafe", storage.SaveOptions{}).Return(claimed(1, nil)).Once()
+	urlSaverMock.On("ClaimURLContext", mock.Anything, url, "cafe", storage.SaveOptions{}).Return(claimed(0, storage.ErrURLExists)).Once()
+	urlCacheMock.On("Set", mock.Anything, "cafe", cache.Entry{URL: url, Enabled: true}, cacheTTL).Return(nil).Once()
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{
+		CacheTTL:    cacheTTL,
+		FoldAliases: true,
+	})
+
+	for _, tc := range []struct {
+		alias      string
+		statusCode int
+		respAlias  string
+	}{
+		{alias: "cafe", statusCode: http.StatusOK, respAlias: "cafe"},
+		{alias: "Café", statusCode: http.StatusConflict},
+	} {
+		input := fmt.Sprintf(`{"url": "%s", "alias": "%s"}`, url, tc.alias)
+
+		req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(t, tc.statusCode, rr.Code)
+
+		var resp save.Response
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+		require.Equal(t, tc.respAlias, resp.Alias)
+	}
+}
+
+func TestSaveHandler_AliasCharacters(t *testing.T) {
+	const url = "https://google.com"
+
+	cases := []struct {
+		name       string
+		alias      string
+		respError  string
+		statusCode int
+	}{
+		{
+			name:       "Valid alias",
+			alias:      "summer-sale_2024",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Too short",
+			alias:      "ab",
+			respError:  "alias contains invalid characters",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Too long",
+			alias:      strings.Repeat("a", 65),
+			respError:  "alias contains invalid characters",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Slash",
+			alias:      "a/b",
+			respError:  "alias contains invalid characters",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Space",
+			alias:      "a b",
+			respError:  "alias contains invalid characters",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Reserved",
+			alias:      "health",
+			respError:  "alias is reserved",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Reserved file",
+			alias:      "style.css",
+			respError:  "alias is reserved",
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			if tc.respError == "" {
+				urlSaverMock.On("ClaimURLContext", mock.Anything, url, tc.alias, storage.SaveOptions{}).Return(claimed(1, nil)).Once()
+				urlCacheMock.On("Set", mock.Anything, tc.alias, mock.Anything, mock.Anything).Return(nil).Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{
+				ReservedAliases: []string{"health", "url", "style.css", "script.js"},
+			})
+
+			input := fmt.Sprintf(`{"url": "%s", "alias": "%s"}`, url, tc.alias)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
+
+func TestSaveHandler_Signed(t *testing.T) {
+	const url = "https://google.com"
+
+	signer := signing.New("secret", 8)
+
+	cases := []struct {
+		name       string
+		input      string
+		signer     *signing.Signer
+		respAlias  string
+		respError  string
+		statusCode int
+	}{
+		{
+			name:       "Signed alias",
+			input:      `{"url": "https://google.com", "alias": "promo", "signed": true}`,
+			signer:     signer,
+			respAlias:  signer.Sign("promo"),
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Signing disabled",
+			input:      `{"url": "https://google.com", "alias": "promo", "signed": true}`,
+			respError:  "signed links are not enabled",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Alias with separator",
+			input:      `{"url": "https://google.com", "alias": "pro.mo"}`,
+			signer:     signer,
+			respError:  "alias must not contain .",
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			if tc.respError == "" {
+				urlSaverMock.On("ClaimURLContext", mock.Anything, url, tc.respAlias, storage.SaveOptions{}).Return(claimed(1, nil)).Once()
+				urlCacheMock.On("Set", mock.Anything, tc.respAlias, cache.Entry{URL: url, Enabled: true}, cacheTTL).Return(nil).Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{
+				CacheTTL: cacheTTL,
+				Signer:   tc.signer,
+			})
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+			require.Equal(t, tc.respAlias, resp.Alias)
+		})
+	}
+}
+
+func TestSaveHandler_MinUserAliasLength(t *testing.T) {
+	const (
+		url      = "https://google.com"
+		user     = "admin"
+		password = "secret"
+	)
+
+	cases := []struct {
+		name       string
+		alias      string
+		admin      bool
+		respError  string
+		statusCode int
+	}{
+		{
+			name:       "Short alias by user",
+			alias:      "abc",
+			respError:  "alias must be at least 5 characters",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Short alias by admin",
+			alias:      "abc",
+			admin:      true,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Long enough alias by user",
+			alias:      "abcde",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Generated alias",
+			alias:      "",
+			statusCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			if tc.respError == "" {
+				urlSaverMock.On("ClaimURLContext", mock.Anything, url, mock.AnythingOfType("string"), storage.SaveOptions{}).Return(claimed(1, nil)).Once()
+				urlCacheMock.On("Set", mock.Anything, mock.AnythingOfType("string"), cache.Entry{URL: url, Enabled: true}, cacheTTL).Return(nil).Once()
+			}
+
+			handler := auth.Admin(user, password)(save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{
+				CacheTTL:           cacheTTL,
+				MinUserAliasLength: 5,
+			}))
+
+			input := fmt.Sprintf(`{"url": "%s", "alias": "%s"}`, url, tc.alias)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+			if tc.admin {
+				req.SetBasicAuth(user, password)
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
+
+func TestSaveHandler_AllowedSchemes(t *testing.T) {
+	cases := []struct {
+		name       string
+		url        string
+		respError  string
+		statusCode int
+	}{
+		{
+			name:       "mailto",
+			url:        "mailto:sales@example.com",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "tel",
+			url:        "tel:+1-555-0100",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Invalid tel",
+			url:        "tel:call-me",
+			respError:  "tel url must contain a valid phone number",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "geo not allowed",
+			url:        "geo:37.78,-122.39",
+			respError:  "url scheme is not allowed",
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			if tc.respError == "" {
+				urlSaverMock.On("ClaimURLContext", mock.Anything, tc.url, "contact", storage.SaveOptions{}).Return(claimed(1, nil)).Once()
+				urlCacheMock.On("Set", mock.Anything, "contact", cache.Entry{URL: tc.url, Enabled: true}, cacheTTL).Return(nil).Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{
+				CacheTTL:       cacheTTL,
+				AllowedSchemes: []string{"https", "mailto", "tel"},
+			})
+
+			input := fmt.Sprintf(`{"url": "%s", "alias": "contact"}`, tc.url)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
+
+func TestSaveHandler_ProblemDetails(t *testing.T) {
+	handler := resp.PreferProblems(save.New(slogdiscard.NewDiscardLogger(), mocks.NewURLSaver(t), mocks.NewURLCache(t), save.Options{}))
+
+	input := `{"url": "some invalid URL", "alias": "some_alias"}`
+
+	req, err := http.NewRequest(http.MethodPost, "/url", bytes.NewReader([]byte(input)))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	require.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+
+	var problem resp.Problem
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+
+	require.Equal(t, resp.Problem{
+		Type:     "about:blank",
+		Title:    "Bad Request",
+		Status:   http.StatusBadRequest,
+		Detail:   "field URL is not a valid URL",
+		Instance: "/url",
+	}, problem)
+}
+
+func TestSaveHandler_Sponsored(t *testing.T) {
+	const url = "https://google.com"
+
+	cases := []struct {
+		name       string
+		enabled    bool
+		respError  string
+		statusCode int
+	}{
+		{
+			name:       "Ads enabled",
+			enabled:    true,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Ads disabled",
+			respError:  "sponsored links are not enabled",
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			// sponsored links are never cached
+			urlCacheMock := mocks.NewURLCache(t)
+
+			if tc.respError == "" {
+				urlSaverMock.On("ClaimURLContext", mock.Anything, url, "promo", storage.SaveOptions{Sponsored: true}).Return(claimed(1, nil)).Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{
+				Sponsored: tc.enabled,
+			})
+
+			input := fmt.Sprintf(`{"url": "%s", "alias": "promo", "sponsored": true}`, url)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
+
+func TestSaveHandler_Generator(t *testing.T) {
+	const url = "https://google.com"
+
+	cases := []struct {
+		name    string
+		service http.HandlerFunc
+		alias   string
+	}{
+		{
+			name: "External",
+			service: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"alias": "ext001"}`))
+			},
+			alias: "ext001",
+		},
+		{
+			name: "Fallback",
+			service: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ts := httptest.NewServer(tc.service)
+			defer ts.Close()
+
+			aliasMatcher := mock.MatchedBy(func(alias string) bool {
+				if tc.alias != "" {
+					return alias == tc.alias
+				}
+				return len(alias) == save.AliasLength
+			})
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			urlSaverMock.On("ClaimURLContext", mock.Anything, url, aliasMatcher, storage.SaveOptions{}).Return(claimed(1, nil)).Once()
+			urlCacheMock.On("Set", mock.Anything, aliasMatcher, cache.Entry{URL: url, Enabled: true}, cacheTTL).Return(nil).Once()
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{
+				CacheTTL: cacheTTL,
+				Generator: generator.NewHTTP(slogdiscard.NewDiscardLogger(), ts.URL,
+					&http.Client{Timeout: time.Second},
+					generator.Random{Length: save.AliasLength},
+				),
+			})
+
+			input := fmt.Sprintf(`{"url": "%s"}`, url)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+		})
+	}
+}
+
+func TestSaveHandler_Password(t *testing.T) {
+	const url = "https://google.com"
+
+	cases := []struct {
+		name       string
+		enabled    bool
+		respError  string
+		statusCode int
+	}{
+		{
+			name:       "Passwords enabled",
+			enabled:    true,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Passwords disabled",
+			respError:  "password protected links are not enabled",
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			// only the hash is stored, and protected links are not cached
+			if tc.respError == "" {
+				urlSaverMock.On("ClaimURLContext", mock.Anything, url, "secret", mock.MatchedBy(func(opts storage.SaveOptions) bool {
+					return opts.PasswordHash != "s3cret" && password.Matches(opts.PasswordHash, "s3cret")
+				})).Return(claimed(1, nil)).Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{
+				Passwords: tc.enabled,
+			})
+
+			input := fmt.Sprintf(`{"url": "%s", "alias": "secret", "password": "s3cret"}`, url)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
+
+func TestRequest_LogValue(t *testing.T) {
+	var buf bytes.Buffer
+
+	log := slog.New(slog.NewJSONHandler(&buf, nil))
+	log.Info("request body decoded", slog.Any("request", save.Request{URL: "https://google.com", Password: "s3cret"}))
+
+	require.NotContains(t, buf.String(), "s3cret")
+	require.Contains(t, buf.String(), "https://google.com")
+}
+
+func TestSaveHandler_LogsValidation(t *testing.T) {
+	const invalid = "not-a-url?token=s3cret"
+
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	handler := save.New(log, mocks.NewURLSaver(t), mocks.NewURLCache(t), save.Options{})
+
+	input := fmt.Sprintf(`{"url": "%s"}`, invalid)
+	req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	var found bool
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry struct {
+			Msg        string `json:"msg"`
+			Validation struct {
+				Field string `json:"field"`
+				Tag   string `json:"tag"`
+			} `json:"validation"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+
+		if entry.Msg != "request field failed validation" {
+			continue
+		}
+		found = true
+
+		assert.Equal(t, "URL", entry.Validation.Field)
+		assert.Equal(t, "url", entry.Validation.Tag)
+		assert.NotContains(t, line, "s3cret")
+	}
+	require.True(t, found, "validation failure not logged")
+}
+
+func TestSaveHandler_RejectURLAliases(t *testing.T) {
+	const url = "https://google.com"
+
+	cases := []struct {
+		name       string
+		alias      string
+		respError  string
+		statusCode int
+	}{
+		{
+			name:       "Normal alias",
+			alias:      "google",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "URL alias",
+			alias:      "https://google.com",
+			respError:  "alias must not be a url, put the link in the url field",
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			if tc.respError == "" {
+				urlSaverMock.On("ClaimURLContext", mock.Anything, url, tc.alias, storage.SaveOptions{}).Return(claimed(1, nil)).Once()
+				urlCacheMock.On("Set", mock.Anything, tc.alias, cache.Entry{URL: url, Enabled: true}, cacheTTL).Return(nil).Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{
+				CacheTTL:         cacheTTL,
+				RejectURLAliases: true,
+			})
+
+			input := fmt.Sprintf(`{"url": "%s", "alias": "%s"}`, url, tc.alias)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
+
+func TestSaveHandler_ReturnIDs(t *testing.T) {
+	const url = "https://google.com"
+
+	for _, tc := range []struct {
+		name      string
+		returnIDs bool
+		id        int64
+	}{
+		{name: "Enabled", returnIDs: true, id: 42},
+		{name: "Disabled"},
+	} {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			urlSaverMock.On("ClaimURLContext", mock.Anything, url, "google", storage.SaveOptions{}).Return(claimed(42, nil)).Once()
+			urlCacheMock.On("Set", mock.Anything, "google", cache.Entry{URL: url, Enabled: true}, cacheTTL).Return(nil).Once()
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{
+				CacheTTL:  cacheTTL,
+				ReturnIDs: tc.returnIDs,
+			})
+
+			input := fmt.Sprintf(`{"url": "%s", "alias": "google"}`, url)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.id, resp.ID)
+		})
+	}
+}
+
+func TestSaveHandler_ShortURL(t *testing.T) {
+	const url = "https://google.com"
+
+	cases := []struct {
+		name           string
+		baseURL        string
+		target         string
+		forwardedProto string
+		wantShortURL   string
+	}{
+		{
+			name:         "Base URL",
+			baseURL:      "https://sho.rt",
+			target:       "http://localhost:8082/url",
+			wantShortURL: "https://sho.rt/google",
+		},
+		{
+			name:         "Base URL with trailing slash",
+			baseURL:      "https://sho.rt/",
+			target:       "http://localhost:8082/url",
+			wantShortURL: "https://sho.rt/google",
+		},
+		{
+			name:         "Request host",
+			target:       "http://localhost:8082/url",
+			wantShortURL: "http://localhost:8082/google",
+		},
+		{
+			name:         "TLS",
+			target:       "https://sho.rt/url",
+			wantShortURL: "https://sho.rt/google",
+		},
+		{
+			name:           "Behind a proxy",
+			target:         "http://sho.rt/url",
+			forwardedProto: "HTTPS, http",
+			wantShortURL:   "https://sho.rt/google",
+		},
+		{
+			name:           "Bogus forwarded scheme",
+			target:         "https://sho.rt/url",
+			forwardedProto: "javascript",
+			wantShortURL:   "https://sho.rt/google",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			urlSaverMock.On("ClaimURLContext", mock.Anything, url, "google", storage.SaveOptions{}).Return(claimed(1, nil)).Once()
+			urlCacheMock.On("Set", mock.Anything, "google", mock.Anything, mock.Anything).Return(nil).Once()
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{
+				BaseURL: tc.baseURL,
+			})
+
+			input := fmt.Sprintf(`{"url": "%s", "alias": "google"}`, url)
+
+			req := httptest.NewRequest(http.MethodPost, tc.target, bytes.NewReader([]byte(input)))
+			if tc.forwardedProto != "" {
+				req.Header.Set("X-Forwarded-Proto", tc.forwardedProto)
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.wantShortURL, resp.ShortURL)
+		})
+	}
+}
+
+func TestSaveHandler_CanonicalQuery(t *testing.T) {
+	cases := []struct {
+		name      string
+		canonical bool
+		url       string
+		wantSaved string
+	}{
+		{
+			name:      "Reordered",
+			canonical: true,
+			url:       "https://google.com/search?q=go&hl=en",
+			wantSaved: "https://google.com/search?hl=en&q=go",
+		},
+		{
+			name:      "Disabled",
+			url:       "https://google.com/search?q=go&hl=en",
+			wantSaved: "https://google.com/search?q=go&hl=en",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			urlSaverMock.On("ClaimURLContext", mock.Anything, tc.wantSaved, "google", storage.SaveOptions{}).Return(claimed(1, nil)).Once()
+			urlCacheMock.On("Set", mock.Anything, "google", cache.Entry{URL: tc.wantSaved, Enabled: true}, cacheTTL).Return(nil).Once()
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{
+				CacheTTL:       cacheTTL,
+				CanonicalQuery: tc.canonical,
+			})
+
+			input := fmt.Sprintf(`{"url": "%s", "alias": "google"}`, tc.url)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+		})
+	}
+}
+
+func TestSaveHandler_NormalizeURLs(t *testing.T) {
+	cases := []struct {
+		name      string
+		normalize bool
+		url       string
+		wantSaved string
+	}{
+		{
+			name:      "Normalized",
+			normalize: true,
+			url:       "https://Google.com:443/?q=go&hl=en",
+			wantSaved: "https://google.com?hl=en&q=go",
+		},
+		{
+			name:      "Disabled",
+			url:       "https://Google.com:443/?q=go&hl=en",
+			wantSaved: "https://Google.com:443/?q=go&hl=en",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			urlSaverMock.On("ClaimURLContext", mock.Anything, tc.wantSaved, "google", storage.SaveOptions{}).Return(claimed(1, nil)).Once()
+			urlCacheMock.On("Set", mock.Anything, "google", cache.Entry{URL: tc.wantSaved, Enabled: true}, cacheTTL).Return(nil).Once()
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{
+				CacheTTL:      cacheTTL,
+				NormalizeURLs: tc.normalize,
+			})
+
+			input := fmt.Sprintf(`{"url": "%s", "alias": "google"}`, tc.url)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+		})
+	}
+}
+
+func TestSaveHandler_Existing(t *testing.T) {
+	const url = "https://google.com"
+
+	cases := []struct {
+		name      string
+		input     string
+		existing  []storage.URL
+		findError error
+		// wantAlias is the reused alias, a new link is saved when empty
+		wantAlias string
+	}{
+		{
+			name:      "Reused",
+			input:     `{"url": "https://google.com/"}`,
+			existing:  []storage.URL{{ID: 7, Alias: "abc123", URL: url}},
+			wantAlias: "abc123",
+		},
+		{
+			name:  "Skips protected links",
+			input: `{"url": "https://google.com/"}`,
+			existing: []storage.URL{
+				{ID: 7, Alias: "secret", URL: url, PasswordHash: "hash"},
+				{ID: 8, Alias: "abc123", URL: url},
+			},
+			wantAlias: "abc123",
+		},
+		{
+			name:     "Skips other namespaces",
+			input:    `{"url": "https://google.com/"}`,
+			existing: []storage.URL{{ID: 7, Alias: "go~abc123", URL: url}},
+		},
+		{
+			name:  "No existing link",
+			input: `{"url": "https://google.com/"}`,
+		},
+		{
+			name:      "Lookup error",
+			input:     `{"url": "https://google.com/"}`,
+			findError: errors.New("connection refused"),
+		},
+		{
+			name:  "Custom alias",
+			input: `{"url": "https://google.com/", "alias": "google"}`,
+		},
+		{
+			name:  "Not plain",
+			input: `{"url": "https://google.com/", "ttl": 60}`,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlCacheMock := mocks.NewURLCache(t)
+			urlFinderMock := mocks.NewURLFinder(t)
+
+			var in save.Request
+			require.NoError(t, json.Unmarshal([]byte(tc.input), &in))
+
+			if in.Alias == "" && in.TTL == 0 {
+				urlFinderMock.On("GetURLsByTarget", mock.Anything, url, mock.Anything).Return(tc.existing, tc.findError).Once()
+			}
+			if tc.wantAlias == "" {
+				urlSaverMock.On("ClaimURLContext", mock.Anything, url, mock.Anything, mock.Anything).Return(claimed(9, nil)).Once()
+				urlCacheMock.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{
+				CacheTTL:      cacheTTL,
+				NormalizeURLs: true,
+				ReturnIDs:     true,
+				Existing:      urlFinderMock,
+			})
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			if tc.wantAlias != "" {
+				require.Equal(t, tc.wantAlias, resp.Alias)
+				require.Equal(t, tc.existing[len(tc.existing)-1].ID, resp.ID)
+			} else {
+				require.Equal(t, int64(9), resp.ID)
+			}
+		})
+	}
+}
+
+func TestSaveHandler_Fingerprint(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gone" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("same content"))
+	}))
+	defer target.Close()
+
+	sum := sha256.Sum256([]byte("same content"))
+	hash := hex.EncodeToString(sum[:])
+
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlCacheMock := mocks.NewURLCache(t)
+
+	// different URLs serving the same body share the fingerprint,
+	// an unreachable target is saved without one
+	urlSaverMock.On("ClaimURLContext", mock.Anything, target.URL+"/a", "first", storage.SaveOptions{ContentHash: hash}).Return(claimed(1, nil)).Once()
+	urlSaverMock.On("ClaimURLContext", mock.Anything, target.URL+"/b?utm=x", "second", storage.SaveOptions{ContentHash: hash}).Return(claimed(2, nil)).Once()
+	urlSaverMock.On("ClaimURLContext", mock.Anything, target.URL+"/gone", "third", storage.SaveOptions{}).Return(claimed(3, nil)).Once()
+	urlCacheMock.On("Set", mock.Anything, mock.AnythingOfType("string"), mock.Anything, cacheTTL).Return(nil).Times(3)
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{
+		CacheTTL: cacheTTL,
+		Fingerprinter: fingerprint.New(fingerprint.Options{
+			Timeout:      time.Second,
+			MaxBytes:     1024,
+			AllowPrivate: true,
+		}),
+	})
+
+	for _, tc := range []struct {
+		url   string
+		alias string
+	}{
+		{url: target.URL + "/a", alias: "first"},
+		{url: target.URL + "/b?utm=x", alias: "second"},
+		{url: target.URL + "/gone", alias: "third"},
+	} {
+		input := fmt.Sprintf(`{"url": "%s", "alias": "%s"}`, tc.url, tc.alias)
+
+		req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+	}
+}
+
+func TestSaveHandler_Audited(t *testing.T) {
+	const url = "https://google.com"
+
+	cases := []struct {
+		name       string
+		audit      bool
+		respError  string
+		statusCode int
+	}{
+		{
+			name:       "Audit enabled",
+			audit:      true,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Audit disabled",
+			respError:  "audited links are not enabled",
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// audited links are not cached, so the cache mock expects nothing
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			if tc.respError == "" {
+				urlSaverMock.On("ClaimURLContext", mock.Anything, url, "vip", storage.SaveOptions{Audited: true}).Return(claimed(1, nil)).Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{
+				Audit: tc.audit,
+			})
+
+			input := fmt.Sprintf(`{"url": "%s", "alias": "vip", "audited": true}`, url)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
+
+func TestSaveHandler_ExternalID(t *testing.T) {
+	const url = "https://google.com"
+
+	cases := []struct {
+		name       string
+		enabled    bool
+		perCreator bool
+		admin      bool
+		externalID string
+		stored     string
+		mockError  error
+		respError  string
+		statusCode int
+	}{
+		{
+			name:       "Saved",
+			enabled:    true,
+			externalID: "order-42",
+			stored:     "order-42",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Per creator",
+			enabled:    true,
+			perCreator: true,
+			admin:      true,
+			externalID: "order-42",
+			stored:     "admin~order-42",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Per creator anonymous",
+			enabled:    true,
+			perCreator: true,
+			externalID: "order-42",
+			stored:     "~order-42",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Taken",
+			enabled:    true,
+			externalID: "order-42",
+			stored:     "order-42",
+			mockError:  storage.ErrExternalIDExists,
+			respError:  "external id already exists",
+			statusCode: http.StatusConflict,
+		},
+		{
+			name:       "Disabled",
+			externalID: "order-42",
+			respError:  "external ids are not enabled",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Separator",
+			enabled:    true,
+			externalID: "a~b",
+			respError:  "field ExternalID is not valid",
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			if tc.stored != "" {
+				urlSaverMock.On("ClaimURLContext", mock.Anything, url, "google", storage.SaveOptions{ExternalID: tc.stored}).Return(claimed(1, tc.mockError)).Once()
+			}
+			if tc.respError == "" {
+				urlCacheMock.On("Set", mock.Anything, "google", cache.Entry{URL: url, Enabled: true}, cacheTTL).Return(nil).Once()
+			}
+
+			handler := auth.Admin("admin", "password")(save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{
+				CacheTTL:              cacheTTL,
+				ExternalIDs:           tc.enabled,
+				ExternalIDsPerCreator: tc.perCreator,
+			}))
+
+			input := fmt.Sprintf(`{"url": "%s", "alias": "google", "external_id": "%s"}`, url, tc.externalID)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+			if tc.admin {
+				req.SetBasicAuth("admin", "password")
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
+
+func TestSaveHandler_RedirectStatus(t *testing.T) {
+	const url = "https://google.com"
+
+	cases := []struct {
+		name       string
+		status     int
+		respError  string
+		statusCode int
+	}{
+		{
+			name:       "Permanent",
+			status:     http.StatusMovedPermanently,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Not a redirect",
+			status:     http.StatusOK,
+			respError:  "field RedirectStatus is not valid",
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			if tc.respError == "" {
+				urlSaverMock.On("ClaimURLContext", mock.Anything, url, "google", storage.SaveOptions{RedirectStatus: tc.status}).Return(claimed(1, nil)).Once()
+				urlCacheMock.On("Set", mock.Anything, "google", cache.Entry{URL: url, Code: tc.status, Enabled: true}, cacheTTL).Return(nil).Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{
+				CacheTTL: cacheTTL,
+			})
+
+			input := fmt.Sprintf(`{"url": "%s", "alias": "google", "redirect_status": %d}`, url, tc.status)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
+
+func TestSaveHandler_Variants(t *testing.T) {
+	const url = "https://google.com"
+
+	cases := []struct {
+		name       string
+		split      bool
+		variants   string
+		respError  string
+		statusCode int
+	}{
+		{
+			name:       "Success",
+			split:      true,
+			variants:   `[{"url": "https://a.example.com", "weight": 1}, {"url": "https://b.example.com", "weight": 3}]`,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Split targets disabled",
+			variants:   `[{"url": "https://a.example.com", "weight": 1}, {"url": "https://b.example.com", "weight": 3}]`,
+			respError:  "split targets are not enabled",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Single variant",
+			split:      true,
+			variants:   `[{"url": "https://a.example.com", "weight": 1}]`,
+			respError:  "field Variants is not valid",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Zero weight",
+			split:      true,
+			variants:   `[{"url": "https://a.example.com", "weight": 0}, {"url": "https://b.example.com", "weight": 3}]`,
+			respError:  "field Weight is a required field",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid variant url",
+			split:      true,
+			variants:   `[{"url": "not a url", "weight": 1}, {"url": "https://b.example.com", "weight": 3}]`,
+			respError:  "field URL is not a valid URL",
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// split links are not cached, so the cache mock expects nothing
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			if tc.respError == "" {
+				urlSaverMock.On("ClaimURLContext", mock.Anything, url, "split", storage.SaveOptions{Variants: []storage.Variant{
+					{URL: "https://a.example.com", Weight: 1},
+					{URL: "https://b.example.com", Weight: 3},
+				}}).Return(claimed(1, nil)).Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{
+				SplitTargets: tc.split,
+			})
+
+			input := fmt.Sprintf(`{"url": "%s", "alias": "split", "variants": %s}`, url, tc.variants)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
+
+func TestSaveHandler_Owner(t *testing.T) {
+	const url = "https://google.com"
+
+	cases := []struct {
+		name       string
+		require    bool
+		input      string
+		header     string
+		wantOwner  string
+		respError  string
+		statusCode int
+	}{
+		{
+			name:       "Owner in body",
+			require:    true,
+			input:      `{"url": "https://google.com", "alias": "google", "owner": "jane@example.com"}`,
+			wantOwner:  "jane@example.com",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Owner in header",
+			require:    true,
+			input:      `{"url": "https://google.com", "alias": "google"}`,
+			header:     "jane@example.com",
+			wantOwner:  "jane@example.com",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Body over header",
+			require:    true,
+			input:      `{"url": "https://google.com", "alias": "google", "owner": "jane@example.com"}`,
+			header:     "john@example.com",
+			wantOwner:  "jane@example.com",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Missing owner",
+			require:    true,
+			input:      `{"url": "https://google.com", "alias": "google"}`,
+			respError:  "owner is required",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid owner",
+			input:      `{"url": "https://google.com", "alias": "google", "owner": "jane"}`,
+			respError:  "owner must be an email address",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Owner not required",
+			input:      `{"url": "https://google.com", "alias": "google"}`,
+			statusCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			if tc.respError == "" {
+				urlSaverMock.On("ClaimURLContext", mock.Anything, url, "google", storage.SaveOptions{Owner: tc.wantOwner}).Return(claimed(1, nil)).Once()
+				urlCacheMock.On("Set", mock.Anything, "google", cache.Entry{URL: url, Enabled: true}, cacheTTL).Return(nil).Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{
+				CacheTTL:     cacheTTL,
+				RequireOwner: tc.require,
+			})
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+			if tc.header != "" {
+				req.Header.Set(save.OwnerHeader, tc.header)
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
+
+func TestSaveHandler_Folder(t *testing.T) {
+	const url = "https://google.com"
+
+	cases := []struct {
+		name       string
+		enabled    bool
+		input      string
+		getError   error
+		saveError  error
+		wantFolder int64
+		respError  string
+		statusCode int
+	}{
+		{
+			name:       "Filed",
+			enabled:    true,
+			input:      `{"url": "https://google.com", "alias": "google", "folder_id": 3}`,
+			wantFolder: 3,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "No folder",
+			input:      `{"url": "https://google.com", "alias": "google"}`,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Disabled",
+			input:      `{"url": "https://google.com", "alias": "google", "folder_id": 3}`,
+			respError:  "folders are not enabled",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Negative id",
+			enabled:    true,
+			input:      `{"url": "https://google.com", "alias": "google", "folder_id": -1}`,
+			respError:  "field FolderID is not valid",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Folder not found",
+			enabled:    true,
+			input:      `{"url": "https://google.com", "alias": "google", "folder_id": 3}`,
+			getError:   storage.ErrFolderNotFound,
+			respError:  "folder not found",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Folder deleted meanwhile",
+			enabled:    true,
+			input:      `{"url": "https://google.com", "alias": "google", "folder_id": 3}`,
+			saveError:  storage.ErrFolderNotFound,
+			wantFolder: 3,
+			respError:  "folder not found",
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlCacheMock := mocks.NewURLCache(t)
+			folderGetterMock := mocks.NewFolderGetter(t)
+
+			if tc.enabled && strings.Contains(tc.input, `"folder_id": 3`) {
+				folderGetterMock.On("GetFolder", int64(3)).Return(storage.Folder{ID: 3}, tc.getError).Once()
+			}
+			if tc.getError == nil && (tc.respError == "" || tc.saveError != nil) {
+				urlSaverMock.On("ClaimURLContext", mock.Anything, url, "google", storage.SaveOptions{FolderID: tc.wantFolder}).Return(claimed(1, tc.saveError)).Once()
+			}
+			if tc.respError == "" {
+				urlCacheMock.On("Set", mock.Anything, "google", cache.Entry{URL: url, Enabled: true}, cacheTTL).Return(nil).Once()
+			}
+
+			opts := save.Options{CacheTTL: cacheTTL}
+			if tc.enabled {
+				opts.Folders = folderGetterMock
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, opts)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
+
+func TestSaveHandler_AllowedReferrers(t *testing.T) {
+	const url = "https://google.com"
+
+	cases := []struct {
+		name       string
+		enabled    bool
+		referrers  string
+		respError  string
+		statusCode int
+	}{
+		{
+			name:       "Allowlists enabled",
+			enabled:    true,
+			referrers:  `["Example.com", "blog.example.org"]`,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Allowlists disabled",
+			referrers:  `["example.com"]`,
+			respError:  "referrer allowlists are not enabled",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid domain",
+			enabled:    true,
+			referrers:  `["https://example.com/"]`,
+			respError:  "field AllowedReferrers[0] is not valid",
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// restricted links are not cached, so the cache mock expects nothing
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			if tc.respError == "" {
+				urlSaverMock.On("ClaimURLContext", mock.Anything, url, "embed", storage.SaveOptions{
+					AllowedReferrers: []string{"example.com", "blog.example.org"},
+				}).Return(claimed(1, nil)).Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{
+				ReferrerAllowlists: tc.enabled,
+			})
+
+			input := fmt.Sprintf(`{"url": "%s", "alias": "embed", "allowed_referrers": %s}`, url, tc.referrers)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
+
+type observingGenerator struct {
+	alias    string
+	observed []bool
+}
+
+func (g *observingGenerator) Generate(_ context.Context) (string, error) {
+	return g.alias, nil
+}
+
+func (g *observingGenerator) ObserveCollision(_ context.Context, collided bool) {
+	g.observed = append(g.observed, collided)
+}
+
+func TestSaveHandler_ObserveCollisions(t *testing.T) {
+	const url = "https://google.com"
+
+	gen := &observingGenerator{alias: "gen123"}
+
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlCacheMock := mocks.NewURLCache(t)
+
+	urlSaverMock.On("ClaimURLContext", mock.Anything, url, "gen123", storage.SaveOptions{}).Return(claimed(1, nil)).Once()
+	urlSaverMock.On("ClaimURLContext", mock.Anything, url, "gen123", storage.SaveOptions{}).Return(claimed(0, storage.ErrURLExists)).Once()
+	urlSaverMock.On("ClaimURLContext", mock.Anything, url, "custom", storage.SaveOptions{}).Return(claimed(0, storage.ErrURLExists)).Once()
+	urlCacheMock.On("Set", mock.Anything, "gen123", cache.Entry{URL: url, Enabled: true}, cacheTTL).Return(nil).Once()
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{
+		CacheTTL:     cacheTTL,
+		Generator:    gen,
+		SaveAttempts: 1,
+	})
+
+	for _, alias := range []string{"", "", "custom"} {
+		input := fmt.Sprintf(`{"url": "%s", "alias": "%s"}`, url, alias)
+
+		req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+		require.NoError(t, err)
+
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// custom aliases say nothing about the generated keyspace
+	require.Equal(t, []bool{false, true}, gen.observed)
+}
+
+func TestSaveHandler_RetryGeneratedAlias(t *testing.T) {
+	const url = "https://google.com"
+
+	cases := []struct {
+		name       string
+		aliases    []string
+		attempts   int
+		taken      int
+		respAlias  string
+		respError  string
+		statusCode int
+	}{
+		{
+			name:       "Taken twice",
+			aliases:    []string{"first", "second", "third"},
+			taken:      2,
+			respAlias:  "third",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Out of attempts",
+			aliases:    []string{"first", "second", "third"},
+			attempts:   2,
+			taken:      2,
+			respError:  "failed to add url",
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			for _, alias := range tc.aliases[:tc.taken] {
+				urlSaverMock.On("ClaimURLContext", mock.Anything, url, alias, storage.SaveOptions{}).
+					Return(claimed(0, storage.ErrURLExists)).Once()
+			}
+			if tc.respAlias != "" {
+				urlSaverMock.On("ClaimURLContext", mock.Anything, url, tc.respAlias, storage.SaveOptions{}).
+					Return(claimed(1, nil)).Once()
+				urlCacheMock.On("Set", mock.Anything, tc.respAlias, cache.Entry{URL: url, Enabled: true}, cacheTTL).
+					Return(nil).Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{
+				CacheTTL:     cacheTTL,
+				Generator:    &sequenceGenerator{aliases: tc.aliases},
+				SaveAttempts: tc.attempts,
+			})
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(fmt.Sprintf(`{"url": "%s"}`, url))))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+			require.Equal(t, tc.respAlias, resp.Alias)
+		})
+	}
+}
+
+func TestSaveHandler_QueryTimeout(t *testing.T) {
+	const url = "https://google.com"
+
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlCacheMock := mocks.NewURLCache(t)
+
+	hasDeadline := mock.MatchedBy(func(ctx context.Context) bool {
+		_, ok := ctx.Deadline()
+		return ok
+	})
+
+	urlSaverMock.On("ClaimURLContext", hasDeadline, url, "slow_alias", storage.SaveOptions{}).
+		Return(claimed(0, fmt.Errorf("storage.postgres.ClaimURLContext: %w", context.DeadlineExceeded))).Once()
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{
+		CacheTTL:     cacheTTL,
+		QueryTimeout: time.Second,
+	})
+
+	input := fmt.Sprintf(`{"url": "%s", "alias": "slow_alias"}`, url)
+
+	req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	var resp save.Response
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	require.Equal(t, "storage unavailable", resp.Error)
+}
+
+type sequenceGenerator struct {
+	mu      sync.Mutex
+	aliases []string
+}
+
+func (g *sequenceGenerator) Generate(_ context.Context) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	alias := g.aliases[0]
+	if len(g.aliases) > 1 {
+		g.aliases = g.aliases[1:]
+	}
+
+	return alias, nil
+}
+
+func TestSaveHandler_Aliases(t *testing.T) {
+	const url = "https://google.com"
+
+	cases := []struct {
+		name      string
+		generated []string
+		wantAlias string
+	}{
+		{
+			name:      "Generated alias free",
+			generated: []string{"fresh1"},
+			wantAlias: "fresh1",
+		},
+		{
+			name:      "Generated alias taken",
+			generated: []string{"taken1", "fresh1"},
+			wantAlias: "fresh1",
+		},
+		{
+			// e.g. a deleted alias, the filter can't forget it
+			name:      "Every draw reported taken",
+			generated: []string{"taken1"},
+			wantAlias: "taken1",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			aliases := bloom.New(100, 0.01)
+			aliases.Add("taken1")
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			urlSaverMock.On("ClaimURLContext", mock.Anything, url, tc.wantAlias, storage.SaveOptions{}).Return(claimed(1, nil)).Once()
+			urlCacheMock.On("Set", mock.Anything, tc.wantAlias, mock.Anything, mock.Anything).Return(nil).Once()
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{
+				Generator: &sequenceGenerator{aliases: tc.generated},
+				Aliases:   aliases,
+			})
+
+			input := fmt.Sprintf(`{"url": "%s"}`, url)
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp save.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			assert.Equal(t, tc.wantAlias, resp.Alias)
+			// saved aliases are known to the redirect handler right away
+			assert.True(t, aliases.MayContain(tc.wantAlias))
+		})
+	}
+}
+
+func TestSaveHandler_TTL(t *testing.T) {
+	const url = "https://google.com"
+
+	before := time.Now()
+
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlCacheMock := mocks.NewURLCache(t)
+
+	var expiresAt time.Time
+
+	urlSaverMock.On("ClaimURLContext", mock.Anything, url, "brief", mock.MatchedBy(func(opts storage.SaveOptions) bool {
+		if opts.ExpiresAt == nil {
+			return false
+		}
+		expiresAt = *opts.ExpiresAt
+		return !expiresAt.Before(before.Add(time.Minute)) && !expiresAt.After(time.Now().Add(time.Minute))
+	})).Return(claimed(1, nil)).Once()
+
+	// the cache entry expires with the link, well before the default 5 minutes
+	urlCacheMock.On("Set", mock.Anything, "brief",
+		mock.MatchedBy(func(e cache.Entry) bool {
+			return e.URL == url && e.ExpiresAt != nil && e.ExpiresAt.Equal(expiresAt)
+		}),
+		mock.MatchedBy(func(ttl time.Duration) bool {
+			return ttl > 0 && ttl <= time.Minute && ttl > time.Minute-time.Second
+		}),
+	).Return(nil).Once()
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{})
+
+	input := fmt.Sprintf(`{"url": "%s", "alias": "brief", "ttl": 60}`, url)
+
+	req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestSaveHandler_TTLTooLong(t *testing.T) {
+	// a longer TTL overflows time.Duration
+	ttl := int64(math.MaxInt64/int64(time.Second)) + 1
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), mocks.NewURLSaver(t), mocks.NewURLCache(t), save.Options{})
+
+	input := fmt.Sprintf(`{"url": "https://google.com", "alias": "forever", "ttl": %d}`, ttl)
+
+	req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	var resp save.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	assert.Equal(t, "field TTL is not valid", resp.Error)
+}
+
+func TestSaveHandler_ExpiresAt(t *testing.T) {
+	const url = "https://google.com"
+
+	future := time.Now().Add(time.Minute).UTC().Truncate(time.Second)
+
+	cases := []struct {
+		name       string
+		input      string
+		respError  string
+		statusCode int
+	}{
+		{
+			name:       "Future expiry",
+			input:      fmt.Sprintf(`{"url": "%s", "alias": "brief", "expires_at": "%s"}`, url, future.Format(time.RFC3339)),
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Past expiry",
+			input:      fmt.Sprintf(`{"url": "%s", "alias": "brief", "expires_at": "2000-01-01T00:00:00Z"}`, url),
+			respError:  "expires_at must be in the future",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Expiry and TTL",
+			input:      fmt.Sprintf(`{"url": "%s", "alias": "brief", "ttl": 60, "expires_at": "%s"}`, url, future.Format(time.RFC3339)),
+			respError:  "ttl and expires_at are mutually exclusive",
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			if tc.respError == "" {
+				urlSaverMock.On("ClaimURLContext", mock.Anything, url, "brief", mock.MatchedBy(func(opts storage.SaveOptions) bool {
+					return opts.ExpiresAt != nil && opts.ExpiresAt.Equal(future)
+				})).Return(claimed(1, nil)).Once()
+
+				// the cache entry expires with the link
+				urlCacheMock.On("Set", mock.Anything, "brief", mock.Anything,
+					mock.MatchedBy(func(ttl time.Duration) bool {
+						return ttl > 0 && ttl <= time.Minute
+					}),
+				).Return(nil).Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{})
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
+
+func TestSaveHandler_Deduplicate(t *testing.T) {
+	const (
+		url = "https://google.com"
+		n   = 10
+	)
+
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlCacheMock := mocks.NewURLCache(t)
+
+	// the slow insert keeps the flight open while the other requests arrive
+	urlSaverMock.On("ClaimURLContext", mock.Anything, url, mock.AnythingOfType("string"), storage.SaveOptions{}).
+		Return(claimed(1, nil)).Once().
+		After(200 * time.Millisecond)
+	urlCacheMock.On("Set", mock.Anything, mock.AnythingOfType("string"), cache.Entry{URL: url, Enabled: true}, cacheTTL).
+		Return(nil).Once()
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{
+		CacheTTL:    cacheTTL,
+		Deduplicate: true,
+	})
+
+	var (
+		wg      sync.WaitGroup
+		aliases = make([]string, n)
+	)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			req := httptest.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(`{"url": "`+url+`"}`)))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var resp save.Response
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			aliases[i] = resp.Alias
+		}(i)
+	}
+
+	wg.Wait()
+
+	// every request got the one generated alias
+	for _, alias := range aliases {
+		assert.Equal(t, aliases[0], alias)
+	}
+	assert.NotEmpty(t, aliases[0])
+}
+
+func TestSaveHandler_DeduplicatePerClient(t *testing.T) {
+	const url = "https://google.com"
+
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlCacheMock := mocks.NewURLCache(t)
+
+	// both inserts are under way at the same time
+	urlSaverMock.On("ClaimURLContext", mock.Anything, url, mock.AnythingOfType("string"), storage.SaveOptions{}).
+		Return(claimed(1, nil)).Twice().
+		After(200 * time.Millisecond)
+	urlCacheMock.On("Set", mock.Anything, mock.AnythingOfType("string"), cache.Entry{URL: url, Enabled: true}, cacheTTL).
+		Return(nil).Twice()
+
+	handler := mwAPIKey.New(slogdiscard.NewDiscardLogger(), keyValidator(""))(save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{
+		CacheTTL:    cacheTTL,
+		Deduplicate: true,
+	}))
+
+	var wg sync.WaitGroup
+	for _, key := range []string{"first-key", "second-key"} {
+		wg.Add(1)
+
+		go func(key string) {
+			defer wg.Done()
+
+			req := httptest.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(`{"url": "`+url+`"}`)))
+			req.Header.Set(mwAPIKey.Header, key)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+		}(key)
+	}
+
+	wg.Wait()
+}
+
+// claimed returns the claim of an alias saved with id, or taken when err
+// is storage.ErrURLExists.
+func claimed(id int64, err error) (storage.Claim, error) {
+	if errors.Is(err, storage.ErrURLExists) {
+		return storage.Claim{URL: "https://example.org"}, nil
+	}
+	if err != nil {
+		return storage.Claim{}, err
+	}
+
+	return storage.Claim{Created: true, ID: id}, nil
+}
+
+type keyValidator string
+
+// ValidateAPIKey accepts the key v, or any key when v is empty.
+func (v keyValidator) ValidateAPIKey(key string) (bool, error) {
+	return v == "" || key == string(v), nil
+}
+
+func TestSaveHandler_RecordCreator(t *testing.T) {
+	const (
+		url = "https://google.com"
+		key = "secret-key"
+	)
+
+	cases := []struct {
+		name    string
+		enabled bool
+		header  func(r *http.Request)
+		creator storage.Creator
+	}{
+		{
+			name: "Disabled",
+		},
+		{
+			name:    "Anonymous",
+			enabled: true,
+			creator: storage.Creator{IP: "203.0.113.7", UserAgent: "curl/8.0"},
+		},
+		{
+			name:    "Admin",
+			enabled: true,
+			header:  func(r *http.Request) { r.SetBasicAuth("admin", "password") },
+			creator: storage.Creator{IP: "203.0.113.7", UserAgent: "curl/8.0", Identity: "admin"},
+		},
+		{
+			name:    "API key",
+			enabled: true,
+			header:  func(r *http.Request) { r.Header.Set(mwAPIKey.Header, key) },
+			creator: storage.Creator{IP: "203.0.113.7", UserAgent: "curl/8.0", Identity: "key:" + apikey.Hash(key)},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			urlSaverMock.On("ClaimURLContext", mock.Anything, url, "google", storage.SaveOptions{Creator: tc.creator}).Return(claimed(1, nil)).Once()
+			urlCacheMock.On("Set", mock.Anything, "google", cache.Entry{URL: url, Enabled: true}, cacheTTL).Return(nil).Once()
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, urlCacheMock, save.Options{
+				CacheTTL:      cacheTTL,
+				RecordCreator: tc.enabled,
+			})
+
+			var h http.Handler = handler
+			if tc.header != nil {
+				h = auth.Admin("admin", "password")(mwAPIKey.New(slogdiscard.NewDiscardLogger(), keyValidator(key))(handler))
+			}
+
+			input := fmt.Sprintf(`{"url": "%s", "alias": "google"}`, url)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+			req.RemoteAddr = "203.0.113.7:54321"
+			req.Header.Set("User-Agent", "curl/8.0")
+			if tc.header != nil {
+				tc.header(req)
+			}
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package search
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/normalize"
+)
+
+type Response struct {
+	resp.Response
+	Aliases []string `json:"aliases"`
+}
+
+// AliasSearcher is an interface for looking up aliases by prefix.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=AliasSearcher
+type AliasSearcher interface {
+	SearchAliasesByPrefix(prefix string, limit int) ([]string, error)
+}
+
+// Options holds the optional behaviour of the search handler.
+type Options struct {
+	// FoldAliases folds the prefix the same way the save handler stores aliases.
+	FoldAliases bool
+	// MaxLimit caps the number of aliases returned, and is the default
+	// when the limit parameter is missing.
+	MaxLimit int
+}
+
+// New returns a handler listing the aliases starting with the prefix
+// query parameter, for type-ahead in the admin UI.
+func New(log *slog.Logger, searcher AliasSearcher, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.search.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		prefix := r.URL.Query().Get("prefix")
+		if prefix == "" {
+			log.Info("prefix is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("prefix is required"))
+			return
+		}
+		if opts.FoldAliases {
+			prefix = normalize.Alias(prefix)
+		}
+
+		limit := opts.MaxLimit
+		if raw := r.URL.Query().Get("limit"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 {
+				log.Info("invalid limit", slog.String("limit", raw))
+				resp.RenderError(w, r, http.StatusBadRequest, resp.Error("limit must be a positive number"))
+				return
+			}
+			limit = min(n, opts.MaxLimit)
+		}
+
+		aliases, err := searcher.SearchAliasesByPrefix(prefix, limit)
+		if err != nil {
+			log.Error("failed to search aliases", sl.Err(err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Aliases:  aliases,
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package search_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/search"
+	"url-shortener/internal/http-server/handlers/url/search/mocks"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestSearchHandler(t *testing.T) {
+	cases := []struct {
+		name        string
+		query       string
+		prefix      string
+		limit       int
+		aliases     []string
+		mockError   error
+		respAliases []string
+		respError   string
+		statusCode  int
+	}{
+		{
+			name:        "Default limit",
+			query:       "?prefix=ab",
+			prefix:      "ab",
+			limit:       20,
+			aliases:     []string{"abc", "abd"},
+			respAliases: []string{"abc", "abd"},
+			statusCode:  http.StatusOK,
+		},
+		{
+			name:        "Custom limit",
+			query:       "?prefix=ab&limit=1",
+			prefix:      "ab",
+			limit:       1,
+			aliases:     []string{"abc"},
+			respAliases: []string{"abc"},
+			statusCode:  http.StatusOK,
+		},
+		{
+			name:        "Limit capped",
+			query:       "?prefix=ab&limit=1000",
+			prefix:      "ab",
+			limit:       20,
+			aliases:     []string{},
+			respAliases: []string{},
+			statusCode:  http.StatusOK,
+		},
+		{
+			name:       "Missing prefix",
+			respError:  "prefix is required",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid limit",
+			query:      "?prefix=ab&limit=-1",
+			respError:  "limit must be a positive number",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Storage error",
+			query:      "?prefix=ab",
+			prefix:     "ab",
+			limit:      20,
+			mockError:  errors.New("connection refused"),
+			respError:  "internal error",
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			searcherMock := mocks.NewAliasSearcher(t)
+			if tc.prefix != "" {
+				searcherMock.On("SearchAliasesByPrefix", tc.prefix, tc.limit).Return(tc.aliases, tc.mockError).Once()
+			}
+
+			handler := search.New(slogdiscard.NewDiscardLogger(), searcherMock, search.Options{MaxLimit: 20})
+
+			req, err := http.NewRequest(http.MethodGet, "/admin/url/search"+tc.query, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp search.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+			require.Equal(t, tc.respAliases, resp.Aliases)
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+
+	"url-shortener/internal/cache"
+	"url-shortener/internal/http-server/middleware/namespace"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/normalize"
+	"url-shortener/internal/storage"
+)
+
+// DefaultBatchLimit is the number of aliases a batch may ask for when
+// BatchOptions.MaxAliases is not set.
+const DefaultBatchLimit = 100
+
+type BatchRequest struct {
+	Aliases []string `json:"aliases" validate:"required,min=1,dive,required"`
+}
+
+type BatchResponse struct {
+	resp.Response
+	// Stats holds the stats of every requested alias, keyed as requested.
+	// Aliases that aren't stored map to null.
+	Stats map[string]*Stats `json:"stats"`
+}
+
+// Stats are the stats of a single alias in a batch.
+type Stats struct {
+	Clicks int64 `json:"clicks"`
+	// UniqueVisitors is omitted when visitors are not counted.
+	UniqueVisitors *int64 `json:"unique_visitors,omitempty"`
+}
+
+// URLsGetter is an interface for getting many stored links at once.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLsGetter
+type URLsGetter interface {
+	GetURLsInfo(aliases []string) ([]storage.URL, error)
+}
+
+// BatchVisitorCounter is an interface for reading the unique visitor
+// estimates of many aliases at once.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=BatchVisitorCounter
+type BatchVisitorCounter interface {
+	PFCountEach(ctx context.Context, keys []string) ([]int64, error)
+}
+
+// BatchOptions holds the optional behaviour of the batch stats handler.
+type BatchOptions struct {
+	// FoldAliases looks aliases up case- and accent-insensitively.
+	FoldAliases bool
+	// MaxAliases caps the aliases of a single request, DefaultBatchLimit
+	// when zero.
+	MaxAliases int
+	// Visitors estimates the unique visitors of the aliases. The estimates
+	// are left out when it is nil.
+	Visitors BatchVisitorCounter
+}
+
+// NewBatch returns a handler serving the stats of many aliases with one
+// storage query and one Redis round-trip.
+func NewBatch(log *slog.Logger, urlsGetter URLsGetter, opts BatchOptions) http.HandlerFunc {
+	maxAliases := opts.MaxAliases
+	if maxAliases <= 0 {
+		maxAliases = DefaultBatchLimit
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.stats.NewBatch"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req BatchRequest
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.Error("invalid request", sl.Err(err))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.ValidationError(validateErr))
+			return
+		}
+		if len(req.Aliases) > maxAliases {
+			log.Info("too many aliases", slog.Int("count", len(req.Aliases)))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error(fmt.Sprintf("at most %d aliases may be requested", maxAliases)))
+			return
+		}
+
+		// lookup maps every requested alias to the alias it is stored as
+		lookup := make(map[string]string, len(req.Aliases))
+		aliases := make([]string, 0, len(req.Aliases))
+		seen := make(map[string]bool, len(req.Aliases))
+		for _, requested := range req.Aliases {
+			alias := requested
+			if opts.FoldAliases {
+				alias = normalize.Alias(alias)
+			}
+			alias = namespace.Qualify(r.Context(), alias)
+			lookup[requested] = alias
+			if !seen[alias] {
+				seen[alias] = true
+				aliases = append(aliases, alias)
+			}
+		}
+
+		links, err := urlsGetter.GetURLsInfo(aliases)
+		if err != nil {
+			log.Error("failed to get urls", sl.Err(err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+			return
+		}
+
+		found := make(map[string]*Stats, len(links))
+		for _, link := range links {
+			found[link.Alias] = &Stats{Clicks: link.Clicks}
+		}
+
+		if opts.Visitors != nil && len(links) > 0 {
+			keys := make([]string, len(links))
+			for i, link := range links {
+				keys[i] = cache.VisitorsKey(link.Alias)
+			}
+
+			counts, err := opts.Visitors.PFCountEach(r.Context(), keys)
+			if err != nil {
+				log.Error("failed to count visitors", sl.Err(err))
+				resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+				return
+			}
+			for i, link := range links {
+				found[link.Alias].UniqueVisitors = &counts[i]
+			}
+		}
+
+		res := BatchResponse{
+			Response: resp.OK(),
+			Stats:    make(map[string]*Stats, len(req.Aliases)),
+		}
+		for requested, alias := range lookup {
+			res.Stats[requested] = found[alias]
+		}
+
+		render.JSON(w, r, res)
+	}
+}
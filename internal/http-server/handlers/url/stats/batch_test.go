@@ -0,0 +1,162 @@
+package stats_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/stats"
+	"url-shortener/internal/http-server/handlers/url/stats/mocks"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+func TestBatchStatsHandler(t *testing.T) {
+	cases := []struct {
+		name          string
+		body          string
+		opts          stats.BatchOptions
+		lookup        []string
+		links         []storage.URL
+		getError      error
+		countVisitors bool
+		visitorKeys   []string
+		visitors      []int64
+		countError    error
+		respError     string
+		statusCode    int
+		want          map[string]*stats.Stats
+	}{
+		{
+			name:       "Known and unknown aliases",
+			body:       `{"aliases":["google","missing","yandex"]}`,
+			lookup:     []string{"google", "missing", "yandex"},
+			links:      []storage.URL{{Alias: "google", Clicks: 7}, {Alias: "yandex", Clicks: 0}},
+			statusCode: http.StatusOK,
+			want: map[string]*stats.Stats{
+				"google":  {Clicks: 7},
+				"missing": nil,
+				"yandex":  {Clicks: 0},
+			},
+		},
+		{
+			name:          "With visitors",
+			body:          `{"aliases":["google","missing"]}`,
+			lookup:        []string{"google", "missing"},
+			links:         []storage.URL{{Alias: "google", Clicks: 7}},
+			countVisitors: true,
+			visitorKeys:   []string{"visitors:google"},
+			visitors:      []int64{3},
+			statusCode:    http.StatusOK,
+			want: map[string]*stats.Stats{
+				"google":  {Clicks: 7, UniqueVisitors: int64Ptr(3)},
+				"missing": nil,
+			},
+		},
+		{
+			name:          "Only unknown aliases",
+			body:          `{"aliases":["missing"]}`,
+			lookup:        []string{"missing"},
+			countVisitors: true,
+			statusCode:    http.StatusOK,
+			want:          map[string]*stats.Stats{"missing": nil},
+		},
+		{
+			name:       "Folded aliases",
+			body:       `{"aliases":["Google","google"]}`,
+			opts:       stats.BatchOptions{FoldAliases: true},
+			lookup:     []string{"google"},
+			links:      []storage.URL{{Alias: "google", Clicks: 7}},
+			statusCode: http.StatusOK,
+			want: map[string]*stats.Stats{
+				"Google": {Clicks: 7},
+				"google": {Clicks: 7},
+			},
+		},
+		{
+			name:       "Empty aliases",
+			body:       `{"aliases":[]}`,
+			respError:  "field Aliases is not valid",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Too many aliases",
+			body:       `{"aliases":["a","b","c"]}`,
+			opts:       stats.BatchOptions{MaxAliases: 2},
+			respError:  "at most 2 aliases may be requested",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Storage error",
+			body:       `{"aliases":["google"]}`,
+			lookup:     []string{"google"},
+			getError:   errors.New("unexpected error"),
+			respError:  "internal error",
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name:          "Counter error",
+			body:          `{"aliases":["google"]}`,
+			lookup:        []string{"google"},
+			links:         []storage.URL{{Alias: "google", Clicks: 7}},
+			countVisitors: true,
+			visitorKeys:   []string{"visitors:google"},
+			countError:    errors.New("connection refused"),
+			respError:     "internal error",
+			statusCode:    http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlsGetterMock := mocks.NewURLsGetter(t)
+			if tc.lookup != nil {
+				urlsGetterMock.On("GetURLsInfo", tc.lookup).
+					Return(tc.links, tc.getError).Once()
+			}
+
+			opts := tc.opts
+			if tc.countVisitors {
+				visitorsMock := mocks.NewBatchVisitorCounter(t)
+				if tc.visitorKeys != nil {
+					visitorsMock.On("PFCountEach", mock.Anything, tc.visitorKeys).
+						Return(tc.visitors, tc.countError).Once()
+				}
+				opts.Visitors = visitorsMock
+			}
+
+			handler := stats.NewBatch(slogdiscard.NewDiscardLogger(), urlsGetterMock, opts)
+
+			req, err := http.NewRequest(http.MethodPost, "/admin/url/stats", bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp stats.BatchResponse
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+			if tc.want != nil {
+				require.Equal(t, tc.want, resp.Stats)
+			}
+		})
+	}
+}
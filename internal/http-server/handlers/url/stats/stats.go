@@ -0,0 +1,136 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"url-shortener/internal/cache"
+	"url-shortener/internal/http-server/middleware/namespace"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/normalize"
+	"url-shortener/internal/storage"
+)
+
+type Response struct {
+	resp.Response
+	Alias  string `json:"alias,omitempty"`
+	Clicks int64  `json:"clicks"`
+	// UniqueVisitors is an estimate, HyperLogLogs are off by up to ~1%.
+	// It is omitted when visitors are not counted.
+	UniqueVisitors *int64 `json:"unique_visitors,omitempty"`
+	// Variants are the clicks of every variant of a split link.
+	Variants []Variant `json:"variants,omitempty"`
+}
+
+// Variant is a weighted target of a split link and its clicks.
+type Variant struct {
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+	Clicks int64  `json:"clicks"`
+}
+
+// URLGetter is an interface for getting a stored link by alias.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLGetter
+type URLGetter interface {
+	GetURLInfo(alias string) (storage.URL, error)
+}
+
+// VisitorCounter is an interface for reading the unique visitor estimate.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=VisitorCounter
+type VisitorCounter interface {
+	PFCount(ctx context.Context, keys ...string) (int64, error)
+}
+
+// VariantClicksGetter is an interface for reading the clicks of the
+// variants of a split link.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=VariantClicksGetter
+type VariantClicksGetter interface {
+	GetVariantClicks(alias string) (map[int]int64, error)
+}
+
+// Options holds the optional behaviour of the stats handler.
+type Options struct {
+	// FoldAliases looks aliases up case- and accent-insensitively.
+	FoldAliases bool
+	// Visitors estimates the unique visitors of the alias. The estimate
+	// is left out when it is nil.
+	Visitors VisitorCounter
+	// Variants reads the clicks of every variant of split links. They are
+	// left out when it is nil.
+	Variants VariantClicksGetter
+}
+
+func New(log *slog.Logger, urlGetter URLGetter, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.stats.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("invalid request"))
+			return
+		}
+		if opts.FoldAliases {
+			alias = normalize.Alias(alias)
+		}
+		alias = namespace.Qualify(r.Context(), alias)
+
+		link, err := urlGetter.GetURLInfo(alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url not found", slog.String("alias", alias))
+			resp.RenderError(w, r, http.StatusNotFound, resp.Error("not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get url", sl.Err(err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+			return
+		}
+
+		res := Response{
+			Response: resp.OK(),
+			Alias:    namespace.Unqualify(r.Context(), link.Alias),
+			Clicks:   link.Clicks,
+		}
+
+		if opts.Visitors != nil {
+			uniqueVisitors, err := opts.Visitors.PFCount(r.Context(), cache.VisitorsKey(link.Alias))
+			if err != nil {
+				log.Error("failed to count visitors", sl.Err(err))
+				resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+				return
+			}
+			res.UniqueVisitors = &uniqueVisitors
+		}
+
+		if opts.Variants != nil && len(link.Variants) > 0 {
+			clicks, err := opts.Variants.GetVariantClicks(link.Alias)
+			if err != nil {
+				log.Error("failed to get variant clicks", sl.Err(err))
+				resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+				return
+			}
+			res.Variants = make([]Variant, len(link.Variants))
+			for i, v := range link.Variants {
+				res.Variants[i] = Variant{URL: v.URL, Weight: v.Weight, Clicks: clicks[i]}
+			}
+		}
+
+		render.JSON(w, r, res)
+	}
+}
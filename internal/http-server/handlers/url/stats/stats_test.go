@@ -0,0 +1,145 @@
+package stats_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/stats"
+	"url-shortener/internal/http-server/handlers/url/stats/mocks"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestStatsHandler(t *testing.T) {
+	cases := []struct {
+		name           string
+		alias          string
+		clicks         int64
+		countVisitors  bool
+		getError       error
+		countError     error
+		uniqueVisitors int64
+		respError      string
+		statusCode     int
+	}{
+		{
+			name:       "Clicks",
+			alias:      "google",
+			clicks:     7,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:           "Clicks and visitors",
+			alias:          "google",
+			clicks:         7,
+			countVisitors:  true,
+			uniqueVisitors: 42,
+			statusCode:     http.StatusOK,
+		},
+		{
+			name:       "Not found",
+			alias:      "missing",
+			getError:   storage.ErrURLNotFound,
+			respError:  "not found",
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name:          "Counter error",
+			alias:         "google",
+			countVisitors: true,
+			countError:    errors.New("connection refused"),
+			respError:     "internal error",
+			statusCode:    http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+
+			urlGetterMock.On("GetURLInfo", tc.alias).
+				Return(storage.URL{Alias: tc.alias, Clicks: tc.clicks}, tc.getError).Once()
+
+			opts := stats.Options{}
+			if tc.countVisitors {
+				visitorsMock := mocks.NewVisitorCounter(t)
+				visitorsMock.On("PFCount", mock.Anything, "visitors:"+tc.alias).
+					Return(tc.uniqueVisitors, tc.countError).Once()
+				opts.Visitors = visitorsMock
+			}
+
+			r := chi.NewRouter()
+			r.Get("/url/{alias}/stats", stats.New(slogdiscard.NewDiscardLogger(), urlGetterMock, opts))
+
+			req, err := http.NewRequest(http.MethodGet, "/url/"+tc.alias+"/stats", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp stats.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+			require.Equal(t, tc.clicks, resp.Clicks)
+			if tc.countVisitors && tc.respError == "" {
+				require.NotNil(t, resp.UniqueVisitors)
+				require.Equal(t, tc.uniqueVisitors, *resp.UniqueVisitors)
+			} else {
+				require.Nil(t, resp.UniqueVisitors)
+			}
+		})
+	}
+}
+
+func TestStatsHandler_Variants(t *testing.T) {
+	urlGetterMock := mocks.NewURLGetter(t)
+	variantsMock := mocks.NewVariantClicksGetter(t)
+
+	urlGetterMock.On("GetURLInfo", "split").Return(storage.URL{
+		Alias:  "split",
+		Clicks: 5,
+		Variants: []storage.Variant{
+			{URL: "https://a.example.com", Weight: 1},
+			{URL: "https://b.example.com", Weight: 3},
+		},
+	}, nil).Once()
+	// variants never clicked have no count
+	variantsMock.On("GetVariantClicks", "split").Return(map[int]int64{1: 5}, nil).Once()
+
+	r := chi.NewRouter()
+	r.Get("/url/{alias}/stats", stats.New(slogdiscard.NewDiscardLogger(), urlGetterMock, stats.Options{
+		Variants: variantsMock,
+	}))
+
+	req, err := http.NewRequest(http.MethodGet, "/url/split/stats", nil)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp stats.Response
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	require.Equal(t, []stats.Variant{
+		{URL: "https://a.example.com", Weight: 1, Clicks: 0},
+		{URL: "https://b.example.com", Weight: 3, Clicks: 5},
+	}, resp.Variants)
+}
@@ -0,0 +1,142 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+
+	"url-shortener/internal/http-server/middleware/namespace"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/normalize"
+	"url-shortener/internal/lib/urlnorm"
+	"url-shortener/internal/lib/validate"
+	"url-shortener/internal/storage"
+)
+
+type Request struct {
+	URL string `json:"url" validate:"required,url"`
+}
+
+// URLUpdater is an interface for pointing a stored alias to a new URL.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLUpdater
+type URLUpdater interface {
+	UpdateURL(alias, newURL string) error
+}
+
+// URLCache is an interface for evicting a cached alias.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLCache
+type URLCache interface {
+	Delete(ctx context.Context, key string) error
+}
+
+// Options holds the optional behaviour of the update handler.
+type Options struct {
+	// FoldAliases looks aliases up case- and accent-insensitively.
+	FoldAliases bool
+	// AllowedSchemes are the accepted target URL schemes,
+	// validate.DefaultSchemes when empty.
+	AllowedSchemes []string
+	// CanonicalQuery sorts the query parameters of the new URL, as the
+	// save handler does.
+	CanonicalQuery bool
+	// NormalizeURLs saves the new URL in the form of urlnorm.Normalize,
+	// as the save handler does.
+	NormalizeURLs bool
+	// RelatedKeys returns further cache keys derived from an alias,
+	// such as cached responses about it, to evict along with it.
+	RelatedKeys func(alias string) []string
+}
+
+// New returns a handler pointing an existing alias to the URL in the
+// request body, validated as on save.
+func New(log *slog.Logger, urlUpdater URLUpdater, urlCache URLCache, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.update.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("invalid request"))
+			return
+		}
+		if opts.FoldAliases {
+			alias = normalize.Alias(alias)
+		}
+		stored := namespace.Qualify(r.Context(), alias)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.Error("invalid request", sl.Err(err))
+			for _, fe := range validateErr {
+				log.Debug("request field failed validation", sl.Validation(fe))
+			}
+			resp.RenderError(w, r, http.StatusBadRequest, resp.ValidationError(validateErr))
+			return
+		}
+
+		if err := validate.URL(req.URL, opts.AllowedSchemes); err != nil {
+			log.Error("invalid url", sl.Err(err))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error(err.Error()))
+			return
+		}
+
+		req.URL = urlnorm.Target(req.URL, opts.NormalizeURLs, opts.CanonicalQuery)
+
+		err = urlUpdater.UpdateURL(stored, req.URL)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url not found", slog.String("alias", stored))
+			resp.RenderError(w, r, http.StatusNotFound, resp.Error("not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to update url", sl.Err(err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("failed to update url"))
+			return
+		}
+
+		log.Info("url updated", slog.String("alias", stored))
+
+		// the next redirect must read the new URL from storage
+		if err := urlCache.Delete(r.Context(), stored); err != nil {
+			log.Error("failed to evict url from cache", sl.Err(err))
+		}
+		if opts.RelatedKeys != nil {
+			for _, key := range opts.RelatedKeys(alias) {
+				if err := urlCache.Delete(r.Context(), namespace.Qualify(r.Context(), key)); err != nil {
+					log.Error("failed to evict related key from cache", slog.String("key", key), sl.Err(err))
+				}
+			}
+		}
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,168 @@
+package update_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/update"
+	"url-shortener/internal/http-server/handlers/url/update/mocks"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestUpdateHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		alias      string
+		body       string
+		url        string
+		mockError  error
+		respStatus string
+		respError  string
+		statusCode int
+	}{
+		{
+			name:       "Success",
+			alias:      "campaign",
+			body:       `{"url": "https://example.com/spring"}`,
+			url:        "https://example.com/spring",
+			respStatus: resp.StatusOK,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Not found",
+			alias:      "missing",
+			body:       `{"url": "https://example.com/spring"}`,
+			url:        "https://example.com/spring",
+			mockError:  storage.ErrURLNotFound,
+			respStatus: resp.StatusError,
+			respError:  "not found",
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name:       "UpdateURL error",
+			alias:      "campaign",
+			body:       `{"url": "https://example.com/spring"}`,
+			url:        "https://example.com/spring",
+			mockError:  errors.New("unexpected error"),
+			respStatus: resp.StatusError,
+			respError:  "failed to update url",
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name:       "Empty body",
+			alias:      "campaign",
+			respStatus: resp.StatusError,
+			respError:  "empty request",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid URL",
+			alias:      "campaign",
+			body:       `{"url": "not a url"}`,
+			respStatus: resp.StatusError,
+			respError:  "field URL is not a valid URL",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid scheme",
+			alias:      "campaign",
+			body:       `{"url": "ftp://example.com/file"}`,
+			respStatus: resp.StatusError,
+			respError:  "url scheme is not allowed",
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlUpdaterMock := mocks.NewURLUpdater(t)
+			urlCacheMock := mocks.NewURLCache(t)
+
+			if tc.url != "" {
+				urlUpdaterMock.On("UpdateURL", tc.alias, tc.url).Return(tc.mockError).Once()
+			}
+			if tc.url != "" && tc.mockError == nil {
+				urlCacheMock.On("Delete", mock.Anything, tc.alias).Return(nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Put("/url/{alias}", update.New(slogdiscard.NewDiscardLogger(), urlUpdaterMock, urlCacheMock, update.Options{}))
+
+			req, err := http.NewRequest(http.MethodPut, "/url/"+tc.alias, bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var res resp.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+
+			require.Equal(t, tc.respStatus, res.Status)
+			require.Equal(t, tc.respError, res.Error)
+		})
+	}
+}
+
+func TestUpdateHandler_Options(t *testing.T) {
+	urlUpdaterMock := mocks.NewURLUpdater(t)
+	urlCacheMock := mocks.NewURLCache(t)
+
+	urlUpdaterMock.On("UpdateURL", "cafe", "https://example.com/?a=1&b=2").Return(nil).Once()
+	urlCacheMock.On("Delete", mock.Anything, "cafe").Return(nil).Once()
+
+	r := chi.NewRouter()
+	r.Put("/url/{alias}", update.New(slogdiscard.NewDiscardLogger(), urlUpdaterMock, urlCacheMock, update.Options{
+		FoldAliases:    true,
+		CanonicalQuery: true,
+	}))
+
+	req, err := http.NewRequest(http.MethodPut, "/url/Caf%C3%A9", bytes.NewReader([]byte(`{"url": "https://example.com/?b=2&a=1"}`)))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestUpdateHandler_NormalizeURLs(t *testing.T) {
+	urlUpdaterMock := mocks.NewURLUpdater(t)
+	urlCacheMock := mocks.NewURLCache(t)
+
+	urlUpdaterMock.On("UpdateURL", "test_alias", "https://example.com").Return(nil).Once()
+	urlCacheMock.On("Delete", mock.Anything, "test_alias").Return(nil).Once()
+	urlCacheMock.On("Delete", mock.Anything, "response:/admin/url/test_alias").Return(nil).Once()
+
+	r := chi.NewRouter()
+	r.Put("/url/{alias}", update.New(slogdiscard.NewDiscardLogger(), urlUpdaterMock, urlCacheMock, update.Options{
+		NormalizeURLs: true,
+		RelatedKeys: func(alias string) []string {
+			return []string{"response:/admin/url/" + alias}
+		},
+	}))
+
+	req, err := http.NewRequest(http.MethodPut, "/url/test_alias", bytes.NewReader([]byte(`{"url": "HTTPS://Example.com:443/"}`)))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+}
@@ -0,0 +1,80 @@
+package validate
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/validate"
+)
+
+type Request struct {
+	URL string `json:"url" validate:"required,url"`
+}
+
+type Response struct {
+	resp.Response
+	Valid bool `json:"valid"`
+}
+
+// Options holds the optional behaviour of the validate handler.
+type Options struct {
+	// AllowedSchemes are the accepted target URL schemes,
+	// validate.DefaultSchemes when empty.
+	AllowedSchemes []string
+}
+
+// New returns a handler running the save validations on a URL without
+// storing anything, so clients can give immediate feedback.
+func New(log *slog.Logger, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.validate.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.Info("invalid url", sl.Err(err))
+			for _, fe := range validateErr {
+				log.Debug("request field failed validation", sl.Validation(fe))
+			}
+			resp.RenderError(w, r, http.StatusBadRequest, resp.ValidationError(validateErr))
+			return
+		}
+
+		if err := validate.URL(req.URL, opts.AllowedSchemes); err != nil {
+			log.Info("invalid url", sl.Err(err))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error(err.Error()))
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Valid:    true,
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package validate_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/validate"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	libvalidate "url-shortener/internal/lib/validate"
+)
+
+func TestValidateHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		url        string
+		schemes    []string
+		valid      bool
+		respError  string
+		statusCode int
+	}{
+		{
+			name:       "Valid URL",
+			url:        "https://google.com",
+			valid:      true,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Empty URL",
+			url:        "",
+			respError:  "field URL is a required field",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid URL",
+			url:        "some invalid URL",
+			respError:  "field URL is not a valid URL",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid scheme",
+			url:        "ftp://example.com/file",
+			respError:  libvalidate.ErrInvalidScheme.Error(),
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Scheme not allowed",
+			url:        "tel:+1-555-0100",
+			schemes:    []string{"https"},
+			respError:  libvalidate.ErrInvalidScheme.Error(),
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Allowed mailto",
+			url:        "mailto:sales@example.com",
+			schemes:    []string{"https", "mailto"},
+			valid:      true,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Invalid mailto",
+			url:        "mailto:nobody",
+			schemes:    []string{"https", "mailto"},
+			respError:  libvalidate.ErrInvalidMailto.Error(),
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Too long",
+			url:        "https://example.com/" + strings.Repeat("a", libvalidate.MaxURLLength),
+			respError:  libvalidate.ErrTooLong.Error(),
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := validate.New(slogdiscard.NewDiscardLogger(), validate.Options{
+				AllowedSchemes: tc.schemes,
+			})
+
+			input := fmt.Sprintf(`{"url": "%s"}`, tc.url)
+
+			req, err := http.NewRequest(http.MethodPost, "/url/validate", bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp validate.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.valid, resp.Valid)
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package apikey
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	resp "url-shortener/internal/lib/api/response"
+	libapikey "url-shortener/internal/lib/apikey"
+	"url-shortener/internal/lib/logger/sl"
+)
+
+// Header carries the API key of a request.
+const Header = "X-API-Key"
+
+type ctxKey int
+
+const keyHashKey ctxKey = iota
+
+// Validator is an interface for checking API keys.
+type Validator interface {
+	ValidateAPIKey(key string) (bool, error)
+}
+
+// New answers requests without a valid API key with 401. Requests marked
+// as admin by auth.Admin need no key.
+func New(log *slog.Logger, keys Validator) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/apikey"),
+		)
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if auth.IsAdmin(r.Context()) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := r.Header.Get(Header)
+			if key == "" {
+				resp.RenderError(w, r, http.StatusUnauthorized, resp.Error("api key is required"))
+				return
+			}
+
+			ok, err := keys.ValidateAPIKey(key)
+			if err != nil {
+				log.Error("failed to validate api key",
+					slog.String("request_id", middleware.GetReqID(r.Context())),
+					sl.Err(err),
+				)
+				resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+				return
+			}
+			if !ok {
+				log.Info("invalid api key", slog.String("request_id", middleware.GetReqID(r.Context())))
+				resp.RenderError(w, r, http.StatusUnauthorized, resp.Error("invalid api key"))
+				return
+			}
+
+			r = r.WithContext(context.WithValue(r.Context(), keyHashKey, libapikey.Hash(key)))
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// KeyHash returns the hash of the API key r was authenticated with, so
+// later middleware can tell tenants apart without handling the key.
+func KeyHash(r *http.Request) (string, bool) {
+	hash, ok := r.Context().Value(keyHashKey).(string)
+	return hash, ok
+}
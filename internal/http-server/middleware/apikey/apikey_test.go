@@ -0,0 +1,110 @@
+package apikey_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/middleware/apikey"
+	"url-shortener/internal/http-server/middleware/auth"
+	libapikey "url-shortener/internal/lib/apikey"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+type keys map[string]bool
+
+func (k keys) ValidateAPIKey(key string) (bool, error) {
+	if key == "broken" {
+		return false, errors.New("connection refused")
+	}
+
+	return k[key], nil
+}
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		name       string
+		key        string
+		admin      bool
+		statusCode int
+		wantInBody string
+	}{
+		{
+			name:       "Valid key",
+			key:        "s3cret",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Missing key",
+			statusCode: http.StatusUnauthorized,
+			wantInBody: "api key is required",
+		},
+		{
+			name:       "Unknown key",
+			key:        "guess",
+			statusCode: http.StatusUnauthorized,
+			wantInBody: "invalid api key",
+		},
+		{
+			name:       "Storage error",
+			key:        "broken",
+			statusCode: http.StatusInternalServerError,
+			wantInBody: "internal error",
+		},
+		{
+			name:       "Admin",
+			admin:      true,
+			statusCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+			handler = apikey.New(slogdiscard.NewDiscardLogger(), keys{"s3cret": true})(handler)
+			handler = auth.Admin("admin", "password")(handler)
+
+			req := httptest.NewRequest(http.MethodPost, "/url", nil)
+			if tc.key != "" {
+				req.Header.Set(apikey.Header, tc.key)
+			}
+			if tc.admin {
+				req.SetBasicAuth("admin", "password")
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+			assert.Contains(t, rr.Body.String(), tc.wantInBody)
+		})
+	}
+}
+
+func TestKeyHash(t *testing.T) {
+	var (
+		hash string
+		ok   bool
+	)
+	handler := apikey.New(slogdiscard.NewDiscardLogger(), keys{"s3cret": true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hash, ok = apikey.KeyHash(r)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/url", nil)
+	req.Header.Set(apikey.Header, "s3cret")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.True(t, ok)
+	assert.Equal(t, libapikey.Hash("s3cret"), hash)
+
+	_, ok = apikey.KeyHash(httptest.NewRequest(http.MethodPost, "/url", nil))
+	assert.False(t, ok)
+}
@@ -0,0 +1,56 @@
+package correlation
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Header is the conventional correlation header.
+const Header = "X-Correlation-ID"
+
+// maxLength bounds the IDs accepted from callers.
+const maxLength = 128
+
+// New replaces middleware.RequestID. A valid ID in the given header is
+// used as the request ID, so the shortener's logs can be tied to upstream
+// callers; other requests get chi's generated ID. Either way the ID is
+// echoed in the response header.
+func New(header string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(header, middleware.GetReqID(r.Context()))
+			next.ServeHTTP(w, r)
+		})
+		generate := middleware.RequestID(echo)
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(header)
+			if !valid(id) {
+				generate.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), middleware.RequestIDKey, id)
+			echo.ServeHTTP(w, r.WithContext(ctx))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// valid accepts short printable ASCII IDs, keeping log lines intact.
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
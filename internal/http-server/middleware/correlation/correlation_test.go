@@ -0,0 +1,79 @@
+package correlation_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/middleware/correlation"
+	mwLogger "url-shortener/internal/http-server/middleware/logger"
+)
+
+func TestCorrelation(t *testing.T) {
+	cases := []struct {
+		name     string
+		incoming string
+		passed   bool
+	}{
+		{
+			name:     "Incoming ID",
+			incoming: "upstream-7f3a9c",
+			passed:   true,
+		},
+		{
+			name: "No ID",
+		},
+		{
+			name:     "Invalid ID",
+			incoming: "forged\nlevel=ERROR",
+		},
+		{
+			name:     "Too long",
+			incoming: strings.Repeat("a", 129),
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var logs bytes.Buffer
+
+			log := slog.New(slog.NewJSONHandler(&logs, nil))
+
+			var handled string
+			handler := correlation.New(correlation.Header)(mwLogger.New(log)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					handled = middleware.GetReqID(r.Context())
+				}),
+			))
+
+			req := httptest.NewRequest(http.MethodGet, "/test_alias", nil)
+			if tc.incoming != "" {
+				req.Header.Set(correlation.Header, tc.incoming)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			echoed := rr.Header().Get(correlation.Header)
+			require.NotEmpty(t, echoed)
+			assert.Equal(t, handled, echoed)
+			assert.Contains(t, logs.String(), `"request_id":"`+echoed+`"`)
+
+			if tc.passed {
+				assert.Equal(t, tc.incoming, echoed)
+			} else {
+				assert.NotEqual(t, tc.incoming, echoed)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package latency
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Observer is an interface for recording request durations per route.
+type Observer interface {
+	Observe(route string, d time.Duration)
+}
+
+// New records the duration of every routed request, keyed by method and
+// route pattern so that all aliases share one "GET /{alias}" entry.
+func New(log *slog.Logger, observer Observer) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/latency"),
+		)
+
+		log.Info("latency middleware enabled")
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			t1 := time.Now()
+
+			next.ServeHTTP(w, r)
+
+			rctx := chi.RouteContext(r.Context())
+			if rctx == nil || rctx.RoutePattern() == "" {
+				return
+			}
+
+			observer.Observe(r.Method+" "+rctx.RoutePattern(), time.Since(t1))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
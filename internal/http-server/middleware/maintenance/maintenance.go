@@ -0,0 +1,53 @@
+package maintenance
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	resp "url-shortener/internal/lib/api/response"
+)
+
+// Mode is the maintenance flag. It can be toggled at runtime and is safe
+// for concurrent use.
+type Mode struct {
+	enabled    atomic.Bool
+	retryAfter time.Duration
+}
+
+// NewMode creates a Mode. Blocked clients are told to retry after retryAfter.
+func NewMode(enabled bool, retryAfter time.Duration) *Mode {
+	m := &Mode{retryAfter: retryAfter}
+	m.enabled.Store(enabled)
+
+	return m
+}
+
+func (m *Mode) Enabled() bool {
+	return m.enabled.Load()
+}
+
+func (m *Mode) Set(enabled bool) {
+	m.enabled.Store(enabled)
+}
+
+// BlockWrites answers write requests with 503 while maintenance mode is
+// on. Reads keep being served.
+func (m *Mode) BlockWrites(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if !m.Enabled() || isRead(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Retry-After", strconv.Itoa(int(m.retryAfter.Seconds())))
+		resp.RenderError(w, r, http.StatusServiceUnavailable, resp.Error("service is under maintenance"))
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+func isRead(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
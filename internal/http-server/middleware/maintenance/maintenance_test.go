@@ -0,0 +1,71 @@
+package maintenance_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/middleware/maintenance"
+)
+
+func TestMode_BlockWrites(t *testing.T) {
+	mode := maintenance.NewMode(false, 2*time.Minute)
+
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+
+	r := chi.NewRouter()
+	r.Route("/url", func(r chi.Router) {
+		r.Use(mode.BlockWrites)
+
+		r.Post("/", ok)
+		r.Put("/{alias}", ok)
+		r.Delete("/{alias}", ok)
+		r.Get("/{alias}/stats", ok)
+	})
+	r.Get("/{alias}", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "https://example.com", http.StatusFound)
+	})
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(method, path, nil))
+		return rr
+	}
+
+	cases := []struct {
+		method      string
+		path        string
+		maintenance int
+		normal      int
+	}{
+		{method: http.MethodPost, path: "/url/", maintenance: http.StatusServiceUnavailable, normal: http.StatusOK},
+		{method: http.MethodPut, path: "/url/google", maintenance: http.StatusServiceUnavailable, normal: http.StatusOK},
+		{method: http.MethodDelete, path: "/url/google", maintenance: http.StatusServiceUnavailable, normal: http.StatusOK},
+		{method: http.MethodGet, path: "/url/google/stats", maintenance: http.StatusOK, normal: http.StatusOK},
+		{method: http.MethodGet, path: "/google", maintenance: http.StatusFound, normal: http.StatusFound},
+	}
+
+	// toggled at runtime, no restart
+	for _, enabled := range []bool{true, false} {
+		mode.Set(enabled)
+
+		for _, tc := range cases {
+			rr := do(tc.method, tc.path)
+
+			want := tc.normal
+			if enabled {
+				want = tc.maintenance
+			}
+			require.Equal(t, want, rr.Code, "%s %s, maintenance %v", tc.method, tc.path, enabled)
+
+			if rr.Code == http.StatusServiceUnavailable {
+				assert.Equal(t, "120", rr.Header().Get("Retry-After"))
+			}
+		}
+	}
+}
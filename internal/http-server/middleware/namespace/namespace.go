@@ -0,0 +1,92 @@
+package namespace
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	resp "url-shortener/internal/lib/api/response"
+)
+
+// Separator joins a namespace and an alias in storage. Custom aliases
+// may not contain it, so namespaced aliases never collide with others.
+const Separator = "~"
+
+type ctxKey int
+
+const namespaceKey ctxKey = iota
+
+// New scopes requests to the alias namespace their Host is mapped to in
+// domains. Requests on other hosts stay in the default namespace, whose
+// aliases are stored as they are. Paths containing Separator are not
+// found, they would reach the aliases of other namespaces.
+func New(domains map[string]string) func(next http.Handler) http.Handler {
+	namespaces := make(map[string]string, len(domains))
+	for domain, namespace := range domains {
+		namespaces[normalizeHost(domain)] = namespace
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if strings.Contains(r.URL.Path, Separator) {
+				resp.RenderError(w, r, http.StatusNotFound, resp.Error("not found"))
+				return
+			}
+
+			if namespace := namespaces[normalizeHost(r.Host)]; namespace != "" {
+				r = r.WithContext(context.WithValue(r.Context(), namespaceKey, namespace))
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// FromContext returns the namespace of the request, empty for the
+// default namespace.
+func FromContext(ctx context.Context) string {
+	namespace, _ := ctx.Value(namespaceKey).(string)
+	return namespace
+}
+
+// Qualify returns the alias under which alias is stored in the namespace
+// of the request.
+func Qualify(ctx context.Context, alias string) string {
+	namespace := FromContext(ctx)
+	if namespace == "" {
+		return alias
+	}
+
+	return namespace + Separator + alias
+}
+
+// Unqualify returns the alias a stored alias is known by in the namespace
+// of the request, the reverse of Qualify.
+func Unqualify(ctx context.Context, alias string) string {
+	namespace := FromContext(ctx)
+	if namespace == "" {
+		return alias
+	}
+
+	return strings.TrimPrefix(alias, namespace+Separator)
+}
+
+// Holds reports whether a stored alias belongs to the namespace of the
+// request. Aliases of other namespaces are qualified differently.
+func Holds(ctx context.Context, alias string) bool {
+	unqualified := Unqualify(ctx, alias)
+
+	return Qualify(ctx, unqualified) == alias && !strings.Contains(unqualified, Separator)
+}
+
+// normalizeHost drops the port, trailing dot and case of host.
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
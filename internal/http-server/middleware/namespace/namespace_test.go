@@ -0,0 +1,78 @@
+package namespace_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/middleware/namespace"
+)
+
+func TestNamespace(t *testing.T) {
+	cases := []struct {
+		name      string
+		host      string
+		path      string
+		namespace string
+		qualified string
+		notFound  bool
+	}{
+		{
+			name:      "Vanity domain",
+			host:      "a.example.com",
+			path:      "/abc",
+			namespace: "a",
+			qualified: "a~abc",
+		},
+		{
+			name:      "Port and case",
+			host:      "A.Example.com.:8082",
+			path:      "/abc",
+			namespace: "a",
+			qualified: "a~abc",
+		},
+		{
+			name:      "Default namespace",
+			host:      "example.com",
+			path:      "/abc",
+			qualified: "abc",
+		},
+		{
+			name:     "Separator in path",
+			host:     "example.com",
+			path:     "/a~abc",
+			notFound: true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var called bool
+			handler := namespace.New(map[string]string{"a.example.com": "a"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				assert.Equal(t, tc.namespace, namespace.FromContext(r.Context()))
+				assert.Equal(t, tc.qualified, namespace.Qualify(r.Context(), "abc"))
+				assert.Equal(t, "abc", namespace.Unqualify(r.Context(), tc.qualified))
+				assert.True(t, namespace.Holds(r.Context(), tc.qualified))
+				assert.False(t, namespace.Holds(r.Context(), "b~abc"))
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Host = tc.host
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, !tc.notFound, called)
+			if tc.notFound {
+				require.Equal(t, http.StatusNotFound, rr.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+)
+
+// WindowCounter is an interface for counting requests in fixed windows
+// shared by every instance, such as Redis.
+type WindowCounter interface {
+	IncrWindow(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error)
+}
+
+// KeyOptions configures a KeyLimiter.
+type KeyOptions struct {
+	// Key returns the tenant a request was authenticated as, e.g. the
+	// hash of its API key.
+	Key func(r *http.Request) (string, bool)
+	// Requests per Window are admitted from every tenant, unless
+	// Limits has its own.
+	Requests int
+	Window   time.Duration
+	Limits   map[string]int
+}
+
+// KeyLimiter limits authenticated clients by tenant rather than IP, so
+// tenants sharing an address don't starve each other and a tenant can't
+// spread over many. Counts are kept in a WindowCounter shared by every
+// instance. Unauthenticated clients are left to a per-IP Limiter.
+type KeyLimiter struct {
+	log     *slog.Logger
+	counter WindowCounter
+	byIP    *Limiter
+	opts    KeyOptions
+}
+
+// NewKeyLimiter creates a KeyLimiter falling back to byIP.
+func NewKeyLimiter(log *slog.Logger, counter WindowCounter, byIP *Limiter, opts KeyOptions) *KeyLimiter {
+	return &KeyLimiter{
+		log:     log.With(slog.String("component", "middleware/ratelimit")),
+		counter: counter,
+		byIP:    byIP,
+		opts:    opts,
+	}
+}
+
+// Limit answers tenants past their limit with 429.
+func (l *KeyLimiter) Limit(next http.Handler) http.Handler {
+	limitedByIP := l.byIP.Limit(next)
+
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		key, ok := l.opts.Key(r)
+		if !ok {
+			limitedByIP.ServeHTTP(w, r)
+			return
+		}
+
+		limit := l.opts.Requests
+		if n, ok := l.opts.Limits[key]; ok {
+			limit = n
+		}
+
+		count, left, err := l.counter.IncrWindow(r.Context(), "ratelimit:"+key, l.opts.Window)
+		if err != nil {
+			// an unavailable counter must not take the API down
+			l.log.Error("failed to count request", sl.Err(err))
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if count > int64(limit) {
+			l.log.Info("rate limit exceeded",
+				slog.String("key", key),
+				slog.String("path", r.URL.Path),
+			)
+
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(left.Seconds()))))
+			resp.RenderError(w, r, http.StatusTooManyRequests, resp.Error("rate limit exceeded"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,98 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/middleware/ratelimit"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+// windowCounter counts hits in a single never-ending window.
+type windowCounter struct {
+	mu     sync.Mutex
+	counts map[string]int64
+	err    error
+}
+
+func (c *windowCounter) IncrWindow(_ context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	if c.err != nil {
+		return 0, 0, c.err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.counts[key]++
+	return c.counts[key], window / 2, nil
+}
+
+func newKeyLimiter(counter *windowCounter) http.Handler {
+	byIP := ratelimit.New(slogdiscard.NewDiscardLogger(), 1, time.Minute)
+
+	limiter := ratelimit.NewKeyLimiter(slogdiscard.NewDiscardLogger(), counter, byIP, ratelimit.KeyOptions{
+		Key: func(r *http.Request) (string, bool) {
+			key := r.Header.Get("X-Tenant")
+			return key, key != ""
+		},
+		Requests: 2,
+		Window:   time.Minute,
+		Limits:   map[string]int{"big": 3},
+	})
+
+	return limiter.Limit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+}
+
+func serve(handler http.Handler, tenant string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/url", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	if tenant != "" {
+		req.Header.Set("X-Tenant", tenant)
+	}
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestKeyLimiter(t *testing.T) {
+	handler := newKeyLimiter(&windowCounter{counts: map[string]int64{}})
+
+	// tenants behind the same address get buckets of their own
+	for _, tenant := range []string{"a", "b"} {
+		for i := 0; i < 2; i++ {
+			require.Equal(t, http.StatusOK, serve(handler, tenant).Code, tenant)
+		}
+	}
+
+	rr := serve(handler, "a")
+	require.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "30", rr.Header().Get("Retry-After"))
+	require.Equal(t, http.StatusTooManyRequests, serve(handler, "b").Code)
+
+	// tenants may have limits of their own
+	for i := 0; i < 3; i++ {
+		require.Equal(t, http.StatusOK, serve(handler, "big").Code)
+	}
+	require.Equal(t, http.StatusTooManyRequests, serve(handler, "big").Code)
+
+	// unauthenticated clients are limited by IP
+	require.Equal(t, http.StatusOK, serve(handler, "").Code)
+	require.Equal(t, http.StatusTooManyRequests, serve(handler, "").Code)
+}
+
+func TestKeyLimiter_CounterError(t *testing.T) {
+	handler := newKeyLimiter(&windowCounter{err: errors.New("connection refused")})
+
+	for i := 0; i < 5; i++ {
+		require.Equal(t, http.StatusOK, serve(handler, "a").Code)
+	}
+}
@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	resp "url-shortener/internal/lib/api/response"
+)
+
+// Limiter limits every client IP to a number of requests per window with
+// a token bucket, so clients may burst up to the whole window's requests.
+type Limiter struct {
+	log    *slog.Logger
+	limit  rate.Limit
+	burst  int
+	window time.Duration
+
+	mu        sync.Mutex
+	clients   map[string]*client
+	lastSweep time.Time
+}
+
+type client struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// New creates a Limiter admitting requests per window from every client IP.
+func New(log *slog.Logger, requests int, window time.Duration) *Limiter {
+	requests = max(requests, 1)
+
+	return &Limiter{
+		log:       log.With(slog.String("component", "middleware/ratelimit")),
+		limit:     rate.Limit(float64(requests) / window.Seconds()),
+		burst:     requests,
+		window:    window,
+		clients:   make(map[string]*client),
+		lastSweep: time.Now(),
+	}
+}
+
+// Limit answers requests of clients past their limit with 429.
+func (l *Limiter) Limit(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ip := ClientIP(r)
+
+		now := time.Now()
+		res := l.limiter(ip, now).ReserveN(now, 1)
+
+		if delay := res.DelayFrom(now); delay > 0 {
+			// the request is refused, it must not use up a token
+			res.CancelAt(now)
+
+			l.log.Info("rate limit exceeded",
+				slog.String("ip", ip),
+				slog.String("path", r.URL.Path),
+			)
+
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+			resp.RenderError(w, r, http.StatusTooManyRequests, resp.Error("rate limit exceeded"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// limiter returns the bucket of ip. Buckets idle for a whole window are
+// full again, so they are dropped instead of kept for every IP ever seen.
+func (l *Limiter) limiter(ip string, now time.Time) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > l.window {
+		for key, c := range l.clients {
+			if now.Sub(c.lastSeen) > l.window {
+				delete(l.clients, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	c, ok := l.clients[ip]
+	if !ok {
+		c = &client{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[ip] = c
+	}
+	c.lastSeen = now
+
+	return c.limiter
+}
+
+// ClientIP returns the IP of the client that made r: the first address
+// of X-Forwarded-For when a proxy set it, otherwise the peer address.
+func ClientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		ip, _, _ := strings.Cut(forwarded, ",")
+		if ip = strings.TrimSpace(ip); ip != "" {
+			return ip
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
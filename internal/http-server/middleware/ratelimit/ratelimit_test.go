@@ -0,0 +1,87 @@
+package ratelimit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/middleware/ratelimit"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestLimiter(t *testing.T) {
+	limiter := ratelimit.New(slogdiscard.NewDiscardLogger(), 3, time.Minute)
+
+	handler := limiter.Limit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/url", nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// under the limit everything is served
+	for i := 0; i < 3; i++ {
+		require.Equal(t, http.StatusOK, serve("10.0.0.1:1234", "").Code)
+	}
+
+	// over the limit the client is told when to come back
+	rr := serve("10.0.0.1:5678", "")
+	require.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Contains(t, rr.Body.String(), "rate limit exceeded")
+
+	retryAfter, err := strconv.Atoi(rr.Header().Get("Retry-After"))
+	require.NoError(t, err)
+	assert.InDelta(t, 20, retryAfter, 1)
+
+	// other clients have buckets of their own
+	require.Equal(t, http.StatusOK, serve("10.0.0.2:1234", "").Code)
+
+	// clients behind a proxy are told apart by X-Forwarded-For
+	for i := 0; i < 3; i++ {
+		require.Equal(t, http.StatusOK, serve("10.0.0.1:1234", "203.0.113.7, 10.0.0.1").Code)
+	}
+	require.Equal(t, http.StatusTooManyRequests, serve("10.0.0.3:1234", "203.0.113.7").Code)
+}
+
+func TestClientIP(t *testing.T) {
+	cases := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		want         string
+	}{
+		{name: "Remote address", remoteAddr: "192.0.2.1:1234", want: "192.0.2.1"},
+		{name: "IPv6 remote address", remoteAddr: "[2001:db8::1]:1234", want: "2001:db8::1"},
+		{name: "Forwarded", remoteAddr: "10.0.0.1:1234", forwardedFor: "203.0.113.7", want: "203.0.113.7"},
+		{name: "Forwarded twice", remoteAddr: "10.0.0.1:1234", forwardedFor: " 203.0.113.7 , 10.0.0.2", want: "203.0.113.7"},
+		{name: "Empty forwarded", remoteAddr: "10.0.0.1:1234", forwardedFor: ",", want: "10.0.0.1"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.remoteAddr
+			if tc.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tc.forwardedFor)
+			}
+
+			assert.Equal(t, tc.want, ratelimit.ClientIP(req))
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package respcache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-redis/redis/v8"
+
+	"url-shortener/internal/http-server/middleware/namespace"
+	"url-shortener/internal/lib/logger/sl"
+)
+
+// Cache is an interface for storing rendered responses.
+type Cache interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+}
+
+type entry struct {
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// Key is the cache key of the response to GET path.
+func Key(path string) string {
+	return "response:" + path
+}
+
+// KeyFunc returns the cache key of the response to r. Requests given the
+// same key share a cached response.
+type KeyFunc func(r *http.Request) string
+
+// New serves repeated GET requests from the cache for ttl. Only 200
+// responses are cached, keyed by key, or Key of the path when key is nil;
+// evict the same key when the underlying data changes.
+func New(log *slog.Logger, cache Cache, ttl time.Duration, key KeyFunc) func(next http.Handler) http.Handler {
+	if key == nil {
+		key = func(r *http.Request) string { return Key(r.URL.Path) }
+	}
+
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/respcache"),
+		)
+
+		log.Info("response cache middleware enabled", slog.Duration("ttl", ttl))
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cacheKey := namespace.Qualify(r.Context(), key(r))
+
+			cached, err := cache.Get(r.Context(), cacheKey)
+			if err == nil {
+				var e entry
+				if err := json.Unmarshal([]byte(cached), &e); err == nil {
+					w.Header().Set("Content-Type", e.ContentType)
+					w.Header().Set("X-Cache", "HIT")
+					_, _ = w.Write(e.Body)
+					return
+				}
+				log.Error("failed to decode cached response", sl.Err(err))
+			} else if err != redis.Nil {
+				log.Error("failed to get cached response", sl.Err(err))
+			}
+
+			var body bytes.Buffer
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&body)
+			ww.Header().Set("X-Cache", "MISS")
+
+			next.ServeHTTP(ww, r)
+
+			if ww.Status() != http.StatusOK {
+				return
+			}
+
+			value, err := json.Marshal(entry{
+				ContentType: ww.Header().Get("Content-Type"),
+				Body:        body.Bytes(),
+			})
+			if err != nil {
+				log.Error("failed to encode response", sl.Err(err))
+				return
+			}
+
+			if err := cache.Set(r.Context(), cacheKey, value, ttl); err != nil {
+				log.Error("failed to cache response", sl.Err(err))
+			}
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,129 @@
+package respcache_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/middleware/respcache"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+type memCache struct {
+	mu    sync.Mutex
+	items map[string]string
+}
+
+func (c *memCache) Get(_ context.Context, key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.items[key]
+	if !ok {
+		return "", redis.Nil
+	}
+	return v, nil
+}
+
+func (c *memCache) Set(_ context.Context, key string, value interface{}, _ time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items[key] = string(value.([]byte))
+	return nil
+}
+
+func (c *memCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.items, key)
+}
+
+func TestRespCache(t *testing.T) {
+	cache := &memCache{items: map[string]string{}}
+
+	calls := 0
+	handler := respcache.New(slogdiscard.NewDiscardLogger(), cache, time.Minute, nil)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if r.URL.Path == "/admin/url/missing" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			_, _ = w.Write([]byte(`{"status":"OK","alias":"abc"}`))
+		}),
+	)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	first := get("/admin/url/abc")
+	require.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "MISS", first.Header().Get("X-Cache"))
+
+	// the second identical request never reaches the handler
+	second := get("/admin/url/abc")
+	require.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, "HIT", second.Header().Get("X-Cache"))
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "application/json; charset=utf-8", second.Header().Get("Content-Type"))
+	assert.Equal(t, 1, calls)
+
+	// an update evicts the response
+	cache.Delete(respcache.Key("/admin/url/abc"))
+
+	third := get("/admin/url/abc")
+	assert.Equal(t, "MISS", third.Header().Get("X-Cache"))
+	assert.Equal(t, 2, calls)
+
+	// errors are not cached
+	get("/admin/url/missing")
+	get("/admin/url/missing")
+	assert.Equal(t, 4, calls)
+}
+
+func TestRespCache_Key(t *testing.T) {
+	cache := &memCache{items: map[string]string{}}
+
+	// keyed like the handlers evicting responses with folded aliases
+	key := func(r *http.Request) string {
+		return respcache.Key(strings.ToLower(r.URL.Path))
+	}
+
+	calls := 0
+	handler := respcache.New(slogdiscard.NewDiscardLogger(), cache, time.Minute, key)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			_, _ = w.Write([]byte(`{"status":"OK"}`))
+		}),
+	)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	assert.Equal(t, "MISS", get("/admin/url/ABC").Header().Get("X-Cache"))
+	assert.Equal(t, "HIT", get("/admin/url/abc").Header().Get("X-Cache"))
+	assert.Equal(t, 1, calls)
+
+	// evicting the folded key evicts the response to every spelling
+	cache.Delete(respcache.Key("/admin/url/abc"))
+
+	assert.Equal(t, "MISS", get("/admin/url/ABC").Header().Get("X-Cache"))
+	assert.Equal(t, 2, calls)
+}
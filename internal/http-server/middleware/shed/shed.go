@@ -0,0 +1,67 @@
+package shed
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	resp "url-shortener/internal/lib/api/response"
+)
+
+// Limiter caps the requests in flight. Reads, redirects above all, may
+// use the whole capacity, while writes are shed once only the capacity
+// reserved for reads is left, so saves are turned away first under load.
+type Limiter struct {
+	log        *slog.Logger
+	inFlight   atomic.Int64
+	maxReads   int64
+	maxWrites  int64
+	retryAfter time.Duration
+}
+
+// NewLimiter creates a Limiter admitting maxInFlight requests, of which
+// reservedForReads are never given to writes. Shed clients are told to
+// retry after retryAfter.
+func NewLimiter(log *slog.Logger, maxInFlight, reservedForReads int, retryAfter time.Duration) *Limiter {
+	return &Limiter{
+		log:        log.With(slog.String("component", "middleware/shed")),
+		maxReads:   int64(maxInFlight),
+		maxWrites:  int64(max(maxInFlight-reservedForReads, 0)),
+		retryAfter: retryAfter,
+	}
+}
+
+// Limit answers requests past the limit of their priority with 503.
+func (l *Limiter) Limit(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		limit := l.maxWrites
+		if isRead(r.Method) {
+			limit = l.maxReads
+		}
+
+		n := l.inFlight.Add(1)
+		defer l.inFlight.Add(-1)
+
+		if n > limit {
+			l.log.Warn("request shed",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int64("in_flight", n-1),
+			)
+
+			w.Header().Set("Retry-After", strconv.Itoa(int(l.retryAfter.Seconds())))
+			resp.RenderError(w, r, http.StatusServiceUnavailable, resp.Error("server is overloaded"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+func isRead(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
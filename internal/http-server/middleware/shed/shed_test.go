@@ -0,0 +1,68 @@
+package shed_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/middleware/shed"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestLimiter_ShedsWritesFirst(t *testing.T) {
+	limiter := shed.NewLimiter(slogdiscard.NewDiscardLogger(), 3, 1, 2*time.Second)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	r := chi.NewRouter()
+	r.Use(limiter.Limit)
+	r.Get("/slow", func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	})
+	r.Get("/{alias}", func(w http.ResponseWriter, r *http.Request) {})
+	r.Post("/url", func(w http.ResponseWriter, r *http.Request) {})
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(method, path, nil))
+		return rr
+	}
+
+	var wg sync.WaitGroup
+	hold := func() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serve(http.MethodGet, "/slow")
+		}()
+		<-started
+	}
+
+	// below the write limit everything is served
+	hold()
+	require.Equal(t, http.StatusOK, serve(http.MethodPost, "/url").Code)
+
+	// at the write limit saves are shed, redirects still get the reserve
+	hold()
+	rr := serve(http.MethodPost, "/url")
+	require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "2", rr.Header().Get("Retry-After"))
+	require.Equal(t, http.StatusOK, serve(http.MethodGet, "/alias").Code)
+
+	// at full capacity redirects are shed too
+	hold()
+	require.Equal(t, http.StatusServiceUnavailable, serve(http.MethodGet, "/alias").Code)
+
+	close(release)
+	wg.Wait()
+
+	require.Equal(t, http.StatusOK, serve(http.MethodPost, "/url").Code)
+}
@@ -0,0 +1,73 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/render"
+)
+
+// ContentTypeProblem is the media type of RFC 7807 problem details.
+const ContentTypeProblem = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+type ctxKey int
+
+const problemsKey ctxKey = iota
+
+// PreferProblems renders every error of the wrapped handlers as problem
+// details, whatever the client's Accept header says.
+func PreferProblems(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), problemsKey, true)))
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// RenderError writes res with status. It is rendered as problem details
+// instead when the client accepts application/problem+json or the
+// request went through PreferProblems.
+func RenderError(w http.ResponseWriter, r *http.Request, status int, res Response) {
+	if !wantsProblem(r) {
+		render.Status(r, status)
+		render.JSON(w, r, res)
+		return
+	}
+
+	problem := Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   res.Error,
+		Instance: r.URL.Path,
+	}
+
+	body, err := json.Marshal(problem)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentTypeProblem)
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+func wantsProblem(r *http.Request) bool {
+	if prefer, _ := r.Context().Value(problemsKey).(bool); prefer {
+		return true
+	}
+
+	return strings.Contains(r.Header.Get("Accept"), ContentTypeProblem)
+}
@@ -0,0 +1,82 @@
+// Package bloom implements a Bloom filter of strings.
+package bloom
+
+import (
+	"hash/fnv"
+	"math"
+	"sync"
+)
+
+// Filter is a Bloom filter safe for concurrent use. MayContain never
+// reports false for an added string, but may report true for strings
+// that were never added. Strings can't be removed.
+type Filter struct {
+	mu     sync.RWMutex
+	bits   []uint64
+	m      uint64
+	hashes uint64
+}
+
+// New returns a filter sized for capacity strings at a false positive
+// rate of fpRate. More strings can be added, at a higher rate.
+func New(capacity int, fpRate float64) *Filter {
+	capacity = max(capacity, 1)
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+
+	m := uint64(math.Ceil(-float64(capacity) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	m = max(m, 64)
+	hashes := uint64(math.Round(float64(m) / float64(capacity) * math.Ln2))
+
+	return &Filter{
+		bits:   make([]uint64, (m+63)/64),
+		m:      m,
+		hashes: max(hashes, 1),
+	}
+}
+
+// Add adds s to the filter.
+func (f *Filter) Add(s string) {
+	h1, h2 := hash(s)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := uint64(0); i < f.hashes; i++ {
+		bit := (h1 + i*h2) % f.m
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// MayContain reports whether s may have been added. When it returns
+// false, s was definitely never added.
+func (f *Filter) MayContain(s string) bool {
+	h1, h2 := hash(s)
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for i := uint64(0); i < f.hashes; i++ {
+		bit := (h1 + i*h2) % f.m
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hash derives the two hashes combined into the filter's k hashes
+// (Kirsch and Mitzenmacher).
+func hash(s string) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	h1 := h.Sum64()
+
+	h.Write([]byte{0})
+	h2 := h.Sum64()
+
+	// an even step would cycle through only part of an even-sized filter
+	return h1, h2 | 1
+}
@@ -0,0 +1,39 @@
+package bloom
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter(t *testing.T) {
+	f := New(1000, 0.01)
+
+	for i := 0; i < 1000; i++ {
+		f.Add(fmt.Sprintf("alias-%d", i))
+	}
+
+	// added strings are never missed
+	for i := 0; i < 1000; i++ {
+		assert.True(t, f.MayContain(fmt.Sprintf("alias-%d", i)))
+	}
+
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if f.MayContain(fmt.Sprintf("absent-%d", i)) {
+			falsePositives++
+		}
+	}
+	// 1% expected, leave room for an unlucky hash
+	assert.Less(t, falsePositives, 300)
+}
+
+func TestFilter_Empty(t *testing.T) {
+	f := New(0, 0)
+
+	assert.False(t, f.MayContain("google"))
+
+	f.Add("google")
+	assert.True(t, f.MayContain("google"))
+}
@@ -0,0 +1,28 @@
+package expiry
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Header is set on responses about links close to expiring, to the whole
+// seconds they have left, so clients can prompt users to refresh them.
+const Header = "X-Link-Expires-In"
+
+// Warn sets Header on h when expiresAt is less than window after now.
+// Links that never expire, and any link when window is not positive,
+// get no header.
+func Warn(h http.Header, expiresAt *time.Time, window time.Duration, now time.Time) {
+	if expiresAt == nil || window <= 0 {
+		return
+	}
+
+	left := expiresAt.Sub(now)
+	if left <= 0 || left >= window {
+		return
+	}
+
+	h.Set(Header, strconv.Itoa(int(math.Ceil(left.Seconds()))))
+}
@@ -0,0 +1,62 @@
+package expiry
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarn(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+
+	cases := []struct {
+		name      string
+		expiresAt *time.Time
+		window    time.Duration
+		want      string
+	}{
+		{
+			name:      "Within window",
+			expiresAt: at(90*time.Second + 500*time.Millisecond),
+			window:    time.Hour,
+			want:      "91",
+		},
+		{
+			name:      "Outside window",
+			expiresAt: at(2 * time.Hour),
+			window:    time.Hour,
+		},
+		{
+			name:   "Never expires",
+			window: time.Hour,
+		},
+		{
+			name:      "Already expired",
+			expiresAt: at(-time.Second),
+			window:    time.Hour,
+		},
+		{
+			name:      "Disabled",
+			expiresAt: at(time.Minute),
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := http.Header{}
+			Warn(h, tc.expiresAt, tc.window, now)
+
+			assert.Equal(t, tc.want, h.Get(Header))
+		})
+	}
+}
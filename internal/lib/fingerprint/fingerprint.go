@@ -0,0 +1,136 @@
+package fingerprint
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrForbiddenAddress = errors.New("target address is not public")
+	ErrTooLarge         = errors.New("target body is too large")
+	ErrBadStatus        = errors.New("target returned a non-2xx status")
+)
+
+// maxRedirects bounds the redirects followed while fetching a target.
+const maxRedirects = 5
+
+// sharedAddressSpace is the carrier-grade NAT range, which netip doesn't
+// report as private.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// Options configures a Fetcher.
+type Options struct {
+	// Timeout bounds the whole fetch, redirects and body included.
+	Timeout time.Duration
+	// MaxBytes is the largest body hashed, larger targets are not fingerprinted.
+	MaxBytes int64
+	// AllowPrivate lets the fetcher reach loopback and private addresses.
+	// It is only meant for tests.
+	AllowPrivate bool
+}
+
+// Fetcher fetches link targets and hashes their bodies, so aliases whose
+// different URLs serve the same content can be grouped.
+type Fetcher struct {
+	client   *http.Client
+	maxBytes int64
+}
+
+// New creates a Fetcher. Unless opts.AllowPrivate is set it refuses to
+// connect to non-public addresses. The check runs on the dialed address,
+// so DNS answers and redirects can't point it at internal services.
+func New(opts Options) *Fetcher {
+	dialer := &net.Dialer{Timeout: opts.Timeout}
+	if !opts.AllowPrivate {
+		dialer.Control = guard
+	}
+
+	return &Fetcher{
+		client: &http.Client{
+			Timeout: opts.Timeout,
+			Transport: &http.Transport{
+				// a proxy would dial on our behalf and bypass the guard
+				Proxy:       nil,
+				DialContext: dialer.DialContext,
+			},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= maxRedirects {
+					return fmt.Errorf("stopped after %d redirects", maxRedirects)
+				}
+				if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+					return fmt.Errorf("redirect to %s scheme", req.URL.Scheme)
+				}
+				return nil
+			},
+		},
+		maxBytes: opts.MaxBytes,
+	}
+}
+
+// Fingerprint returns the hex-encoded SHA-256 of the body served at target.
+func (f *Fetcher) Fingerprint(ctx context.Context, target string) (string, error) {
+	const op = "fingerprint.Fetcher.Fingerprint"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("%s: %w: %d", op, ErrBadStatus, resp.StatusCode)
+	}
+
+	h := sha256.New()
+
+	n, err := io.Copy(h, io.LimitReader(resp.Body, f.maxBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if n > f.maxBytes {
+		return "", fmt.Errorf("%s: %w", op, ErrTooLarge)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// guard is a net.Dialer Control func refusing non-public addresses.
+func guard(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+
+	if !public(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, ip)
+	}
+
+	return nil
+}
+
+func public(ip netip.Addr) bool {
+	ip = ip.Unmap()
+
+	return ip.IsGlobalUnicast() &&
+		!ip.IsPrivate() &&
+		!sharedAddressSpace.Contains(ip)
+}
@@ -0,0 +1,94 @@
+package fingerprint
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetcher_Fingerprint(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a", "/b":
+			_, _ = w.Write([]byte("same content"))
+		case "/other":
+			_, _ = w.Write([]byte("other content"))
+		case "/moved":
+			http.Redirect(w, r, "/a", http.StatusFound)
+		case "/large":
+			_, _ = w.Write([]byte(strings.Repeat("x", 100)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	f := New(Options{Timeout: time.Second, MaxBytes: 64, AllowPrivate: true})
+	ctx := context.Background()
+
+	a, err := f.Fingerprint(ctx, srv.URL+"/a")
+	require.NoError(t, err)
+	require.Len(t, a, 64)
+
+	b, err := f.Fingerprint(ctx, srv.URL+"/b")
+	require.NoError(t, err)
+	assert.Equal(t, a, b)
+
+	moved, err := f.Fingerprint(ctx, srv.URL+"/moved")
+	require.NoError(t, err)
+	assert.Equal(t, a, moved)
+
+	other, err := f.Fingerprint(ctx, srv.URL+"/other")
+	require.NoError(t, err)
+	assert.NotEqual(t, a, other)
+
+	_, err = f.Fingerprint(ctx, srv.URL+"/large")
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	_, err = f.Fingerprint(ctx, srv.URL+"/missing")
+	assert.ErrorIs(t, err, ErrBadStatus)
+}
+
+func TestFetcher_RefusesPrivateAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("internal"))
+	}))
+	defer srv.Close()
+
+	f := New(Options{Timeout: time.Second, MaxBytes: 64})
+
+	_, err := f.Fingerprint(context.Background(), srv.URL)
+	assert.ErrorIs(t, err, ErrForbiddenAddress)
+}
+
+func TestPublic(t *testing.T) {
+	cases := []struct {
+		ip     string
+		public bool
+	}{
+		{ip: "93.184.216.34", public: true},
+		{ip: "2606:2800:220:1::1", public: true},
+		{ip: "127.0.0.1"},
+		{ip: "::1"},
+		{ip: "10.0.0.1"},
+		{ip: "172.16.0.1"},
+		{ip: "192.168.1.1"},
+		{ip: "169.254.169.254"},
+		{ip: "100.64.0.1"},
+		{ip: "0.0.0.0"},
+		{ip: "fd00::1"},
+		{ip: "fe80::1"},
+		{ip: "::ffff:127.0.0.1"},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.public, public(netip.MustParseAddr(tc.ip)), tc.ip)
+	}
+}
@@ -0,0 +1,110 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/random"
+)
+
+var (
+	ErrEmptyAlias = errors.New("generator returned an empty alias")
+)
+
+// Generator generates aliases for links saved without one.
+type Generator interface {
+	Generate(ctx context.Context) (string, error)
+}
+
+// Random generates aliases locally.
+type Random struct {
+	Length int
+	// Alphabet is the characters aliases are drawn from, random.Base62
+	// when empty.
+	Alphabet string
+}
+
+func (g Random) Generate(_ context.Context) (string, error) {
+	return random.NewRandomStringFrom(g.Length, alphabetOr(g.Alphabet)), nil
+}
+
+// alphabetOr returns alphabet, or random.Base62 when it is empty.
+func alphabetOr(alphabet string) string {
+	if alphabet == "" {
+		return random.Base62
+	}
+
+	return alphabet
+}
+
+// HTTP delegates alias generation to an external ID service. The service
+// is called with POST and must answer with {"alias": "..."}. Whenever it
+// fails or times out the alias comes from Fallback instead.
+type HTTP struct {
+	log      *slog.Logger
+	url      string
+	client   *http.Client
+	fallback Generator
+}
+
+// NewHTTP creates an HTTP generator calling url. The client's timeout
+// bounds how long a save waits for the service.
+func NewHTTP(log *slog.Logger, url string, client *http.Client, fallback Generator) *HTTP {
+	return &HTTP{
+		log: log.With(
+			slog.String("component", "generator/http"),
+		),
+		url:      url,
+		client:   client,
+		fallback: fallback,
+	}
+}
+
+func (g *HTTP) Generate(ctx context.Context) (string, error) {
+	alias, err := g.fetch(ctx)
+	if err != nil {
+		g.log.Warn("external generator failed, falling back to local generation", sl.Err(err))
+		return g.fallback.Generate(ctx)
+	}
+
+	return alias, nil
+}
+
+func (g *HTTP) fetch(ctx context.Context) (string, error) {
+	const op = "generator.HTTP.fetch"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.url, nil)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: unexpected status code %d", op, resp.StatusCode)
+	}
+
+	var body struct {
+		Alias string `json:"alias"`
+	}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<10)).Decode(&body); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if body.Alias == "" {
+		return "", fmt.Errorf("%s: %w", op, ErrEmptyAlias)
+	}
+
+	return body.Alias, nil
+}
@@ -0,0 +1,122 @@
+package generator
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/lib/random"
+)
+
+type staticGenerator string
+
+func (g staticGenerator) Generate(_ context.Context) (string, error) {
+	return string(g), nil
+}
+
+func TestHTTP_Generate(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    string
+	}{
+		{
+			name: "external alias",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				_, _ = w.Write([]byte(`{"alias": "ext123"}`))
+			},
+			want: "ext123",
+		},
+		{
+			name: "server error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			want: "local1",
+		},
+		{
+			name: "invalid body",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`not json`))
+			},
+			want: "local1",
+		},
+		{
+			name: "empty alias",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"alias": ""}`))
+			},
+			want: "local1",
+		},
+		{
+			name: "timeout",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(time.Second):
+				}
+				_, _ = w.Write([]byte(`{"alias": "too_late"}`))
+			},
+			want: "local1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(tt.handler)
+			defer ts.Close()
+
+			g := NewHTTP(slogdiscard.NewDiscardLogger(), ts.URL, &http.Client{Timeout: 50 * time.Millisecond}, staticGenerator("local1"))
+
+			alias, err := g.Generate(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, alias)
+		})
+	}
+}
+
+func TestHTTP_Generate_Unreachable(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	ts.Close()
+
+	g := NewHTTP(slogdiscard.NewDiscardLogger(), ts.URL, &http.Client{Timeout: 50 * time.Millisecond}, Random{Length: 6})
+
+	alias, err := g.Generate(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, alias, 6)
+}
+
+func TestRandom_Generate(t *testing.T) {
+	tests := []struct {
+		name     string
+		alphabet string
+		want     string
+	}{
+		{name: "default", want: random.Base62},
+		{name: "unambiguous", alphabet: random.Unambiguous, want: random.Unambiguous},
+		{name: "custom", alphabet: "abc", want: "abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := Random{Length: 12, Alphabet: tt.alphabet}
+
+			for i := 0; i < 100; i++ {
+				alias, err := g.Generate(context.Background())
+				require.NoError(t, err)
+
+				assert.Len(t, alias, 12)
+				for _, c := range alias {
+					assert.Contains(t, tt.want, string(c))
+				}
+			}
+		})
+	}
+}
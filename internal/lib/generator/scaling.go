@@ -0,0 +1,124 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/random"
+)
+
+// CollisionObserver is implemented by generators that want to know
+// whether their aliases were already taken.
+type CollisionObserver interface {
+	ObserveCollision(ctx context.Context, collided bool)
+}
+
+// LengthStore persists the alias length of a Scaling generator, so
+// restarts don't shrink it back.
+type LengthStore interface {
+	// AliasLength returns the stored length, 0 if none was stored yet.
+	AliasLength(ctx context.Context) (int, error)
+	SetAliasLength(ctx context.Context, length int) error
+}
+
+// ScalingOptions configures a Scaling generator.
+type ScalingOptions struct {
+	// MinLength is the length used before anything was stored.
+	MinLength int
+	// MaxLength caps the length.
+	MaxLength int
+	// Window is the number of recent aliases the collision rate is taken over.
+	Window int
+	// Threshold is the collision rate, between 0 and 1, above which the
+	// length grows by one.
+	Threshold float64
+	// Alphabet is the characters aliases are drawn from, random.Base62
+	// when empty.
+	Alphabet string
+}
+
+// Scaling generates random aliases and makes them one character longer
+// whenever the recent collision rate exceeds the threshold, so generation
+// stays cheap as the keyspace fills up.
+type Scaling struct {
+	log   *slog.Logger
+	store LengthStore
+	opts  ScalingOptions
+
+	mu         sync.Mutex
+	length     int
+	observed   int
+	collisions int
+}
+
+// NewScaling creates a Scaling generator starting at the stored length.
+func NewScaling(ctx context.Context, log *slog.Logger, store LengthStore, opts ScalingOptions) (*Scaling, error) {
+	const op = "generator.NewScaling"
+
+	length, err := store.AliasLength(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if length < opts.MinLength {
+		length = opts.MinLength
+	}
+
+	return &Scaling{
+		log: log.With(
+			slog.String("component", "generator/scaling"),
+		),
+		store:  store,
+		opts:   opts,
+		length: length,
+	}, nil
+}
+
+func (g *Scaling) Generate(_ context.Context) (string, error) {
+	return random.NewRandomStringFrom(g.Length(), alphabetOr(g.opts.Alphabet)), nil
+}
+
+// Length returns the current alias length.
+func (g *Scaling) Length() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.length
+}
+
+// ObserveCollision records whether a generated alias was taken. Once a
+// full window was observed the rate is checked and the window restarts.
+func (g *Scaling) ObserveCollision(ctx context.Context, collided bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.observed++
+	if collided {
+		g.collisions++
+	}
+	if g.observed < g.opts.Window {
+		return
+	}
+
+	rate := float64(g.collisions) / float64(g.observed)
+	g.observed, g.collisions = 0, 0
+
+	if rate <= g.opts.Threshold || g.length >= g.opts.MaxLength {
+		return
+	}
+
+	// persist before switching, a length lost on restart would bring the
+	// collisions back
+	if err := g.store.SetAliasLength(context.WithoutCancel(ctx), g.length+1); err != nil {
+		g.log.Error("failed to store alias length", sl.Err(err))
+		return
+	}
+	g.length++
+
+	g.log.Info("alias length increased",
+		slog.Int("length", g.length),
+		slog.Float64("collision_rate", rate),
+	)
+}
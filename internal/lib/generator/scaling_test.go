@@ -0,0 +1,106 @@
+package generator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+type memLengthStore struct {
+	length int
+}
+
+func (s *memLengthStore) AliasLength(_ context.Context) (int, error) {
+	return s.length, nil
+}
+
+func (s *memLengthStore) SetAliasLength(_ context.Context, length int) error {
+	s.length = length
+	return nil
+}
+
+func TestScaling(t *testing.T) {
+	opts := ScalingOptions{MinLength: 6, MaxLength: 8, Window: 10, Threshold: 0.2}
+
+	tests := []struct {
+		name       string
+		stored     int
+		collisions []int // collisions in each window of 10
+		want       int
+	}{
+		{
+			name:       "low collision rate",
+			collisions: []int{0, 1, 2},
+			want:       6,
+		},
+		{
+			name:       "high collision rate",
+			collisions: []int{5},
+			want:       7,
+		},
+		{
+			name:       "grows once per window",
+			collisions: []int{9, 9},
+			want:       8,
+		},
+		{
+			name:       "capped at max length",
+			collisions: []int{9, 9, 9, 9},
+			want:       8,
+		},
+		{
+			name:   "starts at stored length",
+			stored: 7,
+			want:   7,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := &memLengthStore{length: tt.stored}
+
+			g, err := NewScaling(ctx, slogdiscard.NewDiscardLogger(), store, opts)
+			require.NoError(t, err)
+
+			for _, collisions := range tt.collisions {
+				for i := 0; i < opts.Window; i++ {
+					g.ObserveCollision(ctx, i < collisions)
+				}
+			}
+
+			assert.Equal(t, tt.want, g.Length())
+
+			alias, err := g.Generate(ctx)
+			require.NoError(t, err)
+			assert.Len(t, alias, tt.want)
+
+			// a restart picks up where the last instance left off
+			restarted, err := NewScaling(ctx, slogdiscard.NewDiscardLogger(), store, opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, restarted.Length())
+		})
+	}
+}
+
+func TestScaling_Alphabet(t *testing.T) {
+	g, err := NewScaling(context.Background(), slogdiscard.NewDiscardLogger(), &memLengthStore{}, ScalingOptions{
+		MinLength: 10,
+		MaxLength: 12,
+		Window:    10,
+		Threshold: 0.2,
+		Alphabet:  "xyz",
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		alias, err := g.Generate(context.Background())
+		require.NoError(t, err)
+
+		assert.Regexp(t, `^[xyz]{10}$`, alias)
+	}
+}
@@ -0,0 +1,110 @@
+package latency
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Summary describes the latency distribution of a route.
+type Summary struct {
+	Count int
+	P50   time.Duration
+	P95   time.Duration
+	P99   time.Duration
+}
+
+// Window keeps the last size samples in a ring buffer, so memory stays
+// bounded no matter how much traffic a route gets.
+type Window struct {
+	samples []time.Duration
+	next    int
+	full    bool
+}
+
+func NewWindow(size int) *Window {
+	return &Window{samples: make([]time.Duration, size)}
+}
+
+// Add records d, evicting the oldest sample once the window is full.
+func (w *Window) Add(d time.Duration) {
+	w.samples[w.next] = d
+	w.next++
+	if w.next == len(w.samples) {
+		w.next = 0
+		w.full = true
+	}
+}
+
+// Summary returns the nearest-rank percentiles of the samples in the window.
+func (w *Window) Summary() Summary {
+	n := w.next
+	if w.full {
+		n = len(w.samples)
+	}
+	if n == 0 {
+		return Summary{}
+	}
+
+	sorted := make([]time.Duration, n)
+	copy(sorted, w.samples[:n])
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return Summary{
+		Count: n,
+		P50:   quantile(sorted, 0.50),
+		P95:   quantile(sorted, 0.95),
+		P99:   quantile(sorted, 0.99),
+	}
+}
+
+func quantile(sorted []time.Duration, q float64) time.Duration {
+	rank := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+
+	return sorted[rank]
+}
+
+// Recorder keeps a Window per route. It is safe for concurrent use.
+type Recorder struct {
+	mu     sync.Mutex
+	size   int
+	routes map[string]*Window
+}
+
+// NewRecorder creates a Recorder keeping the last size samples per route.
+func NewRecorder(size int) *Recorder {
+	return &Recorder{
+		size:   size,
+		routes: make(map[string]*Window),
+	}
+}
+
+func (r *Recorder) Observe(route string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.routes[route]
+	if !ok {
+		w = NewWindow(r.size)
+		r.routes[route] = w
+	}
+
+	w.Add(d)
+}
+
+// Summaries returns the current Summary of every observed route.
+func (r *Recorder) Summaries() map[string]Summary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res := make(map[string]Summary, len(r.routes))
+	for route, w := range r.routes {
+		res[route] = w.Summary()
+	}
+
+	return res
+}
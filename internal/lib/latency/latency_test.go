@@ -0,0 +1,71 @@
+package latency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindow_Summary(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		samples int
+		want    Summary
+	}{
+		{
+			name:    "empty",
+			size:    100,
+			samples: 0,
+			want:    Summary{},
+		},
+		{
+			name:    "single sample",
+			size:    100,
+			samples: 1,
+			want:    Summary{Count: 1, P50: time.Millisecond, P95: time.Millisecond, P99: time.Millisecond},
+		},
+		{
+			name:    "full window",
+			size:    100,
+			samples: 100,
+			want:    Summary{Count: 100, P50: 50 * time.Millisecond, P95: 95 * time.Millisecond, P99: 99 * time.Millisecond},
+		},
+		{
+			// only 101ms..200ms are left in the window
+			name:    "old samples evicted",
+			size:    100,
+			samples: 200,
+			want:    Summary{Count: 100, P50: 150 * time.Millisecond, P95: 195 * time.Millisecond, P99: 199 * time.Millisecond},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWindow(tt.size)
+			for i := 1; i <= tt.samples; i++ {
+				w.Add(time.Duration(i) * time.Millisecond)
+			}
+
+			assert.Equal(t, tt.want, w.Summary())
+		})
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	r := NewRecorder(10)
+
+	for i := 1; i <= 10; i++ {
+		r.Observe("GET /{alias}", time.Duration(i)*time.Millisecond)
+	}
+	r.Observe("POST /url/", 7*time.Millisecond)
+
+	summaries := r.Summaries()
+
+	assert.Len(t, summaries, 2)
+	assert.Equal(t, 10, summaries["GET /{alias}"].Count)
+	assert.Equal(t, 5*time.Millisecond, summaries["GET /{alias}"].P50)
+	assert.Equal(t, 10*time.Millisecond, summaries["GET /{alias}"].P99)
+	assert.Equal(t, 7*time.Millisecond, summaries["POST /url/"].P95)
+}
@@ -2,6 +2,8 @@ package sl
 
 import (
 	"log/slog"
+
+	"github.com/go-playground/validator/v10"
 )
 
 func Err(err error) slog.Attr {
@@ -10,3 +12,13 @@ func Err(err error) slog.Attr {
 		Value: slog.StringValue(err.Error()),
 	}
 }
+
+// Validation describes a failed field validation by field and tag. The
+// offending value is left out, URLs and passwords may carry secrets.
+func Validation(fe validator.FieldError) slog.Attr {
+	return slog.Group("validation",
+		slog.String("field", fe.Field()),
+		slog.String("tag", fe.Tag()),
+		slog.String("param", fe.Param()),
+	)
+}
@@ -0,0 +1,72 @@
+// Package metrics holds the Prometheus metrics of the service.
+package metrics
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// OtherHost is the label of the hosts past the cardinality cap, and of
+// targets without a host such as mailto: links.
+const OtherHost = "other"
+
+// Hosts counts redirects by target host. Only the first maxHosts distinct
+// hosts get a label of their own, later ones are counted as OtherHost, so
+// a flood of throwaway domains can't blow up the number of series.
+type Hosts struct {
+	counter *prometheus.CounterVec
+
+	mu       sync.Mutex
+	known    map[string]struct{}
+	maxHosts int
+}
+
+// NewHosts registers the redirects_by_host_total counter on reg.
+func NewHosts(reg prometheus.Registerer, maxHosts int) (*Hosts, error) {
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "url_shortener",
+		Name:      "redirects_by_host_total",
+		Help:      "Redirects by target host, hosts past the cardinality cap are counted as \"other\".",
+	}, []string{"host"})
+
+	if err := reg.Register(counter); err != nil {
+		return nil, err
+	}
+
+	return &Hosts{
+		counter:  counter,
+		known:    make(map[string]struct{}, maxHosts),
+		maxHosts: maxHosts,
+	}, nil
+}
+
+// ObserveRedirect counts a redirect to target.
+func (h *Hosts) ObserveRedirect(target string) {
+	h.counter.WithLabelValues(h.label(target)).Inc()
+}
+
+// label returns the host label of target, admitting new hosts while
+// there is room under the cap.
+func (h *Hosts) label(target string) string {
+	u, err := url.Parse(target)
+	if err != nil || u.Hostname() == "" {
+		return OtherHost
+	}
+	host := strings.ToLower(u.Hostname())
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.known[host]; ok {
+		return host
+	}
+	if len(h.known) >= h.maxHosts {
+		return OtherHost
+	}
+	h.known[host] = struct{}{}
+
+	return host
+}
@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHosts(t *testing.T) {
+	hosts, err := NewHosts(prometheus.NewRegistry(), 2)
+	require.NoError(t, err)
+
+	hosts.ObserveRedirect("https://example.com/a")
+	hosts.ObserveRedirect("https://EXAMPLE.com/b")
+	hosts.ObserveRedirect("https://example.org/")
+	// the cap is reached, new hosts are bucketed
+	hosts.ObserveRedirect("https://spam-1.example/")
+	hosts.ObserveRedirect("https://spam-2.example/")
+	// hosts admitted before keep their label
+	hosts.ObserveRedirect("https://example.org:8443/")
+	hosts.ObserveRedirect("mailto:sales@example.com")
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(hosts.counter.WithLabelValues("example.com")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(hosts.counter.WithLabelValues("example.org")))
+	assert.Equal(t, 3.0, testutil.ToFloat64(hosts.counter.WithLabelValues(OtherHost)))
+	assert.Equal(t, 3, testutil.CollectAndCount(hosts.counter))
+}
+
+func TestNewHosts_Duplicate(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	_, err := NewHosts(reg, 10)
+	require.NoError(t, err)
+
+	_, err = NewHosts(reg, 10)
+	require.Error(t, err)
+}
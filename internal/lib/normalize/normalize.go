@@ -0,0 +1,80 @@
+package normalize
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Alias folds alias to its canonical form: compatibility characters are
+// decomposed, accents are stripped and the result is lowercased, so that
+// "Café", "cafe" and "ｃａｆｅ" all map to "cafe".
+func Alias(alias string) string {
+	// transformers are stateful, so the chain is built per call
+	t := transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+	folded, _, err := transform.String(t, alias)
+	if err != nil {
+		folded = alias
+	}
+
+	return strings.ToLower(folded)
+}
+
+// AliasCollisions returns the aliases that fold to the same alias, by
+// folded alias. Such aliases are distinct links that become one once
+// aliases are folded, so they must be resolved before folding is enabled.
+// Aliases colliding with no other are left out.
+func AliasCollisions(aliases []string) map[string][]string {
+	byFolded := make(map[string][]string)
+	for _, alias := range aliases {
+		folded := Alias(alias)
+		byFolded[folded] = append(byFolded[folded], alias)
+	}
+
+	collisions := make(map[string][]string)
+	for folded, group := range byFolded {
+		if len(group) > 1 {
+			sort.Strings(group)
+			collisions[folded] = group
+		}
+	}
+
+	return collisions
+}
+
+// Query sorts the query parameters of rawURL by name, so that
+// "https://x?b=2&a=1" and "https://x?a=1&b=2" compare equal. Repeated
+// parameters keep their relative order, which may matter to the target,
+// and their encoding is left untouched. Unparsable URLs are returned as is.
+func Query(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.RawQuery == "" {
+		return rawURL
+	}
+
+	var params []string
+	for _, param := range strings.Split(u.RawQuery, "&") {
+		if param != "" {
+			params = append(params, param)
+		}
+	}
+
+	sort.SliceStable(params, func(i, j int) bool {
+		return queryKey(params[i]) < queryKey(params[j])
+	})
+
+	u.RawQuery = strings.Join(params, "&")
+
+	return u.String()
+}
+
+func queryKey(param string) string {
+	key, _, _ := strings.Cut(param, "=")
+	return key
+}
@@ -0,0 +1,67 @@
+package normalize
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlias(t *testing.T) {
+	tests := []struct {
+		name  string
+		alias string
+		want  string
+	}{
+		{name: "plain", alias: "cafe", want: "cafe"},
+		{name: "upper case", alias: "CaFe", want: "cafe"},
+		{name: "accent", alias: "café", want: "cafe"},
+		{name: "decomposed accent", alias: "café", want: "cafe"},
+		{name: "upper case accent", alias: "CAFÉ", want: "cafe"},
+		{name: "full width", alias: "ｃａｆｅ", want: "cafe"},
+		{name: "ligature", alias: "ﬁle", want: "file"},
+		{name: "digits and symbols", alias: "Promo_2024-ü", want: "promo_2024-u"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Alias(tt.alias))
+		})
+	}
+}
+
+func TestAliasCollisions(t *testing.T) {
+	collisions := AliasCollisions([]string{"Foo", "bar", "foo", "café", "Cafe", "cafe2", "FOO"})
+
+	assert.Equal(t, map[string][]string{
+		"foo":  {"FOO", "Foo", "foo"},
+		"cafe": {"Cafe", "café"},
+	}, collisions)
+
+	assert.Empty(t, AliasCollisions([]string{"foo", "bar", "cafe"}))
+	assert.Empty(t, AliasCollisions(nil))
+}
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{name: "no query", url: "https://x.com/a", want: "https://x.com/a"},
+		{name: "sorted", url: "https://x.com/?a=1&b=2", want: "https://x.com/?a=1&b=2"},
+		{name: "reordered", url: "https://x.com/?b=2&a=1", want: "https://x.com/?a=1&b=2"},
+		{name: "repeated keep order", url: "https://x.com/?t=2&a=1&t=1", want: "https://x.com/?a=1&t=2&t=1"},
+		{name: "encoding kept", url: "https://x.com/?q=a+b&p=%2F", want: "https://x.com/?p=%2F&q=a+b"},
+		{name: "empty params dropped", url: "https://x.com/?b=2&&a", want: "https://x.com/?a&b=2"},
+		{name: "fragment kept", url: "https://x.com/?b=2&a=1#top", want: "https://x.com/?a=1&b=2#top"},
+		{name: "not a url", url: "://x?b=2&a=1", want: "://x?b=2&a=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Query(tt.url))
+		})
+	}
+
+	assert.Equal(t, Query("https://x?a=1&b=2"), Query("https://x?b=2&a=1"))
+}
@@ -0,0 +1,23 @@
+package password
+
+import (
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MaxLength is the longest password bcrypt can hash.
+const MaxLength = 72
+
+// Hash returns the bcrypt hash of password.
+func Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// Matches reports whether password is the one hash was created from.
+func Matches(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
@@ -0,0 +1,19 @@
+package password
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatches(t *testing.T) {
+	hash, err := Hash("s3cret")
+	require.NoError(t, err)
+
+	assert.NotEqual(t, "s3cret", hash)
+	assert.True(t, Matches(hash, "s3cret"))
+	assert.False(t, Matches(hash, "S3cret"))
+	assert.False(t, Matches(hash, ""))
+	assert.False(t, Matches("not a hash", "s3cret"))
+}
@@ -0,0 +1,32 @@
+package qr
+
+import (
+	"fmt"
+
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	// DefaultSize is the width and height of codes in pixels when none is asked for.
+	DefaultSize = 256
+	MinSize     = 64
+	MaxSize     = 1024
+)
+
+// Generate encodes url as a size by size pixel PNG QR code. Medium error
+// correction keeps codes readable when printed small or slightly damaged.
+func Generate(url string, size int) ([]byte, error) {
+	const op = "lib.qr.Generate"
+
+	png, err := qrcode.Encode(url, qrcode.Medium, size)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return png, nil
+}
+
+// ClampSize returns size within MinSize and MaxSize.
+func ClampSize(size int) int {
+	return min(max(size, MinSize), MaxSize)
+}
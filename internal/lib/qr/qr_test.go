@@ -0,0 +1,35 @@
+package qr
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	for _, size := range []int{MinSize, DefaultSize, MaxSize} {
+		b, err := Generate("https://sho.rt/abc123", size)
+		require.NoError(t, err)
+		require.NotEmpty(t, b)
+
+		img, err := png.Decode(bytes.NewReader(b))
+		require.NoError(t, err)
+		assert.Equal(t, size, img.Bounds().Dx())
+		assert.Equal(t, size, img.Bounds().Dy())
+	}
+}
+
+func TestGenerate_TooLong(t *testing.T) {
+	// larger than the capacity of the biggest QR code
+	_, err := Generate("https://sho.rt/"+string(bytes.Repeat([]byte("a"), 4000)), DefaultSize)
+	require.Error(t, err)
+}
+
+func TestClampSize(t *testing.T) {
+	assert.Equal(t, MinSize, ClampSize(1))
+	assert.Equal(t, 300, ClampSize(300))
+	assert.Equal(t, MaxSize, ClampSize(5000))
+}
@@ -5,13 +5,30 @@ import (
 	"time"
 )
 
+// Alphabets random strings can be drawn from.
+const (
+	// Base62 is the ASCII letters and digits.
+	Base62 = "ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
+		"abcdefghijklmnopqrstuvwxyz" +
+		"0123456789"
+	// Unambiguous is Base62 without the characters easily mistaken for
+	// one another when read aloud or retyped: 0, O, 1, I and l.
+	Unambiguous = "ABCDEFGHJKLMNPQRSTUVWXYZ" +
+		"abcdefghijkmnopqrstuvwxyz" +
+		"23456789"
+)
+
 // NewRandomString generates random string with given size.
 func NewRandomString(size int) string {
+	return NewRandomStringFrom(size, Base62)
+}
+
+// NewRandomStringFrom generates a random string of size characters of
+// alphabet, which must not be empty.
+func NewRandomStringFrom(size int, alphabet string) string {
 	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
 
-	chars := []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
-		"abcdefghijklmnopqrstuvwxyz" +
-		"0123456789")
+	chars := []rune(alphabet)
 
 	b := make([]rune, size)
 	for i := range b {
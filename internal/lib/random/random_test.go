@@ -47,3 +47,33 @@ func TestNewRandomString(t *testing.T) {
 		})
 	}
 }
+
+func TestNewRandomStringFrom(t *testing.T) {
+	tests := []struct {
+		name     string
+		alphabet string
+	}{
+		{name: "base62", alphabet: Base62},
+		{name: "unambiguous", alphabet: Unambiguous},
+		{name: "hex", alphabet: "0123456789abcdef"},
+		{name: "single character", alphabet: "x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				str := NewRandomStringFrom(32, tt.alphabet)
+
+				assert.Len(t, str, 32)
+				for _, c := range str {
+					assert.Contains(t, tt.alphabet, string(c))
+				}
+			}
+		})
+	}
+}
+
+func TestUnambiguous(t *testing.T) {
+	for _, c := range "0O1Il" {
+		assert.NotContains(t, Unambiguous, string(c))
+	}
+}
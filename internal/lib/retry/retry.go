@@ -0,0 +1,70 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"url-shortener/internal/lib/logger/sl"
+)
+
+// Options bounds how long Do keeps trying.
+type Options struct {
+	// MaxAttempts is the number of calls before giving up, 1 when zero.
+	MaxAttempts int
+	// Timeout bounds the total time spent retrying. No bound when zero.
+	Timeout time.Duration
+	// Backoff is the delay after the first failure. It doubles after
+	// every further failure, up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// Do calls fn until it succeeds, attempts run out or the timeout passes,
+// and returns its last result. Every failed attempt is logged.
+func Do[T any](ctx context.Context, log *slog.Logger, opts Options, fn func() (T, error)) (T, error) {
+	const op = "retry.Do"
+
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = 1
+	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	delay := opts.Backoff
+
+	for attempt := 1; ; attempt++ {
+		res, err := fn()
+		if err == nil {
+			return res, nil
+		}
+
+		if attempt >= opts.MaxAttempts {
+			return res, fmt.Errorf("%s: giving up after %d attempts: %w", op, attempt, err)
+		}
+
+		log.Warn("attempt failed, retrying",
+			slog.Int("attempt", attempt),
+			slog.Int("max_attempts", opts.MaxAttempts),
+			slog.Duration("backoff", delay),
+			sl.Err(err),
+		)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return res, fmt.Errorf("%s: giving up after %d attempts: %w", op, attempt, err)
+		case <-timer.C:
+		}
+
+		delay *= 2
+		if opts.MaxBackoff > 0 && delay > opts.MaxBackoff {
+			delay = opts.MaxBackoff
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+var errUnavailable = errors.New("connection refused")
+
+// dependency becomes available on its nth connection attempt.
+type dependency struct {
+	availableAt int
+	attempts    int
+}
+
+func (d *dependency) connect() (string, error) {
+	d.attempts++
+	if d.attempts < d.availableAt {
+		return "", errUnavailable
+	}
+	return "connected", nil
+}
+
+func TestDo(t *testing.T) {
+	cases := []struct {
+		name         string
+		availableAt  int
+		opts         Options
+		wantErr      bool
+		wantAttempts int
+	}{
+		{
+			name:         "Available immediately",
+			availableAt:  1,
+			opts:         Options{MaxAttempts: 5, Backoff: time.Millisecond},
+			wantAttempts: 1,
+		},
+		{
+			name:         "Available after retries",
+			availableAt:  3,
+			opts:         Options{MaxAttempts: 5, Backoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond},
+			wantAttempts: 3,
+		},
+		{
+			name:         "Attempts exhausted",
+			availableAt:  10,
+			opts:         Options{MaxAttempts: 3, Backoff: time.Millisecond},
+			wantErr:      true,
+			wantAttempts: 3,
+		},
+		{
+			name:         "No retries by default",
+			availableAt:  2,
+			wantErr:      true,
+			wantAttempts: 1,
+		},
+		{
+			name:         "Timeout",
+			availableAt:  10,
+			opts:         Options{MaxAttempts: 10, Timeout: 20 * time.Millisecond, Backoff: time.Hour},
+			wantErr:      true,
+			wantAttempts: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := &dependency{availableAt: tc.availableAt}
+
+			res, err := Do(context.Background(), slogdiscard.NewDiscardLogger(), tc.opts, dep.connect)
+			if tc.wantErr {
+				require.ErrorIs(t, err, errUnavailable)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "connected", res)
+			}
+			assert.Equal(t, tc.wantAttempts, dep.attempts)
+		})
+	}
+}
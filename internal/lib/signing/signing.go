@@ -0,0 +1,68 @@
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// Separator splits a signed alias into the alias and its signature.
+const Separator = "."
+
+var (
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// Signer signs aliases with HMAC-SHA256 so they can't be guessed.
+type Signer struct {
+	key  []byte
+	size int
+}
+
+// New creates a Signer producing signatures of size characters.
+func New(key string, size int) *Signer {
+	return &Signer{
+		key:  []byte(key),
+		size: size,
+	}
+}
+
+// Sign returns alias with its signature appended.
+func (s *Signer) Sign(alias string) string {
+	return alias + Separator + s.signature(alias)
+}
+
+// Verify checks that signed was produced by Sign with the same key.
+func (s *Signer) Verify(signed string) error {
+	i := strings.LastIndex(signed, Separator)
+	if i <= 0 {
+		return ErrInvalidSignature
+	}
+
+	alias, sig := signed[:i], signed[i+len(Separator):]
+
+	if !hmac.Equal([]byte(sig), []byte(s.signature(alias))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// IsSigned reports whether alias carries a signature segment.
+func IsSigned(alias string) bool {
+	return strings.Contains(alias, Separator)
+}
+
+func (s *Signer) signature(alias string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(alias))
+
+	sig := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	if s.size > 0 && s.size < len(sig) {
+		sig = sig[:s.size]
+	}
+
+	return sig
+}
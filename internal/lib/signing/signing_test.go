@@ -0,0 +1,68 @@
+package signing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigner(t *testing.T) {
+	signer := New("secret", 8)
+
+	signed := signer.Sign("abc123")
+
+	require.True(t, strings.HasPrefix(signed, "abc123"+Separator))
+	require.Len(t, signed, len("abc123")+len(Separator)+8)
+	require.True(t, IsSigned(signed))
+
+	tests := []struct {
+		name   string
+		signer *Signer
+		alias  string
+		err    error
+	}{
+		{
+			name:   "valid",
+			signer: signer,
+			alias:  signed,
+		},
+		{
+			name:   "tampered alias",
+			signer: signer,
+			alias:  "abc124" + signed[len("abc123"):],
+			err:    ErrInvalidSignature,
+		},
+		{
+			name:   "tampered signature",
+			signer: signer,
+			alias:  signed[:len(signed)-1] + "x",
+			err:    ErrInvalidSignature,
+		},
+		{
+			name:   "wrong key",
+			signer: New("other-secret", 8),
+			alias:  signed,
+			err:    ErrInvalidSignature,
+		},
+		{
+			name:   "unsigned",
+			signer: signer,
+			alias:  "abc123",
+			err:    ErrInvalidSignature,
+		},
+		{
+			name:   "signature only",
+			signer: signer,
+			alias:  signed[len("abc123"):],
+			err:    ErrInvalidSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.signer.Verify(tt.alias), tt.err)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package urlnorm
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"url-shortener/internal/lib/normalize"
+)
+
+// defaultPorts are the ports implied by each scheme, dropped from hosts.
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// Normalize returns the canonical form of rawURL, so that links to the
+// same destination compare equal: the scheme and host are lowercased,
+// default ports are dropped, the trailing slash of an empty path is
+// removed and the query parameters are sorted. Paths are left as they
+// are, servers may treat their case and slashes as significant.
+// Unparsable URLs are returned as is.
+func Normalize(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+
+	// trimming rather than joining keeps the brackets of IPv6 hosts
+	if _, port, err := net.SplitHostPort(u.Host); err == nil && defaultPorts[u.Scheme] == port {
+		u.Host = strings.TrimSuffix(u.Host, ":"+port)
+	}
+
+	if u.Path == "/" && u.RawPath == "" {
+		u.Path = ""
+	}
+
+	return normalize.Query(u.String())
+}
+
+// Target returns rawURL in the form targets are saved in: normalized when
+// normalizeURL is set, with sorted query parameters when canonicalQuery
+// is. Every entry point saving targets goes through it, so that they store
+// equivalent targets alike.
+func Target(rawURL string, normalizeURL, canonicalQuery bool) string {
+	switch {
+	case normalizeURL:
+		// Normalize sorts the query parameters too
+		return Normalize(rawURL)
+	case canonicalQuery:
+		return normalize.Query(rawURL)
+	default:
+		return rawURL
+	}
+}
@@ -0,0 +1,47 @@
+package urlnorm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{name: "canonical", url: "https://example.com/path", want: "https://example.com/path"},
+		{name: "trailing slash on empty path", url: "https://example.com/", want: "https://example.com"},
+		{name: "trailing slash before query", url: "https://example.com/?a=1", want: "https://example.com?a=1"},
+		{name: "trailing slash on path kept", url: "https://example.com/path/", want: "https://example.com/path/"},
+		{name: "upper case host", url: "https://EXAMPLE.com", want: "https://example.com"},
+		{name: "upper case scheme", url: "HTTPS://example.com", want: "https://example.com"},
+		{name: "path case kept", url: "https://example.com/Path", want: "https://example.com/Path"},
+		{name: "default https port", url: "https://example.com:443/", want: "https://example.com"},
+		{name: "default http port", url: "http://example.com:80/path", want: "http://example.com/path"},
+		{name: "other port kept", url: "https://example.com:8443/", want: "https://example.com:8443"},
+		{name: "http port on https kept", url: "https://example.com:80", want: "https://example.com:80"},
+		{name: "ipv6 default port", url: "http://[::1]:80/", want: "http://[::1]"},
+		{name: "sorted query", url: "https://example.com/?b=2&a=1", want: "https://example.com?a=1&b=2"},
+		{name: "fragment kept", url: "https://example.com/#top", want: "https://example.com#top"},
+		{name: "no host", url: "mailto:someone@example.com", want: "mailto:someone@example.com"},
+		{name: "unparsable", url: "https://exa mple.com/%zz", want: "https://exa mple.com/%zz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Normalize(tt.url))
+		})
+	}
+}
+
+func TestTarget(t *testing.T) {
+	const raw = "HTTPS://Example.com:443/?b=2&a=1"
+
+	assert.Equal(t, raw, Target(raw, false, false))
+	assert.Equal(t, "https://Example.com:443/?a=1&b=2", Target(raw, false, true))
+	assert.Equal(t, "https://example.com?a=1&b=2", Target(raw, true, false))
+	assert.Equal(t, "https://example.com?a=1&b=2", Target(raw, true, true))
+}
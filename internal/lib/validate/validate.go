@@ -0,0 +1,187 @@
+package validate
+
+import (
+	"errors"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// MaxOwnerLength is the longest owner accepted, that of an email address.
+const MaxOwnerLength = 254
+
+// MaxURLLength is the longest target URL accepted.
+const MaxURLLength = 2048
+
+// DefaultSchemes are allowed when no schemes are configured.
+var DefaultSchemes = []string{"http", "https"}
+
+var (
+	ErrTooLong       = errors.New("url is too long")
+	ErrInvalidScheme = errors.New("url scheme is not allowed")
+	ErrMissingHost   = errors.New("url must have a host")
+	ErrInvalidMailto = errors.New("mailto url must contain a valid email address")
+	ErrInvalidTel    = errors.New("tel url must contain a valid phone number")
+	ErrInvalidGeo    = errors.New("geo url must contain valid coordinates")
+	ErrAliasIsURL    = errors.New("alias must not be a url, put the link in the url field")
+	ErrInvalidAlias  = errors.New("alias contains invalid characters")
+	ErrReservedAlias = errors.New("alias is reserved")
+	ErrOwnerRequired = errors.New("owner is required")
+	ErrInvalidOwner  = errors.New("owner must be an email address")
+)
+
+// Custom aliases are made of AliasMinLength to AliasMaxLength of the
+// AliasCharset characters, which are safe in a URL path.
+const (
+	AliasMinLength = 3
+	AliasMaxLength = 64
+	// AliasCharset is a regular expression character class.
+	AliasCharset = "a-zA-Z0-9_-"
+)
+
+// aliasChars matches the custom aliases that are safe in a URL path.
+var aliasChars = regexp.MustCompile(`^[` + AliasCharset + `]{` + strconv.Itoa(AliasMinLength) + `,` + strconv.Itoa(AliasMaxLength) + `}$`)
+
+// telNumber matches RFC 3966 numbers with visual separators and parameters.
+var telNumber = regexp.MustCompile(`^\+?[0-9][0-9().\-]*(;[a-zA-Z0-9\-]+(=[^;]*)?)*$`)
+
+// URL checks that raw is a target the shortener can redirect to and that
+// its scheme is one of schemes (DefaultSchemes when empty). It complements
+// the "url" validator tag, which accepts any scheme.
+func URL(raw string, schemes []string) error {
+	if len(raw) > MaxURLLength {
+		return ErrTooLong
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+
+	if len(schemes) == 0 {
+		schemes = DefaultSchemes
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	if !slices.Contains(schemes, scheme) {
+		return ErrInvalidScheme
+	}
+
+	switch scheme {
+	case "mailto":
+		return mailto(u)
+	case "tel":
+		return tel(u)
+	case "geo":
+		return geo(u)
+	default:
+		if u.Hostname() == "" {
+			return ErrMissingHost
+		}
+	}
+
+	return nil
+}
+
+// AliasNotURL rejects aliases that are URLs themselves, almost always a
+// link pasted into the alias field.
+func AliasNotURL(alias string) error {
+	if strings.Contains(alias, "://") {
+		return ErrAliasIsURL
+	}
+
+	if u, err := url.Parse(alias); err == nil && u.IsAbs() {
+		return ErrAliasIsURL
+	}
+
+	return nil
+}
+
+// AliasRune reports whether r is one of the AliasCharset characters.
+func AliasRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-'
+}
+
+// Alias checks that a custom alias is made of URL-safe characters and
+// doesn't shadow one of the reserved routes, compared case-insensitively.
+func Alias(alias string, reserved []string) error {
+	if slices.ContainsFunc(reserved, func(r string) bool { return strings.EqualFold(r, alias) }) {
+		return ErrReservedAlias
+	}
+
+	if !aliasChars.MatchString(alias) {
+		return ErrInvalidAlias
+	}
+
+	return nil
+}
+
+// Owner checks that the owner of a link is a bare email address, such as
+// "jane@example.com". An empty owner passes unless it is required.
+func Owner(owner string, required bool) error {
+	if owner == "" {
+		if required {
+			return ErrOwnerRequired
+		}
+		return nil
+	}
+
+	if len(owner) > MaxOwnerLength {
+		return ErrInvalidOwner
+	}
+
+	// display names and comments would make the owner ambiguous
+	addr, err := mail.ParseAddress(owner)
+	if err != nil || addr.Address != owner {
+		return ErrInvalidOwner
+	}
+
+	return nil
+}
+
+func mailto(u *url.URL) error {
+	to, err := url.PathUnescape(u.Opaque)
+	if err != nil || to == "" {
+		return ErrInvalidMailto
+	}
+
+	if _, err := mail.ParseAddressList(to); err != nil {
+		return ErrInvalidMailto
+	}
+
+	return nil
+}
+
+func tel(u *url.URL) error {
+	if !telNumber.MatchString(u.Opaque) {
+		return ErrInvalidTel
+	}
+
+	return nil
+}
+
+// geo checks RFC 5870 "geo:lat,lon[,alt][;params]" URIs.
+func geo(u *url.URL) error {
+	coords, _, _ := strings.Cut(u.Opaque, ";")
+
+	parts := strings.Split(coords, ",")
+	if len(parts) < 2 || len(parts) > 3 {
+		return ErrInvalidGeo
+	}
+
+	limits := []float64{90, 180, 0}
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return ErrInvalidGeo
+		}
+		if limits[i] > 0 && (v < -limits[i] || v > limits[i]) {
+			return ErrInvalidGeo
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package validate
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURL(t *testing.T) {
+	all := []string{"http", "https", "mailto", "tel", "geo"}
+
+	tests := []struct {
+		name    string
+		url     string
+		schemes []string
+		err     error
+	}{
+		{name: "http", url: "http://example.com"},
+		{name: "https with path", url: "https://example.com/a/b?c=d"},
+		{name: "upper case scheme", url: "HTTPS://example.com"},
+		{name: "ftp", url: "ftp://example.com/file", err: ErrInvalidScheme},
+		{name: "javascript", url: "javascript:alert(1)", err: ErrInvalidScheme},
+		{name: "no host", url: "https:///path", err: ErrMissingHost},
+		{name: "too long", url: "https://example.com/" + strings.Repeat("a", MaxURLLength), err: ErrTooLong},
+		{name: "mailto by default", url: "mailto:sales@example.com", err: ErrInvalidScheme},
+		{name: "mailto", url: "mailto:sales@example.com", schemes: all},
+		{name: "mailto with subject", url: "mailto:sales@example.com?subject=Hello", schemes: all},
+		{name: "mailto many", url: "mailto:a@example.com,b@example.com", schemes: all},
+		{name: "mailto invalid", url: "mailto:not-an-email", schemes: all, err: ErrInvalidMailto},
+		{name: "mailto empty", url: "mailto:", schemes: all, err: ErrInvalidMailto},
+		{name: "tel", url: "tel:+1-555-0100", schemes: all},
+		{name: "tel with extension", url: "tel:+1-555-0100;ext=42", schemes: all},
+		{name: "tel invalid", url: "tel:call-me", schemes: all, err: ErrInvalidTel},
+		{name: "tel not allowed", url: "tel:+1-555-0100", schemes: []string{"https", "mailto"}, err: ErrInvalidScheme},
+		{name: "geo", url: "geo:37.786971,-122.399677", schemes: all},
+		{name: "geo with altitude and params", url: "geo:37.78,-122.39,250;u=35", schemes: all},
+		{name: "geo out of range", url: "geo:91,0", schemes: all, err: ErrInvalidGeo},
+		{name: "geo invalid", url: "geo:here", schemes: all, err: ErrInvalidGeo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, URL(tt.url, tt.schemes), tt.err)
+		})
+	}
+}
+
+func TestAliasNotURL(t *testing.T) {
+	tests := []struct {
+		alias string
+		err   error
+	}{
+		{alias: "promo"},
+		{alias: "summer-sale_2024"},
+		{alias: "www.example.com"},
+		{alias: "http://x", err: ErrAliasIsURL},
+		{alias: "https://example.com/page", err: ErrAliasIsURL},
+		{alias: "go/docs://x", err: ErrAliasIsURL},
+		{alias: "mailto:sales@example.com", err: ErrAliasIsURL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.alias, func(t *testing.T) {
+			assert.ErrorIs(t, AliasNotURL(tt.alias), tt.err)
+		})
+	}
+}
+
+func TestAlias(t *testing.T) {
+	reserved := []string{"health", "url", "style.css", "script.js"}
+
+	tests := []struct {
+		name  string
+		alias string
+		err   error
+	}{
+		{name: "valid", alias: "summer-sale_2024"},
+		{name: "shortest", alias: "abc"},
+		{name: "longest", alias: strings.Repeat("a", 64)},
+		{name: "too short", alias: "ab", err: ErrInvalidAlias},
+		{name: "too long", alias: strings.Repeat("a", 65), err: ErrInvalidAlias},
+		{name: "slash", alias: "a/b/c", err: ErrInvalidAlias},
+		{name: "space", alias: "a b c", err: ErrInvalidAlias},
+		{name: "non-ascii", alias: "café", err: ErrInvalidAlias},
+		{name: "reserved", alias: "health", err: ErrReservedAlias},
+		{name: "reserved in other case", alias: "URL", err: ErrReservedAlias},
+		{name: "reserved file", alias: "style.css", err: ErrReservedAlias},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, Alias(tt.alias, reserved), tt.err)
+		})
+	}
+}
+
+func TestOwner(t *testing.T) {
+	tests := []struct {
+		name     string
+		owner    string
+		required bool
+		err      error
+	}{
+		{name: "valid", owner: "jane@example.com"},
+		{name: "valid when required", owner: "jane.doe+links@example.co.uk", required: true},
+		{name: "empty", owner: ""},
+		{name: "empty when required", owner: "", required: true, err: ErrOwnerRequired},
+		{name: "no domain", owner: "jane", err: ErrInvalidOwner},
+		{name: "display name", owner: "Jane <jane@example.com>", err: ErrInvalidOwner},
+		{name: "spaces", owner: " jane@example.com", err: ErrInvalidOwner},
+		{name: "too long", owner: strings.Repeat("a", 250) + "@example.com", err: ErrInvalidOwner},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, Owner(tt.owner, tt.required), tt.err)
+		})
+	}
+}
+
+func TestAliasRune(t *testing.T) {
+	charset := regexp.MustCompile(`^[` + AliasCharset + `]$`)
+
+	for r := rune(0); r < 256; r++ {
+		assert.Equal(t, charset.MatchString(string(r)), AliasRune(r), "%q", r)
+	}
+}
@@ -0,0 +1,29 @@
+package weighted
+
+import (
+	"math/rand/v2"
+
+	"url-shortener/internal/storage"
+)
+
+// Draw draws the index of one of the variants of a split link, each with
+// a chance proportional to its weight.
+func Draw(variants []storage.Variant) int {
+	var total int
+	for _, v := range variants {
+		total += v.Weight
+	}
+	if total <= 0 {
+		return 0
+	}
+
+	n := rand.IntN(total)
+	for i, v := range variants {
+		if n < v.Weight {
+			return i
+		}
+		n -= v.Weight
+	}
+
+	return len(variants) - 1
+}
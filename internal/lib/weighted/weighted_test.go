@@ -0,0 +1,29 @@
+package weighted
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"url-shortener/internal/storage"
+)
+
+func TestDraw(t *testing.T) {
+	variants := []storage.Variant{
+		{URL: "https://a.example.com", Weight: 1},
+		{URL: "https://b.example.com", Weight: 0},
+		{URL: "https://c.example.com", Weight: 3},
+	}
+
+	drawn := make(map[int]int)
+	for i := 0; i < 1000; i++ {
+		drawn[Draw(variants)]++
+	}
+
+	// a variant without weight is never drawn
+	assert.Zero(t, drawn[1])
+	assert.Greater(t, drawn[2], drawn[0])
+
+	assert.Zero(t, Draw(nil))
+	assert.Zero(t, Draw([]storage.Variant{{URL: "https://a.example.com"}}))
+}
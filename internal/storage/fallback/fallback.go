@@ -0,0 +1,60 @@
+// Package fallback is a read-only set of links loaded from a JSON file,
+// the last resort for redirects when both the cache and storage fail.
+package fallback
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// Fallback maps aliases to URLs as read from a JSON object like
+// {"docs": "https://example.com/docs"}.
+type Fallback struct {
+	path  string
+	links atomic.Pointer[map[string]string]
+}
+
+// New loads the links from the file at path.
+func New(path string) (*Fallback, error) {
+	const op = "storage.fallback.New"
+
+	f := &Fallback{path: path}
+	if err := f.Reload(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return f, nil
+}
+
+// Reload rereads the file. The loaded links are kept if it fails.
+func (f *Fallback) Reload() error {
+	const op = "storage.fallback.Reload"
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var links map[string]string
+	if err := json.Unmarshal(data, &links); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	f.links.Store(&links)
+
+	return nil
+}
+
+// Len returns the number of loaded links.
+func (f *Fallback) Len() int {
+	return len(*f.links.Load())
+}
+
+// Lookup returns the URL of alias and whether it is known.
+func (f *Fallback) Lookup(alias string) (string, bool) {
+	url, ok := (*f.links.Load())[alias]
+
+	return url, ok
+}
@@ -0,0 +1,46 @@
+package fallback
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFallback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "links.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"docs": "https://example.com/docs"}`), 0o644))
+
+	f, err := New(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, f.Len())
+
+	url, ok := f.Lookup("docs")
+	assert.True(t, ok)
+	assert.Equal(t, "https://example.com/docs", url)
+
+	_, ok = f.Lookup("missing")
+	assert.False(t, ok)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"status": "https://example.com/status"}`), 0o644))
+	require.NoError(t, f.Reload())
+
+	_, ok = f.Lookup("docs")
+	assert.False(t, ok)
+	_, ok = f.Lookup("status")
+	assert.True(t, ok)
+
+	// a broken file must not drop the loaded links
+	require.NoError(t, os.WriteFile(path, []byte(`{"status": `), 0o644))
+	require.Error(t, f.Reload())
+
+	_, ok = f.Lookup("status")
+	assert.True(t, ok)
+}
+
+func TestNew_MissingFile(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
@@ -0,0 +1,58 @@
+// Package migrations applies the versioned SQL migrations of the Postgres
+// schema with golang-migrate.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+)
+
+// RunMigrations brings the schema of db up to date with the migrations in
+// the directory at path and returns the version it ends at, 0 when there
+// are no migrations. It is a no-op on an up to date schema. db is left
+// open for the caller.
+func RunMigrations(db *sql.DB, path string) (version uint, dirty bool, err error) {
+	const op = "storage.migrations.RunMigrations"
+
+	ctx := context.Background()
+
+	// a driver made from a connection only closes the connection, one
+	// made WithInstance would close db as well
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{})
+	if err != nil {
+		conn.Close()
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	m, err := migrate.NewWithDatabaseInstance("file://"+path, "postgres", driver)
+	if err != nil {
+		driver.Close()
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	version, dirty, err = m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return version, dirty, nil
+}
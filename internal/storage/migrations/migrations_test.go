@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"os"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMigrationFiles checks that every migration in the repository can
+// be rolled back.
+func TestMigrationFiles(t *testing.T) {
+	src, err := (&file.File{}).Open("file://../../../migrations")
+	require.NoError(t, err)
+	defer src.Close()
+
+	version, err := src.First()
+	require.NoError(t, err)
+
+	for {
+		up, _, err := src.ReadUp(version)
+		require.NoError(t, err, "version %d has no up migration", version)
+		up.Close()
+
+		down, _, err := src.ReadDown(version)
+		require.NoError(t, err, "version %d has no down migration", version)
+		down.Close()
+
+		version, err = src.Next(version)
+		if err != nil {
+			require.ErrorIs(t, err, os.ErrNotExist)
+			break
+		}
+	}
+}
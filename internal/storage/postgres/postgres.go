@@ -1,20 +1,44 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/lib/pq"
 	_ "github.com/lib/pq"
 
+	"url-shortener/internal/lib/apikey"
 	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/migrations"
 )
 
 type Storage struct {
 	db *sql.DB
 }
 
-func New(storagePath string) (*Storage, error) {
+// externalIDConstraint is the unique index on url.external_id, told
+// apart from alias conflicts when a save violates it.
+const externalIDConstraint = "url_external_id_key"
+
+// PoolOptions limits the connection pool. Zero fields keep the defaults
+// of database/sql.
+type PoolOptions struct {
+	// MaxOpenConns caps the connections open at once, in use or idle.
+	MaxOpenConns int
+	// MaxIdleConns caps the connections kept open while idle.
+	MaxIdleConns int
+	// ConnMaxLifetime closes connections once they are this old.
+	ConnMaxLifetime time.Duration
+}
+
+// New connects to the database at storagePath with a pool limited by
+// pool and applies the migrations in the directory at migrationsPath.
+func New(storagePath, migrationsPath string, pool PoolOptions) (*Storage, error) {
 	const op = "storage.postgres.New"
 
 	db, err := sql.Open("postgres", storagePath)
@@ -22,72 +46,1053 @@ func New(storagePath string) (*Storage, error) {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
+	if pool.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(pool.MaxOpenConns)
+	}
+	if pool.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(pool.MaxIdleConns)
+	}
+	if pool.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(pool.ConnMaxLifetime)
 	}
 
-	_, err = db.Exec(`
-	CREATE TABLE IF NOT EXISTS url(
-		id SERIAL PRIMARY KEY,
-		alias TEXT NOT NULL UNIQUE,
-		url TEXT NOT NULL);
-	`)
-	if err != nil {
+	if err := db.Ping(); err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	_, err = db.Exec(`
-	CREATE INDEX IF NOT EXISTS idx_alias ON url(alias);
-	`)
-	if err != nil {
+	if _, _, err := migrations.RunMigrations(db, migrationsPath); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return &Storage{db: db}, nil
 }
 
-func (s *Storage) SaveURL(urlToSave string, alias string) (int64, error) {
-	const op = "storage.postgres.SaveURL"
+func (s *Storage) SaveURL(urlToSave string, alias string, opts storage.SaveOptions) (int64, error) {
+	return s.SaveURLContext(context.Background(), urlToSave, alias, opts)
+}
+
+// SaveURLContext is SaveURL giving up when ctx is done.
+func (s *Storage) SaveURLContext(ctx context.Context, urlToSave string, alias string, opts storage.SaveOptions) (int64, error) {
+	const op = "storage.postgres.SaveURLContext"
 
-	stmt, err := s.db.Prepare("INSERT INTO url(url, alias) VALUES($1, $2) RETURNING id")
+	variants, err := variantsJSON(opts.Variants)
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
+
+	stmt, err := s.db.PrepareContext(ctx, "INSERT INTO url(url, alias, sponsored, password_hash, content_hash, audited, expires_at, allowed_referrers, creator_ip, creator_user_agent, creator_identity, external_id, redirect_status, variants, owner, folder_id) VALUES($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8, NULLIF($9, ''), NULLIF($10, ''), NULLIF($11, ''), NULLIF($12, ''), NULLIF($13, 0), $14::JSONB, NULLIF($15, ''), NULLIF($16, 0)) RETURNING id")
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, contextErr(ctx, err))
+	}
 	defer stmt.Close()
 
 	var id int64
-	err = stmt.QueryRow(urlToSave, alias).Scan(&id)
+	err = stmt.QueryRowContext(ctx, urlToSave, alias, opts.Sponsored, opts.PasswordHash, opts.ContentHash, opts.Audited, opts.ExpiresAt, pq.Array(opts.AllowedReferrers), opts.Creator.IP, opts.Creator.UserAgent, opts.Creator.Identity, opts.ExternalID, opts.RedirectStatus, variants, opts.Owner, opts.FolderID).Scan(&id)
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" { // unique_violation
+			if pqErr.Constraint == externalIDConstraint {
+				return 0, fmt.Errorf("%s: %w", op, storage.ErrExternalIDExists)
+			}
 			return 0, fmt.Errorf("%s: %w", op, storage.ErrURLExists)
 		}
-		return 0, fmt.Errorf("%s: %w", op, err)
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" { // foreign_key_violation
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrFolderNotFound)
+		}
+		return 0, fmt.Errorf("%s: %w", op, contextErr(ctx, err))
 	}
 
 	return id, nil
 }
 
+// SaveURLBatch saves items in a single transaction. Taken aliases are
+// reported in their result and don't abort the batch, any other error
+// rolls the whole batch back.
+func (s *Storage) SaveURLBatch(items []storage.URLItem) ([]storage.SaveResult, error) {
+	const op = "storage.postgres.SaveURLBatch"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	// a conflict inserts nothing and so returns no id
+	stmt, err := tx.Prepare("INSERT INTO url(url, alias, owner) VALUES($1, $2, NULLIF($3, '')) ON CONFLICT (alias) DO NOTHING RETURNING id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	results := make([]storage.SaveResult, len(items))
+	for i, item := range items {
+		err := stmt.QueryRow(item.URL, item.Alias, item.Owner).Scan(&results[i].ID)
+		if err == sql.ErrNoRows {
+			results[i].Err = storage.ErrURLExists
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: commit transaction: %w", op, err)
+	}
+
+	return results, nil
+}
+
+// ClaimURL saves urlToSave under alias unless the alias is taken. In one
+// round-trip it reports whether the link was created, and the URL the
+// alias points to otherwise.
+func (s *Storage) ClaimURL(urlToSave string, alias string, opts storage.SaveOptions) (bool, string, error) {
+	claim, err := s.ClaimURLContext(context.Background(), urlToSave, alias, opts)
+
+	return claim.Created, claim.URL, err
+}
+
+// ClaimURLContext is ClaimURL giving up when ctx is done, also returning
+// the id of a created link.
+func (s *Storage) ClaimURLContext(ctx context.Context, urlToSave string, alias string, opts storage.SaveOptions) (storage.Claim, error) {
+	const op = "storage.postgres.ClaimURLContext"
+
+	variants, err := variantsJSON(opts.Variants)
+	if err != nil {
+		return storage.Claim{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// the no-op update locks and returns the row of a concurrent claim
+	// once it commits, where DO NOTHING would return no row at all. Only
+	// rows inserted by this statement have no xmax.
+	stmt, err := s.db.PrepareContext(ctx, `
+	INSERT INTO url(url, alias, sponsored, password_hash, content_hash, audited, expires_at, allowed_referrers, creator_ip, creator_user_agent, creator_identity, external_id, redirect_status, variants, owner, folder_id) VALUES($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8, NULLIF($9, ''), NULLIF($10, ''), NULLIF($11, ''), NULLIF($12, ''), NULLIF($13, 0), $14::JSONB, NULLIF($15, ''), NULLIF($16, 0))
+	ON CONFLICT (alias) DO UPDATE SET alias = EXCLUDED.alias
+	RETURNING id, url, (xmax = 0)
+	`)
+	if err != nil {
+		return storage.Claim{}, fmt.Errorf("%s: %w", op, contextErr(ctx, err))
+	}
+	defer stmt.Close()
+
+	var claim storage.Claim
+
+	err = stmt.QueryRowContext(ctx, urlToSave, alias, opts.Sponsored, opts.PasswordHash, opts.ContentHash, opts.Audited, opts.ExpiresAt, pq.Array(opts.AllowedReferrers), opts.Creator.IP, opts.Creator.UserAgent, opts.Creator.Identity, opts.ExternalID, opts.RedirectStatus, variants, opts.Owner, opts.FolderID).Scan(&claim.ID, &claim.URL, &claim.Created)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" && pqErr.Constraint == externalIDConstraint { // unique_violation
+			return storage.Claim{}, fmt.Errorf("%s: %w", op, storage.ErrExternalIDExists)
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" { // foreign_key_violation
+			return storage.Claim{}, fmt.Errorf("%s: %w", op, storage.ErrFolderNotFound)
+		}
+		return storage.Claim{}, fmt.Errorf("%s: %w", op, contextErr(ctx, err))
+	}
+
+	if claim.Created {
+		return storage.Claim{Created: true, ID: claim.ID}, nil
+	}
+
+	return storage.Claim{URL: claim.URL}, nil
+}
+
 func (s *Storage) GetURL(alias string) (string, error) {
-	const op = "storage.postgres.GetURL"
+	return s.GetURLContext(context.Background(), alias)
+}
+
+// GetURLContext is GetURL giving up when ctx is done.
+func (s *Storage) GetURLContext(ctx context.Context, alias string) (string, error) {
+	const op = "storage.postgres.GetURLContext"
 
-	stmt, err := s.db.Prepare("SELECT url FROM url WHERE alias = $1")
+	stmt, err := s.db.PrepareContext(ctx, "SELECT url, COALESCE(expires_at <= NOW(), FALSE) FROM url WHERE alias = $1")
 	if err != nil {
-		return "", fmt.Errorf("%s: prepare statement: %w", op, err)
+		return "", fmt.Errorf("%s: prepare statement: %w", op, contextErr(ctx, err))
 	}
 	defer stmt.Close()
 
-	var resURL string
-	err = stmt.QueryRow(alias).Scan(&resURL)
+	var (
+		resURL  string
+		expired bool
+	)
+	err = stmt.QueryRowContext(ctx, alias).Scan(&resURL, &expired)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return "", storage.ErrURLNotFound
 		}
-		return "", fmt.Errorf("%s: execute statement: %w", op, err)
+		return "", fmt.Errorf("%s: execute statement: %w", op, contextErr(ctx, err))
+	}
+	if expired {
+		return "", storage.ErrURLExpired
 	}
 
 	return resURL, nil
 }
 
+// GetURLInfo returns the stored link with its metadata.
+func (s *Storage) GetURLInfo(alias string) (storage.URL, error) {
+	return s.GetURLInfoContext(context.Background(), alias)
+}
+
+// GetURLInfoContext is GetURLInfo giving up when ctx is done.
+func (s *Storage) GetURLInfoContext(ctx context.Context, alias string) (storage.URL, error) {
+	const op = "storage.postgres.GetURLInfoContext"
+
+	stmt, err := s.db.PrepareContext(ctx, "SELECT id, alias, url, last_accessed_at, sponsored, created_at, password_hash, content_hash, audited, expires_at, allowed_referrers, clicks, creator_ip, creator_user_agent, creator_identity, external_id, redirect_status, variants, owner, folder_id FROM url WHERE alias = $1")
+	if err != nil {
+		return storage.URL{}, fmt.Errorf("%s: prepare statement: %w", op, contextErr(ctx, err))
+	}
+	defer stmt.Close()
+
+	res, err := scanURL(stmt.QueryRowContext(ctx, alias))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return storage.URL{}, storage.ErrURLNotFound
+		}
+		return storage.URL{}, fmt.Errorf("%s: execute statement: %w", op, contextErr(ctx, err))
+	}
+
+	return res, nil
+}
+
+// GetURLsInfo returns the stored links of aliases in a single query.
+// Aliases that aren't stored are left out.
+func (s *Storage) GetURLsInfo(aliases []string) ([]storage.URL, error) {
+	const op = "storage.postgres.GetURLsInfo"
+
+	rows, err := s.db.Query("SELECT id, alias, url, last_accessed_at, sponsored, created_at, password_hash, content_hash, audited, expires_at, allowed_referrers, clicks, creator_ip, creator_user_agent, creator_identity, external_id, redirect_status, variants, owner, folder_id FROM url WHERE alias = ANY($1)", pq.Array(aliases))
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	var urls []storage.URL
+	for rows.Next() {
+		res, err := scanURL(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+		urls = append(urls, res)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return urls, nil
+}
+
+// GetURLByID returns the stored link with the given row id.
+func (s *Storage) GetURLByID(id int64) (storage.URL, error) {
+	const op = "storage.postgres.GetURLByID"
+
+	stmt, err := s.db.Prepare("SELECT id, alias, url, last_accessed_at, sponsored, created_at, password_hash, content_hash, audited, expires_at, allowed_referrers, clicks, creator_ip, creator_user_agent, creator_identity, external_id, redirect_status, variants, owner, folder_id FROM url WHERE id = $1")
+	if err != nil {
+		return storage.URL{}, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	res, err := scanURL(stmt.QueryRow(id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return storage.URL{}, storage.ErrURLNotFound
+		}
+		return storage.URL{}, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return res, nil
+}
+
+// GetURLByExternalID returns the stored link saved under the given
+// external id.
+func (s *Storage) GetURLByExternalID(externalID string) (storage.URL, error) {
+	const op = "storage.postgres.GetURLByExternalID"
+
+	stmt, err := s.db.Prepare("SELECT id, alias, url, last_accessed_at, sponsored, created_at, password_hash, content_hash, audited, expires_at, allowed_referrers, clicks, creator_ip, creator_user_agent, creator_identity, external_id, redirect_status, variants, owner, folder_id FROM url WHERE external_id = $1")
+	if err != nil {
+		return storage.URL{}, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	res, err := scanURL(stmt.QueryRow(externalID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return storage.URL{}, storage.ErrURLNotFound
+		}
+		return storage.URL{}, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return res, nil
+}
+
+// GetURLsByTarget returns up to limit links saved for target, oldest
+// first.
+func (s *Storage) GetURLsByTarget(ctx context.Context, target string, limit int) ([]storage.URL, error) {
+	const op = "storage.postgres.GetURLsByTarget"
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, alias, url, last_accessed_at, sponsored, created_at, password_hash, content_hash, audited, expires_at, allowed_referrers, clicks, creator_ip, creator_user_agent, creator_identity, external_id, redirect_status, variants, owner, folder_id FROM url WHERE url = $1 ORDER BY id LIMIT $2", target, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	urls := []storage.URL{}
+	for rows.Next() {
+		res, err := scanURL(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+		urls = append(urls, res)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return urls, nil
+}
+
+// ExportURLs calls fn for every stored link in id order. Rows are
+// streamed, so exports don't hold the whole table in memory.
+func (s *Storage) ExportURLs(ctx context.Context, fn func(storage.URL) error) error {
+	const op = "storage.postgres.ExportURLs"
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, alias, url, last_accessed_at, sponsored, created_at, password_hash, content_hash, audited, expires_at, allowed_referrers, clicks, creator_ip, creator_user_agent, creator_identity, external_id, redirect_status, variants, owner, folder_id FROM url ORDER BY id")
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		res, err := scanURL(rows)
+		if err != nil {
+			return fmt.Errorf("%s: scan row: %w", op, err)
+		}
+
+		if err := fn(res); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GroupByContentHash returns the sets of links whose targets served
+// identical content, for spotting duplicates behind different URLs.
+func (s *Storage) GroupByContentHash(ctx context.Context) ([]storage.ContentGroup, error) {
+	const op = "storage.postgres.GroupByContentHash"
+
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT content_hash, array_agg(alias ORDER BY alias) FROM url
+	WHERE content_hash IS NOT NULL
+	GROUP BY content_hash HAVING COUNT(*) > 1
+	ORDER BY content_hash
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	var groups []storage.ContentGroup
+	for rows.Next() {
+		var group storage.ContentGroup
+		if err := rows.Scan(&group.Hash, pq.Array(&group.Aliases)); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+		groups = append(groups, group)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return groups, nil
+}
+
+// scanURL scans a row selected as id, alias, url, last_accessed_at,
+// sponsored, created_at, password_hash, content_hash, audited, expires_at,
+// allowed_referrers, clicks, creator_ip, creator_user_agent, creator_identity,
+// external_id, redirect_status, variants, owner, folder_id.
+func scanURL(row interface{ Scan(dest ...any) error }) (storage.URL, error) {
+	var (
+		res            storage.URL
+		lastAccessedAt sql.NullTime
+		passwordHash   sql.NullString
+		contentHash    sql.NullString
+		expiresAt      sql.NullTime
+		referrers      pq.StringArray
+		creatorIP      sql.NullString
+		creatorAgent   sql.NullString
+		creatorID      sql.NullString
+		externalID     sql.NullString
+		redirectStatus sql.NullInt64
+		variants       sql.NullString
+		owner          sql.NullString
+		folderID       sql.NullInt64
+	)
+
+	if err := row.Scan(&res.ID, &res.Alias, &res.URL, &lastAccessedAt, &res.Sponsored, &res.CreatedAt, &passwordHash, &contentHash, &res.Audited, &expiresAt, &referrers, &res.Clicks, &creatorIP, &creatorAgent, &creatorID, &externalID, &redirectStatus, &variants, &owner, &folderID); err != nil {
+		return storage.URL{}, err
+	}
+
+	if lastAccessedAt.Valid {
+		res.LastAccessedAt = &lastAccessedAt.Time
+	}
+	res.PasswordHash = passwordHash.String
+	res.ContentHash = contentHash.String
+	if expiresAt.Valid {
+		res.ExpiresAt = &expiresAt.Time
+	}
+	if len(referrers) > 0 {
+		res.AllowedReferrers = referrers
+	}
+	res.Creator = storage.Creator{
+		IP:        creatorIP.String,
+		UserAgent: creatorAgent.String,
+		Identity:  creatorID.String,
+	}
+	res.ExternalID = externalID.String
+	res.RedirectStatus = int(redirectStatus.Int64)
+	if variants.Valid {
+		if err := json.Unmarshal([]byte(variants.String), &res.Variants); err != nil {
+			return storage.URL{}, fmt.Errorf("decode variants: %w", err)
+		}
+	}
+	res.Owner = owner.String
+	res.FolderID = folderID.Int64
+
+	return res, nil
+}
+
+// SearchAliasesByPrefix returns up to limit aliases starting with prefix,
+// in alphabetical order. LIKE wildcards in prefix match literally.
+func (s *Storage) SearchAliasesByPrefix(prefix string, limit int) ([]string, error) {
+	const op = "storage.postgres.SearchAliasesByPrefix"
+
+	rows, err := s.db.Query(`SELECT alias FROM url WHERE alias LIKE $1 || '%' ESCAPE '\' ORDER BY alias LIMIT $2`, escapeLike(prefix), limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	aliases := []string{}
+	for rows.Next() {
+		var alias string
+		if err := rows.Scan(&alias); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+		aliases = append(aliases, alias)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return aliases, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s, with \ as escape character.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ListURLs returns up to limit links in id order, skipping the first
+// offset. Only their id, alias, url, clicks and folder are set.
+func (s *Storage) ListURLs(limit, offset int) ([]storage.URL, error) {
+	const op = "storage.postgres.ListURLs"
+
+	rows, err := s.db.Query("SELECT id, alias, url, clicks, folder_id FROM url ORDER BY id LIMIT $1 OFFSET $2", limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	urls, err := scanListed(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return urls, nil
+}
+
+// ListURLsInFolder is ListURLs for the links filed in folderID, leaving
+// out those of the folders nested in it.
+func (s *Storage) ListURLsInFolder(folderID int64, limit, offset int) ([]storage.URL, error) {
+	const op = "storage.postgres.ListURLsInFolder"
+
+	rows, err := s.db.Query("SELECT id, alias, url, clicks, folder_id FROM url WHERE folder_id = $1 ORDER BY id LIMIT $2 OFFSET $3", folderID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	urls, err := scanListed(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return urls, nil
+}
+
+// scanListed scans and closes rows selected as id, alias, url, clicks,
+// folder_id.
+func scanListed(rows *sql.Rows) ([]storage.URL, error) {
+	defer rows.Close()
+
+	urls := []storage.URL{}
+	for rows.Next() {
+		var (
+			u        storage.URL
+			folderID sql.NullInt64
+		)
+		if err := rows.Scan(&u.ID, &u.Alias, &u.URL, &u.Clicks, &folderID); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+		u.FolderID = folderID.Int64
+		urls = append(urls, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return urls, nil
+}
+
+// CountURLs returns the number of stored links.
+func (s *Storage) CountURLs() (int, error) {
+	const op = "storage.postgres.CountURLs"
+
+	var n int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM url").Scan(&n); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// CountURLsInFolder returns the number of links filed in folderID.
+func (s *Storage) CountURLsInFolder(folderID int64) (int, error) {
+	const op = "storage.postgres.CountURLsInFolder"
+
+	var n int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM url WHERE folder_id = $1", folderID).Scan(&n); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// EstimateURLs returns the number of stored links as last estimated by
+// VACUUM and ANALYZE, without scanning the table. Links are counted
+// exactly while the table was never analyzed.
+func (s *Storage) EstimateURLs() (int, error) {
+	const op = "storage.postgres.EstimateURLs"
+
+	var n int64
+	if err := s.db.QueryRow("SELECT reltuples::BIGINT FROM pg_class WHERE oid = 'url'::regclass").Scan(&n); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if n < 0 {
+		return s.CountURLs()
+	}
+
+	return int(n), nil
+}
+
+// UpdateURL points the link stored under alias to newURL.
+func (s *Storage) UpdateURL(alias, newURL string) error {
+	const op = "storage.postgres.UpdateURL"
+
+	res, err := s.db.Exec("UPDATE url SET url = $1 WHERE alias = $2", newURL, alias)
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return storage.ErrURLNotFound
+	}
+
+	return nil
+}
+
+// DeleteURL removes the link stored under alias.
+func (s *Storage) DeleteURL(alias string) error {
+	const op = "storage.postgres.DeleteURL"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("DELETE FROM url WHERE alias = $1", alias)
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return storage.ErrURLNotFound
+	}
+
+	if err := deleteClicks(tx, []string{alias}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// deleteClicks deletes the click events and variant counters of deleted
+// links, so that a link saved later under one of their aliases starts
+// without them.
+func deleteClicks(tx *sql.Tx, aliases []string) error {
+	if _, err := tx.Exec("DELETE FROM clicks WHERE alias = ANY($1)", pq.Array(aliases)); err != nil {
+		return fmt.Errorf("delete clicks: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM variant_clicks WHERE alias = ANY($1)", pq.Array(aliases)); err != nil {
+		return fmt.Errorf("delete variant clicks: %w", err)
+	}
+
+	return nil
+}
+
+// folderTree selects the ids of folder $1 and of every folder nested in it.
+const folderTree = `
+	WITH RECURSIVE tree(id) AS (
+		SELECT id FROM folders WHERE id = $1
+		UNION ALL
+		SELECT f.id FROM folders f JOIN tree t ON f.parent_id = t.id
+	)
+	SELECT id FROM tree`
+
+// CreateFolder creates a folder named name in parentID, or at the top
+// level when it is zero.
+func (s *Storage) CreateFolder(name string, parentID int64) (int64, error) {
+	const op = "storage.postgres.CreateFolder"
+
+	var id int64
+	err := s.db.QueryRow("INSERT INTO folders(name, parent_id) VALUES($1, NULLIF($2, 0)) RETURNING id", name, parentID).Scan(&id)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code {
+			case "23505": // unique_violation
+				return 0, fmt.Errorf("%s: %w", op, storage.ErrFolderExists)
+			case "23503": // foreign_key_violation
+				return 0, fmt.Errorf("%s: %w", op, storage.ErrFolderNotFound)
+			}
+		}
+		return 0, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// GetFolder returns the folder id.
+func (s *Storage) GetFolder(id int64) (storage.Folder, error) {
+	const op = "storage.postgres.GetFolder"
+
+	var (
+		f        storage.Folder
+		parentID sql.NullInt64
+	)
+	err := s.db.QueryRow("SELECT id, name, parent_id, created_at FROM folders WHERE id = $1", id).Scan(&f.ID, &f.Name, &parentID, &f.CreatedAt)
+	if err == sql.ErrNoRows {
+		return storage.Folder{}, storage.ErrFolderNotFound
+	}
+	if err != nil {
+		return storage.Folder{}, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	f.ParentID = parentID.Int64
+
+	return f, nil
+}
+
+// ListFolders returns every folder in id order, so parents come before
+// the folders nested in them.
+func (s *Storage) ListFolders() ([]storage.Folder, error) {
+	const op = "storage.postgres.ListFolders"
+
+	rows, err := s.db.Query("SELECT id, name, parent_id, created_at FROM folders ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	folders := []storage.Folder{}
+	for rows.Next() {
+		var (
+			f        storage.Folder
+			parentID sql.NullInt64
+		)
+		if err := rows.Scan(&f.ID, &f.Name, &parentID, &f.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+		f.ParentID = parentID.Int64
+		folders = append(folders, f)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return folders, nil
+}
+
+// RenameFolder renames the folder id.
+func (s *Storage) RenameFolder(id int64, name string) error {
+	const op = "storage.postgres.RenameFolder"
+
+	res, err := s.db.Exec("UPDATE folders SET name = $2 WHERE id = $1", id, name)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" { // unique_violation
+			return fmt.Errorf("%s: %w", op, storage.ErrFolderExists)
+		}
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return storage.ErrFolderNotFound
+	}
+
+	return nil
+}
+
+// DeleteFolder deletes the folder id and the folders nested in it. Their
+// links are deleted too when cascade is set, and returned by alias so
+// they can be evicted, or else moved out of any folder.
+func (s *Storage) DeleteFolder(id int64, cascade bool) ([]string, error) {
+	const op = "storage.postgres.DeleteFolder"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	deleted := []string{}
+	if cascade {
+		rows, err := tx.Query("DELETE FROM url WHERE folder_id IN ("+folderTree+") RETURNING alias", id)
+		if err != nil {
+			return nil, fmt.Errorf("%s: delete urls: %w", op, err)
+		}
+		for rows.Next() {
+			var alias string
+			if err := rows.Scan(&alias); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("%s: scan row: %w", op, err)
+			}
+			deleted = append(deleted, alias)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err := deleteClicks(tx, deleted); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	} else {
+		if _, err := tx.Exec("UPDATE url SET folder_id = NULL WHERE folder_id IN ("+folderTree+")", id); err != nil {
+			return nil, fmt.Errorf("%s: orphan urls: %w", op, err)
+		}
+	}
+
+	res, err := tx.Exec("DELETE FROM folders WHERE id IN ("+folderTree+")", id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: delete folders: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return nil, storage.ErrFolderNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: commit transaction: %w", op, err)
+	}
+
+	return deleted, nil
+}
+
+// TouchURL records the time alias was last accessed.
+func (s *Storage) TouchURL(alias string, at time.Time) error {
+	const op = "storage.postgres.TouchURL"
+
+	_, err := s.db.Exec("UPDATE url SET last_accessed_at = $1 WHERE alias = $2", at, alias)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// IncrementClicks counts one more redirect of alias.
+func (s *Storage) IncrementClicks(alias string) error {
+	const op = "storage.postgres.IncrementClicks"
+
+	_, err := s.db.Exec("UPDATE url SET clicks = clicks + 1 WHERE alias = $1", alias)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// BulkIncrementClicks adds the counted clicks of several aliases in one
+// statement. Aliases that don't exist are ignored.
+func (s *Storage) BulkIncrementClicks(counts map[string]int64) error {
+	const op = "storage.postgres.BulkIncrementClicks"
+
+	if len(counts) == 0 {
+		return nil
+	}
+
+	aliases := make([]string, 0, len(counts))
+	deltas := make([]int64, 0, len(counts))
+	for alias, delta := range counts {
+		aliases = append(aliases, alias)
+		deltas = append(deltas, delta)
+	}
+
+	_, err := s.db.Exec(`
+	UPDATE url SET clicks = url.clicks + c.delta
+	FROM unnest($1::TEXT[], $2::BIGINT[]) AS c(alias, delta)
+	WHERE url.alias = c.alias
+	`, pq.Array(aliases), pq.Array(deltas))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// IncrementVariantClicks counts one more redirect of alias to the variant
+// at the given index.
+func (s *Storage) IncrementVariantClicks(alias string, variant int) error {
+	const op = "storage.postgres.IncrementVariantClicks"
+
+	_, err := s.db.Exec(`
+	INSERT INTO variant_clicks(alias, variant, clicks) VALUES($1, $2, 1)
+	ON CONFLICT (alias, variant) DO UPDATE SET clicks = variant_clicks.clicks + 1
+	`, alias, variant)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetVariantClicks returns the redirects of alias by variant index.
+// Variants never redirected to are left out.
+func (s *Storage) GetVariantClicks(alias string) (map[int]int64, error) {
+	const op = "storage.postgres.GetVariantClicks"
+
+	rows, err := s.db.Query("SELECT variant, clicks FROM variant_clicks WHERE alias = $1", alias)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	clicks := make(map[int]int64)
+	for rows.Next() {
+		var (
+			variant int
+			n       int64
+		)
+		if err := rows.Scan(&variant, &n); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+		clicks[variant] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return clicks, nil
+}
+
+// RecordClick stores a redirect for analytics.
+func (s *Storage) RecordClick(event storage.ClickEvent) error {
+	const op = "storage.postgres.RecordClick"
+
+	_, err := s.db.Exec("INSERT INTO clicks(alias, ts, referrer, user_agent, ip) VALUES($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''))",
+		event.Alias, event.Time, event.Referrer, event.UserAgent, event.IP)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetDailyClicks returns the redirects of alias recorded since the given
+// time, counted per UTC day. Days without redirects are left out.
+func (s *Storage) GetDailyClicks(alias string, since time.Time) ([]storage.DailyClicks, error) {
+	const op = "storage.postgres.GetDailyClicks"
+
+	rows, err := s.db.Query(`
+	SELECT (ts AT TIME ZONE 'UTC')::DATE AS day, COUNT(*) FROM clicks
+	WHERE alias = $1 AND ts >= $2
+	GROUP BY day ORDER BY day
+	`, alias, since)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	var days []storage.DailyClicks
+	for rows.Next() {
+		var d storage.DailyClicks
+		if err := rows.Scan(&d.Day, &d.Clicks); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+		days = append(days, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return days, nil
+}
+
+// AliasLength returns the stored length of generated aliases, 0 if none
+// was stored yet.
+func (s *Storage) AliasLength(ctx context.Context) (int, error) {
+	const op = "storage.postgres.AliasLength"
+
+	var length int
+	err := s.db.QueryRowContext(ctx, "SELECT value::INTEGER FROM settings WHERE key = 'alias_length'").Scan(&length)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return length, nil
+}
+
+// SetAliasLength stores the length of generated aliases.
+func (s *Storage) SetAliasLength(ctx context.Context, length int) error {
+	const op = "storage.postgres.SetAliasLength"
+
+	_, err := s.db.ExecContext(ctx, `
+	INSERT INTO settings(key, value) VALUES('alias_length', $1)
+	ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value
+	`, strconv.Itoa(length))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SaveAPIKey stores the hash of a new API key.
+func (s *Storage) SaveAPIKey(key string) error {
+	const op = "storage.postgres.SaveAPIKey"
+
+	_, err := s.db.Exec("INSERT INTO api_keys(key_hash) VALUES($1)", apikey.Hash(key))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ValidateAPIKey reports whether key was saved with SaveAPIKey.
+func (s *Storage) ValidateAPIKey(key string) (bool, error) {
+	const op = "storage.postgres.ValidateAPIKey"
+
+	var found bool
+	err := s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM api_keys WHERE key_hash = $1)", apikey.Hash(key)).Scan(&found)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return found, nil
+}
+
+func (s *Storage) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Migrate brings the schema at storagePath up to date and disconnects,
+// for deploy pipelines that apply schema changes in a separate job. It
+// returns the schema version it ends at.
+func Migrate(storagePath, migrationsPath string) (version uint, dirty bool, err error) {
+	const op = "storage.postgres.Migrate"
+
+	db, err := sql.Open("postgres", storagePath)
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+	defer db.Close()
+
+	if err := db.Ping(); err != nil {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	version, dirty, err = migrations.RunMigrations(db, migrationsPath)
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := db.Close(); err != nil {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return version, dirty, nil
+}
+
+// Stats returns the connection pool statistics.
+func (s *Storage) Stats() sql.DBStats {
+	return s.db.Stats()
+}
+
 func (s *Storage) Close() error {
 	return s.db.Close()
 }
+
+// variantsJSON encodes the variants of a split link, NULL if it has none.
+func variantsJSON(variants []storage.Variant) (sql.NullString, error) {
+	if len(variants) == 0 {
+		return sql.NullString{}, nil
+	}
+
+	b, err := json.Marshal(variants)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
+// contextErr returns the error of ctx once it is done, which lib/pq
+// reports as a cancelled statement instead.
+func contextErr(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+
+	return err
+}
@@ -0,0 +1,24 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeLike(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "abc", want: "abc"},
+		{in: "50%", want: `50\%`},
+		{in: "a_b", want: `a\_b`},
+		{in: `a\b`, want: `a\\b`},
+		{in: `%_\`, want: `\%\_\\`},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, escapeLike(tt.in), tt.in)
+	}
+}
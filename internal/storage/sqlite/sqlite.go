@@ -0,0 +1,1147 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"url-shortener/internal/lib/apikey"
+	"url-shortener/internal/storage"
+)
+
+// Storage keeps links in a SQLite file, for running the service locally
+// without Postgres.
+type Storage struct {
+	db *sql.DB
+}
+
+func New(storagePath string) (*Storage, error) {
+	const op = "storage.sqlite.New"
+
+	if err := os.MkdirAll(filepath.Dir(storagePath), 0o755); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	db, err := sql.Open("sqlite", storagePath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// SQLite allows a single writer, and every connection to ":memory:"
+	// would open a database of its own
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS url(
+		id INTEGER PRIMARY KEY,
+		alias TEXT NOT NULL UNIQUE,
+		url TEXT NOT NULL,
+		last_accessed_at TIMESTAMP,
+		sponsored BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		password_hash TEXT,
+		content_hash TEXT,
+		audited BOOLEAN NOT NULL DEFAULT FALSE,
+		expires_at TIMESTAMP,
+		allowed_referrers TEXT,
+		clicks INTEGER NOT NULL DEFAULT 0,
+		creator_ip TEXT,
+		creator_user_agent TEXT,
+		creator_identity TEXT,
+		external_id TEXT,
+		redirect_status INTEGER,
+		variants TEXT,
+		owner TEXT,
+		folder_id INTEGER);
+	CREATE INDEX IF NOT EXISTS idx_alias ON url(alias);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_external_id ON url(external_id);
+	CREATE INDEX IF NOT EXISTS idx_content_hash ON url(content_hash);
+	CREATE INDEX IF NOT EXISTS idx_url ON url(url);
+	CREATE INDEX IF NOT EXISTS idx_url_folder_id ON url(folder_id);
+	CREATE TABLE IF NOT EXISTS folders(
+		id INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		parent_id INTEGER,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_folders_parent_name ON folders(COALESCE(parent_id, 0), name);
+	CREATE TABLE IF NOT EXISTS variant_clicks(
+		alias TEXT NOT NULL,
+		variant INTEGER NOT NULL,
+		clicks INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (alias, variant));
+	CREATE TABLE IF NOT EXISTS clicks(
+		id INTEGER PRIMARY KEY,
+		alias TEXT NOT NULL,
+		ts TIMESTAMP NOT NULL,
+		referrer TEXT,
+		user_agent TEXT,
+		ip TEXT);
+	CREATE INDEX IF NOT EXISTS idx_clicks_alias_ts ON clicks(alias, ts);
+	CREATE TABLE IF NOT EXISTS settings(
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL);
+	CREATE TABLE IF NOT EXISTS api_keys(
+		key_hash TEXT PRIMARY KEY,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP);
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Storage{db: db}, nil
+}
+
+func (s *Storage) SaveURL(urlToSave string, alias string, opts storage.SaveOptions) (int64, error) {
+	return s.SaveURLContext(context.Background(), urlToSave, alias, opts)
+}
+
+// SaveURLContext is SaveURL giving up when ctx is done.
+func (s *Storage) SaveURLContext(ctx context.Context, urlToSave string, alias string, opts storage.SaveOptions) (int64, error) {
+	const op = "storage.sqlite.SaveURLContext"
+
+	var referrers sql.NullString
+	if len(opts.AllowedReferrers) > 0 {
+		b, err := json.Marshal(opts.AllowedReferrers)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		referrers = sql.NullString{String: string(b), Valid: true}
+	}
+
+	variants, err := variantsJSON(opts.Variants)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+	INSERT INTO url(url, alias, sponsored, password_hash, content_hash, audited, expires_at, allowed_referrers, creator_ip, creator_user_agent, creator_identity, external_id, redirect_status, variants, owner, folder_id)
+	VALUES(?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, 0), ?, NULLIF(?, ''), NULLIF(?, 0))
+	`, urlToSave, alias, opts.Sponsored, opts.PasswordHash, opts.ContentHash, opts.Audited, opts.ExpiresAt, referrers, opts.Creator.IP, opts.Creator.UserAgent, opts.Creator.Identity, opts.ExternalID, opts.RedirectStatus, variants, opts.Owner, opts.FolderID)
+	if err != nil {
+		var sqliteErr *sqlite.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+			// the message names the column whose constraint failed
+			if strings.Contains(sqliteErr.Error(), "url.external_id") {
+				return 0, fmt.Errorf("%s: %w", op, storage.ErrExternalIDExists)
+			}
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrURLExists)
+		}
+		return 0, fmt.Errorf("%s: %w", op, contextErr(ctx, err))
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// ClaimURL saves urlToSave under alias unless the alias is taken. It
+// reports whether the link was created, and the URL the alias points to
+// otherwise.
+func (s *Storage) ClaimURL(urlToSave string, alias string, opts storage.SaveOptions) (bool, string, error) {
+	claim, err := s.ClaimURLContext(context.Background(), urlToSave, alias, opts)
+
+	return claim.Created, claim.URL, err
+}
+
+// ClaimURLContext is ClaimURL giving up when ctx is done, also returning
+// the id of a created link.
+func (s *Storage) ClaimURLContext(ctx context.Context, urlToSave string, alias string, opts storage.SaveOptions) (storage.Claim, error) {
+	const op = "storage.sqlite.ClaimURLContext"
+
+	var referrers sql.NullString
+	if len(opts.AllowedReferrers) > 0 {
+		b, err := json.Marshal(opts.AllowedReferrers)
+		if err != nil {
+			return storage.Claim{}, fmt.Errorf("%s: %w", op, err)
+		}
+		referrers = sql.NullString{String: string(b), Valid: true}
+	}
+
+	variants, err := variantsJSON(opts.Variants)
+	if err != nil {
+		return storage.Claim{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// writers are serialized, so the taken alias is read before anyone
+	// else can change it
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return storage.Claim{}, fmt.Errorf("%s: begin transaction: %w", op, contextErr(ctx, err))
+	}
+	defer tx.Rollback()
+
+	// a conflict inserts nothing and so returns no id
+	var id int64
+	err = tx.QueryRowContext(ctx, `
+	INSERT INTO url(url, alias, sponsored, password_hash, content_hash, audited, expires_at, allowed_referrers, creator_ip, creator_user_agent, creator_identity, external_id, redirect_status, variants, owner, folder_id)
+	VALUES(?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, 0), ?, NULLIF(?, ''), NULLIF(?, 0))
+	ON CONFLICT (alias) DO NOTHING
+	RETURNING id
+	`, urlToSave, alias, opts.Sponsored, opts.PasswordHash, opts.ContentHash, opts.Audited, opts.ExpiresAt, referrers, opts.Creator.IP, opts.Creator.UserAgent, opts.Creator.Identity, opts.ExternalID, opts.RedirectStatus, variants, opts.Owner, opts.FolderID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		var existing string
+		if err := tx.QueryRowContext(ctx, "SELECT url FROM url WHERE alias = ?", alias).Scan(&existing); err != nil {
+			return storage.Claim{}, fmt.Errorf("%s: get claimed url: %w", op, contextErr(ctx, err))
+		}
+
+		return storage.Claim{URL: existing}, nil
+	}
+	if err != nil {
+		var sqliteErr *sqlite.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+			return storage.Claim{}, fmt.Errorf("%s: %w", op, storage.ErrExternalIDExists)
+		}
+		return storage.Claim{}, fmt.Errorf("%s: %w", op, contextErr(ctx, err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return storage.Claim{}, fmt.Errorf("%s: commit transaction: %w", op, err)
+	}
+
+	return storage.Claim{Created: true, ID: id}, nil
+}
+
+// SaveURLBatch saves items in a single transaction. Taken aliases are
+// reported in their result and don't abort the batch, any other error
+// rolls the whole batch back.
+func (s *Storage) SaveURLBatch(items []storage.URLItem) ([]storage.SaveResult, error) {
+	const op = "storage.sqlite.SaveURLBatch"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	// a conflict inserts nothing and so returns no id
+	stmt, err := tx.Prepare("INSERT INTO url(url, alias, owner) VALUES(?, ?, NULLIF(?, '')) ON CONFLICT (alias) DO NOTHING RETURNING id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	results := make([]storage.SaveResult, len(items))
+	for i, item := range items {
+		err := stmt.QueryRow(item.URL, item.Alias, item.Owner).Scan(&results[i].ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			results[i].Err = storage.ErrURLExists
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: commit transaction: %w", op, err)
+	}
+
+	return results, nil
+}
+
+func (s *Storage) GetURL(alias string) (string, error) {
+	return s.GetURLContext(context.Background(), alias)
+}
+
+// GetURLContext is GetURL giving up when ctx is done.
+func (s *Storage) GetURLContext(ctx context.Context, alias string) (string, error) {
+	const op = "storage.sqlite.GetURLContext"
+
+	var (
+		resURL    string
+		expiresAt sql.NullTime
+	)
+	err := s.db.QueryRowContext(ctx, "SELECT url, expires_at FROM url WHERE alias = ?", alias).Scan(&resURL, &expiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", storage.ErrURLNotFound
+		}
+		return "", fmt.Errorf("%s: execute statement: %w", op, contextErr(ctx, err))
+	}
+	// timestamps are stored as text, so they are compared here rather than in SQL
+	if expiresAt.Valid && !time.Now().Before(expiresAt.Time) {
+		return "", storage.ErrURLExpired
+	}
+
+	return resURL, nil
+}
+
+// GetURLInfo returns the stored link with its metadata.
+func (s *Storage) GetURLInfo(alias string) (storage.URL, error) {
+	return s.GetURLInfoContext(context.Background(), alias)
+}
+
+// GetURLInfoContext is GetURLInfo giving up when ctx is done.
+func (s *Storage) GetURLInfoContext(ctx context.Context, alias string) (storage.URL, error) {
+	const op = "storage.sqlite.GetURLInfoContext"
+
+	res, err := scanURL(s.db.QueryRowContext(ctx, "SELECT "+urlColumns+" FROM url WHERE alias = ?", alias))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return storage.URL{}, storage.ErrURLNotFound
+		}
+		return storage.URL{}, fmt.Errorf("%s: execute statement: %w", op, contextErr(ctx, err))
+	}
+
+	return res, nil
+}
+
+// GetURLsInfo returns the stored links of aliases in a single query.
+// Aliases that aren't stored are left out.
+func (s *Storage) GetURLsInfo(aliases []string) ([]storage.URL, error) {
+	const op = "storage.sqlite.GetURLsInfo"
+
+	b, err := json.Marshal(aliases)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.db.Query("SELECT "+urlColumns+" FROM url WHERE alias IN (SELECT value FROM json_each(?))", string(b))
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	var urls []storage.URL
+	for rows.Next() {
+		res, err := scanURL(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+		urls = append(urls, res)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return urls, nil
+}
+
+// GetURLByID returns the stored link with the given row id.
+func (s *Storage) GetURLByID(id int64) (storage.URL, error) {
+	const op = "storage.sqlite.GetURLByID"
+
+	res, err := scanURL(s.db.QueryRow("SELECT "+urlColumns+" FROM url WHERE id = ?", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return storage.URL{}, storage.ErrURLNotFound
+		}
+		return storage.URL{}, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return res, nil
+}
+
+// GetURLByExternalID returns the stored link saved under the given
+// external id.
+func (s *Storage) GetURLByExternalID(externalID string) (storage.URL, error) {
+	const op = "storage.sqlite.GetURLByExternalID"
+
+	res, err := scanURL(s.db.QueryRow("SELECT "+urlColumns+" FROM url WHERE external_id = ?", externalID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return storage.URL{}, storage.ErrURLNotFound
+		}
+		return storage.URL{}, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return res, nil
+}
+
+// GetURLsByTarget returns up to limit links saved for target, oldest
+// first.
+func (s *Storage) GetURLsByTarget(ctx context.Context, target string, limit int) ([]storage.URL, error) {
+	const op = "storage.sqlite.GetURLsByTarget"
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+urlColumns+" FROM url WHERE url = ? ORDER BY id LIMIT ?", target, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	urls := []storage.URL{}
+	for rows.Next() {
+		res, err := scanURL(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+		urls = append(urls, res)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return urls, nil
+}
+
+// ExportURLs calls fn for every stored link in id order.
+func (s *Storage) ExportURLs(ctx context.Context, fn func(storage.URL) error) error {
+	const op = "storage.sqlite.ExportURLs"
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+urlColumns+" FROM url ORDER BY id")
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		res, err := scanURL(rows)
+		if err != nil {
+			return fmt.Errorf("%s: scan row: %w", op, err)
+		}
+
+		if err := fn(res); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GroupByContentHash returns the sets of links whose targets served
+// identical content.
+func (s *Storage) GroupByContentHash(ctx context.Context) ([]storage.ContentGroup, error) {
+	const op = "storage.sqlite.GroupByContentHash"
+
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT content_hash, json_group_array(alias ORDER BY alias) FROM url
+	WHERE content_hash IS NOT NULL
+	GROUP BY content_hash HAVING COUNT(*) > 1
+	ORDER BY content_hash
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	var groups []storage.ContentGroup
+	for rows.Next() {
+		var (
+			group   storage.ContentGroup
+			aliases string
+		)
+		if err := rows.Scan(&group.Hash, &aliases); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+		if err := json.Unmarshal([]byte(aliases), &group.Aliases); err != nil {
+			return nil, fmt.Errorf("%s: decode aliases: %w", op, err)
+		}
+		groups = append(groups, group)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return groups, nil
+}
+
+// SearchAliasesByPrefix returns up to limit aliases starting with prefix,
+// in alphabetical order.
+func (s *Storage) SearchAliasesByPrefix(prefix string, limit int) ([]string, error) {
+	const op = "storage.sqlite.SearchAliasesByPrefix"
+
+	// LIKE ignores case in SQLite, comparing the prefix doesn't and needs no escaping
+	rows, err := s.db.Query("SELECT alias FROM url WHERE substr(alias, 1, length(?1)) = ?1 ORDER BY alias LIMIT ?2", prefix, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	aliases := []string{}
+	for rows.Next() {
+		var alias string
+		if err := rows.Scan(&alias); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+		aliases = append(aliases, alias)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return aliases, nil
+}
+
+// ListURLs returns up to limit links in id order, skipping the first
+// offset. Only their id, alias, url, clicks and folder are set.
+func (s *Storage) ListURLs(limit, offset int) ([]storage.URL, error) {
+	const op = "storage.sqlite.ListURLs"
+
+	rows, err := s.db.Query("SELECT id, alias, url, clicks, folder_id FROM url ORDER BY id LIMIT ? OFFSET ?", limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	urls, err := scanListed(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return urls, nil
+}
+
+// ListURLsInFolder is ListURLs for the links filed in folderID, leaving
+// out those of the folders nested in it.
+func (s *Storage) ListURLsInFolder(folderID int64, limit, offset int) ([]storage.URL, error) {
+	const op = "storage.sqlite.ListURLsInFolder"
+
+	rows, err := s.db.Query("SELECT id, alias, url, clicks, folder_id FROM url WHERE folder_id = ? ORDER BY id LIMIT ? OFFSET ?", folderID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	urls, err := scanListed(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return urls, nil
+}
+
+// scanListed scans and closes rows selected as id, alias, url, clicks,
+// folder_id.
+func scanListed(rows *sql.Rows) ([]storage.URL, error) {
+	defer rows.Close()
+
+	urls := []storage.URL{}
+	for rows.Next() {
+		var (
+			u        storage.URL
+			folderID sql.NullInt64
+		)
+		if err := rows.Scan(&u.ID, &u.Alias, &u.URL, &u.Clicks, &folderID); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+		u.FolderID = folderID.Int64
+		urls = append(urls, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return urls, nil
+}
+
+// CountURLs returns the number of stored links.
+func (s *Storage) CountURLs() (int, error) {
+	const op = "storage.sqlite.CountURLs"
+
+	var n int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM url").Scan(&n); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// CountURLsInFolder returns the number of links filed in folderID.
+func (s *Storage) CountURLsInFolder(folderID int64) (int, error) {
+	const op = "storage.sqlite.CountURLsInFolder"
+
+	var n int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM url WHERE folder_id = ?", folderID).Scan(&n); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// EstimateURLs returns the highest link id, read from the end of the
+// table without scanning it. Deleted links are still counted.
+func (s *Storage) EstimateURLs() (int, error) {
+	const op = "storage.sqlite.EstimateURLs"
+
+	var n int
+	if err := s.db.QueryRow("SELECT COALESCE(MAX(id), 0) FROM url").Scan(&n); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// UpdateURL points the link stored under alias to newURL.
+func (s *Storage) UpdateURL(alias, newURL string) error {
+	const op = "storage.sqlite.UpdateURL"
+
+	res, err := s.db.Exec("UPDATE url SET url = ? WHERE alias = ?", newURL, alias)
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return storage.ErrURLNotFound
+	}
+
+	return nil
+}
+
+// DeleteURL removes the link stored under alias.
+func (s *Storage) DeleteURL(alias string) error {
+	const op = "storage.sqlite.DeleteURL"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("DELETE FROM url WHERE alias = ?", alias)
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return storage.ErrURLNotFound
+	}
+
+	if err := deleteClicks(tx, []string{alias}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// deleteClicks deletes the click events and variant counters of deleted
+// links, so that a link saved later under one of their aliases starts
+// without them.
+func deleteClicks(tx *sql.Tx, aliases []string) error {
+	for _, table := range []string{"clicks", "variant_clicks"} {
+		stmt, err := tx.Prepare("DELETE FROM " + table + " WHERE alias = ?")
+		if err != nil {
+			return fmt.Errorf("delete %s: %w", table, err)
+		}
+
+		for _, alias := range aliases {
+			if _, err := stmt.Exec(alias); err != nil {
+				stmt.Close()
+				return fmt.Errorf("delete %s: %w", table, err)
+			}
+		}
+		stmt.Close()
+	}
+
+	return nil
+}
+
+// folderTree selects the ids of folder ? and of every folder nested in it.
+const folderTree = `
+	WITH RECURSIVE tree(id) AS (
+		SELECT id FROM folders WHERE id = ?
+		UNION ALL
+		SELECT f.id FROM folders f JOIN tree t ON f.parent_id = t.id
+	)
+	SELECT id FROM tree`
+
+// CreateFolder creates a folder named name in parentID, or at the top
+// level when it is zero.
+func (s *Storage) CreateFolder(name string, parentID int64) (int64, error) {
+	const op = "storage.sqlite.CreateFolder"
+
+	// foreign keys are not enforced, the parent is checked by the insert
+	res, err := s.db.Exec(`
+	INSERT INTO folders(name, parent_id)
+	SELECT ?, NULLIF(?, 0) WHERE ? = 0 OR EXISTS (SELECT 1 FROM folders WHERE id = ?)
+	`, name, parentID, parentID, parentID)
+	if err != nil {
+		var sqliteErr *sqlite.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrFolderExists)
+		}
+		return 0, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrFolderNotFound)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// GetFolder returns the folder id.
+func (s *Storage) GetFolder(id int64) (storage.Folder, error) {
+	const op = "storage.sqlite.GetFolder"
+
+	var (
+		f        storage.Folder
+		parentID sql.NullInt64
+	)
+	err := s.db.QueryRow("SELECT id, name, parent_id, created_at FROM folders WHERE id = ?", id).Scan(&f.ID, &f.Name, &parentID, &f.CreatedAt)
+	if err == sql.ErrNoRows {
+		return storage.Folder{}, storage.ErrFolderNotFound
+	}
+	if err != nil {
+		return storage.Folder{}, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	f.ParentID = parentID.Int64
+
+	return f, nil
+}
+
+// ListFolders returns every folder in id order, so parents come before
+// the folders nested in them.
+func (s *Storage) ListFolders() ([]storage.Folder, error) {
+	const op = "storage.sqlite.ListFolders"
+
+	rows, err := s.db.Query("SELECT id, name, parent_id, created_at FROM folders ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	folders := []storage.Folder{}
+	for rows.Next() {
+		var (
+			f        storage.Folder
+			parentID sql.NullInt64
+		)
+		if err := rows.Scan(&f.ID, &f.Name, &parentID, &f.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+		f.ParentID = parentID.Int64
+		folders = append(folders, f)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return folders, nil
+}
+
+// RenameFolder renames the folder id.
+func (s *Storage) RenameFolder(id int64, name string) error {
+	const op = "storage.sqlite.RenameFolder"
+
+	res, err := s.db.Exec("UPDATE folders SET name = ? WHERE id = ?", name, id)
+	if err != nil {
+		var sqliteErr *sqlite.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+			return fmt.Errorf("%s: %w", op, storage.ErrFolderExists)
+		}
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return storage.ErrFolderNotFound
+	}
+
+	return nil
+}
+
+// DeleteFolder deletes the folder id and the folders nested in it. Their
+// links are deleted too when cascade is set, and returned by alias so
+// they can be evicted, or else moved out of any folder.
+func (s *Storage) DeleteFolder(id int64, cascade bool) ([]string, error) {
+	const op = "storage.sqlite.DeleteFolder"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	deleted := []string{}
+	if cascade {
+		rows, err := tx.Query("DELETE FROM url WHERE folder_id IN ("+folderTree+") RETURNING alias", id)
+		if err != nil {
+			return nil, fmt.Errorf("%s: delete urls: %w", op, err)
+		}
+		for rows.Next() {
+			var alias string
+			if err := rows.Scan(&alias); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("%s: scan row: %w", op, err)
+			}
+			deleted = append(deleted, alias)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err := deleteClicks(tx, deleted); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	} else {
+		if _, err := tx.Exec("UPDATE url SET folder_id = NULL WHERE folder_id IN ("+folderTree+")", id); err != nil {
+			return nil, fmt.Errorf("%s: orphan urls: %w", op, err)
+		}
+	}
+
+	res, err := tx.Exec("DELETE FROM folders WHERE id IN ("+folderTree+")", id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: delete folders: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return nil, storage.ErrFolderNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: commit transaction: %w", op, err)
+	}
+
+	return deleted, nil
+}
+
+// TouchURL records the time alias was last accessed.
+func (s *Storage) TouchURL(alias string, at time.Time) error {
+	const op = "storage.sqlite.TouchURL"
+
+	_, err := s.db.Exec("UPDATE url SET last_accessed_at = ? WHERE alias = ?", at, alias)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// IncrementClicks counts one more redirect of alias.
+func (s *Storage) IncrementClicks(alias string) error {
+	const op = "storage.sqlite.IncrementClicks"
+
+	_, err := s.db.Exec("UPDATE url SET clicks = clicks + 1 WHERE alias = ?", alias)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// BulkIncrementClicks adds the counted clicks of several aliases in one
+// statement. Aliases that don't exist are ignored.
+func (s *Storage) BulkIncrementClicks(counts map[string]int64) error {
+	const op = "storage.sqlite.BulkIncrementClicks"
+
+	if len(counts) == 0 {
+		return nil
+	}
+
+	b, err := json.Marshal(counts)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = s.db.Exec(`
+	UPDATE url SET clicks = url.clicks + c.value
+	FROM json_each(?) AS c
+	WHERE url.alias = c.key
+	`, string(b))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// IncrementVariantClicks counts one more redirect of alias to the variant
+// at the given index.
+func (s *Storage) IncrementVariantClicks(alias string, variant int) error {
+	const op = "storage.sqlite.IncrementVariantClicks"
+
+	_, err := s.db.Exec(`
+	INSERT INTO variant_clicks(alias, variant, clicks) VALUES(?, ?, 1)
+	ON CONFLICT (alias, variant) DO UPDATE SET clicks = variant_clicks.clicks + 1
+	`, alias, variant)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetVariantClicks returns the redirects of alias by variant index.
+// Variants never redirected to are left out.
+func (s *Storage) GetVariantClicks(alias string) (map[int]int64, error) {
+	const op = "storage.sqlite.GetVariantClicks"
+
+	rows, err := s.db.Query("SELECT variant, clicks FROM variant_clicks WHERE alias = ?", alias)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	clicks := make(map[int]int64)
+	for rows.Next() {
+		var (
+			variant int
+			n       int64
+		)
+		if err := rows.Scan(&variant, &n); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+		clicks[variant] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return clicks, nil
+}
+
+// RecordClick stores a redirect for analytics.
+func (s *Storage) RecordClick(event storage.ClickEvent) error {
+	const op = "storage.sqlite.RecordClick"
+
+	// UTC times sort and truncate to days as text
+	_, err := s.db.Exec("INSERT INTO clicks(alias, ts, referrer, user_agent, ip) VALUES(?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''))",
+		event.Alias, event.Time.UTC(), event.Referrer, event.UserAgent, event.IP)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetDailyClicks returns the redirects of alias recorded since the given
+// time, counted per UTC day. Days without redirects are left out.
+func (s *Storage) GetDailyClicks(alias string, since time.Time) ([]storage.DailyClicks, error) {
+	const op = "storage.sqlite.GetDailyClicks"
+
+	rows, err := s.db.Query(`
+	SELECT substr(ts, 1, 10) AS day, COUNT(*) FROM clicks
+	WHERE alias = ? AND ts >= ?
+	GROUP BY day ORDER BY day
+	`, alias, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	var days []storage.DailyClicks
+	for rows.Next() {
+		var (
+			d   storage.DailyClicks
+			day string
+		)
+		if err := rows.Scan(&day, &d.Clicks); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+		d.Day, err = time.Parse(time.DateOnly, day)
+		if err != nil {
+			return nil, fmt.Errorf("%s: parse day: %w", op, err)
+		}
+		days = append(days, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return days, nil
+}
+
+// AliasLength returns the stored length of generated aliases, 0 if none
+// was stored yet.
+func (s *Storage) AliasLength(ctx context.Context) (int, error) {
+	const op = "storage.sqlite.AliasLength"
+
+	var length int
+	err := s.db.QueryRowContext(ctx, "SELECT CAST(value AS INTEGER) FROM settings WHERE key = 'alias_length'").Scan(&length)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return length, nil
+}
+
+// SetAliasLength stores the length of generated aliases.
+func (s *Storage) SetAliasLength(ctx context.Context, length int) error {
+	const op = "storage.sqlite.SetAliasLength"
+
+	_, err := s.db.ExecContext(ctx, `
+	INSERT INTO settings(key, value) VALUES('alias_length', ?)
+	ON CONFLICT (key) DO UPDATE SET value = excluded.value
+	`, strconv.Itoa(length))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SaveAPIKey stores the hash of a new API key.
+func (s *Storage) SaveAPIKey(key string) error {
+	const op = "storage.sqlite.SaveAPIKey"
+
+	_, err := s.db.Exec("INSERT INTO api_keys(key_hash) VALUES(?)", apikey.Hash(key))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ValidateAPIKey reports whether key was saved with SaveAPIKey.
+func (s *Storage) ValidateAPIKey(key string) (bool, error) {
+	const op = "storage.sqlite.ValidateAPIKey"
+
+	var found bool
+	err := s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM api_keys WHERE key_hash = ?)", apikey.Hash(key)).Scan(&found)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return found, nil
+}
+
+func (s *Storage) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Migrate brings the schema at storagePath up to date and disconnects.
+// The SQLite schema is not versioned, so the version is always 0; it is
+// returned for parity with postgres.Migrate.
+func Migrate(storagePath string) (version uint, dirty bool, err error) {
+	const op = "storage.sqlite.Migrate"
+
+	// New applies the schema
+	s, err := New(storagePath)
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.Close(); err != nil {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return 0, false, nil
+}
+
+func (s *Storage) Close() error {
+	return s.db.Close()
+}
+
+// urlColumns are the columns scanned by scanURL.
+const urlColumns = "id, alias, url, last_accessed_at, sponsored, created_at, password_hash, content_hash, audited, expires_at, allowed_referrers, clicks, creator_ip, creator_user_agent, creator_identity, external_id, redirect_status, variants, owner, folder_id"
+
+// scanURL scans a row selected as urlColumns.
+func scanURL(row interface{ Scan(dest ...any) error }) (storage.URL, error) {
+	var (
+		res            storage.URL
+		lastAccessedAt sql.NullTime
+		passwordHash   sql.NullString
+		contentHash    sql.NullString
+		expiresAt      sql.NullTime
+		referrers      sql.NullString
+		creatorIP      sql.NullString
+		creatorAgent   sql.NullString
+		creatorID      sql.NullString
+		externalID     sql.NullString
+		redirectStatus sql.NullInt64
+		variants       sql.NullString
+		owner          sql.NullString
+		folderID       sql.NullInt64
+	)
+
+	if err := row.Scan(&res.ID, &res.Alias, &res.URL, &lastAccessedAt, &res.Sponsored, &res.CreatedAt, &passwordHash, &contentHash, &res.Audited, &expiresAt, &referrers, &res.Clicks, &creatorIP, &creatorAgent, &creatorID, &externalID, &redirectStatus, &variants, &owner, &folderID); err != nil {
+		return storage.URL{}, err
+	}
+
+	if lastAccessedAt.Valid {
+		res.LastAccessedAt = &lastAccessedAt.Time
+	}
+	res.PasswordHash = passwordHash.String
+	res.ContentHash = contentHash.String
+	if expiresAt.Valid {
+		res.ExpiresAt = &expiresAt.Time
+	}
+	if referrers.Valid {
+		if err := json.Unmarshal([]byte(referrers.String), &res.AllowedReferrers); err != nil {
+			return storage.URL{}, fmt.Errorf("decode allowed referrers: %w", err)
+		}
+	}
+	res.Creator = storage.Creator{
+		IP:        creatorIP.String,
+		UserAgent: creatorAgent.String,
+		Identity:  creatorID.String,
+	}
+	res.ExternalID = externalID.String
+	res.RedirectStatus = int(redirectStatus.Int64)
+	if variants.Valid {
+		if err := json.Unmarshal([]byte(variants.String), &res.Variants); err != nil {
+			return storage.URL{}, fmt.Errorf("decode variants: %w", err)
+		}
+	}
+	res.Owner = owner.String
+	res.FolderID = folderID.Int64
+
+	return res, nil
+}
+
+// variantsJSON encodes the variants of a split link, NULL if it has none.
+func variantsJSON(variants []storage.Variant) (sql.NullString, error) {
+	if len(variants) == 0 {
+		return sql.NullString{}, nil
+	}
+
+	b, err := json.Marshal(variants)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
+// contextErr returns the error of ctx once it is done, which the driver
+// reports as an interrupted statement instead.
+func contextErr(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+
+	return err
+}
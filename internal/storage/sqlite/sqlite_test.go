@@ -0,0 +1,683 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/storage"
+)
+
+func newTestStorage(t *testing.T) *Storage {
+	t.Helper()
+
+	s, err := New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+
+	return s
+}
+
+func TestStorage_SaveURL(t *testing.T) {
+	s := newTestStorage(t)
+
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Microsecond)
+
+	id, err := s.SaveURL("https://example.com", "example", storage.SaveOptions{
+		Sponsored:        true,
+		ContentHash:      "abc",
+		ExpiresAt:        &expiresAt,
+		AllowedReferrers: []string{"example.org"},
+	})
+	require.NoError(t, err)
+
+	_, err = s.SaveURL("https://example.org", "example", storage.SaveOptions{})
+	require.ErrorIs(t, err, storage.ErrURLExists)
+
+	got, err := s.GetURL("example")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", got)
+
+	info, err := s.GetURLByID(id)
+	require.NoError(t, err)
+	assert.Equal(t, "example", info.Alias)
+	assert.True(t, info.Sponsored)
+	assert.Equal(t, "abc", info.ContentHash)
+	assert.Empty(t, info.PasswordHash)
+	require.NotNil(t, info.ExpiresAt)
+	assert.True(t, expiresAt.Equal(*info.ExpiresAt))
+	assert.Equal(t, []string{"example.org"}, info.AllowedReferrers)
+	assert.WithinDuration(t, time.Now(), info.CreatedAt, time.Minute)
+	assert.Zero(t, info.Creator)
+
+	_, err = s.GetURLInfo("missing")
+	require.ErrorIs(t, err, storage.ErrURLNotFound)
+}
+
+func TestStorage_ClaimURL(t *testing.T) {
+	s := newTestStorage(t)
+
+	created, existing, err := s.ClaimURL("https://example.com", "example", storage.SaveOptions{})
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Empty(t, existing)
+
+	created, existing, err = s.ClaimURL("https://example.org", "example", storage.SaveOptions{})
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, "https://example.com", existing)
+
+	_, err = s.SaveURL("https://example.com", "first", storage.SaveOptions{ExternalID: "ext"})
+	require.NoError(t, err)
+	_, err = s.ClaimURLContext(context.Background(), "https://example.com", "second", storage.SaveOptions{ExternalID: "ext"})
+	require.ErrorIs(t, err, storage.ErrExternalIDExists)
+}
+
+func TestStorage_ClaimURLConcurrent(t *testing.T) {
+	s := newTestStorage(t)
+
+	const n = 8
+
+	claims := make([]storage.Claim, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			var err error
+			claims[i], err = s.ClaimURLContext(context.Background(), fmt.Sprintf("https://example.com/%d", i), "raced", storage.SaveOptions{})
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	got, err := s.GetURL("raced")
+	require.NoError(t, err)
+
+	// exactly one claim wins, the others see its URL
+	var won int
+	for _, claim := range claims {
+		if claim.Created {
+			won++
+			continue
+		}
+		assert.Equal(t, got, claim.URL)
+	}
+	assert.Equal(t, 1, won)
+}
+
+func TestStorage_Creator(t *testing.T) {
+	s := newTestStorage(t)
+
+	creator := storage.Creator{IP: "203.0.113.7", UserAgent: "curl/8.0", Identity: "admin"}
+
+	_, err := s.SaveURL("https://example.com", "example", storage.SaveOptions{Creator: creator})
+	require.NoError(t, err)
+
+	info, err := s.GetURLInfo("example")
+	require.NoError(t, err)
+	assert.Equal(t, creator, info.Creator)
+}
+
+func TestStorage_ExternalID(t *testing.T) {
+	s := newTestStorage(t)
+
+	_, err := s.SaveURL("https://example.com", "example", storage.SaveOptions{ExternalID: "order-42"})
+	require.NoError(t, err)
+	// links without one don't conflict with each other
+	_, err = s.SaveURL("https://example.org", "first", storage.SaveOptions{})
+	require.NoError(t, err)
+	_, err = s.SaveURL("https://example.org", "second", storage.SaveOptions{})
+	require.NoError(t, err)
+
+	_, err = s.SaveURL("https://example.net", "other", storage.SaveOptions{ExternalID: "order-42"})
+	require.ErrorIs(t, err, storage.ErrExternalIDExists)
+	_, err = s.SaveURL("https://example.net", "example", storage.SaveOptions{})
+	require.ErrorIs(t, err, storage.ErrURLExists)
+
+	info, err := s.GetURLByExternalID("order-42")
+	require.NoError(t, err)
+	assert.Equal(t, "example", info.Alias)
+	assert.Equal(t, "order-42", info.ExternalID)
+
+	_, err = s.GetURLByExternalID("missing")
+	require.ErrorIs(t, err, storage.ErrURLNotFound)
+}
+
+func TestStorage_RedirectStatus(t *testing.T) {
+	s := newTestStorage(t)
+
+	_, err := s.SaveURL("https://example.com", "permanent", storage.SaveOptions{RedirectStatus: 301})
+	require.NoError(t, err)
+	_, err = s.SaveURL("https://example.com", "default", storage.SaveOptions{})
+	require.NoError(t, err)
+
+	info, err := s.GetURLInfo("permanent")
+	require.NoError(t, err)
+	assert.Equal(t, 301, info.RedirectStatus)
+
+	info, err = s.GetURLInfo("default")
+	require.NoError(t, err)
+	assert.Zero(t, info.RedirectStatus)
+}
+
+func TestStorage_Variants(t *testing.T) {
+	s := newTestStorage(t)
+
+	variants := []storage.Variant{
+		{URL: "https://a.example.com", Weight: 1},
+		{URL: "https://b.example.com", Weight: 3},
+	}
+
+	_, err := s.SaveURL("https://example.com", "split", storage.SaveOptions{Variants: variants})
+	require.NoError(t, err)
+	_, err = s.SaveURL("https://example.com", "plain", storage.SaveOptions{})
+	require.NoError(t, err)
+
+	info, err := s.GetURLInfo("split")
+	require.NoError(t, err)
+	assert.Equal(t, variants, info.Variants)
+
+	info, err = s.GetURLInfo("plain")
+	require.NoError(t, err)
+	assert.Empty(t, info.Variants)
+
+	require.NoError(t, s.IncrementVariantClicks("split", 1))
+	require.NoError(t, s.IncrementVariantClicks("split", 1))
+	require.NoError(t, s.IncrementVariantClicks("split", 0))
+
+	clicks, err := s.GetVariantClicks("split")
+	require.NoError(t, err)
+	assert.Equal(t, map[int]int64{0: 1, 1: 2}, clicks)
+
+	clicks, err = s.GetVariantClicks("plain")
+	require.NoError(t, err)
+	assert.Empty(t, clicks)
+}
+
+func TestStorage_GetURLsByTarget(t *testing.T) {
+	s := newTestStorage(t)
+
+	for _, link := range []struct{ alias, url string }{
+		{"first", "https://example.com"},
+		{"other", "https://example.org"},
+		{"second", "https://example.com"},
+		{"third", "https://example.com"},
+	} {
+		_, err := s.SaveURL(link.url, link.alias, storage.SaveOptions{})
+		require.NoError(t, err)
+	}
+
+	got, err := s.GetURLsByTarget(context.Background(), "https://example.com", 2)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "first", got[0].Alias)
+	assert.Equal(t, "second", got[1].Alias)
+
+	got, err = s.GetURLsByTarget(context.Background(), "https://missing.example.com", 2)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestStorage_Owner(t *testing.T) {
+	s := newTestStorage(t)
+
+	_, err := s.SaveURL("https://example.com", "owned", storage.SaveOptions{Owner: "jane@example.com"})
+	require.NoError(t, err)
+	_, err = s.SaveURLBatch([]storage.URLItem{{URL: "https://example.com", Alias: "batched", Owner: "team@example.com"}})
+	require.NoError(t, err)
+	_, err = s.SaveURL("https://example.com", "unowned", storage.SaveOptions{})
+	require.NoError(t, err)
+
+	info, err := s.GetURLInfo("owned")
+	require.NoError(t, err)
+	assert.Equal(t, "jane@example.com", info.Owner)
+
+	info, err = s.GetURLInfo("batched")
+	require.NoError(t, err)
+	assert.Equal(t, "team@example.com", info.Owner)
+
+	info, err = s.GetURLInfo("unowned")
+	require.NoError(t, err)
+	assert.Empty(t, info.Owner)
+}
+
+func TestStorage_SaveURLBatch(t *testing.T) {
+	s := newTestStorage(t)
+
+	_, err := s.SaveURL("https://taken.example.com", "taken", storage.SaveOptions{})
+	require.NoError(t, err)
+
+	results, err := s.SaveURLBatch([]storage.URLItem{
+		{URL: "https://a.example.com", Alias: "first"},
+		{URL: "https://b.example.com", Alias: "taken"},
+		{URL: "https://c.example.com", Alias: "first"},
+		{URL: "https://d.example.com", Alias: "second"},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	// conflicts don't abort the rest of the batch
+	assert.NoError(t, results[0].Err)
+	assert.ErrorIs(t, results[1].Err, storage.ErrURLExists)
+	assert.ErrorIs(t, results[2].Err, storage.ErrURLExists)
+	assert.NoError(t, results[3].Err)
+	assert.NotZero(t, results[3].ID)
+
+	got, err := s.GetURL("taken")
+	require.NoError(t, err)
+	assert.Equal(t, "https://taken.example.com", got)
+
+	got, err = s.GetURL("second")
+	require.NoError(t, err)
+	assert.Equal(t, "https://d.example.com", got)
+}
+
+func TestStorage_GetURLExpired(t *testing.T) {
+	s := newTestStorage(t)
+
+	expiresAt := time.Now().Add(-time.Minute)
+
+	_, err := s.SaveURL("https://example.com", "gone", storage.SaveOptions{ExpiresAt: &expiresAt})
+	require.NoError(t, err)
+
+	_, err = s.GetURL("gone")
+	require.ErrorIs(t, err, storage.ErrURLExpired)
+}
+
+func TestStorage_Clicks(t *testing.T) {
+	s := newTestStorage(t)
+
+	for _, alias := range []string{"first", "second"} {
+		_, err := s.SaveURL("https://example.com/"+alias, alias, storage.SaveOptions{})
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, s.IncrementClicks("first"))
+	require.NoError(t, s.BulkIncrementClicks(map[string]int64{"first": 4, "second": 2, "missing": 1}))
+
+	first, err := s.GetURLInfo("first")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), first.Clicks)
+
+	second, err := s.GetURLInfo("second")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), second.Clicks)
+}
+
+func TestStorage_SearchAliasesByPrefix(t *testing.T) {
+	s := newTestStorage(t)
+
+	for _, alias := range []string{"abc", "abd", "ABe", "a%c", "xyz"} {
+		_, err := s.SaveURL("https://example.com", alias, storage.SaveOptions{})
+		require.NoError(t, err)
+	}
+
+	got, err := s.SearchAliasesByPrefix("ab", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"abc", "abd"}, got)
+
+	got, err = s.SearchAliasesByPrefix("a%", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a%c"}, got)
+
+	got, err = s.SearchAliasesByPrefix("ab", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"abc"}, got)
+}
+
+func TestStorage_GroupByContentHash(t *testing.T) {
+	s := newTestStorage(t)
+
+	for alias, hash := range map[string]string{"b": "same", "a": "same", "c": "other"} {
+		_, err := s.SaveURL("https://example.com/"+alias, alias, storage.SaveOptions{ContentHash: hash})
+		require.NoError(t, err)
+	}
+
+	groups, err := s.GroupByContentHash(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []storage.ContentGroup{{Hash: "same", Aliases: []string{"a", "b"}}}, groups)
+}
+
+func TestStorage_AliasLength(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	length, err := s.AliasLength(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, length)
+
+	require.NoError(t, s.SetAliasLength(ctx, 7))
+	require.NoError(t, s.SetAliasLength(ctx, 8))
+
+	length, err = s.AliasLength(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 8, length)
+}
+
+func TestStorage_APIKeys(t *testing.T) {
+	s := newTestStorage(t)
+
+	require.NoError(t, s.SaveAPIKey("s3cret"))
+
+	ok, err := s.ValidateAPIKey("s3cret")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = s.ValidateAPIKey("guess")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// only the hash is stored
+	var stored string
+	require.NoError(t, s.db.QueryRow("SELECT key_hash FROM api_keys").Scan(&stored))
+	assert.NotEqual(t, "s3cret", stored)
+}
+
+func TestStorage_UpdateURL(t *testing.T) {
+	s := newTestStorage(t)
+
+	_, err := s.SaveURL("https://example.com", "example", storage.SaveOptions{})
+	require.NoError(t, err)
+
+	require.NoError(t, s.UpdateURL("example", "https://example.org"))
+
+	got, err := s.GetURL("example")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.org", got)
+
+	require.ErrorIs(t, s.UpdateURL("missing", "https://example.org"), storage.ErrURLNotFound)
+}
+
+func TestStorage_DeleteURL(t *testing.T) {
+	s := newTestStorage(t)
+
+	_, err := s.SaveURL("https://example.com", "example", storage.SaveOptions{})
+	require.NoError(t, err)
+
+	require.NoError(t, s.DeleteURL("example"))
+	require.ErrorIs(t, s.DeleteURL("example"), storage.ErrURLNotFound)
+}
+
+func TestStorage_DeleteURLClicks(t *testing.T) {
+	s := newTestStorage(t)
+
+	since := time.Now().Add(-time.Hour)
+
+	_, err := s.SaveURL("https://example.com", "example", storage.SaveOptions{})
+	require.NoError(t, err)
+	require.NoError(t, s.RecordClick(storage.ClickEvent{Alias: "example", Time: time.Now()}))
+	require.NoError(t, s.IncrementVariantClicks("example", 0))
+
+	require.NoError(t, s.DeleteURL("example"))
+
+	// a new link under the alias starts without the old one's clicks
+	_, err = s.SaveURL("https://example.org", "example", storage.SaveOptions{})
+	require.NoError(t, err)
+
+	days, err := s.GetDailyClicks("example", since)
+	require.NoError(t, err)
+	assert.Empty(t, days)
+
+	variants, err := s.GetVariantClicks("example")
+	require.NoError(t, err)
+	assert.Empty(t, variants)
+}
+
+func TestStorage_Context(t *testing.T) {
+	s := newTestStorage(t)
+
+	_, err := s.SaveURLContext(context.Background(), "https://example.com", "example", storage.SaveOptions{})
+	require.NoError(t, err)
+
+	got, err := s.GetURLContext(context.Background(), "example")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", got)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = s.GetURLInfoContext(ctx, "example")
+	require.ErrorIs(t, err, context.Canceled)
+	assert.True(t, storage.Unavailable(err))
+
+	_, err = s.SaveURLContext(ctx, "https://example.org", "other", storage.SaveOptions{})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestStorage_GetURLsInfo(t *testing.T) {
+	s := newTestStorage(t)
+
+	for _, alias := range []string{"a", "b"} {
+		_, err := s.SaveURL("https://example.com/"+alias, alias, storage.SaveOptions{})
+		require.NoError(t, err)
+	}
+	require.NoError(t, s.IncrementClicks("b"))
+
+	urls, err := s.GetURLsInfo([]string{"b", "missing", "a"})
+	require.NoError(t, err)
+	require.Len(t, urls, 2)
+
+	clicks := make(map[string]int64)
+	for _, u := range urls {
+		clicks[u.Alias] = u.Clicks
+	}
+	assert.Equal(t, map[string]int64{"a": 0, "b": 1}, clicks)
+
+	urls, err = s.GetURLsInfo([]string{"missing"})
+	require.NoError(t, err)
+	assert.Empty(t, urls)
+}
+
+func TestStorage_ListURLs(t *testing.T) {
+	s := newTestStorage(t)
+
+	for _, alias := range []string{"a", "b", "c"} {
+		_, err := s.SaveURL("https://example.com/"+alias, alias, storage.SaveOptions{})
+		require.NoError(t, err)
+	}
+	require.NoError(t, s.IncrementClicks("b"))
+
+	urls, err := s.ListURLs(2, 1)
+	require.NoError(t, err)
+	require.Len(t, urls, 2)
+	assert.Equal(t, "b", urls[0].Alias)
+	assert.Equal(t, "https://example.com/b", urls[0].URL)
+	assert.Equal(t, int64(1), urls[0].Clicks)
+	assert.Equal(t, "c", urls[1].Alias)
+
+	urls, err = s.ListURLs(2, 3)
+	require.NoError(t, err)
+	assert.Empty(t, urls)
+
+	total, err := s.CountURLs()
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+}
+
+func TestStorage_EstimateURLs(t *testing.T) {
+	s := newTestStorage(t)
+
+	n, err := s.EstimateURLs()
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	for _, alias := range []string{"a", "b", "c"} {
+		_, err := s.SaveURL("https://example.com/"+alias, alias, storage.SaveOptions{})
+		require.NoError(t, err)
+	}
+
+	n, err = s.EstimateURLs()
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	// the estimate doesn't notice deleted links, the exact count does
+	require.NoError(t, s.DeleteURL("b"))
+
+	n, err = s.EstimateURLs()
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	total, err := s.CountURLs()
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+}
+
+func TestStorage_DailyClicks(t *testing.T) {
+	s := newTestStorage(t)
+
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	events := []storage.ClickEvent{
+		{Alias: "example", Time: day.Add(-time.Hour)},
+		{Alias: "example", Time: day.Add(time.Hour), Referrer: "https://example.org/", UserAgent: "curl/8.0", IP: "203.0.113.7"},
+		// local times are counted on their UTC day
+		{Alias: "example", Time: day.Add(23 * time.Hour).In(time.FixedZone("UTC+3", 3*60*60))},
+		{Alias: "example", Time: day.Add(25 * time.Hour)},
+		{Alias: "other", Time: day.Add(time.Hour)},
+	}
+	for _, event := range events {
+		require.NoError(t, s.RecordClick(event))
+	}
+
+	days, err := s.GetDailyClicks("example", day)
+	require.NoError(t, err)
+	assert.Equal(t, []storage.DailyClicks{
+		{Day: day, Clicks: 2},
+		{Day: day.AddDate(0, 0, 1), Clicks: 1},
+	}, days)
+
+	days, err = s.GetDailyClicks("missing", day)
+	require.NoError(t, err)
+	assert.Empty(t, days)
+}
+
+func TestStorage_Folders(t *testing.T) {
+	s := newTestStorage(t)
+
+	marketing, err := s.CreateFolder("marketing", 0)
+	require.NoError(t, err)
+	campaigns, err := s.CreateFolder("campaigns", marketing)
+	require.NoError(t, err)
+
+	// names are unique per parent
+	_, err = s.CreateFolder("campaigns", marketing)
+	assert.ErrorIs(t, err, storage.ErrFolderExists)
+	_, err = s.CreateFolder("campaigns", 0)
+	require.NoError(t, err)
+	_, err = s.CreateFolder("orphan", 999)
+	assert.ErrorIs(t, err, storage.ErrFolderNotFound)
+
+	require.NoError(t, s.RenameFolder(campaigns, "spring"))
+	assert.ErrorIs(t, s.RenameFolder(999, "missing"), storage.ErrFolderNotFound)
+
+	folder, err := s.GetFolder(campaigns)
+	require.NoError(t, err)
+	assert.Equal(t, "spring", folder.Name)
+	assert.Equal(t, marketing, folder.ParentID)
+
+	folders, err := s.ListFolders()
+	require.NoError(t, err)
+	require.Len(t, folders, 3)
+	assert.Zero(t, folders[0].ParentID)
+
+	_, err = s.SaveURL("https://example.com/sale", "sale", storage.SaveOptions{FolderID: campaigns})
+	require.NoError(t, err)
+	_, err = s.SaveURL("https://example.com", "loose", storage.SaveOptions{})
+	require.NoError(t, err)
+
+	info, err := s.GetURLInfo("sale")
+	require.NoError(t, err)
+	assert.Equal(t, campaigns, info.FolderID)
+
+	links, err := s.ListURLsInFolder(campaigns, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, links, 1)
+	assert.Equal(t, "sale", links[0].Alias)
+	assert.Equal(t, campaigns, links[0].FolderID)
+
+	n, err := s.CountURLsInFolder(campaigns)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	// links of nested folders are not listed with their parent
+	n, err = s.CountURLsInFolder(marketing)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestStorage_DeleteFolder(t *testing.T) {
+	tests := []struct {
+		name    string
+		cascade bool
+	}{
+		{name: "cascade", cascade: true},
+		{name: "orphan"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStorage(t)
+
+			parent, err := s.CreateFolder("parent", 0)
+			require.NoError(t, err)
+			child, err := s.CreateFolder("child", parent)
+			require.NoError(t, err)
+			other, err := s.CreateFolder("other", 0)
+			require.NoError(t, err)
+
+			for alias, folderID := range map[string]int64{"top": parent, "nested": child, "kept": other} {
+				_, err := s.SaveURL("https://example.com/"+alias, alias, storage.SaveOptions{FolderID: folderID})
+				require.NoError(t, err)
+			}
+
+			since := time.Now().Add(-time.Hour)
+			require.NoError(t, s.RecordClick(storage.ClickEvent{Alias: "nested", Time: time.Now()}))
+
+			deleted, err := s.DeleteFolder(parent, tt.cascade)
+			require.NoError(t, err)
+
+			// nested folders go with their parent
+			_, err = s.GetFolder(child)
+			assert.ErrorIs(t, err, storage.ErrFolderNotFound)
+
+			for _, alias := range []string{"top", "nested"} {
+				info, err := s.GetURLInfo(alias)
+				if tt.cascade {
+					assert.ErrorIs(t, err, storage.ErrURLNotFound, alias)
+				} else {
+					require.NoError(t, err)
+					assert.Zero(t, info.FolderID, alias)
+				}
+			}
+			if tt.cascade {
+				assert.ElementsMatch(t, []string{"top", "nested"}, deleted)
+			} else {
+				assert.Empty(t, deleted)
+			}
+
+			// only the clicks of deleted links go with them
+			days, err := s.GetDailyClicks("nested", since)
+			require.NoError(t, err)
+			if tt.cascade {
+				assert.Empty(t, days)
+			} else {
+				assert.Len(t, days, 1)
+			}
+
+			// other folders are left alone
+			info, err := s.GetURLInfo("kept")
+			require.NoError(t, err)
+			assert.Equal(t, other, info.FolderID)
+
+			_, err = s.DeleteFolder(parent, tt.cascade)
+			assert.ErrorIs(t, err, storage.ErrFolderNotFound)
+		})
+	}
+}
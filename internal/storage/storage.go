@@ -1,8 +1,224 @@
 package storage
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"time"
+)
 
 var (
 	ErrURLNotFound = errors.New("url not found")
 	ErrURLExists   = errors.New("url exists")
+	ErrURLExpired  = errors.New("url expired")
+	// ErrExternalIDExists is returned when the external id of a new link
+	// is already used by another.
+	ErrExternalIDExists = errors.New("external id exists")
+	ErrFolderNotFound   = errors.New("folder not found")
+	// ErrFolderExists is returned when a folder is named like another
+	// folder of the same parent.
+	ErrFolderExists = errors.New("folder exists")
 )
+
+// WithTimeout bounds the queries made with the returned context by
+// timeout. ctx is returned as is when timeout is not positive.
+func WithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
+
+// Unavailable reports whether err is a query given up on because its
+// context was cancelled or timed out.
+func Unavailable(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)
+}
+
+// URL is a stored short link.
+type URL struct {
+	ID             int64
+	Alias          string
+	URL            string
+	LastAccessedAt *time.Time
+	Sponsored      bool
+	CreatedAt      time.Time
+	// PasswordHash is the bcrypt hash protecting the link, if any.
+	PasswordHash string
+	// ContentHash fingerprints the target body at save time, if it was fetched.
+	ContentHash string
+	// Audited links report every redirect to the audit webhook.
+	Audited bool
+	// ExpiresAt is when the link stops resolving, nil if it never does.
+	ExpiresAt *time.Time
+	// AllowedReferrers are the Referer domains the link may be used from,
+	// any referrer may use it when empty.
+	AllowedReferrers []string
+	// Clicks is the number of redirects served for the link.
+	Clicks int64
+	// Creator is who saved the link, zero if it wasn't recorded.
+	Creator Creator
+	// ExternalID is the reference the creator saved the link under,
+	// scoped as stored. Empty if it has none.
+	ExternalID string
+	// RedirectStatus is the status code the link redirects with, the
+	// configured default when zero.
+	RedirectStatus int
+	// Variants are the weighted targets the redirects of a split link are
+	// divided between. Links redirect to URL when it is empty.
+	Variants []Variant
+	// Owner is the email of who is accountable for the link, if any.
+	Owner string
+	// FolderID is the folder the link is filed in, zero if none.
+	FolderID int64
+}
+
+// Folder organizes links. Folders nest, top-level ones have no parent.
+type Folder struct {
+	ID   int64
+	Name string
+	// ParentID is the folder the folder is nested in, zero if none.
+	ParentID  int64
+	CreatedAt time.Time
+}
+
+// Variant is one of the weighted targets of a split link.
+type Variant struct {
+	URL string `json:"url"`
+	// Weight is the share of redirects sent to URL, relative to the
+	// weights of the other variants.
+	Weight int `json:"weight"`
+}
+
+// Creator identifies the client that saved a link, for investigating
+// abuse.
+type Creator struct {
+	IP        string
+	UserAgent string
+	// Identity is "admin" for links saved with the admin credentials, or
+	// "key:" followed by the hash of the API key they were saved with.
+	Identity string
+}
+
+// Expired reports whether the link has expired at now.
+func (u URL) Expired(now time.Time) bool {
+	return u.ExpiresAt != nil && !now.Before(*u.ExpiresAt)
+}
+
+// SaveOptions holds the optional attributes of a new link.
+type SaveOptions struct {
+	// Sponsored links are shown behind an ad interstitial.
+	Sponsored bool
+	// PasswordHash protects the link with a bcrypt-hashed password.
+	PasswordHash string
+	// ContentHash fingerprints the target body.
+	ContentHash string
+	// Audited links report every redirect to the audit webhook.
+	Audited bool
+	// ExpiresAt is when the link stops resolving, nil if it never does.
+	ExpiresAt *time.Time
+	// AllowedReferrers restricts the Referer domains the link may be used from.
+	AllowedReferrers []string
+	// Creator is who saved the link, nothing is recorded when zero.
+	Creator Creator
+	// ExternalID is a reference of the creator to look the link up by,
+	// unique among links. None is stored when empty.
+	ExternalID string
+	// RedirectStatus overrides the configured redirect status code of the
+	// link, e.g. 301 for a permanent one. None is stored when zero.
+	RedirectStatus int
+	// Variants split the redirects of the link between weighted targets.
+	Variants []Variant
+	// Owner is the email of who is accountable for the link.
+	Owner string
+	// FolderID files the link in a folder, none when zero.
+	FolderID int64
+}
+
+// URLItem is a link of a batch save.
+type URLItem struct {
+	URL   string
+	Alias string
+	Owner string
+}
+
+// SaveResult is the outcome of saving one URLItem: the id of the new
+// link, or ErrURLExists if its alias was taken.
+type SaveResult struct {
+	ID  int64
+	Err error
+}
+
+// Claim is the outcome of claiming an alias: the id of the new link when
+// Created, or else the URL the taken alias points to.
+type Claim struct {
+	Created bool
+	ID      int64
+	URL     string
+}
+
+// Storage is a link store, implemented by the postgres and sqlite packages.
+type Storage interface {
+	SaveURL(urlToSave string, alias string, opts SaveOptions) (int64, error)
+	SaveURLContext(ctx context.Context, urlToSave string, alias string, opts SaveOptions) (int64, error)
+	ClaimURL(urlToSave string, alias string, opts SaveOptions) (bool, string, error)
+	ClaimURLContext(ctx context.Context, urlToSave string, alias string, opts SaveOptions) (Claim, error)
+	SaveURLBatch(items []URLItem) ([]SaveResult, error)
+	GetURL(alias string) (string, error)
+	GetURLContext(ctx context.Context, alias string) (string, error)
+	GetURLInfo(alias string) (URL, error)
+	GetURLInfoContext(ctx context.Context, alias string) (URL, error)
+	GetURLsInfo(aliases []string) ([]URL, error)
+	GetURLByID(id int64) (URL, error)
+	GetURLByExternalID(externalID string) (URL, error)
+	GetURLsByTarget(ctx context.Context, target string, limit int) ([]URL, error)
+	ExportURLs(ctx context.Context, fn func(URL) error) error
+	GroupByContentHash(ctx context.Context) ([]ContentGroup, error)
+	SearchAliasesByPrefix(prefix string, limit int) ([]string, error)
+	ListURLs(limit, offset int) ([]URL, error)
+	CountURLs() (int, error)
+	ListURLsInFolder(folderID int64, limit, offset int) ([]URL, error)
+	CountURLsInFolder(folderID int64) (int, error)
+	EstimateURLs() (int, error)
+	UpdateURL(alias, newURL string) error
+	DeleteURL(alias string) error
+	CreateFolder(name string, parentID int64) (int64, error)
+	GetFolder(id int64) (Folder, error)
+	ListFolders() ([]Folder, error)
+	RenameFolder(id int64, name string) error
+	DeleteFolder(id int64, cascade bool) ([]string, error)
+	TouchURL(alias string, at time.Time) error
+	IncrementClicks(alias string) error
+	BulkIncrementClicks(counts map[string]int64) error
+	IncrementVariantClicks(alias string, variant int) error
+	GetVariantClicks(alias string) (map[int]int64, error)
+	RecordClick(event ClickEvent) error
+	GetDailyClicks(alias string, since time.Time) ([]DailyClicks, error)
+	AliasLength(ctx context.Context) (int, error)
+	SetAliasLength(ctx context.Context, length int) error
+	SaveAPIKey(key string) error
+	ValidateAPIKey(key string) (bool, error)
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// ContentGroup is a set of links whose targets served the same content.
+type ContentGroup struct {
+	Hash    string
+	Aliases []string
+}
+
+// ClickEvent is a single redirect, recorded for analytics.
+type ClickEvent struct {
+	Alias     string
+	Time      time.Time
+	Referrer  string
+	UserAgent string
+	IP        string
+}
+
+// DailyClicks is the number of redirects of an alias on a UTC day.
+type DailyClicks struct {
+	Day    time.Time
+	Clicks int64
+}
@@ -0,0 +1,115 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/retry"
+)
+
+var ErrBadStatus = errors.New("webhook returned a non-2xx status")
+
+// Options configures a Sender.
+type Options struct {
+	// QueueSize is the number of events buffered for delivery. Events
+	// sent while the queue is full are dropped.
+	QueueSize int
+	// Retry bounds the delivery attempts of a single event.
+	Retry retry.Options
+}
+
+// Sender delivers events to a webhook as JSON POST requests in the
+// background, retrying failed deliveries.
+type Sender struct {
+	log    *slog.Logger
+	url    string
+	client *http.Client
+	retry  retry.Options
+
+	queue chan any
+	wg    sync.WaitGroup
+}
+
+// New creates a Sender posting to url and starts its delivery worker.
+func New(log *slog.Logger, url string, client *http.Client, opts Options) *Sender {
+	s := &Sender{
+		log: log.With(
+			slog.String("component", "webhook"),
+		),
+		url:    url,
+		client: client,
+		retry:  opts.Retry,
+		queue:  make(chan any, opts.QueueSize),
+	}
+
+	s.wg.Add(1)
+	go s.run()
+
+	return s
+}
+
+// Send queues event for delivery without blocking. It reports whether
+// the event was queued.
+func (s *Sender) Send(event any) bool {
+	select {
+	case s.queue <- event:
+		return true
+	default:
+		s.log.Warn("webhook queue is full, dropping event")
+		return false
+	}
+}
+
+// Close stops accepting events and waits until the queued ones are
+// delivered or given up on. Send must not be called after Close.
+func (s *Sender) Close() {
+	close(s.queue)
+	s.wg.Wait()
+}
+
+func (s *Sender) run() {
+	defer s.wg.Done()
+
+	for event := range s.queue {
+		_, err := retry.Do(context.Background(), s.log, s.retry, func() (struct{}, error) {
+			return struct{}{}, s.deliver(event)
+		})
+		if err != nil {
+			s.log.Error("failed to deliver webhook", sl.Err(err))
+		}
+	}
+}
+
+func (s *Sender) deliver(event any) error {
+	const op = "webhook.Sender.deliver"
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s: %w: %d", op, ErrBadStatus, resp.StatusCode)
+	}
+
+	return nil
+}
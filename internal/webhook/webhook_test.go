@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/lib/retry"
+)
+
+type event struct {
+	Alias string `json:"alias"`
+}
+
+func TestSender(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		attempts int
+		received []event
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		attempts++
+		// the first delivery fails and must be retried
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var e event
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&e))
+		received = append(received, e)
+	}))
+	defer srv.Close()
+
+	s := New(slogdiscard.NewDiscardLogger(), srv.URL, srv.Client(), Options{
+		QueueSize: 10,
+		Retry:     retry.Options{MaxAttempts: 3, Backoff: time.Millisecond},
+	})
+
+	require.True(t, s.Send(event{Alias: "first"}))
+	require.True(t, s.Send(event{Alias: "second"}))
+
+	s.Close()
+
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, []event{{Alias: "first"}, {Alias: "second"}}, received)
+}
+
+func TestSender_GivesUp(t *testing.T) {
+	var attempts int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	s := New(slogdiscard.NewDiscardLogger(), srv.URL, srv.Client(), Options{
+		QueueSize: 1,
+		Retry:     retry.Options{MaxAttempts: 2, Backoff: time.Millisecond},
+	})
+
+	require.True(t, s.Send(event{Alias: "first"}))
+
+	s.Close()
+
+	assert.Equal(t, 2, attempts)
+}
@@ -0,0 +1,9 @@
+-- IF NOT EXISTS keeps databases created before migrations were introduced working
+CREATE TABLE IF NOT EXISTS url(
+	id SERIAL PRIMARY KEY,
+	alias TEXT NOT NULL UNIQUE,
+	url TEXT NOT NULL);
+
+CREATE INDEX IF NOT EXISTS idx_alias ON url(alias);
+-- text_pattern_ops serves prefix LIKE queries whatever the collation
+CREATE INDEX IF NOT EXISTS idx_alias_prefix ON url(alias text_pattern_ops);
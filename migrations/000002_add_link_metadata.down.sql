@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_content_hash;
+ALTER TABLE url
+	DROP COLUMN IF EXISTS last_accessed_at,
+	DROP COLUMN IF EXISTS sponsored,
+	DROP COLUMN IF EXISTS created_at,
+	DROP COLUMN IF EXISTS password_hash,
+	DROP COLUMN IF EXISTS content_hash,
+	DROP COLUMN IF EXISTS audited,
+	DROP COLUMN IF EXISTS expires_at,
+	DROP COLUMN IF EXISTS allowed_referrers,
+	DROP COLUMN IF EXISTS clicks;
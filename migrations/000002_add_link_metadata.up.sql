@@ -0,0 +1,10 @@
+ALTER TABLE url ADD COLUMN IF NOT EXISTS last_accessed_at TIMESTAMPTZ;
+ALTER TABLE url ADD COLUMN IF NOT EXISTS sponsored BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE url ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+ALTER TABLE url ADD COLUMN IF NOT EXISTS password_hash TEXT;
+ALTER TABLE url ADD COLUMN IF NOT EXISTS content_hash TEXT;
+CREATE INDEX IF NOT EXISTS idx_content_hash ON url(content_hash);
+ALTER TABLE url ADD COLUMN IF NOT EXISTS audited BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE url ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+ALTER TABLE url ADD COLUMN IF NOT EXISTS allowed_referrers TEXT[];
+ALTER TABLE url ADD COLUMN IF NOT EXISTS clicks BIGINT NOT NULL DEFAULT 0;
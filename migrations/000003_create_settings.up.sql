@@ -0,0 +1,3 @@
+CREATE TABLE IF NOT EXISTS settings(
+	key TEXT PRIMARY KEY,
+	value TEXT NOT NULL);
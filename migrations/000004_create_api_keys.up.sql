@@ -0,0 +1,3 @@
+CREATE TABLE IF NOT EXISTS api_keys(
+	key_hash TEXT PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW());
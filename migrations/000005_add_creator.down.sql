@@ -0,0 +1,4 @@
+ALTER TABLE url
+	DROP COLUMN IF EXISTS creator_ip,
+	DROP COLUMN IF EXISTS creator_user_agent,
+	DROP COLUMN IF EXISTS creator_identity;
@@ -0,0 +1,3 @@
+ALTER TABLE url ADD COLUMN IF NOT EXISTS creator_ip TEXT;
+ALTER TABLE url ADD COLUMN IF NOT EXISTS creator_user_agent TEXT;
+ALTER TABLE url ADD COLUMN IF NOT EXISTS creator_identity TEXT;
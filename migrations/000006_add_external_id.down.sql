@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS url_external_id_key;
+ALTER TABLE url DROP COLUMN IF EXISTS external_id;
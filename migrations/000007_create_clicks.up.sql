@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS clicks(
+	id BIGSERIAL PRIMARY KEY,
+	alias TEXT NOT NULL,
+	ts TIMESTAMPTZ NOT NULL,
+	referrer TEXT,
+	user_agent TEXT,
+	ip TEXT);
+
+CREATE INDEX IF NOT EXISTS idx_clicks_alias_ts ON clicks(alias, ts);
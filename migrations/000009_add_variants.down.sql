@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS variant_clicks;
+ALTER TABLE url DROP COLUMN IF EXISTS variants;
@@ -0,0 +1,7 @@
+ALTER TABLE url ADD COLUMN IF NOT EXISTS variants JSONB;
+
+CREATE TABLE IF NOT EXISTS variant_clicks(
+	alias TEXT NOT NULL,
+	variant INTEGER NOT NULL,
+	clicks BIGINT NOT NULL DEFAULT 0,
+	PRIMARY KEY (alias, variant));
@@ -0,0 +1,2 @@
+-- looked up to reuse the aliases of existing links to a target
+CREATE INDEX IF NOT EXISTS idx_url ON url(url);
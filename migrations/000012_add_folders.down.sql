@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_url_folder_id;
+ALTER TABLE url DROP COLUMN IF EXISTS folder_id;
+DROP TABLE IF EXISTS folders;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS folders(
+	id BIGSERIAL PRIMARY KEY,
+	name TEXT NOT NULL,
+	parent_id BIGINT REFERENCES folders(id),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW());
+
+-- names are unique among the folders of a parent, top-level ones included
+CREATE UNIQUE INDEX IF NOT EXISTS idx_folders_parent_name ON folders(COALESCE(parent_id, 0), name);
+
+ALTER TABLE url ADD COLUMN IF NOT EXISTS folder_id BIGINT REFERENCES folders(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_url_folder_id ON url(folder_id);
@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/cache"
+	"url-shortener/internal/lib/random"
+)
+
+func TestCache_DeleteMany(t *testing.T) {
+	c, err := cache.New("localhost:6379", "", 0)
+	require.NoError(t, err)
+	defer c.Close()
+
+	ctx := context.Background()
+
+	evicted := []string{random.NewRandomString(10), random.NewRandomString(10)}
+	kept := random.NewRandomString(10)
+
+	for _, key := range append(evicted, kept) {
+		require.NoError(t, c.Set(ctx, key, "https://example.com", time.Minute))
+	}
+
+	require.NoError(t, c.DeleteMany(ctx, evicted))
+
+	for _, key := range evicted {
+		_, err := c.Get(ctx, key)
+		require.ErrorIs(t, err, redis.Nil)
+	}
+
+	got, err := c.Get(ctx, kept)
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com", got)
+}
+
+func TestCache_Entry(t *testing.T) {
+	c, err := cache.New("localhost:6379", "", 0)
+	require.NoError(t, err)
+	defer c.Close()
+
+	ctx := context.Background()
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	entry := cache.Entry{URL: "https://example.com", Code: 301, ExpiresAt: &expiresAt, Enabled: true}
+
+	key := random.NewRandomString(10)
+	require.NoError(t, c.Set(ctx, key, entry, time.Minute))
+
+	got, err := c.GetEntry(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, entry, got)
+
+	// values cached as bare URLs still decode
+	legacy := random.NewRandomString(10)
+	require.NoError(t, c.Set(ctx, legacy, "https://example.com", time.Minute))
+
+	got, err = c.GetEntry(ctx, legacy)
+	require.NoError(t, err)
+	require.Equal(t, cache.Entry{URL: "https://example.com", Enabled: true}, got)
+}
+
+func TestCache_PFCount(t *testing.T) {
+	c, err := cache.New("localhost:6379", "", 0)
+	require.NoError(t, err)
+	defer c.Close()
+
+	ctx := context.Background()
+	key := cache.VisitorsKey(random.NewRandomString(10))
+
+	for _, visitor := range []string{"a", "b", "a", "c", "b", "a"} {
+		require.NoError(t, c.PFAdd(ctx, key, visitor))
+	}
+
+	count, err := c.PFCount(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), count)
+}
+
+func TestCache_PFCountEach(t *testing.T) {
+	c, err := cache.New("localhost:6379", "", 0)
+	require.NoError(t, err)
+	defer c.Close()
+
+	ctx := context.Background()
+	first := cache.VisitorsKey(random.NewRandomString(10))
+	second := cache.VisitorsKey(random.NewRandomString(10))
+
+	require.NoError(t, c.PFAdd(ctx, first, "a", "b"))
+	require.NoError(t, c.PFAdd(ctx, second, "a"))
+
+	counts, err := c.PFCountEach(ctx, []string{first, second, cache.VisitorsKey(random.NewRandomString(10))})
+	require.NoError(t, err)
+	require.Equal(t, []int64{2, 1, 0}, counts)
+}
+
+func TestCache_EvictOnClose(t *testing.T) {
+	prefix := random.NewRandomString(10)
+	evicted := []string{prefix + ":a", prefix + ":b"}
+	kept := random.NewRandomString(10)
+
+	c, err := cache.New("localhost:6379", "", 0)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for _, key := range append(evicted, kept) {
+		require.NoError(t, c.Set(ctx, key, "https://example.com", time.Minute))
+	}
+
+	c.EvictOnClose([]string{prefix + ":*"})
+	require.NoError(t, c.Close())
+
+	c, err = cache.New("localhost:6379", "", 0)
+	require.NoError(t, err)
+	defer c.Close()
+
+	for _, key := range evicted {
+		_, err := c.Get(ctx, key)
+		require.ErrorIs(t, err, redis.Nil)
+	}
+
+	got, err := c.Get(ctx, kept)
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com", got)
+}
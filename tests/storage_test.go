@@ -0,0 +1,777 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/random"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/postgres"
+)
+
+func TestStorage_ClaimURL(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	alias := random.NewRandomString(10)
+	first := gofakeit.URL()
+
+	// create
+	created, existing, err := s.ClaimURL(first, alias, storage.SaveOptions{})
+	require.NoError(t, err)
+	require.True(t, created)
+	require.Empty(t, existing)
+
+	// conflict
+	created, existing, err = s.ClaimURL(gofakeit.URL(), alias, storage.SaveOptions{})
+	require.NoError(t, err)
+	require.False(t, created)
+	require.Equal(t, first, existing)
+
+	got, err := s.GetURL(alias)
+	require.NoError(t, err)
+	require.Equal(t, first, got)
+}
+
+func TestStorage_ClaimURLConcurrent(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	const n = 8
+
+	alias := random.NewRandomString(10)
+
+	// every claim of the raced alias gets an answer, not a missing row
+	claims := make([]storage.Claim, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			var err error
+			claims[i], err = s.ClaimURLContext(context.Background(), gofakeit.URL(), alias, storage.SaveOptions{})
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	got, err := s.GetURL(alias)
+	require.NoError(t, err)
+
+	var won int
+	for _, claim := range claims {
+		if claim.Created {
+			won++
+			continue
+		}
+		assert.Equal(t, got, claim.URL)
+	}
+	require.Equal(t, 1, won)
+}
+
+func TestStorage_ExportURLs(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	alias := random.NewRandomString(10)
+	target := gofakeit.URL()
+
+	_, err = s.SaveURL(target, alias, storage.SaveOptions{})
+	require.NoError(t, err)
+
+	var found *storage.URL
+	err = s.ExportURLs(context.Background(), func(u storage.URL) error {
+		if u.Alias == alias {
+			found = &u
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	require.Equal(t, target, found.URL)
+	require.False(t, found.CreatedAt.IsZero())
+}
+
+func TestStorage_Migrate(t *testing.T) {
+	// re-running on a migrated database is a no-op
+	_, _, err := postgres.Migrate(testPostgres, testMigrations)
+	require.NoError(t, err)
+	_, _, err = postgres.Migrate(testPostgres, testMigrations)
+	require.NoError(t, err)
+
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	_, err = s.GetURLInfo(random.NewRandomString(10))
+	require.ErrorIs(t, err, storage.ErrURLNotFound)
+}
+
+func TestStorage_MigrateOnlyVersion(t *testing.T) {
+	src, err := (&file.File{}).Open("file://" + testMigrations)
+	require.NoError(t, err)
+	defer src.Close()
+
+	// the last migration in the directory is the version --migrate-only
+	// reports
+	latest, err := src.First()
+	require.NoError(t, err)
+	for {
+		next, err := src.Next(latest)
+		if errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		require.NoError(t, err)
+		latest = next
+	}
+
+	version, dirty, err := postgres.Migrate(testPostgres, testMigrations)
+	require.NoError(t, err)
+	require.Equal(t, latest, version)
+	require.False(t, dirty)
+}
+
+func TestStorage_GetURLByID(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	alias := random.NewRandomString(10)
+	target := gofakeit.URL()
+
+	id, err := s.SaveURL(target, alias, storage.SaveOptions{})
+	require.NoError(t, err)
+
+	got, err := s.GetURLByID(id)
+	require.NoError(t, err)
+	require.Equal(t, alias, got.Alias)
+	require.Equal(t, target, got.URL)
+
+	_, err = s.GetURLByID(-1)
+	require.ErrorIs(t, err, storage.ErrURLNotFound)
+}
+
+func TestStorage_GroupByContentHash(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	hash := random.NewRandomString(64)
+	first, second := random.NewRandomString(10), random.NewRandomString(10)
+
+	_, err = s.SaveURL(gofakeit.URL(), first, storage.SaveOptions{ContentHash: hash})
+	require.NoError(t, err)
+	_, err = s.SaveURL(gofakeit.URL(), second, storage.SaveOptions{ContentHash: hash})
+	require.NoError(t, err)
+	_, err = s.SaveURL(gofakeit.URL(), random.NewRandomString(10), storage.SaveOptions{ContentHash: random.NewRandomString(64)})
+	require.NoError(t, err)
+
+	info, err := s.GetURLInfo(first)
+	require.NoError(t, err)
+	require.Equal(t, hash, info.ContentHash)
+
+	groups, err := s.GroupByContentHash(context.Background())
+	require.NoError(t, err)
+
+	var found *storage.ContentGroup
+	for _, g := range groups {
+		if g.Hash == hash {
+			found = &g
+		}
+	}
+	require.NotNil(t, found)
+	require.ElementsMatch(t, []string{first, second}, found.Aliases)
+}
+
+func TestStorage_AliasLength(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	ctx := context.Background()
+
+	require.NoError(t, s.SetAliasLength(ctx, 7))
+	require.NoError(t, s.SetAliasLength(ctx, 8))
+
+	length, err := s.AliasLength(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 8, length)
+}
+
+func TestStorage_SaveURLBatch(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	taken := random.NewRandomString(10)
+	fresh := random.NewRandomString(10)
+
+	_, err = s.SaveURL(gofakeit.URL(), taken, storage.SaveOptions{})
+	require.NoError(t, err)
+
+	target := gofakeit.URL()
+	results, err := s.SaveURLBatch([]storage.URLItem{
+		{URL: gofakeit.URL(), Alias: taken},
+		{URL: target, Alias: fresh},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	require.ErrorIs(t, results[0].Err, storage.ErrURLExists)
+	require.NoError(t, results[1].Err)
+
+	got, err := s.GetURL(fresh)
+	require.NoError(t, err)
+	require.Equal(t, target, got)
+}
+
+func TestStorage_APIKeys(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	key := random.NewRandomString(32)
+
+	require.NoError(t, s.SaveAPIKey(key))
+
+	ok, err := s.ValidateAPIKey(key)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = s.ValidateAPIKey(key + "x")
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestStorage_ExpiresAt(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	alias := random.NewRandomString(10)
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Microsecond)
+
+	_, err = s.SaveURL(gofakeit.URL(), alias, storage.SaveOptions{ExpiresAt: &expiresAt})
+	require.NoError(t, err)
+
+	got, err := s.GetURLInfo(alias)
+	require.NoError(t, err)
+	require.NotNil(t, got.ExpiresAt)
+	require.True(t, expiresAt.Equal(*got.ExpiresAt))
+	require.False(t, got.Expired(time.Now()))
+	require.True(t, got.Expired(expiresAt))
+}
+
+func TestStorage_GetURLExpired(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	alias := random.NewRandomString(10)
+	expiresAt := time.Now().Add(-time.Minute)
+
+	_, err = s.SaveURL(gofakeit.URL(), alias, storage.SaveOptions{ExpiresAt: &expiresAt})
+	require.NoError(t, err)
+
+	_, err = s.GetURL(alias)
+	require.ErrorIs(t, err, storage.ErrURLExpired)
+}
+
+func TestStorage_UpdateURL(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	alias := random.NewRandomString(10)
+	newURL := gofakeit.URL()
+
+	_, err = s.SaveURL(gofakeit.URL(), alias, storage.SaveOptions{})
+	require.NoError(t, err)
+
+	require.NoError(t, s.UpdateURL(alias, newURL))
+
+	got, err := s.GetURL(alias)
+	require.NoError(t, err)
+	require.Equal(t, newURL, got)
+
+	require.ErrorIs(t, s.UpdateURL(random.NewRandomString(10), newURL), storage.ErrURLNotFound)
+}
+
+func TestStorage_DeleteURL(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	alias := random.NewRandomString(10)
+
+	_, err = s.SaveURL(gofakeit.URL(), alias, storage.SaveOptions{})
+	require.NoError(t, err)
+
+	require.NoError(t, s.DeleteURL(alias))
+	require.ErrorIs(t, s.DeleteURL(alias), storage.ErrURLNotFound)
+
+	_, err = s.GetURLInfo(alias)
+	require.ErrorIs(t, err, storage.ErrURLNotFound)
+}
+
+func TestStorage_DeleteURLClicks(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	alias := random.NewRandomString(10)
+	since := time.Now().Add(-time.Hour)
+
+	_, err = s.SaveURL(gofakeit.URL(), alias, storage.SaveOptions{})
+	require.NoError(t, err)
+	require.NoError(t, s.RecordClick(storage.ClickEvent{Alias: alias, Time: time.Now()}))
+	require.NoError(t, s.IncrementVariantClicks(alias, 0))
+
+	require.NoError(t, s.DeleteURL(alias))
+
+	// a new link under the alias starts without the old one's clicks
+	_, err = s.SaveURL(gofakeit.URL(), alias, storage.SaveOptions{})
+	require.NoError(t, err)
+
+	days, err := s.GetDailyClicks(alias, since)
+	require.NoError(t, err)
+	require.Empty(t, days)
+
+	variants, err := s.GetVariantClicks(alias)
+	require.NoError(t, err)
+	require.Empty(t, variants)
+}
+
+func TestStorage_Pool(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{
+		MaxOpenConns:    2,
+		MaxIdleConns:    1,
+		ConnMaxLifetime: time.Minute,
+	})
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.Equal(t, 2, s.Stats().MaxOpenConnections)
+
+	// more concurrent queries than connections wait for one instead of
+	// opening more
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.CountURLs()
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	stats := s.Stats()
+	require.LessOrEqual(t, stats.OpenConnections, 2)
+	require.LessOrEqual(t, stats.Idle, 1)
+}
+
+func TestStorage_Context(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	alias := random.NewRandomString(10)
+	url := gofakeit.URL()
+
+	_, err = s.SaveURLContext(context.Background(), url, alias, storage.SaveOptions{})
+	require.NoError(t, err)
+
+	got, err := s.GetURLContext(context.Background(), alias)
+	require.NoError(t, err)
+	require.Equal(t, url, got)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = s.GetURLInfoContext(ctx, alias)
+	require.ErrorIs(t, err, context.Canceled)
+	require.True(t, storage.Unavailable(err))
+}
+
+func TestStorage_GetURLsInfo(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	first, second := random.NewRandomString(10), random.NewRandomString(10)
+
+	_, err = s.SaveURL(gofakeit.URL(), first, storage.SaveOptions{})
+	require.NoError(t, err)
+	_, err = s.SaveURL(gofakeit.URL(), second, storage.SaveOptions{})
+	require.NoError(t, err)
+	require.NoError(t, s.IncrementClicks(second))
+
+	urls, err := s.GetURLsInfo([]string{second, random.NewRandomString(10), first})
+	require.NoError(t, err)
+	require.Len(t, urls, 2)
+
+	clicks := make(map[string]int64)
+	for _, u := range urls {
+		clicks[u.Alias] = u.Clicks
+	}
+	require.Equal(t, map[string]int64{first: 0, second: 1}, clicks)
+}
+
+func TestStorage_ListURLs(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	before, err := s.CountURLs()
+	require.NoError(t, err)
+
+	first, second := random.NewRandomString(10), random.NewRandomString(10)
+	secondURL := gofakeit.URL()
+
+	_, err = s.SaveURL(gofakeit.URL(), first, storage.SaveOptions{})
+	require.NoError(t, err)
+	_, err = s.SaveURL(secondURL, second, storage.SaveOptions{})
+	require.NoError(t, err)
+
+	total, err := s.CountURLs()
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, total, before+2)
+
+	// links are listed in id order, so the newest is last
+	urls, err := s.ListURLs(2, total-2)
+	require.NoError(t, err)
+	require.Len(t, urls, 2)
+	require.Equal(t, first, urls[0].Alias)
+	require.Equal(t, second, urls[1].Alias)
+	require.Equal(t, secondURL, urls[1].URL)
+
+	urls, err = s.ListURLs(10, total)
+	require.NoError(t, err)
+	require.Empty(t, urls)
+}
+
+func TestStorage_EstimateURLs(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	for i := 0; i < 10; i++ {
+		_, err = s.SaveURL(gofakeit.URL(), random.NewRandomString(10), storage.SaveOptions{})
+		require.NoError(t, err)
+	}
+
+	// the estimate is as fresh as the last ANALYZE
+	db, err := sql.Open("postgres", testPostgres)
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("ANALYZE url")
+	require.NoError(t, err)
+
+	exact, err := s.CountURLs()
+	require.NoError(t, err)
+
+	estimate, err := s.EstimateURLs()
+	require.NoError(t, err)
+	require.InDelta(t, exact, estimate, float64(exact)/10+1)
+}
+
+func TestStorage_SearchAliasesByPrefix(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	prefix := random.NewRandomString(8)
+	for _, suffix := range []string{"c", "a", "b"} {
+		_, err = s.SaveURL(gofakeit.URL(), prefix+suffix, storage.SaveOptions{})
+		require.NoError(t, err)
+	}
+
+	aliases, err := s.SearchAliasesByPrefix(prefix, 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{prefix + "a", prefix + "b"}, aliases)
+
+	// wildcards in the prefix match literally
+	aliases, err = s.SearchAliasesByPrefix(prefix[:4]+"_", 10)
+	require.NoError(t, err)
+	require.Empty(t, aliases)
+
+	aliases, err = s.SearchAliasesByPrefix("%", 10)
+	require.NoError(t, err)
+	require.Empty(t, aliases)
+}
+
+func TestStorage_AllowedReferrers(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	restricted, open := random.NewRandomString(10), random.NewRandomString(10)
+
+	_, err = s.SaveURL(gofakeit.URL(), restricted, storage.SaveOptions{AllowedReferrers: []string{"example.com", "example.org"}})
+	require.NoError(t, err)
+	_, err = s.SaveURL(gofakeit.URL(), open, storage.SaveOptions{})
+	require.NoError(t, err)
+
+	got, err := s.GetURLInfo(restricted)
+	require.NoError(t, err)
+	require.Equal(t, []string{"example.com", "example.org"}, got.AllowedReferrers)
+
+	got, err = s.GetURLInfo(open)
+	require.NoError(t, err)
+	require.Empty(t, got.AllowedReferrers)
+}
+
+func TestStorage_Creator(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	recorded, anonymous := random.NewRandomString(10), random.NewRandomString(10)
+	creator := storage.Creator{IP: "203.0.113.7", UserAgent: "curl/8.0", Identity: "admin"}
+
+	_, err = s.SaveURL(gofakeit.URL(), recorded, storage.SaveOptions{Creator: creator})
+	require.NoError(t, err)
+	_, err = s.SaveURL(gofakeit.URL(), anonymous, storage.SaveOptions{})
+	require.NoError(t, err)
+
+	got, err := s.GetURLInfo(recorded)
+	require.NoError(t, err)
+	require.Equal(t, creator, got.Creator)
+
+	got, err = s.GetURLInfo(anonymous)
+	require.NoError(t, err)
+	require.Zero(t, got.Creator)
+}
+
+func TestStorage_ExternalID(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	alias, externalID := random.NewRandomString(10), random.NewRandomString(10)
+
+	_, err = s.SaveURL(gofakeit.URL(), alias, storage.SaveOptions{ExternalID: externalID})
+	require.NoError(t, err)
+
+	_, err = s.SaveURL(gofakeit.URL(), random.NewRandomString(10), storage.SaveOptions{ExternalID: externalID})
+	require.ErrorIs(t, err, storage.ErrExternalIDExists)
+
+	got, err := s.GetURLByExternalID(externalID)
+	require.NoError(t, err)
+	require.Equal(t, alias, got.Alias)
+	require.Equal(t, externalID, got.ExternalID)
+
+	_, err = s.GetURLByExternalID(random.NewRandomString(10))
+	require.ErrorIs(t, err, storage.ErrURLNotFound)
+}
+
+func TestStorage_RedirectStatus(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	permanent, temporary := random.NewRandomString(10), random.NewRandomString(10)
+
+	_, err = s.SaveURL(gofakeit.URL(), permanent, storage.SaveOptions{RedirectStatus: 301})
+	require.NoError(t, err)
+	_, err = s.SaveURL(gofakeit.URL(), temporary, storage.SaveOptions{})
+	require.NoError(t, err)
+
+	got, err := s.GetURLInfo(permanent)
+	require.NoError(t, err)
+	require.Equal(t, 301, got.RedirectStatus)
+
+	got, err = s.GetURLInfo(temporary)
+	require.NoError(t, err)
+	require.Zero(t, got.RedirectStatus)
+}
+
+func TestStorage_Variants(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	alias := random.NewRandomString(10)
+	variants := []storage.Variant{
+		{URL: gofakeit.URL(), Weight: 1},
+		{URL: gofakeit.URL(), Weight: 3},
+	}
+
+	_, err = s.SaveURL(gofakeit.URL(), alias, storage.SaveOptions{Variants: variants})
+	require.NoError(t, err)
+
+	got, err := s.GetURLInfo(alias)
+	require.NoError(t, err)
+	require.Equal(t, variants, got.Variants)
+
+	require.NoError(t, s.IncrementVariantClicks(alias, 1))
+	require.NoError(t, s.IncrementVariantClicks(alias, 1))
+
+	clicks, err := s.GetVariantClicks(alias)
+	require.NoError(t, err)
+	require.Equal(t, map[int]int64{1: 2}, clicks)
+}
+
+func TestStorage_GetURLsByTarget(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	target := gofakeit.URL() + "/" + random.NewRandomString(10)
+	first, second := random.NewRandomString(10), random.NewRandomString(10)
+
+	_, err = s.SaveURL(target, first, storage.SaveOptions{})
+	require.NoError(t, err)
+	_, err = s.SaveURL(target, second, storage.SaveOptions{})
+	require.NoError(t, err)
+
+	got, err := s.GetURLsByTarget(context.Background(), target, 10)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	require.Equal(t, first, got[0].Alias)
+	require.Equal(t, second, got[1].Alias)
+}
+
+func TestStorage_Owner(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	owned, batched := random.NewRandomString(10), random.NewRandomString(10)
+	owner := gofakeit.Email()
+
+	_, err = s.SaveURL(gofakeit.URL(), owned, storage.SaveOptions{Owner: owner})
+	require.NoError(t, err)
+	_, err = s.SaveURLBatch([]storage.URLItem{{URL: gofakeit.URL(), Alias: batched, Owner: owner}})
+	require.NoError(t, err)
+
+	for _, alias := range []string{owned, batched} {
+		got, err := s.GetURLInfo(alias)
+		require.NoError(t, err)
+		require.Equal(t, owner, got.Owner)
+	}
+}
+
+func TestStorage_DeleteFolder(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	for _, cascade := range []bool{true, false} {
+		parent, err := s.CreateFolder(random.NewRandomString(10), 0)
+		require.NoError(t, err)
+		child, err := s.CreateFolder(random.NewRandomString(10), parent)
+		require.NoError(t, err)
+
+		_, err = s.CreateFolder(random.NewRandomString(10), 1<<40)
+		require.ErrorIs(t, err, storage.ErrFolderNotFound)
+
+		top, nested := random.NewRandomString(10), random.NewRandomString(10)
+		_, err = s.SaveURL(gofakeit.URL(), top, storage.SaveOptions{FolderID: parent})
+		require.NoError(t, err)
+		_, err = s.SaveURL(gofakeit.URL(), nested, storage.SaveOptions{FolderID: child})
+		require.NoError(t, err)
+
+		deleted, err := s.DeleteFolder(parent, cascade)
+		require.NoError(t, err)
+
+		_, err = s.GetFolder(child)
+		require.ErrorIs(t, err, storage.ErrFolderNotFound)
+
+		if cascade {
+			require.ElementsMatch(t, []string{top, nested}, deleted)
+			_, err = s.GetURLInfo(nested)
+			require.ErrorIs(t, err, storage.ErrURLNotFound)
+		} else {
+			require.Empty(t, deleted)
+			got, err := s.GetURLInfo(nested)
+			require.NoError(t, err)
+			require.Zero(t, got.FolderID)
+		}
+	}
+}
+
+func TestStorage_IncrementClicks(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	alias := random.NewRandomString(10)
+
+	_, err = s.SaveURL(gofakeit.URL(), alias, storage.SaveOptions{})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, s.IncrementClicks(alias))
+	}
+
+	got, err := s.GetURLInfo(alias)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), got.Clicks)
+}
+
+func TestStorage_BulkIncrementClicks(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	first, second := random.NewRandomString(10), random.NewRandomString(10)
+
+	for _, alias := range []string{first, second} {
+		_, err = s.SaveURL(gofakeit.URL(), alias, storage.SaveOptions{})
+		require.NoError(t, err)
+	}
+	require.NoError(t, s.IncrementClicks(first))
+
+	require.NoError(t, s.BulkIncrementClicks(map[string]int64{
+		first:                      4,
+		second:                     2,
+		random.NewRandomString(10): 1,
+	}))
+	require.NoError(t, s.BulkIncrementClicks(nil))
+
+	got, err := s.GetURLInfo(first)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), got.Clicks)
+
+	got, err = s.GetURLInfo(second)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), got.Clicks)
+}
+
+func TestStorage_DailyClicks(t *testing.T) {
+	s, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	alias := random.NewRandomString(10)
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, at := range []time.Time{day.Add(-time.Hour), day.Add(time.Hour), day.Add(2 * time.Hour), day.Add(25 * time.Hour)} {
+		require.NoError(t, s.RecordClick(storage.ClickEvent{Alias: alias, Time: at, Referrer: "https://example.org/", IP: "203.0.113.7"}))
+	}
+
+	days, err := s.GetDailyClicks(alias, day)
+	require.NoError(t, err)
+	require.Len(t, days, 2)
+	require.Equal(t, day.Format(time.DateOnly), days[0].Day.Format(time.DateOnly))
+	require.Equal(t, int64(2), days[0].Clicks)
+	require.Equal(t, day.AddDate(0, 0, 1).Format(time.DateOnly), days[1].Day.Format(time.DateOnly))
+	require.Equal(t, int64(1), days[1].Clicks)
+}
@@ -3,6 +3,7 @@ package tests
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/brianvoe/gofakeit/v6"
@@ -22,8 +23,10 @@ import (
 )
 
 const (
-	testUser     = "test_user"
-	testPassword = "test_password"
+	testUser       = "test_user"
+	testPassword   = "test_password"
+	testPostgres   = "host=localhost port=5432 user=postgres password=password dbname=url_shortener_test sslmode=disable"
+	testMigrations = "../migrations"
 )
 
 func TestURLShortener_HappyPath(t *testing.T) {
@@ -111,11 +114,52 @@ func TestURLShortener_SaveRedirect(t *testing.T) {
 	}
 }
 
+func TestURLShortener_CaseInsensitiveAliases(t *testing.T) {
+	srv := startTestServerWith(t, save.Options{FoldAliases: true}, redirect.Options{FoldAliases: true})
+	defer srv.Close()
+
+	e := httpexpect.Default(t, srv.URL)
+
+	url := gofakeit.URL()
+	alias := "Foo" + random.NewRandomString(8)
+
+	// the canonical alias is stored and returned
+	e.POST("/url").
+		WithJSON(save.Request{
+			URL:   url,
+			Alias: alias,
+		}).
+		WithBasicAuth(testUser, testPassword).
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object().
+		Value("alias").String().IsEqual(strings.ToLower(alias))
+
+	testRedirect(t, srv.URL, strings.ToLower(alias), url)
+	testRedirect(t, srv.URL, alias, url)
+	testRedirect(t, srv.URL, strings.ToUpper(alias), url)
+
+	// another spelling is the same alias
+	e.POST("/url").
+		WithJSON(save.Request{
+			URL:   gofakeit.URL(),
+			Alias: strings.ToUpper(alias),
+		}).
+		WithBasicAuth(testUser, testPassword).
+		Expect().
+		Status(http.StatusConflict)
+}
+
 func startTestServer(t *testing.T) *httptest.Server {
 	t.Helper()
 
-	psqlInfo := "host=localhost port=5432 user=postgres password=password dbname=url_shortener_test sslmode=disable"
-	storage, err := postgres.New(psqlInfo)
+	return startTestServerWith(t, save.Options{}, redirect.Options{})
+}
+
+func startTestServerWith(t *testing.T, saveOpts save.Options, redirectOpts redirect.Options) *httptest.Server {
+	t.Helper()
+
+	storage, err := postgres.New(testPostgres, testMigrations, postgres.PoolOptions{})
 	require.NoError(t, err)
 
 	cache, err := cache.New("localhost:6379", "", 0)
@@ -134,10 +178,10 @@ func startTestServer(t *testing.T) *httptest.Server {
 		r.Use(middleware.BasicAuth("url-shortener", map[string]string{
 			testUser: testPassword,
 		}))
-		r.Post("/", save.New(log, storage, cache))
+		r.Post("/", save.New(log, storage, cache, saveOpts))
 	})
 
-	router.Get("/{alias}", redirect.New(log, storage, cache))
+	router.Get("/{alias}", redirect.New(log, storage, cache, redirectOpts))
 
 	return httptest.NewServer(router)
 }